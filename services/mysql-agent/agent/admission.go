@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mysql-agent/config"
+)
+
+const (
+	defaultMaxConcurrentQueries = 4
+	defaultQueueTimeout         = 30 * time.Second
+)
+
+// ErrAgentBusy 并发诊断数已满且等待队列也已满，或排队超时。调用方应稍后重试
+var ErrAgentBusy = errors.New("agent busy")
+
+// QueueStats 诊断请求的并发与排队情况
+type QueueStats struct {
+	Running  int   `json:"running"`
+	Queued   int   `json:"queued"`
+	Rejected int64 `json:"rejected"`
+	// MaxRunning/MaxQueued 当前配置的上限
+	MaxRunning int `json:"max_running"`
+	MaxQueued  int `json:"max_queued"`
+}
+
+// admission 限制同时执行的诊断请求数，超出时在有界队列中按到达顺序等待
+type admission struct {
+	mu       sync.Mutex
+	running  int
+	waiters  []chan struct{}
+	rejected int64
+}
+
+var queries = &admission{}
+
+func admissionLimits() (maxRunning, maxQueued int, timeout time.Duration) {
+	maxRunning, timeout = defaultMaxConcurrentQueries, defaultQueueTimeout
+	if cfg := config.AppConfig; cfg != nil {
+		if cfg.Agent.MaxConcurrentQueries > 0 {
+			maxRunning = cfg.Agent.MaxConcurrentQueries
+		}
+		maxQueued = cfg.Agent.MaxQueuedQueries
+		if cfg.Agent.QueueTimeout > 0 {
+			timeout = cfg.Agent.QueueTimeout
+		}
+	}
+	return maxRunning, maxQueued, timeout
+}
+
+// acquireQuery 占用一个诊断名额，返回的函数用于释放。名额已满时进入等待队列，
+// 队列已满时立即返回 ErrAgentBusy；排队超过 agent.queue_timeout 或 ctx 结束时放弃等待
+func acquireQuery(ctx context.Context) (func(), error) {
+	maxRunning, maxQueued, timeout := admissionLimits()
+	a := queries
+
+	a.mu.Lock()
+	if a.running < maxRunning && len(a.waiters) == 0 {
+		a.running++
+		a.mu.Unlock()
+		return a.release, nil
+	}
+	if len(a.waiters) >= maxQueued {
+		a.rejected++
+		depth := len(a.waiters)
+		a.mu.Unlock()
+		log.Printf("[admission] rejected: max_running=%d queued=%d", maxRunning, depth)
+		return nil, fmt.Errorf("%w: 等待队列已满 (%d)", ErrAgentBusy, depth)
+	}
+	ready := make(chan struct{})
+	a.waiters = append(a.waiters, ready)
+	depth := len(a.waiters)
+	a.mu.Unlock()
+	log.Printf("[admission] queued: depth=%d", depth)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var cause error
+	select {
+	case <-ready:
+		return a.release, nil
+	case <-timer.C:
+		cause = fmt.Errorf("%w: 排队超过 %s", ErrAgentBusy, timeout)
+	case <-ctx.Done():
+		cause = ctx.Err()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, w := range a.waiters {
+		if w == ready {
+			a.waiters = append(a.waiters[:i], a.waiters[i+1:]...)
+			a.rejected++
+			return nil, cause
+		}
+	}
+	// 放弃等待的同时已被分配名额，直接交还
+	a.releaseLocked()
+	return nil, cause
+}
+
+func (a *admission) release() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.releaseLocked()
+}
+
+// releaseLocked 把名额直接转交给队首的等待者，没有等待者时减少运行数
+func (a *admission) releaseLocked() {
+	if len(a.waiters) > 0 {
+		next := a.waiters[0]
+		a.waiters = a.waiters[1:]
+		close(next)
+		return
+	}
+	a.running--
+}
+
+func (a *admission) stats() QueueStats {
+	maxRunning, maxQueued, _ := admissionLimits()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return QueueStats{
+		Running:    a.running,
+		Queued:     len(a.waiters),
+		Rejected:   a.rejected,
+		MaxRunning: maxRunning,
+		MaxQueued:  maxQueued,
+	}
+}
+
+// QueryQueueStats 返回诊断请求当前的并发与排队情况
+func QueryQueueStats() QueueStats {
+	return queries.stats()
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/eino-ext/components/model/deepseek"
 	"github.com/cloudwego/eino/components/model"
@@ -56,8 +58,9 @@ func createChatModel(ctx context.Context) (model.ChatModel, error) {
 	}
 
 	cfg := &deepseek.ChatModelConfig{
-		APIKey: apiKey,
-		Model:  modelID,
+		APIKey:     apiKey,
+		Model:      modelID,
+		HTTPClient: &http.Client{Transport: newRetryTransport(http.DefaultTransport)},
 	}
 	if base := strings.TrimSpace(os.Getenv("DEEPSEEK_BASE_URL")); base != "" {
 		cfg.BaseURL = base
@@ -81,14 +84,38 @@ func Generate(ctx context.Context, messages []*schema.Message) (*schema.Message,
 	if err != nil {
 		return nil, err
 	}
+	if err := checkTokenBudget(); err != nil {
+		return nil, err
+	}
 
+	start := time.Now()
 	resp, err := chat.Generate(ctx, messages)
+	observeLLM("generate", start, err)
 	if err != nil {
 		return nil, err
 	}
+	recordUsage(ctx, resp)
 	return resp, nil
 }
 
+// Stream 以 stream: true 请求模型，返回逐块生成的消息流，调用方负责关闭；
+// 用量由调用方在读完流后通过 recordUsage 记录
+func Stream(ctx context.Context, messages []*schema.Message) (*schema.StreamReader[*schema.Message], error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("消息不能为空")
+	}
+
+	chat, err := initAgent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTokenBudget(); err != nil {
+		return nil, err
+	}
+
+	return chat.Stream(ctx, messages)
+}
+
 func ChatModel(ctx context.Context) (model.ChatModel, error) {
 	return initAgent(ctx)
 }
@@ -0,0 +1,329 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"mysql-agent/config"
+	"mysql-agent/databases"
+)
+
+// 告警来源
+const (
+	AlertSourceCheck = "check" // 定时规则检查
+	AlertSourceQuery = "query" // 诊断请求走规则诊断
+)
+
+const (
+	alertSendTimeout = 10 * time.Second
+	defaultAlertTmpl = `[MySQL 告警][{{.Severity}}]{{if .Instance}} {{.Instance}}{{end}}
+{{.Message}}{{if .Recommendation}}
+建议：{{.Recommendation}}{{end}}
+规则：{{.Rule}}  时间：{{.Time}}`
+)
+
+// AlertEvent 一条待发送的告警，也是消息模板的数据
+type AlertEvent struct {
+	Rule           string `json:"rule"`
+	Severity       string `json:"severity"`
+	Message        string `json:"message"`
+	Recommendation string `json:"recommendation,omitempty"`
+	Source         string `json:"source"`
+	Instance       string `json:"instance,omitempty"`
+	Time           string `json:"time"`
+}
+
+var severityRank = map[string]int{severityLow: 1, severityModerate: 2, severityHigh: 3}
+
+// alertDedup 记录每个实例每个规则最近一次发送告警的时间与级别
+type alertDedup struct {
+	mu   sync.Mutex
+	sent map[string]alertSent
+}
+
+type alertSent struct {
+	at       time.Time
+	severity string
+}
+
+var (
+	alertState = &alertDedup{sent: map[string]alertSent{}}
+
+	alertTmplMu sync.Mutex
+	alertTmpls  = map[string]*template.Template{}
+
+	alertClient = &http.Client{Timeout: alertSendTimeout}
+)
+
+func alertConfig() config.AlertConfig {
+	if config.AppConfig == nil {
+		return config.AlertConfig{}
+	}
+	return config.AppConfig.Alert
+}
+
+// RunAlertChecks 按 alert.check_interval 执行规则检查并对命中的结论告警，直到 ctx 结束
+func RunAlertChecks(ctx context.Context) error {
+	interval := alertConfig().CheckInterval
+	log.Printf("[alert] rule checks every %s", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			runAlertCheck(ctx)
+		}
+	}
+}
+
+// runAlertCheck 逐个执行规则诊断所需的工具，单个工具失败（如缺少复制权限）不影响其他规则
+func runAlertCheck(ctx context.Context) {
+	var outputs []map[string]interface{}
+	for _, spec := range ruleFallbackPlan(ctx) {
+		_, out, failure := executePlan(ctx, []ToolCallSpec{spec}, nil)
+		if failure != "" {
+			log.Printf("[alert] check tool %s failed: %s", spec.Name, failure)
+			continue
+		}
+		outputs = append(outputs, out...)
+	}
+	findings := diagnoseWithRules(outputs)
+	log.Printf("[alert] rule check produced %d findings", len(findings))
+	fireAlerts(findings, AlertSourceCheck, config.DefaultInstanceID)
+}
+
+// fireAlerts 过滤、去重后异步推送 instanceID 实例上的告警，告警未启用或处于静默时段时直接返回
+func fireAlerts(findings []Finding, source, instanceID string) {
+	cfg := alertConfig()
+	if !cfg.Enabled || len(cfg.Channels) == 0 || len(findings) == 0 {
+		return
+	}
+	now := time.Now()
+	if inSilence(cfg, now) {
+		log.Printf("[alert] %d findings suppressed by silence window", len(findings))
+		return
+	}
+
+	var events []AlertEvent
+	for _, f := range findings {
+		if !severityAtLeast(f.Severity, cfg.MinSeverity) || !alertRuleEnabled(cfg, f.Rule) {
+			continue
+		}
+		if !alertState.claim(instanceID, f, cfg.DedupWindow, now) {
+			continue
+		}
+		events = append(events, AlertEvent{
+			Rule:           f.Rule,
+			Severity:       f.Severity,
+			Message:        f.Message,
+			Recommendation: f.Recommendation,
+			Source:         source,
+			Instance:       config.AppConfig.InstanceName(instanceID),
+			Time:           now.Format("2006-01-02 15:04:05"),
+		})
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	go func() {
+		for _, ch := range cfg.Channels {
+			for _, ev := range events {
+				if !severityAtLeast(ev.Severity, ch.MinSeverity) {
+					continue
+				}
+				if err := sendAlert(ch, ev); err != nil {
+					log.Printf("[alert] channel=%s rule=%s send failed: %v", ch.Name, ev.Rule, err)
+				}
+			}
+		}
+	}()
+}
+
+// claim 同一实例同一规则同一级别在 window 内已发送过时返回 false；级别升高时总是发送
+func (d *alertDedup) claim(instanceID string, f Finding, window time.Duration, now time.Time) bool {
+	key := instanceID + "/" + f.Rule
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	last, ok := d.sent[key]
+	if ok && window > 0 && now.Sub(last.at) < window && severityRank[f.Severity] <= severityRank[last.severity] {
+		return false
+	}
+	d.sent[key] = alertSent{at: now, severity: f.Severity}
+	return true
+}
+
+// severityAtLeast min 为空或无法识别时不过滤
+func severityAtLeast(severity, min string) bool {
+	want, ok := severityRank[strings.ToLower(min)]
+	return !ok || severityRank[severity] >= want
+}
+
+func alertRuleEnabled(cfg config.AlertConfig, rule string) bool {
+	if len(cfg.Rules) == 0 {
+		return true
+	}
+	for _, r := range cfg.Rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// inSilence 判断 now 是否处于每日静默时段，结束时间早于开始时间表示跨零点
+func inSilence(cfg config.AlertConfig, now time.Time) bool {
+	start, okStart := clockMinutes(cfg.SilenceStart)
+	end, okEnd := clockMinutes(cfg.SilenceEnd)
+	if !okStart || !okEnd || start == end {
+		return false
+	}
+	cur := now.Hour()*60 + now.Minute()
+	if start < end {
+		return cur >= start && cur < end
+	}
+	return cur >= start || cur < end
+}
+
+func clockMinutes(raw string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(raw))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// instanceLabel 返回 ctx 所属实例写入结论与告警的名称
+func instanceLabel(ctx context.Context) string {
+	if config.AppConfig == nil {
+		return ""
+	}
+	return config.AppConfig.InstanceName(databases.InstanceFrom(ctx))
+}
+
+// renderAlert 按渠道模板渲染消息，模板解析或执行失败时退回内置模板
+func renderAlert(ch config.AlertChannelConfig, ev AlertEvent) string {
+	alertTmplMu.Lock()
+	tmpl, ok := alertTmpls[ch.Name]
+	if !ok {
+		text := ch.Template
+		if text == "" {
+			text = defaultAlertTmpl
+		}
+		var err error
+		if tmpl, err = template.New(ch.Name).Parse(text); err != nil {
+			log.Printf("[alert] channel=%s parse template failed, using default: %v", ch.Name, err)
+			tmpl = template.Must(template.New(ch.Name).Parse(defaultAlertTmpl))
+		}
+		alertTmpls[ch.Name] = tmpl
+	}
+	alertTmplMu.Unlock()
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ev); err != nil {
+		log.Printf("[alert] channel=%s render failed: %v", ch.Name, err)
+		buf.Reset()
+		_ = template.Must(template.New("default").Parse(defaultAlertTmpl)).Execute(&buf, ev)
+	}
+	return buf.String()
+}
+
+func sendAlert(ch config.AlertChannelConfig, ev AlertEvent) error {
+	text := renderAlert(ch, ev)
+	target := ch.URL
+	var payload interface{}
+	switch ch.Type {
+	case config.AlertChannelDingTalk:
+		if ch.Secret != "" {
+			ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+			sign := hmacBase64(ch.Secret, ts+"\n"+ch.Secret)
+			target = fmt.Sprintf("%s&timestamp=%s&sign=%s", target, ts, url.QueryEscape(sign))
+		}
+		payload = map[string]interface{}{"msgtype": "text", "text": map[string]string{"content": text}}
+	case config.AlertChannelFeishu:
+		body := map[string]interface{}{"msg_type": "text", "content": map[string]string{"text": text}}
+		if ch.Secret != "" {
+			ts := strconv.FormatInt(time.Now().Unix(), 10)
+			// 飞书以 timestamp+"\n"+secret 为密钥对空串签名
+			body["timestamp"] = ts
+			body["sign"] = hmacBase64(ts+"\n"+ch.Secret, "")
+		}
+		payload = body
+	case config.AlertChannelSlack:
+		payload = map[string]string{"text": text}
+	case config.AlertChannelWebhook, "":
+		payload = struct {
+			AlertEvent
+			Text string `json:"text"`
+		}{ev, text}
+	default:
+		return fmt.Errorf("未知的告警渠道类型: %s", ch.Type)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), alertSendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := alertClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return checkBotResponse(ch.Type, body)
+}
+
+// checkBotResponse 钉钉、飞书机器人出错时仍返回 HTTP 200，需要检查响应体中的错误码
+func checkBotResponse(channelType string, body []byte) error {
+	var result struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	switch channelType {
+	case config.AlertChannelDingTalk, config.AlertChannelFeishu:
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil
+		}
+		if result.ErrCode != nil && *result.ErrCode != 0 {
+			return fmt.Errorf("errcode=%d: %s", *result.ErrCode, result.ErrMsg)
+		}
+		if result.Code != nil && *result.Code != 0 {
+			return fmt.Errorf("code=%d: %s", *result.Code, result.Msg)
+		}
+	}
+	return nil
+}
+
+func hmacBase64(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
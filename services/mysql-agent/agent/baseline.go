@@ -0,0 +1,294 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mysql-agent/config"
+)
+
+// 基线指标类型
+const (
+	BaselineGauge = "gauge"
+	BaselineRate  = "rate"
+)
+
+// baselineGauges 按瞬时值建立基线的状态变量（小写），其余状态变量视为累计计数器，按每秒增量建立基线
+var baselineGauges = map[string]bool{
+	"threads_connected":              true,
+	"threads_running":                true,
+	"threads_cached":                 true,
+	"max_used_connections":           true,
+	"open_tables":                    true,
+	"open_files":                     true,
+	"innodb_row_lock_current_waits":  true,
+	"innodb_buffer_pool_pages_dirty": true,
+	"innodb_buffer_pool_pages_free":  true,
+	"innodb_buffer_pool_pages_data":  true,
+}
+
+// Anomaly 偏离基线的指标
+type Anomaly struct {
+	Metric string  `json:"metric"`
+	Kind   string  `json:"kind"` // gauge 为瞬时值，rate 为每秒增量
+	Value  float64 `json:"value"`
+	Mean   float64 `json:"mean"`
+	Stddev float64 `json:"stddev"`
+	// Sigma 偏离均值的标准差倍数（标准差按 detectAnomalies 的下限修正），正数表示高于基线
+	Sigma   float64 `json:"sigma"`
+	Samples int     `json:"samples"`
+}
+
+// BaselineStat 单个指标的基线统计
+type BaselineStat struct {
+	Metric  string  `json:"metric"`
+	Kind    string  `json:"kind"`
+	Samples int     `json:"samples"`
+	Mean    float64 `json:"mean"`
+	Stddev  float64 `json:"stddev"`
+	Last    float64 `json:"last"`
+}
+
+type BaselineRequest struct{}
+
+type BaselineResponse struct {
+	Enabled bool `json:"enabled"`
+	// SampledAt 最近一次采样时间，尚未采样时为空
+	SampledAt string         `json:"sampled_at,omitempty"`
+	Metrics   []BaselineStat `json:"metrics"`
+}
+
+// baselineStore 每个指标保留最近 baseline.window 个基线值，数据来自采样器（见 sampler.go）；配置了 baseline.file 时每次采样后写入文件，重启后恢复
+type baselineStore struct {
+	mu     sync.Mutex
+	series map[string][]float64
+	// last/lastAt 最近一次采样的原始值与时间，用于计算计数器的每秒增量
+	last   map[string]float64
+	lastAt time.Time
+	path   string
+	ready  bool
+}
+
+var baseline = &baselineStore{series: map[string][]float64{}}
+
+func baselineConfig() config.BaselineConfig {
+	if config.AppConfig == nil {
+		return config.BaselineConfig{}
+	}
+	return config.AppConfig.Baseline
+}
+
+func (b *baselineStore) load() {
+	if b.ready {
+		return
+	}
+	b.ready = true
+	b.path = baselineConfig().File
+	if b.path == "" {
+		return
+	}
+	raw, err := os.ReadFile(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("[baseline] read %s failed: %v", b.path, err)
+		return
+	}
+	if err := json.Unmarshal(raw, &b.series); err != nil {
+		log.Printf("[baseline] decode %s failed: %v", b.path, err)
+		b.series = map[string][]float64{}
+	}
+}
+
+// save 先写临时文件再重命名，避免进程中途退出留下不完整的文件
+func (b *baselineStore) save() {
+	if b.path == "" {
+		return
+	}
+	raw, err := json.Marshal(b.series)
+	if err != nil {
+		log.Printf("[baseline] encode failed: %v", err)
+		return
+	}
+	tmp := b.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		log.Printf("[baseline] create dir failed: %v", err)
+		return
+	}
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		log.Printf("[baseline] write %s failed: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		log.Printf("[baseline] rename %s failed: %v", tmp, err)
+	}
+}
+
+// observe 由采样器在每次采样后调用，距上次写入基线不足 baseline.interval 时跳过
+func (b *baselineStore) observe(s MetricSample) {
+	cfg := baselineConfig()
+	if !cfg.Enabled {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.lastAt.IsZero() && s.At.Sub(b.lastAt) < cfg.Interval {
+		return
+	}
+	b.load()
+
+	now := s.At
+	values, raw := b.values(s.Status, now)
+	window := baselineConfig().Window
+	for name, v := range values {
+		s := append(b.series[name], v)
+		if window > 0 && len(s) > window {
+			s = s[len(s)-window:]
+		}
+		b.series[name] = s
+	}
+	b.last, b.lastAt = raw, now
+	b.save()
+}
+
+// values 按配置的指标计算基线取值：瞬时值直接使用，计数器用与上次采样的差值除以间隔秒数；
+// 计数器变小（实例重启或 FLUSH STATUS）时跳过本次。调用方需持有 b.mu
+func (b *baselineStore) values(status map[string]float64, now time.Time) (values, raw map[string]float64) {
+	values = map[string]float64{}
+	raw = map[string]float64{}
+	elapsed := now.Sub(b.lastAt).Seconds()
+	for _, name := range baselineConfig().Metrics {
+		key := strings.ToLower(name)
+		cur, ok := status[key]
+		if !ok {
+			continue
+		}
+		raw[key] = cur
+		if baselineGauges[key] {
+			values[key] = cur
+			continue
+		}
+		prev, ok := b.last[key]
+		if !ok || b.lastAt.IsZero() || elapsed < 1 || cur < prev {
+			continue
+		}
+		values[key] = (cur - prev) / elapsed
+	}
+	return values, raw
+}
+
+func baselineKind(key string) string {
+	if baselineGauges[key] {
+		return BaselineGauge
+	}
+	return BaselineRate
+}
+
+func meanStddev(values []float64) (mean, stddev float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		stddev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(values)))
+}
+
+// detectAnomalies 采样一次当前值并与基线比较，返回偏离超过 baseline.sigma 倍标准差的指标，按偏离程度降序。
+// 标准差不足均值的 5% 时以 5% 计算，避免长期平稳的指标出现微小波动就被标记；未启用或采样失败时返回空
+func detectAnomalies(ctx context.Context) []Anomaly {
+	cfg := baselineConfig()
+	if !cfg.Enabled {
+		return nil
+	}
+	status, err := globalStatusValues(ctx)
+	if err != nil {
+		log.Printf("[baseline] read current status failed: %v", err)
+		return nil
+	}
+
+	baseline.mu.Lock()
+	defer baseline.mu.Unlock()
+	baseline.load()
+
+	floats := make(map[string]float64, len(status))
+	for name, v := range status {
+		floats[name] = parseFloat(v)
+	}
+	current, _ := baseline.values(floats, time.Now())
+	var anomalies []Anomaly
+	for _, name := range cfg.Metrics {
+		key := strings.ToLower(name)
+		v, ok := current[key]
+		series := baseline.series[key]
+		if !ok || len(series) < cfg.MinSamples || len(series) < 2 {
+			continue
+		}
+		mean, stddev := meanStddev(series)
+		spread := math.Max(stddev, math.Abs(mean)*0.05)
+		if spread == 0 {
+			continue
+		}
+		sigma := (v - mean) / spread
+		if math.Abs(sigma) < cfg.Sigma {
+			continue
+		}
+		anomalies = append(anomalies, Anomaly{
+			Metric:  name,
+			Kind:    baselineKind(key),
+			Value:   round2(v),
+			Mean:    round2(mean),
+			Stddev:  round2(stddev),
+			Sigma:   round2(sigma),
+			Samples: len(series),
+		})
+	}
+	sort.SliceStable(anomalies, func(i, j int) bool {
+		return math.Abs(anomalies[i].Sigma) > math.Abs(anomalies[j].Sigma)
+	})
+	return anomalies
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Baseline 返回各指标当前的基线统计
+func (RPCService) Baseline(req BaselineRequest, resp *BaselineResponse) error {
+	cfg := baselineConfig()
+	resp.Enabled = cfg.Enabled
+	resp.Metrics = []BaselineStat{}
+
+	baseline.mu.Lock()
+	defer baseline.mu.Unlock()
+	baseline.load()
+	if !baseline.lastAt.IsZero() {
+		resp.SampledAt = baseline.lastAt.Format(time.RFC3339)
+	}
+	for _, name := range cfg.Metrics {
+		key := strings.ToLower(name)
+		series := baseline.series[key]
+		stat := BaselineStat{Metric: name, Kind: baselineKind(key), Samples: len(series)}
+		if len(series) > 0 {
+			mean, stddev := meanStddev(series)
+			stat.Mean, stat.Stddev, stat.Last = round2(mean), round2(stddev), round2(series[len(series)-1])
+		}
+		resp.Metrics = append(resp.Metrics, stat)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package agent
+
+import (
+	"encoding/json"
+	"sort"
+	"unicode/utf8"
+
+	"mysql-agent/config"
+)
+
+const (
+	defaultToolOutputBudget = 60000
+	// minToolOutputShare 预算耗尽时每个工具仍保留的字节数，保证 LLM 至少能看到部分数据和截断标记
+	minToolOutputShare = 1024
+)
+
+// budgetToolOutputs 按 agent.tool_output_budget 限制发送给 LLM 的工具输出总字节数（按序列化后的 JSON 计算），
+// 返回裁剪后的副本以及被裁剪的工具名，原始输出不变。预算先分配给 agent.priority_tools 中的工具，剩余部分再分给其他工具；
+// 同一批工具中输出小的先拿满，超出份额的工具截断行数并标记 "truncated": true
+func budgetToolOutputs(toolOutputs []map[string]interface{}) ([]map[string]interface{}, []string) {
+	budget := defaultToolOutputBudget
+	priority := map[string]struct{}{}
+	if cfg := config.AppConfig; cfg != nil {
+		if cfg.Agent.ToolOutputBudget > 0 {
+			budget = cfg.Agent.ToolOutputBudget
+		}
+		for _, name := range cfg.Agent.PriorityTools {
+			priority[name] = struct{}{}
+		}
+	}
+
+	sizes := make([]int, len(toolOutputs))
+	total := 0
+	for i, item := range toolOutputs {
+		sizes[i] = jsonSize(item["output"])
+		total += sizes[i]
+	}
+	if total <= budget {
+		return toolOutputs, nil
+	}
+
+	var required, others []int
+	for i, item := range toolOutputs {
+		name, _ := item["name"].(string)
+		if _, ok := priority[name]; ok {
+			required = append(required, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+
+	// 为其他工具预留保底份额，避免优先工具占满全部预算
+	reserve := len(others) * minToolOutputShare
+	if reserve > budget/2 {
+		reserve = budget / 2
+	}
+
+	shares := make([]int, len(toolOutputs))
+	remaining := budget - reserve
+	for t, tier := range [][]int{required, others} {
+		if t == 1 {
+			remaining += reserve
+		}
+		sort.SliceStable(tier, func(a, b int) bool { return sizes[tier[a]] < sizes[tier[b]] })
+		for k, idx := range tier {
+			share := remaining / (len(tier) - k)
+			if sizes[idx] < share {
+				share = sizes[idx]
+			}
+			shares[idx] = share
+			remaining -= share
+		}
+	}
+
+	result := make([]map[string]interface{}, len(toolOutputs))
+	var truncated []string
+	for i, item := range toolOutputs {
+		if sizes[i] <= shares[i] || sizes[i] <= minToolOutputShare {
+			result[i] = item
+			continue
+		}
+		limit := shares[i]
+		if limit < minToolOutputShare {
+			limit = minToolOutputShare
+		}
+		name, _ := item["name"].(string)
+		result[i] = map[string]interface{}{"name": name, "output": shrinkOutput(item["output"], limit)}
+		truncated = append(truncated, name)
+	}
+	return result, truncated
+}
+
+// shrinkOutput 将工具输出裁剪到 limit 字节以内：行数组保留前面的行，其余情况退化为截断的 JSON 文本预览
+func shrinkOutput(output interface{}, limit int) interface{} {
+	switch v := output.(type) {
+	case []interface{}:
+		rows := shrinkRows(v, func(kept []interface{}) interface{} {
+			return map[string]interface{}{"rows": kept, "truncated": true, "total_rows": len(v)}
+		}, limit)
+		if rows != nil {
+			return rows
+		}
+	case map[string]interface{}:
+		if shrunk := shrinkFields(v, limit); shrunk != nil {
+			return shrunk
+		}
+	}
+	return previewOutput(output, limit)
+}
+
+// shrinkFields 反复将当前最大的数组字段行数减半，直到整体不超过 limit；无数组字段可裁剪时返回 nil
+func shrinkFields(v map[string]interface{}, limit int) interface{} {
+	out := make(map[string]interface{}, len(v)+2)
+	for k, val := range v {
+		out[k] = val
+	}
+	omitted := map[string]int{}
+	out["truncated"] = true
+	out["omitted_rows"] = omitted
+
+	for jsonSize(out) > limit {
+		field, rows := "", []interface{}(nil)
+		largest := -1
+		for k, val := range out {
+			arr, ok := val.([]interface{})
+			if !ok || len(arr) == 0 {
+				continue
+			}
+			if size := jsonSize(arr); size > largest {
+				field, rows, largest = k, arr, size
+			}
+		}
+		if field == "" {
+			return nil
+		}
+		keep := len(rows) / 2
+		omitted[field] += len(rows) - keep
+		out[field] = rows[:keep]
+	}
+	return out
+}
+
+// shrinkRows 二分查找能放进 limit 的最多行数，wrap 负责给保留的行加上截断标记；一行都放不下时返回 nil
+func shrinkRows(rows []interface{}, wrap func([]interface{}) interface{}, limit int) interface{} {
+	lo, hi := 0, len(rows)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if jsonSize(wrap(rows[:mid])) <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	if lo == 0 {
+		return nil
+	}
+	return wrap(rows[:lo])
+}
+
+func previewOutput(output interface{}, limit int) interface{} {
+	raw, _ := json.Marshal(output)
+	cut := limit
+	if cut > len(raw) {
+		cut = len(raw)
+	}
+	for cut > 0 && cut < len(raw) && !utf8.RuneStart(raw[cut]) {
+		cut--
+	}
+	return map[string]interface{}{"truncated": true, "preview": string(raw[:cut])}
+}
+
+func jsonSize(v interface{}) int {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(raw)
+}
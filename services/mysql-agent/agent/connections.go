@@ -0,0 +1,377 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"mysql-agent/databases"
+)
+
+// connectionTools 连接错误、按主机/账号汇总与空闲连接相关的诊断工具
+func connectionTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolConnErrors, "读取 Connection_errors_*、Aborted_connects、Aborted_clients 等状态计数与 `performance_schema.host_cache` 中各主机的连接/认证错误，识别被 max_connect_errors 封禁的主机与连接数打满等问题", connectionErrorsTool),
+		inferTool(toolHostSummary, "查询 `sys.host_summary` 按客户端主机汇总语句数、语句耗时、全表扫描、文件 IO 与当前连接数，定位是哪台应用服务器在压数据库", hostSummaryTool),
+		inferTool(toolUserSummary, "查询 `sys.user_summary` 按数据库账号汇总语句数、语句耗时、全表扫描、文件 IO 与当前连接数，定位是哪个应用账号在压数据库", userSummaryTool),
+		inferTool(toolIdleConns, "关联 `information_schema.processlist` 与 `innodb_trx`，将客户端连接分为 active、idle、idle_in_transaction 并按 user/host 分组，重点列出处于 Sleep 却持有未提交事务的连接", idleConnectionsTool),
+	}
+}
+
+type ConnectionErrorsInput struct {
+	Limit int `json:"limit,omitempty" jsonschema:"description=返回的有错误记录的主机数量,默认 20,minimum=1"`
+}
+
+type HostConnectionErrors struct {
+	IP                string `json:"ip"`
+	Host              string `json:"host,omitempty"`
+	ConnectErrors     int64  `json:"sum_connect_errors"`
+	Blocked           bool   `json:"blocked"` // SUM_CONNECT_ERRORS 达到 max_connect_errors，后续连接会被拒绝
+	BlockedAttempts   int64  `json:"host_blocked_errors"`
+	HandshakeErrors   int64  `json:"handshake_errors"`
+	AuthErrors        int64  `json:"authentication_errors"`
+	SSLErrors         int64  `json:"ssl_errors"`
+	MaxUserConnErrors int64  `json:"max_user_connections_errors"`
+	DefaultDBErrors   int64  `json:"default_database_errors"`
+	OtherErrors       int64  `json:"other_errors"`
+	FirstErrorSeen    string `json:"first_error_seen,omitempty"`
+	LastErrorSeen     string `json:"last_error_seen,omitempty"`
+}
+
+type ConnectionErrorsResult struct {
+	Counters          map[string]int64       `json:"counters"` // Connection_errors_*、Aborted_* 等状态计数
+	Connections       int64                  `json:"connections"`
+	ThreadsConnected  int64                  `json:"threads_connected"`
+	MaxUsedConns      int64                  `json:"max_used_connections"`
+	MaxConnections    int64                  `json:"max_connections"`
+	MaxConnectErrors  int64                  `json:"max_connect_errors"`
+	AbortedConnectPct float64                `json:"aborted_connect_pct"` // Aborted_connects 占 Connections 的百分比
+	Hosts             []HostConnectionErrors `json:"hosts"`
+	Findings          []string               `json:"findings,omitempty"`
+}
+
+func connectionErrorsTool(ctx context.Context, input *ConnectionErrorsInput) (*ConnectionErrorsResult, error) {
+	limit := 0
+	if input != nil && input.Limit > 0 {
+		limit = input.Limit
+	}
+
+	status, err := globalStatusValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := databases.QueryGlobalVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConnectionErrorsResult{
+		Counters:         make(map[string]int64),
+		Connections:      parseInt(status["connections"]),
+		ThreadsConnected: parseInt(status["threads_connected"]),
+		MaxUsedConns:     parseInt(status["max_used_connections"]),
+		MaxConnections:   parseInt(vars["max_connections"]),
+		MaxConnectErrors: parseInt(vars["max_connect_errors"]),
+		Hosts:            []HostConnectionErrors{},
+	}
+	for name, value := range status {
+		if strings.HasPrefix(name, "connection_errors_") || name == "aborted_connects" || name == "aborted_clients" {
+			result.Counters[name] = parseInt(value)
+		}
+	}
+	if result.Connections > 0 {
+		result.AbortedConnectPct = 100 * float64(result.Counters["aborted_connects"]) / float64(result.Connections)
+	}
+
+	// host_cache_size=0 或没有 performance_schema 时主机明细为空，只返回计数
+	rows, err := databases.QueryHostCacheErrors(ctx, limit)
+	if errors.Is(err, databases.ErrUnsupported) {
+		result.Findings = append(result.Findings, err.Error())
+	} else if err != nil {
+		log.Printf("[connectionErrorsTool] query host_cache failed: %v", err)
+		result.Findings = append(result.Findings, fmt.Sprintf("无法读取 performance_schema.host_cache: %v", err))
+	}
+	blocked := 0
+	for _, row := range normalizeRows(rows) {
+		h := HostConnectionErrors{
+			IP:                row["ip"],
+			Host:              row["host"],
+			ConnectErrors:     parseInt(row["sum_connect_errors"]),
+			BlockedAttempts:   parseInt(row["count_host_blocked_errors"]),
+			HandshakeErrors:   parseInt(row["count_handshake_errors"]),
+			AuthErrors:        parseInt(row["count_authentication_errors"]),
+			SSLErrors:         parseInt(row["count_ssl_errors"]),
+			MaxUserConnErrors: parseInt(row["count_max_user_connections_errors"]),
+			DefaultDBErrors:   parseInt(row["count_default_database_errors"]),
+			OtherErrors:       parseInt(row["count_local_errors"]) + parseInt(row["count_unknown_errors"]),
+			FirstErrorSeen:    row["first_error_seen"],
+			LastErrorSeen:     row["last_error_seen"],
+		}
+		h.Blocked = result.MaxConnectErrors > 0 && h.ConnectErrors >= result.MaxConnectErrors
+		if h.Blocked {
+			blocked++
+		}
+		result.Hosts = append(result.Hosts, h)
+	}
+	if strings.TrimSpace(vars["host_cache_size"]) == "0" {
+		result.Findings = append(result.Findings, "host_cache_size=0，host_cache 已禁用，无法按主机统计连接错误")
+	}
+
+	if n := result.Counters["connection_errors_max_connections"]; n > 0 {
+		result.Findings = append(result.Findings, fmt.Sprintf("有 %d 次连接因达到 max_connections=%d 被拒绝(历史峰值 %d)", n, result.MaxConnections, result.MaxUsedConns))
+	}
+	if blocked > 0 {
+		result.Findings = append(result.Findings, fmt.Sprintf("%d 个主机的连接错误数达到 max_connect_errors=%d 已被封禁，可执行 FLUSH HOSTS 或 TRUNCATE performance_schema.host_cache 解除", blocked, result.MaxConnectErrors))
+	}
+	if result.Connections >= 100 && result.AbortedConnectPct >= 5 {
+		result.Findings = append(result.Findings, fmt.Sprintf("%.1f%% 的连接尝试失败(Aborted_connects)，常见原因为密码错误、权限不足或 connect_timeout 过短", result.AbortedConnectPct))
+	}
+	if n := result.Counters["aborted_clients"]; result.Connections > 0 && n*10 >= result.Connections {
+		result.Findings = append(result.Findings, fmt.Sprintf("Aborted_clients=%d，客户端未正常关闭连接或超过 wait_timeout(%s 秒)", n, vars["wait_timeout"]))
+	}
+
+	return result, nil
+}
+
+type SysSummaryInput struct {
+	Limit   int    `json:"limit,omitempty" jsonschema:"description=返回的最大行数,默认 20,minimum=1"`
+	OrderBy string `json:"order_by,omitempty" jsonschema:"description=排序方式,enum=statement_latency,enum=statements,enum=file_io_latency,enum=current_connections"`
+}
+
+type SysSummaryRow struct {
+	Name                string  `json:"name"` // host 或 user，后台线程为 background
+	Statements          int64   `json:"statements"`
+	StatementLatencyMs  float64 `json:"statement_latency_ms"`
+	StatementAvgMs      float64 `json:"statement_avg_latency_ms"`
+	TableScans          int64   `json:"table_scans"`
+	FileIOs             int64   `json:"file_ios"`
+	FileIOLatencyMs     float64 `json:"file_io_latency_ms"`
+	CurrentConnections  int64   `json:"current_connections"`
+	TotalConnections    int64   `json:"total_connections"`
+	Unique              int64   `json:"unique_peers"` // host 汇总为不同用户数，user 汇总为不同主机数
+	CurrentMemoryBytes  int64   `json:"current_memory_bytes"`
+	StatementLatencyPct float64 `json:"statement_latency_pct"` // 占本次返回各行语句总耗时的百分比
+}
+
+type SysSummaryResult struct {
+	Rows []SysSummaryRow `json:"rows"`
+	// Note 实例没有 sys schema（如 MariaDB）时说明原因，Rows 为空
+	Note string `json:"note,omitempty"`
+}
+
+func hostSummaryTool(ctx context.Context, input *SysSummaryInput) (*SysSummaryResult, error) {
+	limit, orderBy := sysSummaryArgs(input)
+	rows, err := databases.QueryHostSummary(ctx, orderBy, limit)
+	if errors.Is(err, databases.ErrUnsupported) {
+		return &SysSummaryResult{Rows: []SysSummaryRow{}, Note: err.Error()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buildSysSummary(rows, "host", "unique_users"), nil
+}
+
+func userSummaryTool(ctx context.Context, input *SysSummaryInput) (*SysSummaryResult, error) {
+	limit, orderBy := sysSummaryArgs(input)
+	rows, err := databases.QueryUserSummary(ctx, orderBy, limit)
+	if errors.Is(err, databases.ErrUnsupported) {
+		return &SysSummaryResult{Rows: []SysSummaryRow{}, Note: err.Error()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buildSysSummary(rows, "user", "unique_hosts"), nil
+}
+
+func sysSummaryArgs(input *SysSummaryInput) (int, string) {
+	if input == nil {
+		return 0, ""
+	}
+	return input.Limit, strings.ToLower(strings.TrimSpace(input.OrderBy))
+}
+
+// buildSysSummary 将 x$ 视图中以皮秒为单位的耗时换算为毫秒
+func buildSysSummary(rows []map[string]any, nameColumn, uniqueColumn string) *SysSummaryResult {
+	const picosPerMs = 1e9
+	result := &SysSummaryResult{Rows: make([]SysSummaryRow, 0, len(rows))}
+	var totalLatency float64
+	for _, row := range normalizeRows(rows) {
+		r := SysSummaryRow{
+			Name:               row[nameColumn],
+			Statements:         parseInt(row["statements"]),
+			StatementLatencyMs: parseFloat(row["statement_latency"]) / picosPerMs,
+			StatementAvgMs:     parseFloat(row["statement_avg_latency"]) / picosPerMs,
+			TableScans:         parseInt(row["table_scans"]),
+			FileIOs:            parseInt(row["file_ios"]),
+			FileIOLatencyMs:    parseFloat(row["file_io_latency"]) / picosPerMs,
+			CurrentConnections: parseInt(row["current_connections"]),
+			TotalConnections:   parseInt(row["total_connections"]),
+			Unique:             parseInt(row[uniqueColumn]),
+			CurrentMemoryBytes: parseInt(row["current_memory"]),
+		}
+		totalLatency += r.StatementLatencyMs
+		result.Rows = append(result.Rows, r)
+	}
+	if totalLatency > 0 {
+		for i := range result.Rows {
+			result.Rows[i].StatementLatencyPct = 100 * result.Rows[i].StatementLatencyMs / totalLatency
+		}
+	}
+	return result
+}
+
+type IdleConnectionsInput struct {
+	IdleThresholdSeconds int `json:"idle_threshold_seconds,omitempty" jsonschema:"description=空闲超过该秒数的连接视为长时间空闲,默认 600,minimum=1"`
+	Limit                int `json:"limit,omitempty" jsonschema:"description=返回的最大分组数与空闲事务连接数,默认 20,minimum=1"`
+}
+
+type ConnectionGroup struct {
+	User              string `json:"user"`
+	Host              string `json:"host"`
+	Active            int    `json:"active"`
+	Idle              int    `json:"idle"`
+	IdleInTransaction int    `json:"idle_in_transaction"`
+	LongIdle          int    `json:"long_idle"` // 空闲超过阈值的连接数
+	MaxIdleSeconds    int64  `json:"max_idle_seconds"`
+}
+
+type IdleTransactionConn struct {
+	ID              string `json:"id"`
+	User            string `json:"user"`
+	Host            string `json:"host"`
+	DB              string `json:"db,omitempty"`
+	IdleSeconds     int64  `json:"idle_seconds"`
+	TrxStarted      string `json:"trx_started"`
+	TrxAgeSeconds   int64  `json:"trx_age_seconds"`
+	TrxRowsModified int64  `json:"trx_rows_modified"`
+	TrxRowsLocked   int64  `json:"trx_rows_locked"`
+}
+
+type IdleConnectionsResult struct {
+	Total                int                   `json:"total"`
+	Active               int                   `json:"active"`
+	Idle                 int                   `json:"idle"`
+	IdleInTransaction    int                   `json:"idle_in_transaction"`
+	LongIdle             int                   `json:"long_idle"`
+	IdleThresholdSeconds int                   `json:"idle_threshold_seconds"`
+	Groups               []ConnectionGroup     `json:"groups"`
+	IdleTransactions     []IdleTransactionConn `json:"idle_transactions"` // 处于 Sleep 但持有未提交事务的连接，按事务时长降序
+	Severity             string                `json:"severity"`
+	SeverityReasons      []string              `json:"severity_reasons,omitempty"`
+}
+
+func idleConnectionsTool(ctx context.Context, input *IdleConnectionsInput) (*IdleConnectionsResult, error) {
+	threshold, limit := 600, 20
+	if input != nil {
+		if input.IdleThresholdSeconds > 0 {
+			threshold = input.IdleThresholdSeconds
+		}
+		if input.Limit > 0 {
+			limit = input.Limit
+		}
+	}
+
+	rows, err := databases.QueryConnectionStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &IdleConnectionsResult{IdleThresholdSeconds: threshold, Groups: []ConnectionGroup{}, IdleTransactions: []IdleTransactionConn{}}
+	groupIndex := make(map[string]int)
+	for _, row := range normalizeRows(rows) {
+		key := row["user"] + "@" + row["host"]
+		idx, ok := groupIndex[key]
+		if !ok {
+			idx = len(result.Groups)
+			groupIndex[key] = idx
+			result.Groups = append(result.Groups, ConnectionGroup{User: row["user"], Host: row["host"]})
+		}
+		group := &result.Groups[idx]
+		result.Total++
+
+		seconds := parseInt(row["time"])
+		trxAge := parseInt(row["trx_age"])
+		if !strings.EqualFold(row["command"], "Sleep") {
+			result.Active++
+			group.Active++
+			continue
+		}
+		if seconds > group.MaxIdleSeconds {
+			group.MaxIdleSeconds = seconds
+		}
+		if seconds >= int64(threshold) {
+			result.LongIdle++
+			group.LongIdle++
+		}
+		if trxAge < 0 {
+			result.Idle++
+			group.Idle++
+			continue
+		}
+		result.IdleInTransaction++
+		group.IdleInTransaction++
+		result.IdleTransactions = append(result.IdleTransactions, IdleTransactionConn{
+			ID:              row["id"],
+			User:            row["user"],
+			Host:            row["host"],
+			DB:              row["db"],
+			IdleSeconds:     seconds,
+			TrxStarted:      row["trx_started"],
+			TrxAgeSeconds:   trxAge,
+			TrxRowsModified: parseInt(row["trx_rows_modified"]),
+			TrxRowsLocked:   parseInt(row["trx_rows_locked"]),
+		})
+	}
+
+	sort.Slice(result.Groups, func(i, j int) bool {
+		a, b := result.Groups[i], result.Groups[j]
+		if a.IdleInTransaction != b.IdleInTransaction {
+			return a.IdleInTransaction > b.IdleInTransaction
+		}
+		return a.Active+a.Idle+a.IdleInTransaction > b.Active+b.Idle+b.IdleInTransaction
+	})
+	if len(result.Groups) > limit {
+		result.Groups = result.Groups[:limit]
+	}
+	sort.Slice(result.IdleTransactions, func(i, j int) bool {
+		return result.IdleTransactions[i].TrxAgeSeconds > result.IdleTransactions[j].TrxAgeSeconds
+	})
+
+	var high, moderate []string
+	var longestTrx int64
+	lockedRows := false
+	for _, c := range result.IdleTransactions {
+		longestTrx = max(longestTrx, c.TrxAgeSeconds)
+		if c.TrxRowsLocked > 0 || c.TrxRowsModified > 0 {
+			lockedRows = true
+		}
+	}
+	if len(result.IdleTransactions) > limit {
+		result.IdleTransactions = result.IdleTransactions[:limit]
+	}
+	switch {
+	case result.IdleInTransaction > 0 && (longestTrx >= int64(threshold) || lockedRows):
+		high = append(high, fmt.Sprintf("%d 个空闲连接持有未提交事务，最长已持续 %ds", result.IdleInTransaction, longestTrx))
+	case result.IdleInTransaction > 0:
+		moderate = append(moderate, fmt.Sprintf("%d 个空闲连接持有未提交事务", result.IdleInTransaction))
+	}
+	if result.Total > 0 && result.LongIdle*2 > result.Total {
+		moderate = append(moderate, fmt.Sprintf("%d/%d 个连接空闲超过 %ds，可能是连接池未回收或连接泄漏", result.LongIdle, result.Total, threshold))
+	}
+
+	switch {
+	case len(high) > 0:
+		result.Severity = "high"
+		result.SeverityReasons = append(high, moderate...)
+	case len(moderate) > 0:
+		result.Severity = "moderate"
+		result.SeverityReasons = moderate
+	default:
+		result.Severity = "low"
+	}
+
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"mysql-agent/databases"
+)
+
+// crashSafetyTools 崩溃恢复安全性相关的诊断工具
+func crashSafetyTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolCrashSafety, "读取 binlog_checksum、innodb_flush_method、innodb_doublewrite、innodb_fast_shutdown 并评估断电/崩溃后的恢复安全性", crashSafetyTool),
+	}
+}
+
+type CrashSafetyEntry struct {
+	Parameter string `json:"parameter"`
+	Value     string `json:"value,omitempty"`
+	Verdict   string `json:"verdict"`
+	Note      string `json:"note,omitempty"`
+}
+
+type CrashSafetyResult struct {
+	Items    []CrashSafetyEntry `json:"items"`
+	Warnings []string           `json:"warnings,omitempty"`
+}
+
+func crashSafetyTool(ctx context.Context, _ *emptyInput) (*CrashSafetyResult, error) {
+	vars, err := databases.QueryGlobalVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := vars["binlog_checksum"]
+	flushMethod := vars["innodb_flush_method"]
+	doublewrite := vars["innodb_doublewrite"]
+	fastShutdown := vars["innodb_fast_shutdown"]
+
+	result := &CrashSafetyResult{
+		Items: []CrashSafetyEntry{
+			binlogChecksumVerdict(checksum),
+			flushMethodVerdict(flushMethod),
+			doublewriteVerdict(doublewrite),
+			fastShutdownVerdict(fastShutdown),
+		},
+	}
+
+	doublewriteOff := strings.EqualFold(doublewrite, "OFF") || strings.EqualFold(doublewrite, "DETECT_ONLY")
+	if doublewriteOff && strings.HasPrefix(strings.ToUpper(flushMethod), "O_DIRECT") {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("innodb_doublewrite=%s 且 innodb_flush_method=%s，断电时可能出现无法恢复的页断裂(torn page)", doublewrite, flushMethod))
+	}
+	for _, item := range result.Items {
+		if item.Verdict == verdictWarning {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s=%s: %s", item.Parameter, item.Value, item.Note))
+		}
+	}
+
+	return result, nil
+}
+
+func binlogChecksumVerdict(value string) CrashSafetyEntry {
+	entry := CrashSafetyEntry{Parameter: "binlog_checksum", Value: value}
+	switch strings.ToUpper(value) {
+	case "":
+		entry.Verdict = verdictUnknown
+		entry.Note = "未读取到该变量"
+	case "NONE":
+		entry.Verdict = verdictWarning
+		entry.Note = "binlog 未启用校验，损坏的事件无法被发现"
+	default:
+		entry.Verdict = verdictOK
+	}
+	return entry
+}
+
+func flushMethodVerdict(value string) CrashSafetyEntry {
+	entry := CrashSafetyEntry{Parameter: "innodb_flush_method", Value: value}
+	switch strings.ToUpper(value) {
+	case "":
+		entry.Verdict = verdictUnknown
+		entry.Note = "未读取到该变量"
+	case "O_DIRECT_NO_FSYNC":
+		entry.Verdict = verdictWarning
+		entry.Note = "跳过 fsync，部分文件系统上元数据可能在断电后丢失"
+	case "NOSYNC":
+		entry.Verdict = verdictWarning
+		entry.Note = "仅用于测试，不保证数据落盘"
+	default:
+		entry.Verdict = verdictOK
+	}
+	return entry
+}
+
+func doublewriteVerdict(value string) CrashSafetyEntry {
+	entry := CrashSafetyEntry{Parameter: "innodb_doublewrite", Value: value}
+	switch strings.ToUpper(value) {
+	case "":
+		entry.Verdict = verdictUnknown
+		entry.Note = "未读取到该变量"
+	case "OFF":
+		entry.Verdict = verdictWarning
+		entry.Note = "关闭双写缓冲，页写入中断时无法修复"
+	case "DETECT_ONLY":
+		entry.Verdict = verdictWarning
+		entry.Note = "仅检测页断裂，不保存页内容用于恢复"
+	default:
+		entry.Verdict = verdictOK
+	}
+	return entry
+}
+
+func fastShutdownVerdict(value string) CrashSafetyEntry {
+	entry := CrashSafetyEntry{Parameter: "innodb_fast_shutdown", Value: value}
+	switch value {
+	case "":
+		entry.Verdict = verdictUnknown
+		entry.Note = "未读取到该变量"
+	case "2":
+		entry.Verdict = verdictWarning
+		entry.Note = "关闭时不刷脏页，重启需要执行崩溃恢复，升级前不安全"
+	default:
+		entry.Verdict = verdictOK
+	}
+	return entry
+}
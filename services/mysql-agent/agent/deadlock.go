@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mysql-agent/databases"
+)
+
+// deadlockTools 死锁分析工具
+func deadlockTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolDeadlockInfo, "解析 `SHOW ENGINE INNODB STATUS` 中 LATEST DETECTED DEADLOCK 段，返回死锁涉及的事务、语句、持有/等待的锁以及被回滚的事务", deadlockInfoTool),
+	}
+}
+
+// deadlockStatementLimit 返回给模型的单条语句最大长度
+const deadlockStatementLimit = 1024
+
+type DeadlockLock struct {
+	Type  string `json:"type"` // record / table
+	Table string `json:"table,omitempty"`
+	Index string `json:"index,omitempty"`
+	Mode  string `json:"mode,omitempty"`
+}
+
+type DeadlockTransaction struct {
+	Index         int            `json:"index"`
+	TrxID         string         `json:"trx_id,omitempty"`
+	ActiveSeconds int64          `json:"active_seconds"`
+	ThreadID      string         `json:"thread_id,omitempty"`
+	QueryID       string         `json:"query_id,omitempty"`
+	Client        string         `json:"client,omitempty"` // MySQL thread id 行末尾的 host、user 与状态
+	Statement     string         `json:"statement,omitempty"`
+	Holds         []DeadlockLock `json:"holds,omitempty"`
+	WaitingFor    []DeadlockLock `json:"waiting_for,omitempty"`
+	RolledBack    bool           `json:"rolled_back"`
+}
+
+type DeadlockInfoResult struct {
+	Found        bool                  `json:"found"`
+	DetectedAt   string                `json:"detected_at,omitempty"`
+	Transactions []DeadlockTransaction `json:"transactions,omitempty"`
+	Victim       int                   `json:"victim,omitempty"` // 被回滚的事务序号，对应 transactions[].index
+}
+
+var (
+	deadlockTrxHeader  = regexp.MustCompile(`^\*\*\* \((\d+)\) TRANSACTION:`)
+	deadlockHoldsLine  = regexp.MustCompile(`^\*\*\* \((\d+)\) HOLDS THE LOCK\(S\):`)
+	deadlockWaitLine   = regexp.MustCompile(`^\*\*\* \((\d+)\) WAITING FOR THIS LOCK TO BE GRANTED:`)
+	deadlockVictimLine = regexp.MustCompile(`^\*\*\* WE ROLL BACK TRANSACTION \((\d+)\)`)
+	deadlockTrxLine    = regexp.MustCompile(`^TRANSACTION (\d+), ACTIVE (\d+) sec`)
+	deadlockThreadLine = regexp.MustCompile(`^MySQL thread id (\d+), OS thread handle \S+, query id (\d+)\s*(.*)$`)
+	deadlockLockTable  = regexp.MustCompile("of table (`[^`]*`\\.`[^`]*`)")
+	deadlockLockIndex  = regexp.MustCompile(`index (\S+) of table`)
+	deadlockTableLock  = regexp.MustCompile("^TABLE LOCK table (`[^`]*`\\.`[^`]*`)")
+	deadlockLockMode   = regexp.MustCompile(`lock[ _]mode (.+)$`)
+)
+
+func deadlockInfoTool(ctx context.Context, _ *emptyInput) (*DeadlockInfoResult, error) {
+	rows, err := databases.QueryInnoDBStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range normalizeRows(rows) {
+		if status := row["status"]; status != "" {
+			return parseLatestDeadlock(status), nil
+		}
+	}
+	return &DeadlockInfoResult{}, nil
+}
+
+// parseLatestDeadlock 从 SHOW ENGINE INNODB STATUS 输出中解析 LATEST DETECTED DEADLOCK 段
+func parseLatestDeadlock(status string) *DeadlockInfoResult {
+	result := &DeadlockInfoResult{}
+	lines := deadlockSection(status)
+	if len(lines) == 0 {
+		return result
+	}
+	result.Found = true
+	// 段首行为 "2024-01-01 10:00:00 0x7f..."
+	if first := strings.TrimSpace(lines[0]); len(first) >= 19 {
+		result.DetectedAt = first[:19]
+	}
+
+	var trx *DeadlockTransaction
+	var locks *[]DeadlockLock
+	inStatement := false
+	var stmt []string
+
+	flush := func() {
+		if trx == nil {
+			return
+		}
+		trx.Statement = truncateStatement(strings.TrimSpace(strings.Join(stmt, "\n")))
+		result.Transactions = append(result.Transactions, *trx)
+		trx, locks, stmt, inStatement = nil, nil, nil, false
+	}
+	find := func(idx int) *DeadlockTransaction {
+		if trx != nil && trx.Index == idx {
+			return trx
+		}
+		for i := range result.Transactions {
+			if result.Transactions[i].Index == idx {
+				return &result.Transactions[i]
+			}
+		}
+		return nil
+	}
+
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(line)
+		if m := deadlockTrxHeader.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			idx, _ := strconv.Atoi(m[1])
+			trx = &DeadlockTransaction{Index: idx}
+			continue
+		}
+		if m := deadlockVictimLine.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			result.Victim, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := deadlockHoldsLine.FindStringSubmatch(trimmed); m != nil {
+			inStatement = false
+			idx, _ := strconv.Atoi(m[1])
+			if t := find(idx); t != nil {
+				locks = &t.Holds
+			}
+			continue
+		}
+		if m := deadlockWaitLine.FindStringSubmatch(trimmed); m != nil {
+			inStatement = false
+			idx, _ := strconv.Atoi(m[1])
+			if t := find(idx); t != nil {
+				locks = &t.WaitingFor
+			}
+			continue
+		}
+		if trx == nil && locks == nil {
+			continue
+		}
+
+		if locks != nil {
+			if lock, ok := parseDeadlockLock(trimmed); ok {
+				*locks = append(*locks, lock)
+			}
+			continue
+		}
+		if m := deadlockTrxLine.FindStringSubmatch(trimmed); m != nil {
+			trx.TrxID = m[1]
+			trx.ActiveSeconds, _ = strconv.ParseInt(m[2], 10, 64)
+			continue
+		}
+		if m := deadlockThreadLine.FindStringSubmatch(trimmed); m != nil {
+			trx.ThreadID, trx.QueryID, trx.Client = m[1], m[2], strings.TrimSpace(m[3])
+			// 线程信息之后直到下一个 *** 标记都是语句文本
+			inStatement = true
+			continue
+		}
+		if inStatement {
+			stmt = append(stmt, line)
+		}
+	}
+	flush()
+
+	for i := range result.Transactions {
+		result.Transactions[i].RolledBack = result.Transactions[i].Index == result.Victim
+	}
+	return result
+}
+
+// deadlockSection 返回 LATEST DETECTED DEADLOCK 标题之后、下一个分隔线之前的行
+func deadlockSection(status string) []string {
+	lines := strings.Split(status, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "LATEST DETECTED DEADLOCK" {
+			start = i + 1
+			break
+		}
+	}
+	if start == -1 {
+		return nil
+	}
+	if start < len(lines) && isDashLine(lines[start]) {
+		start++
+	}
+	end := len(lines)
+	for i := start; i < len(lines); i++ {
+		if isDashLine(lines[i]) {
+			end = i
+			break
+		}
+	}
+	return lines[start:end]
+}
+
+func isDashLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) >= 4 && strings.Trim(line, "-") == ""
+}
+
+func parseDeadlockLock(line string) (DeadlockLock, bool) {
+	var lock DeadlockLock
+	switch {
+	case strings.HasPrefix(line, "RECORD LOCKS "):
+		lock.Type = "record"
+		if m := deadlockLockTable.FindStringSubmatch(line); m != nil {
+			lock.Table = m[1]
+		}
+		if m := deadlockLockIndex.FindStringSubmatch(line); m != nil {
+			lock.Index = m[1]
+		}
+	case strings.HasPrefix(line, "TABLE LOCK "):
+		lock.Type = "table"
+		if m := deadlockTableLock.FindStringSubmatch(line); m != nil {
+			lock.Table = m[1]
+		}
+	default:
+		return lock, false
+	}
+	if m := deadlockLockMode.FindStringSubmatch(line); m != nil {
+		lock.Mode = strings.TrimSpace(m[1])
+	}
+	return lock, true
+}
+
+func truncateStatement(stmt string) string {
+	if len(stmt) <= deadlockStatementLimit {
+		return stmt
+	}
+	return stmt[:deadlockStatementLimit] + "..."
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"mysql-agent/databases"
+)
+
+// explainTools 执行计划分析工具
+func explainTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolExplainQuery, "对单条只读 SQL(SELECT/WITH/TABLE) 执行 `EXPLAIN FORMAT=JSON` 返回执行计划，可指定 schema，适合深入分析慢查询工具发现的具体语句", explainQueryTool),
+	}
+}
+
+// explainableKeywords 允许 EXPLAIN 的只读语句类型
+var explainableKeywords = map[string]struct{}{
+	"SELECT": {},
+	"WITH":   {},
+	"TABLE":  {},
+}
+
+type ExplainQueryInput struct {
+	SQL    string `json:"sql" jsonschema:"description=需要查看执行计划的只读 SQL(SELECT/WITH/TABLE)，不要包含 EXPLAIN 前缀"`
+	Schema string `json:"schema,omitempty" jsonschema:"description=执行 EXPLAIN 时的默认库,默认为配置中的库"`
+}
+
+type ExplainQueryResult struct {
+	Schema    string          `json:"schema,omitempty"`
+	Statement string          `json:"statement"`
+	Plan      json.RawMessage `json:"plan"`
+}
+
+func explainQueryTool(ctx context.Context, input *ExplainQueryInput) (*ExplainQueryResult, error) {
+	if input == nil || strings.TrimSpace(input.SQL) == "" {
+		return nil, fmt.Errorf("sql 不能为空")
+	}
+
+	stmt, err := readOnlyStatement(input.SQL)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := databases.QueryExplainJSON(ctx, input.Schema, stmt)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid([]byte(plan)) {
+		return nil, fmt.Errorf("EXPLAIN 返回的不是合法 JSON")
+	}
+
+	return &ExplainQueryResult{Schema: strings.TrimSpace(input.Schema), Statement: stmt, Plan: json.RawMessage(plan)}, nil
+}
+
+// readOnlyStatement 去掉开头注释与末尾分号，校验只有一条 SELECT/WITH/TABLE 语句
+func readOnlyStatement(raw string) (string, error) {
+	stmt := strings.TrimSpace(raw)
+	for {
+		switch {
+		case strings.HasPrefix(stmt, "/*"):
+			end := strings.Index(stmt, "*/")
+			if end == -1 {
+				return "", fmt.Errorf("注释未闭合")
+			}
+			stmt = strings.TrimSpace(stmt[end+2:])
+			continue
+		case strings.HasPrefix(stmt, "--"), strings.HasPrefix(stmt, "#"):
+			end := strings.IndexByte(stmt, '\n')
+			if end == -1 {
+				return "", fmt.Errorf("sql 不能为空")
+			}
+			stmt = strings.TrimSpace(stmt[end+1:])
+			continue
+		}
+		break
+	}
+	stmt = strings.TrimSpace(strings.TrimRight(stmt, "; \t\r\n"))
+
+	// 允许 "(SELECT ...) UNION (SELECT ...)" 形式的括号开头
+	keyword := strings.TrimLeft(stmt, "( \t\r\n")
+	if idx := strings.IndexFunc(keyword, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '(' }); idx != -1 {
+		keyword = keyword[:idx]
+	}
+	if _, ok := explainableKeywords[strings.ToUpper(keyword)]; !ok {
+		return "", fmt.Errorf("只允许对 SELECT/WITH/TABLE 语句执行 EXPLAIN，收到: %s", keyword)
+	}
+	if hasStatementSeparator(stmt) {
+		return "", fmt.Errorf("只允许单条语句")
+	}
+	return stmt, nil
+}
+
+// hasStatementSeparator 判断字符串、标识符与注释之外是否还有分号
+func hasStatementSeparator(stmt string) bool {
+	var quote byte
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		if quote != 0 {
+			switch {
+			case c == '\\' && quote != '`':
+				i++
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case ';':
+			return true
+		case '/':
+			if i+1 < len(stmt) && stmt[i+1] == '*' {
+				end := strings.Index(stmt[i+2:], "*/")
+				if end == -1 {
+					return false
+				}
+				i += end + 3
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,289 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"mysql-agent/config"
+)
+
+// 把采样器收集的 MySQL 指标以 OpenMetrics 文本格式暴露，或推送到 Prometheus remote-write 地址，
+// 使 agent 可以代替 mysqld_exporter。remote-write 的 protobuf 与 snappy 编码只用到很小的子集，直接手写
+
+const (
+	defaultRemoteWriteInterval = 30 * time.Second
+	defaultRemoteWriteTimeout  = 10 * time.Second
+	defaultExportJob           = "mysql-agent"
+	// remoteWriteBatch 单次推送的最大采样数，积压较多时分批发送
+	remoteWriteBatch = 60
+)
+
+func exporterConfig() config.ExporterConfig {
+	if config.AppConfig == nil {
+		return config.ExporterConfig{}
+	}
+	return config.AppConfig.Exporter
+}
+
+// exportSeries 导出的一条时间序列，name 为指标族名，sample 为样本名，labels 已按名称排序且包含 __name__
+type exportSeries struct {
+	name    string
+	sample  string
+	kind    string
+	labels  [][2]string
+	samples []exportSample
+}
+
+type exportSample struct {
+	value float64
+	at    time.Time
+}
+
+// exportName 状态变量导出为 mysql_global_status_<变量名>，计数器在样本名上加 _total
+func exportName(key string) (family, sample, kind string) {
+	family = "mysql_global_status_" + key
+	if baselineGauges[key] {
+		return family, family, "gauge"
+	}
+	return family, family + "_total", "counter"
+}
+
+// baseLabels job 与 exporter.labels 中的固定标签
+func baseLabels() map[string]string {
+	cfg := exporterConfig()
+	labels := map[string]string{"job": cfg.Job}
+	if labels["job"] == "" {
+		labels["job"] = defaultExportJob
+	}
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+func sortedLabels(name string, extra map[string]string) [][2]string {
+	out := make([][2]string, 0, len(extra)+1)
+	out = append(out, [2]string{"__name__", name})
+	for k, v := range extra {
+		out = append(out, [2]string{k, v})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+	return out
+}
+
+// buildSeries 把采样转换为按指标分组的时间序列，复制延迟每个通道一条，标签 channel 为通道名
+func buildSeries(data []MetricSample) []*exportSeries {
+	base := baseLabels()
+	byKey := map[string]*exportSeries{}
+	var order []string
+	add := func(key, family, name, kind string, labels map[string]string, v float64, at time.Time) {
+		s, ok := byKey[key]
+		if !ok {
+			s = &exportSeries{name: family, sample: name, kind: kind, labels: sortedLabels(name, labels)}
+			byKey[key] = s
+			order = append(order, key)
+		}
+		s.samples = append(s.samples, exportSample{value: v, at: at})
+	}
+
+	for _, sample := range data {
+		for key, v := range sample.Status {
+			family, name, kind := exportName(key)
+			add(name, family, name, kind, base, v, sample.At)
+		}
+		for channel, lag := range sample.ReplicaLag {
+			labels := map[string]string{"channel": channel}
+			for k, v := range base {
+				labels[k] = v
+			}
+			add("mysql_replica_lag_seconds\xff"+channel, "mysql_replica_lag_seconds", "mysql_replica_lag_seconds", "gauge", labels, lag, sample.At)
+		}
+	}
+
+	sort.Strings(order)
+	out := make([]*exportSeries, 0, len(order))
+	for _, key := range order {
+		out = append(out, byKey[key])
+	}
+	return out
+}
+
+// WriteOpenMetrics 以 OpenMetrics 文本格式输出最近一次采样。采样超过两个间隔未更新时 mysql_up 为 0，不输出其他指标
+func WriteOpenMetrics(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	data := samples.since(time.Now().Add(-2 * sampleInterval()))
+
+	upLabels := formatLabelPairs(sortedLabels("mysql_up", baseLabels()))
+	fmt.Fprintf(w, "# TYPE mysql_up gauge\n# HELP mysql_up 采样器最近两个采样间隔内是否成功采样\n")
+	if len(data) == 0 {
+		fmt.Fprintf(w, "mysql_up%s 0\n# EOF\n", upLabels)
+		return w.Flush()
+	}
+	fmt.Fprintf(w, "mysql_up%s 1\n", upLabels)
+
+	latest := data[len(data)-1]
+	family := ""
+	for _, s := range buildSeries([]MetricSample{latest}) {
+		if s.name != family {
+			family = s.name
+			fmt.Fprintf(w, "# TYPE %s %s\n", s.name, s.kind)
+		}
+		sample := s.samples[0]
+		ts := float64(sample.at.UnixMilli()) / 1000
+		fmt.Fprintf(w, "%s%s %s %s\n", s.sample, formatLabelPairs(s.labels), formatValue(sample.value), formatValue(ts))
+	}
+	fmt.Fprint(w, "# EOF\n")
+	return w.Flush()
+}
+
+// formatLabelPairs 输出除 __name__ 以外的标签
+func formatLabelPairs(pairs [][2]string) string {
+	var names, values []string
+	for _, p := range pairs {
+		if p[0] == "__name__" {
+			continue
+		}
+		names = append(names, p[0])
+		values = append(values, p[1])
+	}
+	return formatLabels(names, values)
+}
+
+// RunRemoteWrite 每隔 exporter.remote_write_interval 把上次推送之后的采样发送到 remote-write 地址，直到 ctx 结束。
+// 发送失败时保留进度在下次重试（受采样缓冲区容量限制），地址返回 4xx（429 除外）时丢弃该批数据
+func RunRemoteWrite(ctx context.Context) error {
+	cfg := exporterConfig()
+	interval := cfg.RemoteWriteInterval
+	if interval <= 0 {
+		interval = defaultRemoteWriteInterval
+	}
+	log.Printf("[export] remote write to %s every %s", cfg.RemoteWriteURL, interval)
+
+	var pushed time.Time
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		pending := samples.since(pushed.Add(time.Nanosecond))
+		for len(pending) > 0 {
+			batch := pending
+			if len(batch) > remoteWriteBatch {
+				batch = batch[:remoteWriteBatch]
+			}
+			retry, err := remoteWrite(ctx, cfg, batch)
+			if err != nil {
+				log.Printf("[export] remote write %d samples failed: %v", len(batch), err)
+				if retry {
+					break
+				}
+			}
+			pushed = batch[len(batch)-1].At
+			pending = pending[len(batch):]
+		}
+	}
+}
+
+func remoteWrite(ctx context.Context, cfg config.ExporterConfig, batch []MetricSample) (retry bool, err error) {
+	body := snappyEncode(encodeWriteRequest(buildSeries(batch)))
+
+	timeout := cfg.RemoteWriteTimeout
+	if timeout <= 0 {
+		timeout = defaultRemoteWriteTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.RemoteWriteURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "mysql-agent/"+Version)
+	switch {
+	case cfg.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+cfg.BearerToken)
+	case cfg.Username != "":
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5, err
+}
+
+// encodeWriteRequest 按 prometheus.WriteRequest 编码：
+// WriteRequest{timeseries=1} TimeSeries{labels=1, samples=2} Label{name=1, value=2} Sample{value=1 double, timestamp=2 int64 毫秒}
+func encodeWriteRequest(series []*exportSeries) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var label []byte
+			label = appendBytesField(label, 1, []byte(l[0]))
+			label = appendBytesField(label, 2, []byte(l[1]))
+			ts = appendBytesField(ts, 1, label)
+		}
+		for _, sample := range s.samples {
+			var sm []byte
+			sm = binary.AppendUvarint(sm, 1<<3|1)
+			sm = binary.LittleEndian.AppendUint64(sm, math.Float64bits(sample.value))
+			sm = binary.AppendUvarint(sm, 2<<3|0)
+			sm = binary.AppendUvarint(sm, uint64(sample.at.UnixMilli()))
+			ts = appendBytesField(ts, 2, sm)
+		}
+		req = appendBytesField(req, 1, ts)
+	}
+	return req
+}
+
+func appendBytesField(buf []byte, field int, data []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|2)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// snappyEncode 生成只包含字面量块的 snappy block 格式数据，不做压缩但任何 snappy 解码器都能解出原文
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(make([]byte, 0, len(src)+len(src)/65536*3+16), uint64(len(src)))
+	for len(src) > 0 {
+		chunk := src
+		if len(chunk) > 65536 {
+			chunk = chunk[:65536]
+		}
+		n := len(chunk) - 1
+		switch {
+		case n < 60:
+			dst = append(dst, byte(n)<<2)
+		case n < 1<<8:
+			dst = append(dst, 60<<2, byte(n))
+		default:
+			dst = append(dst, 61<<2, byte(n), byte(n>>8))
+		}
+		dst = append(dst, chunk...)
+		src = src[len(chunk):]
+	}
+	return dst
+}
@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"mysql-agent/databases"
+)
+
+// Version agent 版本，构建时可通过 -ldflags "-X mysql-agent/agent.Version=..." 覆盖
+var Version = "1.0.0"
+
+const (
+	defaultDeepSeekBaseURL = "https://api.deepseek.com"
+	healthCheckTimeout     = 5 * time.Second
+	// llmHealthTTL LLM 连通性检查结果的缓存时长，避免频繁探测时每次都请求外部 API
+	llmHealthTTL = 30 * time.Second
+)
+
+// 健康状态
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+var startedAt = time.Now()
+
+type HealthRequest struct {
+	// SkipLLM 为 true 时不检查 LLM 连通性，用于高频的存活探测
+	SkipLLM bool `json:"skip_llm,omitempty"`
+	// InstanceID 检查连通性的实例，为空时使用 [database]
+	InstanceID string `json:"instance_id,omitempty"`
+	// Connection backend 登记实例的连接信息，见 QueryRequest.Connection
+	Connection *InstanceConnection `json:"connection,omitempty"`
+}
+
+// ComponentHealth 单个依赖的检查结果
+type ComponentHealth struct {
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	// Detail 附加信息，如数据库版本、模型地址
+	Detail string `json:"detail,omitempty"`
+	// Skipped 为 true 表示本次未检查
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+type HealthResponse struct {
+	// Status 数据库或工具注册不可用时为 down；仅 LLM 不可用时为 degraded（规则诊断仍可用）
+	Status    string          `json:"status"`
+	Ready     bool            `json:"ready"`
+	Version   string          `json:"version"`
+	UptimeSec int64           `json:"uptime_sec"`
+	Database  ComponentHealth `json:"database"`
+	LLM       ComponentHealth `json:"llm"`
+	Instance  string          `json:"instance"`
+	ToolCount int             `json:"tool_count"`
+	ToolError string          `json:"tool_error,omitempty"`
+	Queue     QueueStats      `json:"queue"`
+}
+
+// Health 检查数据库连通性、LLM 可达性与工具注册情况，供 backend 和编排系统探测
+func (s RPCService) Health(req HealthRequest, resp *HealthResponse) error {
+	if err := checkInstance(s.context(), req.InstanceID, req.Connection); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(databases.WithInstance(s.context(), req.InstanceID), healthCheckTimeout)
+	defer cancel()
+
+	resp.Version = Version
+	resp.Instance = databases.InstanceFrom(ctx)
+	resp.UptimeSec = int64(time.Since(startedAt).Seconds())
+	resp.Queue = QueryQueueStats()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp.Database = checkDatabase(ctx)
+	}()
+	if req.SkipLLM {
+		resp.LLM = ComponentHealth{Skipped: true}
+	} else {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp.LLM = checkLLM(ctx)
+		}()
+	}
+
+	if tools, err := ensureTools(ctx); err != nil {
+		resp.ToolError = err.Error()
+	} else {
+		resp.ToolCount = len(tools)
+	}
+	wg.Wait()
+
+	switch {
+	case !resp.Database.OK || resp.ToolError != "":
+		resp.Status = HealthDown
+	case !resp.LLM.OK && !resp.LLM.Skipped:
+		resp.Status = HealthDegraded
+	default:
+		resp.Status = HealthOK
+	}
+	resp.Ready = resp.Status != HealthDown
+	return nil
+}
+
+func checkDatabase(ctx context.Context) ComponentHealth {
+	start := time.Now()
+	version, err := databases.Ping(ctx)
+	h := ComponentHealth{LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	h.OK = true
+	h.Detail = fmt.Sprintf("%s (%s)", version, databases.ServerFlavor(ctx))
+	return h
+}
+
+var (
+	llmHealthMu     sync.Mutex
+	llmHealthCached ComponentHealth
+	llmHealthAt     time.Time
+)
+
+// checkLLM 请求模型服务的 /models 接口验证地址与 API Key，不消耗 token；结果缓存 llmHealthTTL
+func checkLLM(ctx context.Context) ComponentHealth {
+	llmHealthMu.Lock()
+	defer llmHealthMu.Unlock()
+	if !llmHealthAt.IsZero() && time.Since(llmHealthAt) < llmHealthTTL {
+		return llmHealthCached
+	}
+
+	llmHealthCached = probeLLM(ctx)
+	llmHealthAt = time.Now()
+	return llmHealthCached
+}
+
+func probeLLM(ctx context.Context) ComponentHealth {
+	base := strings.TrimSpace(os.Getenv("DEEPSEEK_BASE_URL"))
+	if base == "" {
+		base = defaultDeepSeekBaseURL
+	}
+	h := ComponentHealth{Detail: base}
+	if err := checkTokenBudget(); err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	apiKey := strings.TrimSpace(os.Getenv("DEEPSEEK_API_KEY"))
+	if apiKey == "" {
+		h.Error = "DEEPSEEK_API_KEY 未设置"
+		return h
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+"/models", nil)
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	h.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		h.Error = fmt.Sprintf("模型服务返回 HTTP %d", resp.StatusCode)
+		return h
+	}
+	h.OK = true
+	return h
+}
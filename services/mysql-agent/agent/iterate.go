@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"mysql-agent/config"
+)
+
+type llmFollowUpResponse struct {
+	Done  bool             `json:"done"`
+	Tools []plannedToolCmd `json:"tools,omitempty"`
+}
+
+// followUp 在首批工具执行后让 LLM 判断数据是否足够，不够时追加工具调用（例如看到锁等待后再查阻塞事务的语句），
+// 最多 agent.max_iterations 轮。追加的结果合并进 resp 并返回合并后的工具输出；
+// 追加阶段的失败不影响已有结果，只记录在 raw.follow_up_error 中
+func followUp(ctx context.Context, req QueryRequest, resp *QueryResponse, toolOutputs []map[string]interface{}, emit func(StreamEvent), onToolDone func(ToolRun)) []map[string]interface{} {
+	maxIterations := 0
+	if cfg := config.AppConfig; cfg != nil {
+		maxIterations = cfg.Agent.MaxIterations
+	}
+	if maxIterations <= 0 {
+		return toolOutputs
+	}
+
+	descriptors, err := ToolDescriptors(ctx)
+	if err != nil {
+		log.Printf("[followUp] ToolDescriptors error: %v", err)
+		return toolOutputs
+	}
+
+	seen := make(map[string]struct{}, len(resp.Plan))
+	for _, spec := range resp.Plan {
+		seen[toolCallKey(spec)] = struct{}{}
+	}
+
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		llmOutputs, _ := budgetToolOutputs(toolOutputs)
+		more, err := requestFollowUp(ctx, req.Query, descriptors, llmOutputs)
+		if err != nil {
+			log.Printf("[followUp] iteration=%d error: %v", iteration, err)
+			resp.Raw["follow_up_error"] = err.Error()
+			break
+		}
+
+		// 已执行过的相同调用不再重复，全部重复视为数据已足够
+		fresh := make([]ToolCallSpec, 0, len(more))
+		for _, spec := range more {
+			key := toolCallKey(spec)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			fresh = append(fresh, spec)
+		}
+		if len(fresh) == 0 {
+			log.Printf("[followUp] iteration=%d done", iteration)
+			break
+		}
+
+		log.Printf("[followUp] iteration=%d plan=%v", iteration, summarizePlan(fresh))
+		resp.Plan = append(resp.Plan, fresh...)
+		if emit != nil {
+			emit(StreamEvent{Type: EventPlan, Plan: fresh, Iteration: iteration})
+		}
+
+		runs, outputs, failure := executePlan(ctx, fresh, onToolDone)
+		resp.ToolRuns = append(resp.ToolRuns, runs...)
+		toolOutputs = append(toolOutputs, outputs...)
+		if failure != "" {
+			log.Printf("[followUp] iteration=%d tools failed: %s", iteration, failure)
+			resp.Raw["follow_up_error"] = failure
+			break
+		}
+	}
+	return toolOutputs
+}
+
+// requestFollowUp 请求 LLM 判断是否需要追加工具，返回空列表表示数据已足够
+func requestFollowUp(ctx context.Context, query string, descriptors []ToolDescriptor, toolOutputs []map[string]interface{}) ([]ToolCallSpec, error) {
+	systemPrompt, err := renderPrompt(ctx, promptFollowUpSystem)
+	if err != nil {
+		return nil, err
+	}
+	messages := []*schema.Message{
+		{Role: schema.System, Content: systemPrompt},
+		{Role: schema.User, Content: buildFollowUpPrompt(descriptors, query, toolOutputs)},
+	}
+
+	result, err := Generate(ctx, messages)
+	if err != nil {
+		return nil, fmt.Errorf("请求 LLM 追加工具失败: %w", err)
+	}
+	if result == nil {
+		return nil, fmt.Errorf("LLM 返回为空")
+	}
+	log.Printf("[requestFollowUp] raw_response=%s", truncate(result.Content))
+
+	var followUp llmFollowUpResponse
+	if err := decodeLLMJSON(result.Content, &followUp); err != nil {
+		return nil, fmt.Errorf("解析 LLM 追加工具响应失败: %w", err)
+	}
+	if followUp.Done {
+		return nil, nil
+	}
+	return toToolSpecs(followUp.Tools)
+}
+
+func buildFollowUpPrompt(descriptors []ToolDescriptor, query string, toolOutputs []map[string]interface{}) string {
+	var sb strings.Builder
+	sb.WriteString("用户问题: ")
+	sb.WriteString(query)
+	sb.WriteString("\n\n已执行的工具及输出:\n")
+	for _, item := range toolOutputs {
+		name, _ := item["name"].(string)
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", name, compactJSON(item["output"])))
+	}
+	sb.WriteString("\n可用工具如下 (仅能从中选择):\n")
+	for _, d := range descriptors {
+		sb.WriteString("- ")
+		sb.WriteString(d.Name)
+		sb.WriteString(": ")
+		sb.WriteString(d.Desc)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n如果已有数据足以回答用户问题，输出 JSON: {\"done\": true}。" +
+		"如果需要进一步数据（例如发现锁等待后查询阻塞事务正在执行的语句），输出 JSON: " +
+		"{\"done\": false, \"tools\": [{\"name\": 工具名, \"args\": 参数对象, \"reason\": \"调用原因\"}]}。" +
+		"不要重复调用参数相同的工具，禁止使用未提供的工具。")
+	return sb.String()
+}
+
+// toolCallKey 以工具名和规范化后的参数标识一次调用
+func toolCallKey(spec ToolCallSpec) string {
+	return spec.Name + "|" + compactJSON(safeParseJSON(string(spec.Args)))
+}
+
+// compactJSON 以紧凑 JSON 表示任意值，map 的键按字典序输出
+func compactJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,312 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"mysql-agent/databases"
+)
+
+// lockTools 行锁、元数据锁与 purge 滞后相关的诊断工具
+func lockTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolRowLockStats, "读取 Innodb_row_lock_* 状态计数，计算每小时锁等待次数与平均等待时长，并给出行锁争用程度(low/moderate/high)", rowLockStatsTool),
+		inferTool(toolMetadataLock, "查询 `performance_schema.metadata_locks` 关联线程与 innodb_trx，按对象列出持有者与等待者，识别被未提交事务阻塞的 DDL(Waiting for table metadata lock)", metadataLocksTool),
+		inferTool(toolPurgeLag, "读取 InnoDB history list length(innodb_metrics 或 `SHOW ENGINE INNODB STATUS`)并关联最早的活跃事务，判断长事务导致的 purge 滞后与 undo 膨胀", purgeLagTool),
+	}
+}
+
+type RowLockStatsResult struct {
+	Waits          int64   `json:"innodb_row_lock_waits"`
+	CurrentWaits   int64   `json:"innodb_row_lock_current_waits"`
+	TimeMs         int64   `json:"innodb_row_lock_time_ms"`
+	TimeAvgMs      int64   `json:"innodb_row_lock_time_avg_ms"`
+	TimeMaxMs      int64   `json:"innodb_row_lock_time_max_ms"`
+	UptimeSeconds  int64   `json:"uptime_seconds"`
+	WaitsPerHour   float64 `json:"waits_per_hour"`
+	AvgWaitMs      float64 `json:"avg_wait_ms"`
+	Severity       string  `json:"severity"`
+	SeverityReason string  `json:"severity_reason,omitempty"`
+}
+
+func rowLockStatsTool(ctx context.Context, _ *emptyInput) (*RowLockStatsResult, error) {
+	status, err := globalStatusValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RowLockStatsResult{
+		Waits:         parseInt(status["innodb_row_lock_waits"]),
+		CurrentWaits:  parseInt(status["innodb_row_lock_current_waits"]),
+		TimeMs:        parseInt(status["innodb_row_lock_time"]),
+		TimeAvgMs:     parseInt(status["innodb_row_lock_time_avg"]),
+		TimeMaxMs:     parseInt(status["innodb_row_lock_time_max"]),
+		UptimeSeconds: parseInt(status["uptime"]),
+	}
+	if result.UptimeSeconds > 0 {
+		result.WaitsPerHour = float64(result.Waits) * 3600 / float64(result.UptimeSeconds)
+	}
+	if result.Waits > 0 {
+		result.AvgWaitMs = float64(result.TimeMs) / float64(result.Waits)
+	}
+
+	switch {
+	case result.CurrentWaits >= 5:
+		result.Severity = "high"
+		result.SeverityReason = fmt.Sprintf("当前有 %d 个行锁等待", result.CurrentWaits)
+	case result.AvgWaitMs >= 1000:
+		result.Severity = "high"
+		result.SeverityReason = fmt.Sprintf("平均行锁等待 %.0fms", result.AvgWaitMs)
+	case result.WaitsPerHour >= 3600:
+		result.Severity = "high"
+		result.SeverityReason = fmt.Sprintf("平均每小时 %.0f 次行锁等待", result.WaitsPerHour)
+	case result.CurrentWaits > 0:
+		result.Severity = "moderate"
+		result.SeverityReason = fmt.Sprintf("当前有 %d 个行锁等待", result.CurrentWaits)
+	case result.AvgWaitMs >= 100:
+		result.Severity = "moderate"
+		result.SeverityReason = fmt.Sprintf("平均行锁等待 %.0fms", result.AvgWaitMs)
+	case result.WaitsPerHour >= 60:
+		result.Severity = "moderate"
+		result.SeverityReason = fmt.Sprintf("平均每小时 %.0f 次行锁等待", result.WaitsPerHour)
+	default:
+		result.Severity = "low"
+	}
+
+	return result, nil
+}
+
+type MetadataLocksInput struct {
+	Schema      string `json:"schema,omitempty" jsonschema:"description=只返回指定数据库中的对象"`
+	IncludeIdle bool   `json:"include_idle,omitempty" jsonschema:"description=是否包含没有等待者的对象,默认只返回存在 MDL 等待的对象"`
+}
+
+type MDLSession struct {
+	ProcesslistID string `json:"processlist_id,omitempty"`
+	User          string `json:"user,omitempty"`
+	Host          string `json:"host,omitempty"`
+	Command       string `json:"command,omitempty"`
+	TimeSeconds   int64  `json:"time_seconds"`
+	State         string `json:"state,omitempty"`
+	Statement     string `json:"statement,omitempty"`
+	LockType      string `json:"lock_type"`
+	LockDuration  string `json:"lock_duration"`
+	TrxStarted    string `json:"trx_started,omitempty"`
+	IdleInTrx     bool   `json:"idle_in_transaction"` // 空闲连接仍持有事务级 MDL，通常是未提交的事务
+}
+
+type MDLObject struct {
+	Type    string       `json:"type"`
+	Schema  string       `json:"schema,omitempty"`
+	Name    string       `json:"name,omitempty"`
+	Holders []MDLSession `json:"holders"`
+	Waiters []MDLSession `json:"waiters"`
+}
+
+type MetadataLocksResult struct {
+	InstrumentEnabled bool        `json:"instrument_enabled"`
+	Objects           []MDLObject `json:"objects"`
+	WaitingSessions   int         `json:"waiting_sessions"`
+	Findings          []string    `json:"findings,omitempty"`
+}
+
+func metadataLocksTool(ctx context.Context, input *MetadataLocksInput) (*MetadataLocksResult, error) {
+	schema := ""
+	includeIdle := false
+	if input != nil {
+		schema = strings.TrimSpace(input.Schema)
+		includeIdle = input.IncludeIdle
+	}
+
+	enabled, err := databases.QueryMDLInstrumentEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &MetadataLocksResult{InstrumentEnabled: enabled, Objects: []MDLObject{}}
+	if !enabled {
+		result.Findings = append(result.Findings, "performance_schema 未开启 wait/lock/metadata/sql/mdl instrument，无法观测 MDL，可执行 UPDATE performance_schema.setup_instruments SET ENABLED='YES' WHERE NAME='wait/lock/metadata/sql/mdl'")
+		return result, nil
+	}
+
+	rows, err := databases.QueryMetadataLocks(ctx, schema)
+	if errors.Is(err, databases.ErrUnsupported) {
+		result.Findings = append(result.Findings, err.Error())
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	for _, row := range normalizeRows(rows) {
+		key := row["object_type"] + "\x00" + row["object_schema"] + "\x00" + row["object_name"]
+		i, ok := index[key]
+		if !ok {
+			i = len(result.Objects)
+			index[key] = i
+			result.Objects = append(result.Objects, MDLObject{
+				Type:    row["object_type"],
+				Schema:  row["object_schema"],
+				Name:    row["object_name"],
+				Holders: []MDLSession{},
+				Waiters: []MDLSession{},
+			})
+		}
+
+		session := MDLSession{
+			ProcesslistID: row["processlist_id"],
+			User:          row["processlist_user"],
+			Host:          row["processlist_host"],
+			Command:       row["processlist_command"],
+			TimeSeconds:   parseInt(row["processlist_time"]),
+			State:         row["processlist_state"],
+			Statement:     row["processlist_info"],
+			LockType:      row["lock_type"],
+			LockDuration:  row["lock_duration"],
+			TrxStarted:    row["trx_started"],
+		}
+		session.IdleInTrx = strings.EqualFold(session.Command, "Sleep") && session.LockDuration == "TRANSACTION"
+
+		if row["lock_status"] == "PENDING" {
+			result.Objects[i].Waiters = append(result.Objects[i].Waiters, session)
+			result.WaitingSessions++
+		} else {
+			result.Objects[i].Holders = append(result.Objects[i].Holders, session)
+		}
+	}
+
+	if !includeIdle {
+		waiting := result.Objects[:0]
+		for _, obj := range result.Objects {
+			if len(obj.Waiters) > 0 {
+				waiting = append(waiting, obj)
+			}
+		}
+		result.Objects = waiting
+	}
+
+	for _, obj := range result.Objects {
+		if len(obj.Waiters) == 0 {
+			continue
+		}
+		name := obj.Schema + "." + obj.Name
+		for _, h := range obj.Holders {
+			if h.IdleInTrx {
+				result.Findings = append(result.Findings, fmt.Sprintf("%s 上有 %d 个会话在等待 MDL，连接 %s(%s@%s) 空闲 %d 秒但事务未提交仍持有锁，可提交/回滚该事务或 KILL %s",
+					name, len(obj.Waiters), h.ProcesslistID, h.User, h.Host, h.TimeSeconds, h.ProcesslistID))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+type OldestTransaction struct {
+	TrxID          string `json:"trx_id"`
+	State          string `json:"state"`
+	Started        string `json:"started"`
+	AgeSeconds     int64  `json:"age_seconds"`
+	ThreadID       string `json:"thread_id"`
+	RowsModified   int64  `json:"rows_modified"`
+	IsolationLevel string `json:"isolation_level,omitempty"`
+	Query          string `json:"query,omitempty"` // 为空表示事务空闲，通常是应用未提交
+}
+
+type PurgeLagResult struct {
+	HistoryListLength int64              `json:"history_list_length"`
+	Source            string             `json:"source"` // innodb_metrics 或 innodb_status
+	OldestTrx         *OldestTransaction `json:"oldest_transaction,omitempty"`
+	PurgeThreads      string             `json:"innodb_purge_threads,omitempty"`
+	MaxPurgeLag       string             `json:"innodb_max_purge_lag,omitempty"`
+	Severity          string             `json:"severity"`
+	SeverityReasons   []string           `json:"severity_reasons,omitempty"`
+}
+
+// historyListLengthPattern 匹配 InnoDB 状态中的 "History list length 1234"
+var historyListLengthPattern = regexp.MustCompile(`History list length (\d+)`)
+
+func purgeLagTool(ctx context.Context, _ *emptyInput) (*PurgeLagResult, error) {
+	result := &PurgeLagResult{Source: "innodb_metrics"}
+
+	hll, ok, err := databases.QueryHistoryListLength(ctx)
+	if err != nil {
+		log.Printf("[purgeLagTool] query innodb_metrics failed: %v", err)
+	}
+	if ok {
+		result.HistoryListLength = hll
+	} else {
+		rows, err := databases.QueryInnoDBStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result.Source = "innodb_status"
+		for _, row := range normalizeRows(rows) {
+			if m := historyListLengthPattern.FindStringSubmatch(row["status"]); m != nil {
+				result.HistoryListLength = parseInt(m[1])
+				break
+			}
+		}
+	}
+
+	trxRows, err := databases.QueryOldestTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rows := normalizeRows(trxRows); len(rows) > 0 {
+		row := rows[0]
+		result.OldestTrx = &OldestTransaction{
+			TrxID:          row["trx_id"],
+			State:          row["trx_state"],
+			Started:        row["trx_started"],
+			AgeSeconds:     parseInt(row["age_seconds"]),
+			ThreadID:       row["trx_mysql_thread_id"],
+			RowsModified:   parseInt(row["trx_rows_modified"]),
+			IsolationLevel: row["trx_isolation_level"],
+			Query:          row["trx_query"],
+		}
+	}
+
+	if vars, err := databases.QueryGlobalVariables(ctx); err != nil {
+		log.Printf("[purgeLagTool] query variables failed: %v", err)
+	} else {
+		result.PurgeThreads = vars["innodb_purge_threads"]
+		result.MaxPurgeLag = vars["innodb_max_purge_lag"]
+	}
+
+	high, moderate := []string{}, []string{}
+	switch {
+	case result.HistoryListLength >= 1000000:
+		high = append(high, fmt.Sprintf("history list length=%d，purge 严重滞后", result.HistoryListLength))
+	case result.HistoryListLength >= 100000:
+		moderate = append(moderate, fmt.Sprintf("history list length=%d", result.HistoryListLength))
+	}
+	if trx := result.OldestTrx; trx != nil && trx.AgeSeconds >= 600 {
+		reason := fmt.Sprintf("最早的事务 %s(线程 %s)已运行 %d 秒", trx.TrxID, trx.ThreadID, trx.AgeSeconds)
+		if trx.Query == "" {
+			reason += "，当前没有执行语句，可能是应用开启事务后未提交"
+		}
+		// 长事务持有的 read view 会阻止 purge，与较高的 history list length 同时出现时判定为根因
+		if result.HistoryListLength >= 100000 || trx.AgeSeconds >= 3600 {
+			high = append(high, reason)
+		} else {
+			moderate = append(moderate, reason)
+		}
+	}
+
+	switch {
+	case len(high) > 0:
+		result.Severity = "high"
+		result.SeverityReasons = append(high, moderate...)
+	case len(moderate) > 0:
+		result.Severity = "moderate"
+		result.SeverityReasons = moderate
+	default:
+		result.Severity = "low"
+	}
+
+	return result, nil
+}
@@ -0,0 +1,250 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// MCP（Model Context Protocol）服务端实现，只提供 tools 能力，工具与诊断计划共用同一注册表。
+// 传输层（stdio、SSE）只负责收发 JSON-RPC 2.0 消息，见 main 包的 mcp_server.go
+
+const mcpServerName = "mysql-agent"
+
+// mcpProtocolVersions 支持的协议版本，第一个为默认版本
+var mcpProtocolVersions = []string{"2025-03-26", "2024-11-05"}
+
+// JSON-RPC 2.0 错误码
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type mcpMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type mcpResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *mcpError       `json:"error,omitempty"`
+}
+
+type mcpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type mcpTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type mcpCallResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError"`
+}
+
+// MCPSession 一个 MCP 客户端会话。工具调用在独立的 goroutine 中执行，
+// 客户端发送 notifications/cancelled 或会话上下文结束时取消对应调用
+type MCPSession struct {
+	ctx  context.Context
+	send func([]byte)
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewMCPSession 创建会话，send 用于向客户端发送一条完整的 JSON-RPC 消息，需可并发调用
+func NewMCPSession(ctx context.Context, send func([]byte)) *MCPSession {
+	return &MCPSession{ctx: ctx, send: send, inflight: map[string]context.CancelFunc{}}
+}
+
+// Wait 等待进行中的工具调用全部返回
+func (s *MCPSession) Wait() {
+	s.wg.Wait()
+}
+
+// Handle 处理客户端发来的一条消息，请求的响应通过 send 异步返回，通知与客户端响应没有返回
+func (s *MCPSession) Handle(raw []byte) {
+	var msg mcpMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		s.reply(json.RawMessage("null"), nil, &mcpError{Code: rpcParseError, Message: err.Error()})
+		return
+	}
+	if msg.JSONRPC != "2.0" {
+		s.reply(json.RawMessage("null"), nil, &mcpError{Code: rpcInvalidRequest, Message: "jsonrpc 必须为 2.0"})
+		return
+	}
+	if msg.Method == "" {
+		// 客户端对服务端请求的响应，本服务不会主动发起请求
+		return
+	}
+	if msg.ID == nil {
+		s.notify(msg)
+		return
+	}
+
+	switch msg.Method {
+	case "initialize":
+		s.reply(msg.ID, s.initialize(msg.Params), nil)
+	case "ping":
+		s.reply(msg.ID, struct{}{}, nil)
+	case "tools/list":
+		tools, err := mcpListTools(s.ctx)
+		if err != nil {
+			s.reply(msg.ID, nil, &mcpError{Code: rpcInternalError, Message: err.Error()})
+			return
+		}
+		s.reply(msg.ID, map[string]interface{}{"tools": tools}, nil)
+	case "tools/call":
+		s.startCall(msg)
+	default:
+		s.reply(msg.ID, nil, &mcpError{Code: rpcMethodNotFound, Message: fmt.Sprintf("不支持的方法: %s", msg.Method)})
+	}
+}
+
+func (s *MCPSession) initialize(params json.RawMessage) interface{} {
+	var req struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ClientInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"clientInfo"`
+	}
+	_ = json.Unmarshal(params, &req)
+
+	version := mcpProtocolVersions[0]
+	for _, v := range mcpProtocolVersions {
+		if v == req.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+	log.Printf("[MCP] initialize client=%s/%s protocol=%s", req.ClientInfo.Name, req.ClientInfo.Version, version)
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{"listChanged": false},
+		},
+		"serverInfo": map[string]string{"name": mcpServerName, "version": Version},
+	}
+}
+
+func (s *MCPSession) notify(msg mcpMessage) {
+	switch msg.Method {
+	case "notifications/cancelled":
+		var params struct {
+			RequestID json.RawMessage `json:"requestId"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return
+		}
+		s.mu.Lock()
+		cancel := s.inflight[string(params.RequestID)]
+		s.mu.Unlock()
+		if cancel != nil {
+			log.Printf("[MCP] cancel request id=%s", params.RequestID)
+			cancel()
+		}
+	}
+}
+
+func (s *MCPSession) startCall(msg mcpMessage) {
+	var params struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil || params.Name == "" {
+		s.reply(msg.ID, nil, &mcpError{Code: rpcInvalidParams, Message: "tools/call 需要 name 参数"})
+		return
+	}
+	if !toolEnabled(s.ctx, params.Name) {
+		s.reply(msg.ID, nil, &mcpError{Code: rpcInvalidParams, Message: fmt.Sprintf("未找到工具: %s", params.Name)})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	key := string(msg.ID)
+	s.mu.Lock()
+	s.inflight[key] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.inflight, key)
+			s.mu.Unlock()
+			cancel()
+		}()
+
+		result := mcpCallTool(ctx, params.Name, string(params.Arguments))
+		if ctx.Err() != nil && s.ctx.Err() == nil {
+			// 已被客户端取消，按协议不再返回响应
+			return
+		}
+		s.reply(msg.ID, result, nil)
+	}()
+}
+
+func (s *MCPSession) reply(id json.RawMessage, result interface{}, rpcErr *mcpError) {
+	resp := mcpResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr}
+	if result == nil && rpcErr == nil {
+		resp.Result = struct{}{}
+	}
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("[MCP] 序列化响应失败: %v", err)
+		return
+	}
+	s.send(raw)
+}
+
+// mcpListTools 返回当前注册的全部工具及其参数 JSON Schema
+func mcpListTools(ctx context.Context) ([]mcpTool, error) {
+	specs, err := ToolSpecs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]mcpTool, 0, len(specs))
+	for _, spec := range specs {
+		result = append(result, mcpTool{Name: spec.Name, Description: spec.Description, InputSchema: spec.Parameters})
+	}
+	return result, nil
+}
+
+// mcpCallTool 校验参数后执行工具。工具本身的失败按 MCP 约定以 isError 结果返回，便于模型看到错误原因
+func mcpCallTool(ctx context.Context, name, rawArgs string) mcpCallResult {
+	args, rejected, err := validateToolArgs(ctx, name, rawArgs)
+	if err != nil {
+		return mcpCallResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	for _, r := range rejected {
+		log.Printf("[MCP] tool=%s dropped param %s: %s", name, r.Name, r.Reason)
+	}
+
+	output, err := CallTool(ctx, name, args)
+	if err != nil {
+		return mcpCallResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	return mcpCallResult{Content: []mcpContent{{Type: "text", Text: output}}}
+}
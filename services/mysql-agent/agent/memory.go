@@ -0,0 +1,311 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"mysql-agent/databases"
+)
+
+// memoryTools 缓冲池、临时表与排序、表缓存等内存相关的诊断工具
+func memoryTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolBufferPool, "读取 `information_schema.innodb_buffer_pool_stats` 与 Innodb_buffer_pool_* 状态计数，计算缓冲池命中率、脏页比例与空闲页比例，并给出压力程度(low/moderate/high)", bufferPoolStatsTool),
+		inferTool(toolTmpSort, "读取 Created_tmp_disk_tables、Created_tmp_tables、Sort_merge_passes、Select_full_join 等状态计数与 tmp_table_size/sort_buffer_size，计算落盘临时表比例与每小时频率，并给出严重程度(low/moderate/high)", tmpSortStatsTool),
+		inferTool(toolTableCache, "读取 Open_tables、Opened_tables、Table_open_cache_hits/misses/overflows 等状态与 table_open_cache、table_definition_cache 配置，计算表缓存使用率、命中率与每秒打开表次数，并给出缓存大小建议", tableCacheTool),
+	}
+}
+
+type BufferPoolInstance struct {
+	PoolID        int64 `json:"pool_id"`
+	PoolSize      int64 `json:"pool_size"`
+	FreeBuffers   int64 `json:"free_buffers"`
+	DatabasePages int64 `json:"database_pages"`
+	ModifiedPages int64 `json:"modified_pages"`
+	HitRate       int64 `json:"hit_rate_per_mille"` // 最近一段时间的命中率，千分比
+}
+
+type BufferPoolStatsResult struct {
+	PagesTotal     int64                `json:"pages_total"`
+	PagesFree      int64                `json:"pages_free"`
+	PagesData      int64                `json:"pages_data"`
+	PagesDirty     int64                `json:"pages_dirty"`
+	ReadRequests   int64                `json:"read_requests"`
+	DiskReads      int64                `json:"disk_reads"`
+	WaitFree       int64                `json:"wait_free"`
+	HitRatio       float64              `json:"hit_ratio_pct"`
+	DirtyPct       float64              `json:"dirty_pct"`
+	FreePct        float64              `json:"free_pct"`
+	Pools          []BufferPoolInstance `json:"pools,omitempty"`
+	Severity       string               `json:"severity"`
+	SeverityReason string               `json:"severity_reason,omitempty"`
+}
+
+func bufferPoolStatsTool(ctx context.Context, _ *emptyInput) (*BufferPoolStatsResult, error) {
+	status, err := globalStatusValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BufferPoolStatsResult{
+		PagesTotal:   parseInt(status["innodb_buffer_pool_pages_total"]),
+		PagesFree:    parseInt(status["innodb_buffer_pool_pages_free"]),
+		PagesData:    parseInt(status["innodb_buffer_pool_pages_data"]),
+		PagesDirty:   parseInt(status["innodb_buffer_pool_pages_dirty"]),
+		ReadRequests: parseInt(status["innodb_buffer_pool_read_requests"]),
+		DiskReads:    parseInt(status["innodb_buffer_pool_reads"]),
+		WaitFree:     parseInt(status["innodb_buffer_pool_wait_free"]),
+	}
+	if result.ReadRequests > 0 {
+		result.HitRatio = 100 * float64(result.ReadRequests-result.DiskReads) / float64(result.ReadRequests)
+	}
+	if result.PagesTotal > 0 {
+		result.DirtyPct = 100 * float64(result.PagesDirty) / float64(result.PagesTotal)
+		result.FreePct = 100 * float64(result.PagesFree) / float64(result.PagesTotal)
+	}
+
+	// 实例级统计只作补充，读取失败时不影响整体结果
+	rows, err := databases.QueryBufferPoolStats(ctx)
+	if err != nil {
+		log.Printf("[bufferPoolStatsTool] query innodb_buffer_pool_stats failed: %v", err)
+	}
+	for _, row := range normalizeRows(rows) {
+		result.Pools = append(result.Pools, BufferPoolInstance{
+			PoolID:        parseInt(row["pool_id"]),
+			PoolSize:      parseInt(row["pool_size"]),
+			FreeBuffers:   parseInt(row["free_buffers"]),
+			DatabasePages: parseInt(row["database_pages"]),
+			ModifiedPages: parseInt(row["modified_database_pages"]),
+			HitRate:       parseInt(row["hit_rate"]),
+		})
+	}
+
+	switch {
+	case result.WaitFree > 0:
+		result.Severity = "high"
+		result.SeverityReason = fmt.Sprintf("出现 %d 次等待空闲页(Innodb_buffer_pool_wait_free)", result.WaitFree)
+	case result.ReadRequests > 0 && result.HitRatio < 95:
+		result.Severity = "high"
+		result.SeverityReason = fmt.Sprintf("缓冲池命中率 %.2f%%", result.HitRatio)
+	case result.DirtyPct >= 75:
+		result.Severity = "high"
+		result.SeverityReason = fmt.Sprintf("脏页比例 %.1f%%", result.DirtyPct)
+	case result.ReadRequests > 0 && result.HitRatio < 99:
+		result.Severity = "moderate"
+		result.SeverityReason = fmt.Sprintf("缓冲池命中率 %.2f%%", result.HitRatio)
+	case result.DirtyPct >= 50:
+		result.Severity = "moderate"
+		result.SeverityReason = fmt.Sprintf("脏页比例 %.1f%%", result.DirtyPct)
+	case result.PagesTotal > 0 && result.FreePct < 1 && result.HitRatio < 99.9:
+		result.Severity = "moderate"
+		result.SeverityReason = fmt.Sprintf("空闲页仅剩 %.2f%%", result.FreePct)
+	default:
+		result.Severity = "low"
+	}
+
+	return result, nil
+}
+
+type TmpSortStatsResult struct {
+	TmpTables         int64    `json:"created_tmp_tables"`
+	TmpDiskTables     int64    `json:"created_tmp_disk_tables"`
+	TmpFiles          int64    `json:"created_tmp_files"`
+	SortMergePasses   int64    `json:"sort_merge_passes"`
+	SortRows          int64    `json:"sort_rows"`
+	SortScan          int64    `json:"sort_scan"`
+	SortRange         int64    `json:"sort_range"`
+	SelectFullJoin    int64    `json:"select_full_join"`
+	SelectRangeCheck  int64    `json:"select_range_check"`
+	SelectScan        int64    `json:"select_scan"`
+	UptimeSeconds     int64    `json:"uptime_seconds"`
+	DiskTmpPct        float64  `json:"disk_tmp_table_pct"` // 落盘临时表占全部临时表的百分比
+	TmpDiskPerHour    float64  `json:"tmp_disk_tables_per_hour"`
+	MergePassesPerSec float64  `json:"sort_merge_passes_per_sec"`
+	FullJoinsPerHour  float64  `json:"full_joins_per_hour"`
+	TmpTableSize      string   `json:"tmp_table_size,omitempty"`
+	MaxHeapTableSize  string   `json:"max_heap_table_size,omitempty"`
+	SortBufferSize    string   `json:"sort_buffer_size,omitempty"`
+	Severity          string   `json:"severity"`
+	SeverityReasons   []string `json:"severity_reasons,omitempty"`
+}
+
+func tmpSortStatsTool(ctx context.Context, _ *emptyInput) (*TmpSortStatsResult, error) {
+	status, err := globalStatusValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TmpSortStatsResult{
+		TmpTables:        parseInt(status["created_tmp_tables"]),
+		TmpDiskTables:    parseInt(status["created_tmp_disk_tables"]),
+		TmpFiles:         parseInt(status["created_tmp_files"]),
+		SortMergePasses:  parseInt(status["sort_merge_passes"]),
+		SortRows:         parseInt(status["sort_rows"]),
+		SortScan:         parseInt(status["sort_scan"]),
+		SortRange:        parseInt(status["sort_range"]),
+		SelectFullJoin:   parseInt(status["select_full_join"]),
+		SelectRangeCheck: parseInt(status["select_range_check"]),
+		SelectScan:       parseInt(status["select_scan"]),
+		UptimeSeconds:    parseInt(status["uptime"]),
+	}
+	if result.TmpTables > 0 {
+		result.DiskTmpPct = 100 * float64(result.TmpDiskTables) / float64(result.TmpTables)
+	}
+	if result.UptimeSeconds > 0 {
+		hours := float64(result.UptimeSeconds) / 3600
+		result.TmpDiskPerHour = float64(result.TmpDiskTables) / hours
+		result.FullJoinsPerHour = float64(result.SelectFullJoin) / hours
+		result.MergePassesPerSec = float64(result.SortMergePasses) / float64(result.UptimeSeconds)
+	}
+
+	// 变量只用于给出调参建议，读取失败不影响计数结果
+	if vars, err := databases.QueryGlobalVariables(ctx); err != nil {
+		log.Printf("[tmpSortStatsTool] query variables failed: %v", err)
+	} else {
+		result.TmpTableSize = vars["tmp_table_size"]
+		result.MaxHeapTableSize = vars["max_heap_table_size"]
+		result.SortBufferSize = vars["sort_buffer_size"]
+	}
+
+	high, moderate := []string{}, []string{}
+	switch {
+	case result.TmpTables >= 100 && result.DiskTmpPct >= 25:
+		high = append(high, fmt.Sprintf("%.1f%% 的临时表落盘", result.DiskTmpPct))
+	case result.TmpTables >= 100 && result.DiskTmpPct >= 10:
+		moderate = append(moderate, fmt.Sprintf("%.1f%% 的临时表落盘", result.DiskTmpPct))
+	}
+	switch {
+	case result.MergePassesPerSec >= 1:
+		high = append(high, fmt.Sprintf("每秒 %.2f 次排序归并(Sort_merge_passes)，sort_buffer_size 可能不足", result.MergePassesPerSec))
+	case result.MergePassesPerSec >= 0.1:
+		moderate = append(moderate, fmt.Sprintf("每秒 %.2f 次排序归并(Sort_merge_passes)", result.MergePassesPerSec))
+	}
+	switch {
+	case result.FullJoinsPerHour >= 3600:
+		high = append(high, fmt.Sprintf("每小时 %.0f 次无索引 JOIN(Select_full_join)", result.FullJoinsPerHour))
+	case result.FullJoinsPerHour >= 60:
+		moderate = append(moderate, fmt.Sprintf("每小时 %.0f 次无索引 JOIN(Select_full_join)", result.FullJoinsPerHour))
+	}
+
+	switch {
+	case len(high) > 0:
+		result.Severity = "high"
+		result.SeverityReasons = append(high, moderate...)
+	case len(moderate) > 0:
+		result.Severity = "moderate"
+		result.SeverityReasons = moderate
+	default:
+		result.Severity = "low"
+	}
+
+	return result, nil
+}
+
+type TableCacheResult struct {
+	OpenTables             int64    `json:"open_tables"`
+	OpenedTables           int64    `json:"opened_tables"`
+	OpenTableDefinitions   int64    `json:"open_table_definitions"`
+	OpenedTableDefinitions int64    `json:"opened_table_definitions"`
+	CacheHits              int64    `json:"table_open_cache_hits"`
+	CacheMisses            int64    `json:"table_open_cache_misses"`
+	CacheOverflows         int64    `json:"table_open_cache_overflows"`
+	TableOpenCache         int64    `json:"table_open_cache"`
+	TableDefinitionCache   int64    `json:"table_definition_cache"`
+	CacheInstances         int64    `json:"table_open_cache_instances"`
+	OpenFilesLimit         int64    `json:"open_files_limit"`
+	UptimeSeconds          int64    `json:"uptime_seconds"`
+	OpenedPerSecond        float64  `json:"opened_tables_per_sec"`
+	CacheUsagePct          float64  `json:"table_open_cache_usage_pct"`
+	DefinitionUsagePct     float64  `json:"table_definition_cache_usage_pct"`
+	HitRatio               float64  `json:"table_open_cache_hit_pct"`
+	SuggestedOpenCache     int64    `json:"suggested_table_open_cache,omitempty"`
+	SuggestedDefCache      int64    `json:"suggested_table_definition_cache,omitempty"`
+	Severity               string   `json:"severity"`
+	SeverityReasons        []string `json:"severity_reasons,omitempty"`
+}
+
+func tableCacheTool(ctx context.Context, _ *emptyInput) (*TableCacheResult, error) {
+	status, err := globalStatusValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := databases.QueryGlobalVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TableCacheResult{
+		OpenTables:             parseInt(status["open_tables"]),
+		OpenedTables:           parseInt(status["opened_tables"]),
+		OpenTableDefinitions:   parseInt(status["open_table_definitions"]),
+		OpenedTableDefinitions: parseInt(status["opened_table_definitions"]),
+		CacheHits:              parseInt(status["table_open_cache_hits"]),
+		CacheMisses:            parseInt(status["table_open_cache_misses"]),
+		CacheOverflows:         parseInt(status["table_open_cache_overflows"]),
+		UptimeSeconds:          parseInt(status["uptime"]),
+		TableOpenCache:         parseInt(vars["table_open_cache"]),
+		TableDefinitionCache:   parseInt(vars["table_definition_cache"]),
+		CacheInstances:         parseInt(vars["table_open_cache_instances"]),
+		OpenFilesLimit:         parseInt(vars["open_files_limit"]),
+	}
+	if result.UptimeSeconds > 0 {
+		result.OpenedPerSecond = float64(result.OpenedTables) / float64(result.UptimeSeconds)
+	}
+	if result.TableOpenCache > 0 {
+		result.CacheUsagePct = 100 * float64(result.OpenTables) / float64(result.TableOpenCache)
+	}
+	if result.TableDefinitionCache > 0 {
+		result.DefinitionUsagePct = 100 * float64(result.OpenTableDefinitions) / float64(result.TableDefinitionCache)
+	}
+	if lookups := result.CacheHits + result.CacheMisses; lookups > 0 {
+		result.HitRatio = 100 * float64(result.CacheHits) / float64(lookups)
+	}
+
+	high, moderate := []string{}, []string{}
+	cacheFull := result.CacheUsagePct >= 95
+	switch {
+	case cacheFull && result.OpenedPerSecond >= 10:
+		high = append(high, fmt.Sprintf("table_open_cache 已用 %.0f%%，每秒打开 %.1f 张表", result.CacheUsagePct, result.OpenedPerSecond))
+	case cacheFull && result.OpenedPerSecond >= 1:
+		moderate = append(moderate, fmt.Sprintf("table_open_cache 已用 %.0f%%，每秒打开 %.1f 张表", result.CacheUsagePct, result.OpenedPerSecond))
+	}
+	if result.CacheHits+result.CacheMisses >= 10000 && result.HitRatio < 90 {
+		moderate = append(moderate, fmt.Sprintf("表缓存命中率 %.1f%%", result.HitRatio))
+	}
+	if result.CacheOverflows > 0 && result.UptimeSeconds > 0 && float64(result.CacheOverflows)/float64(result.UptimeSeconds) >= 1 {
+		moderate = append(moderate, fmt.Sprintf("Table_open_cache_overflows=%d，缓存实例频繁淘汰", result.CacheOverflows))
+	}
+	if result.DefinitionUsagePct >= 95 && result.UptimeSeconds > 0 && float64(result.OpenedTableDefinitions)/float64(result.UptimeSeconds) >= 1 {
+		moderate = append(moderate, fmt.Sprintf("table_definition_cache 已用 %.0f%%，表定义被反复加载", result.DefinitionUsagePct))
+	}
+
+	// 建议值按当前打开数留出 50% 余量，且每张打开的表最多占用两个文件句柄，不超过 open_files_limit 的一半
+	if len(high)+len(moderate) > 0 {
+		if cacheFull {
+			result.SuggestedOpenCache = result.OpenTables * 3 / 2
+			if limit := result.OpenFilesLimit / 2; limit > 0 && result.SuggestedOpenCache > limit {
+				result.SuggestedOpenCache = limit
+			}
+			if result.SuggestedOpenCache <= result.TableOpenCache {
+				result.SuggestedOpenCache = 0
+			}
+		}
+		if result.DefinitionUsagePct >= 95 {
+			result.SuggestedDefCache = result.OpenTableDefinitions * 3 / 2
+		}
+	}
+
+	switch {
+	case len(high) > 0:
+		result.Severity = "high"
+		result.SeverityReasons = append(high, moderate...)
+	case len(moderate) > 0:
+		result.Severity = "moderate"
+		result.SeverityReasons = moderate
+	default:
+		result.Severity = "low"
+	}
+
+	return result, nil
+}
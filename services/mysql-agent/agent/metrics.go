@@ -0,0 +1,273 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mysql-agent/databases"
+)
+
+// 以 Prometheus 文本格式（version 0.0.4）暴露 agent 自身的运行指标，由 /metrics 输出。
+// 指标种类不多，不引入 client_golang，按需实现 counter 与 histogram
+
+const metricsNamespace = "mysql_agent"
+
+// 指标状态标签取值
+const (
+	metricStatusSuccess  = "success"
+	metricStatusError    = "error"
+	metricStatusCanceled = "canceled"
+)
+
+var (
+	toolLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	llmLatencyBuckets  = []float64{0.25, 0.5, 1, 2, 5, 10, 20, 30, 60, 120}
+)
+
+var (
+	toolExecutions = newCounterVec("tool_executions_total", "工具执行次数，按工具与结果统计", "tool", "status")
+	toolDuration   = newHistogramVec("tool_duration_seconds", "工具执行耗时", toolLatencyBuckets, "tool")
+	llmRequests    = newCounterVec("llm_requests_total", "LLM 调用次数，mode 为 generate 或 stream", "mode", "status")
+	llmDuration    = newHistogramVec("llm_duration_seconds", "LLM 调用耗时，流式调用计到读完整个响应", llmLatencyBuckets, "mode")
+	llmTokens      = newCounterVec("llm_tokens_total", "LLM 消耗的 token 数，流式响应不返回用量不计入", "type")
+	requestCounter = newCounterVec("requests_total", "RPC 与 HTTP 请求数", "transport", "method", "status")
+)
+
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+	keys   map[string][]string
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: metricsNamespace + "_" + name, help: help, labels: labels,
+		values: map[string]float64{}, keys: map[string][]string{}}
+}
+
+func (c *counterVec) add(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[key]; !ok {
+		c.keys[key] = labelValues
+	}
+	c.values[key] += v
+}
+
+func (c *counterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.keys) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, c.keys[key]), formatValue(c.values[key]))
+	}
+}
+
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+	keys   map[string][]string
+}
+
+type histogram struct {
+	counts []uint64 // 与 buckets 一一对应，非累计
+	sum    float64
+	count  uint64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: metricsNamespace + "_" + name, help: help, labels: labels, buckets: buckets,
+		series: map[string]*histogram{}, keys: map[string][]string{}}
+}
+
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+		h.keys[key] = labelValues
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *histogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.name, h.help, "histogram")
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+	for _, key := range sortedKeys(h.keys) {
+		s, values := h.series[key], h.keys[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			le := append(append([]string{}, values...), formatValue(upper))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, le), cumulative)
+		}
+		inf := append(append([]string{}, values...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(bucketLabels, inf), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, values), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, values), s.count)
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeGauge(w *bufio.Writer, name, help string, v float64) {
+	name = metricsNamespace + "_" + name
+	writeHeader(w, name, help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", name, formatValue(v))
+}
+
+func writeCounter(w *bufio.Writer, name, help string, v float64) {
+	name = metricsNamespace + "_" + name
+	writeHeader(w, name, help, "counter")
+	fmt.Fprintf(w, "%s %s\n", name, formatValue(v))
+}
+
+// writePoolStats 输出各实例已打开连接池的统计与健康检查结果，instance 标签为实例ID
+func writePoolStats(w *bufio.Writer) {
+	pools := databases.PoolStatuses()
+	if len(pools) == 0 {
+		return
+	}
+
+	metrics := []struct {
+		name, help, kind string
+		value            func(databases.PoolStatus) float64
+	}{
+		{"db_pool_up", "最近一次后台健康检查是否成功", "gauge", func(p databases.PoolStatus) float64 { return boolValue(p.Healthy) }},
+		{"db_max_open_connections", "连接池允许的最大连接数", "gauge", func(p databases.PoolStatus) float64 { return float64(p.Stats.MaxOpenConnections) }},
+		{"db_open_connections", "连接池当前连接数", "gauge", func(p databases.PoolStatus) float64 { return float64(p.Stats.OpenConnections) }},
+		{"db_in_use_connections", "正在使用的连接数", "gauge", func(p databases.PoolStatus) float64 { return float64(p.Stats.InUse) }},
+		{"db_idle_connections", "空闲连接数", "gauge", func(p databases.PoolStatus) float64 { return float64(p.Stats.Idle) }},
+		{"db_wait_count_total", "等待空闲连接的次数", "counter", func(p databases.PoolStatus) float64 { return float64(p.Stats.WaitCount) }},
+		{"db_wait_duration_seconds_total", "等待空闲连接的累计时长", "counter", func(p databases.PoolStatus) float64 { return p.Stats.WaitDuration.Seconds() }},
+	}
+	instanceLabel := []string{"instance"}
+	for _, m := range metrics {
+		name := metricsNamespace + "_" + m.name
+		writeHeader(w, name, m.help, m.kind)
+		for _, p := range pools {
+			fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(instanceLabel, []string{p.ID}), formatValue(m.value(p)))
+		}
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(values[i]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// metricStatus 把调用结果归为 success、canceled（调用方取消或超时）或 error
+func metricStatus(err error) string {
+	switch {
+	case err == nil:
+		return metricStatusSuccess
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return metricStatusCanceled
+	default:
+		return metricStatusError
+	}
+}
+
+func observeTool(name string, start time.Time, err error) {
+	toolExecutions.add(1, name, metricStatus(err))
+	toolDuration.observe(time.Since(start).Seconds(), name)
+}
+
+func observeLLM(mode string, start time.Time, err error) {
+	llmRequests.add(1, mode, metricStatus(err))
+	llmDuration.observe(time.Since(start).Seconds(), mode)
+}
+
+// ObserveRequest 记录一次 RPC 或 HTTP 请求，供 main 包的传输层调用
+func ObserveRequest(transport, method, status string) {
+	requestCounter.add(1, transport, method, status)
+}
+
+// WriteMetrics 按 Prometheus 文本格式输出全部指标，各实例的数据库连接池与请求队列在输出时读取当前值
+func WriteMetrics(out io.Writer) error {
+	w := bufio.NewWriter(out)
+
+	writeGauge(w, "uptime_seconds", "agent 进程运行时长", time.Since(startedAt).Seconds())
+	toolExecutions.write(w)
+	toolDuration.write(w)
+	llmRequests.write(w)
+	llmDuration.write(w)
+	llmTokens.write(w)
+	requestCounter.write(w)
+
+	queue := QueryQueueStats()
+	writeGauge(w, "queries_running", "正在执行的诊断请求数", float64(queue.Running))
+	writeGauge(w, "queries_queued", "排队等待的诊断请求数", float64(queue.Queued))
+	writeCounter(w, "queries_rejected_total", "因队列已满或排队超时被拒绝的诊断请求数", float64(queue.Rejected))
+
+	writePoolStats(w)
+	return w.Flush()
+}
@@ -0,0 +1,218 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RejectedParam 校验时被丢弃的工具参数
+type RejectedParam struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value"`
+	Reason string      `json:"reason"`
+}
+
+// paramSchema 工具参数 JSON Schema 中校验用到的部分，由工具声明的 schema 序列化后解析得到
+type paramSchema struct {
+	Type       string                  `json:"type"`
+	Properties map[string]*paramSchema `json:"properties"`
+	Required   []string                `json:"required"`
+	Items      *paramSchema            `json:"items"`
+	Enum       []interface{}           `json:"enum"`
+	Minimum    *float64                `json:"minimum"`
+	Maximum    *float64                `json:"maximum"`
+}
+
+var (
+	paramSchemaMu    sync.Mutex
+	paramSchemaCache = map[string]*paramSchema{}
+)
+
+// toolParamSchema 返回工具声明的参数 schema，工具未声明参数时返回 nil
+func toolParamSchema(ctx context.Context, name string) (*paramSchema, error) {
+	paramSchemaMu.Lock()
+	defer paramSchemaMu.Unlock()
+	if s, ok := paramSchemaCache[name]; ok {
+		return s, nil
+	}
+
+	tl, ok := toolMap[name]
+	if !ok {
+		return nil, fmt.Errorf("未找到工具: %s", name)
+	}
+	info, err := tl.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var schema *paramSchema
+	if info.ParamsOneOf != nil {
+		js, err := info.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return nil, fmt.Errorf("读取工具 %s 参数定义失败: %w", name, err)
+		}
+		raw, err := json.Marshal(js)
+		if err != nil {
+			return nil, err
+		}
+		schema = &paramSchema{}
+		if err := json.Unmarshal(raw, schema); err != nil {
+			return nil, fmt.Errorf("解析工具 %s 参数定义失败: %w", name, err)
+		}
+	}
+	paramSchemaCache[name] = schema
+	return schema, nil
+}
+
+// validateToolArgs 按工具声明的 schema 校验参数：明显的类型不符（如 "10" 与 10）自动转换，
+// 未声明或无法转换的可选参数被丢弃并返回，缺少必填参数时返回错误。返回校验后的参数 JSON
+func validateToolArgs(ctx context.Context, name, rawArgs string) (string, []RejectedParam, error) {
+	if _, err := ensureTools(ctx); err != nil {
+		return "", nil, err
+	}
+	schema, err := toolParamSchema(ctx, name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	trimmed := strings.TrimSpace(rawArgs)
+	if trimmed == "" || trimmed == "null" {
+		trimmed = "{}"
+	}
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(trimmed), &args); err != nil {
+		return "", nil, fmt.Errorf("工具参数必须是 JSON 对象: %w", err)
+	}
+	if schema == nil || schema.Properties == nil {
+		schema = &paramSchema{Properties: map[string]*paramSchema{}}
+	}
+
+	names := make([]string, 0, len(args))
+	for k := range args {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var rejected []RejectedParam
+	for _, k := range names {
+		prop, ok := schema.Properties[k]
+		if !ok {
+			rejected = append(rejected, RejectedParam{Name: k, Value: args[k], Reason: "工具未声明该参数"})
+			delete(args, k)
+			continue
+		}
+		value, reason := coerceParam(args[k], prop)
+		if reason != "" {
+			rejected = append(rejected, RejectedParam{Name: k, Value: args[k], Reason: reason})
+			delete(args, k)
+			continue
+		}
+		args[k] = value
+	}
+
+	var missing []string
+	for _, k := range schema.Required {
+		if _, ok := args[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		return "", rejected, fmt.Errorf("缺少必填参数: %s", strings.Join(missing, ", "))
+	}
+
+	out, err := json.Marshal(args)
+	if err != nil {
+		return "", rejected, err
+	}
+	return string(out), rejected, nil
+}
+
+// coerceParam 把参数转换为 schema 声明的类型，失败时返回原因
+func coerceParam(v interface{}, s *paramSchema) (interface{}, string) {
+	if s == nil {
+		return v, ""
+	}
+	var out interface{}
+	switch s.Type {
+	case "integer", "number":
+		var f float64
+		switch x := v.(type) {
+		case float64:
+			f = x
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+			if err != nil {
+				return nil, fmt.Sprintf("期望 %s，实际为 %q", s.Type, x)
+			}
+			f = parsed
+		default:
+			return nil, fmt.Sprintf("期望 %s，实际为 %T", s.Type, v)
+		}
+		if s.Type == "integer" && f != math.Trunc(f) {
+			return nil, fmt.Sprintf("期望整数，实际为 %v", f)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return nil, fmt.Sprintf("不能小于 %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return nil, fmt.Sprintf("不能大于 %v", *s.Maximum)
+		}
+		out = f
+	case "boolean":
+		switch x := v.(type) {
+		case bool:
+			out = x
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(x))
+			if err != nil {
+				return nil, fmt.Sprintf("期望 boolean，实际为 %q", x)
+			}
+			out = b
+		default:
+			return nil, fmt.Sprintf("期望 boolean，实际为 %T", v)
+		}
+	case "string":
+		switch x := v.(type) {
+		case string:
+			out = x
+		case float64:
+			out = strconv.FormatFloat(x, 'f', -1, 64)
+		case bool:
+			out = strconv.FormatBool(x)
+		default:
+			return nil, fmt.Sprintf("期望 string，实际为 %T", v)
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			// 单个值按只有一个元素的数组处理，例如 keys: "Threads_running"
+			items = []interface{}{v}
+		}
+		coerced := make([]interface{}, 0, len(items))
+		for i, item := range items {
+			c, reason := coerceParam(item, s.Items)
+			if reason != "" {
+				return nil, fmt.Sprintf("第 %d 个元素%s", i+1, reason)
+			}
+			coerced = append(coerced, c)
+		}
+		out = coerced
+	default:
+		out = v
+	}
+
+	if len(s.Enum) > 0 {
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(out) {
+				return out, ""
+			}
+		}
+		return nil, fmt.Sprintf("取值必须是 %v 之一", s.Enum)
+	}
+	return out, ""
+}
@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"mysql-agent/config"
+	"mysql-agent/databases"
+)
+
+// partitionTools 分区表诊断工具
+func partitionTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolPartitions, "查询 `information_schema.partitions`，汇总分区表的分区数、各分区行数与大小分布，并检查按日期 RANGE 分区的表是否缺少未来分区(缺失时新数据插入会直接失败)", partitionsTool),
+	}
+}
+
+// toDaysEpoch TO_DAYS('1970-01-01') 的值
+const toDaysEpoch = 719528
+
+type PartitionsInput struct {
+	Schema      string `json:"schema,omitempty" jsonschema:"description=指定数据库名,默认为配置中的库"`
+	HorizonDays int    `json:"horizon_days,omitempty" jsonschema:"description=最后一个日期分区的上界距今少于该天数时告警,默认 7,minimum=1"`
+}
+
+type PartitionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"` // RANGE 为上界(VALUES LESS THAN)，LIST 为取值列表
+	Rows        int64  `json:"rows"`
+	DataBytes   int64  `json:"data_bytes"`
+	IndexBytes  int64  `json:"index_bytes"`
+}
+
+type PartitionedTable struct {
+	Table           string          `json:"table"`
+	Method          string          `json:"method"`
+	Expression      string          `json:"expression"`
+	PartitionCount  int             `json:"partition_count"`
+	TotalRows       int64           `json:"total_rows"`
+	TotalBytes      int64           `json:"total_bytes"`
+	LargestPct      float64         `json:"largest_partition_pct"` // 最大分区行数占比，衡量数据倾斜
+	HasMaxValue     bool            `json:"has_maxvalue"`
+	MaxValueRows    int64           `json:"maxvalue_rows,omitempty"`
+	UpperBound      string          `json:"upper_bound,omitempty"`      // 最后一个非 MAXVALUE 分区的上界日期
+	DaysUntilBound  *int            `json:"days_until_bound,omitempty"` // 为负表示已超过上界
+	Partitions      []PartitionInfo `json:"partitions"`
+	Severity        string          `json:"severity"`
+	SeverityReasons []string        `json:"severity_reasons,omitempty"`
+}
+
+type PartitionsResult struct {
+	Schema      string             `json:"schema"`
+	HorizonDays int                `json:"horizon_days"`
+	Tables      []PartitionedTable `json:"tables"`
+	AtRisk      []string           `json:"at_risk,omitempty"` // 缺少未来分区的表
+}
+
+var (
+	partitionDatePattern = regexp.MustCompile(`^'(\d{4}-\d{2}-\d{2})(?:[ T][\d:.]+)?'$`)
+	partitionIntPattern  = regexp.MustCompile(`^-?\d+$`)
+)
+
+func partitionsTool(ctx context.Context, input *PartitionsInput) (*PartitionsResult, error) {
+	schema, horizon := "", 7
+	if input != nil {
+		schema = strings.TrimSpace(input.Schema)
+		if input.HorizonDays > 0 {
+			horizon = input.HorizonDays
+		}
+	}
+	if schema == "" && config.AppConfig != nil {
+		schema = instanceDatabase(ctx).DBName
+	}
+
+	rows, err := databases.QueryPartitions(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PartitionsResult{Schema: schema, HorizonDays: horizon, Tables: []PartitionedTable{}}
+	tableIndex := make(map[string]int)
+	for _, row := range normalizeRows(rows) {
+		name := row["table_name"]
+		idx, ok := tableIndex[name]
+		if !ok {
+			idx = len(result.Tables)
+			tableIndex[name] = idx
+			result.Tables = append(result.Tables, PartitionedTable{
+				Table:      name,
+				Method:     row["partition_method"],
+				Expression: row["partition_expression"],
+				Partitions: []PartitionInfo{},
+			})
+		}
+		result.Tables[idx].Partitions = append(result.Tables[idx].Partitions, PartitionInfo{
+			Name:        row["partition_name"],
+			Description: row["partition_description"],
+			Rows:        parseInt(row["table_rows"]),
+			DataBytes:   parseInt(row["data_length"]),
+			IndexBytes:  parseInt(row["index_length"]),
+		})
+	}
+
+	now := time.Now()
+	for i := range result.Tables {
+		t := &result.Tables[i]
+		analyzePartitionedTable(t, now, horizon)
+		if t.DaysUntilBound != nil && *t.DaysUntilBound < horizon && !t.HasMaxValue {
+			result.AtRisk = append(result.AtRisk, t.Table)
+		}
+	}
+
+	return result, nil
+}
+
+func analyzePartitionedTable(t *PartitionedTable, now time.Time, horizon int) {
+	t.PartitionCount = len(t.Partitions)
+	var largest int64
+	var lastBound string
+	for _, p := range t.Partitions {
+		t.TotalRows += p.Rows
+		t.TotalBytes += p.DataBytes + p.IndexBytes
+		largest = max(largest, p.Rows)
+		if strings.EqualFold(p.Description, "MAXVALUE") {
+			t.HasMaxValue = true
+			t.MaxValueRows = p.Rows
+			continue
+		}
+		lastBound = p.Description
+	}
+	if t.TotalRows > 0 {
+		t.LargestPct = 100 * float64(largest) / float64(t.TotalRows)
+	}
+
+	var high, moderate []string
+	if strings.HasPrefix(t.Method, "RANGE") && lastBound != "" {
+		if bound, ok := partitionBoundDate(t.Expression, lastBound); ok {
+			days := int(bound.Sub(now).Hours() / 24)
+			t.UpperBound = bound.Format("2006-01-02")
+			t.DaysUntilBound = &days
+			switch {
+			case t.HasMaxValue && days < 0 && t.MaxValueRows > 0:
+				moderate = append(moderate, fmt.Sprintf("最后一个日期分区上界 %s 已过，约 %d 行落入 MAXVALUE 分区，后续拆分需要 REORGANIZE 大量数据", t.UpperBound, t.MaxValueRows))
+			case t.HasMaxValue:
+			case days < 0:
+				high = append(high, fmt.Sprintf("最后一个分区上界 %s 已过且没有 MAXVALUE 分区，新日期的数据插入会失败(ERROR 1526)", t.UpperBound))
+			case days < horizon:
+				high = append(high, fmt.Sprintf("最后一个分区上界 %s 距今仅 %d 天且没有 MAXVALUE 分区，需要尽快添加未来分区", t.UpperBound, days))
+			}
+		}
+	}
+	if t.PartitionCount > 1 && t.TotalRows > 0 && t.LargestPct >= 80 {
+		moderate = append(moderate, fmt.Sprintf("最大分区占 %.0f%% 的行，分区数据严重倾斜", t.LargestPct))
+	}
+
+	switch {
+	case len(high) > 0:
+		t.Severity = "high"
+		t.SeverityReasons = append(high, moderate...)
+	case len(moderate) > 0:
+		t.Severity = "moderate"
+		t.SeverityReasons = moderate
+	default:
+		t.Severity = "low"
+	}
+}
+
+// partitionBoundDate 将 RANGE 分区上界换算为日期，支持 RANGE COLUMNS(日期列) 以及
+// TO_DAYS、UNIX_TIMESTAMP、YEAR 分区表达式；无法识别时返回 false
+func partitionBoundDate(expression, description string) (time.Time, bool) {
+	if m := partitionDatePattern.FindStringSubmatch(description); m != nil {
+		t, err := time.ParseInLocation("2006-01-02", m[1], time.Local)
+		return t, err == nil
+	}
+	if !partitionIntPattern.MatchString(description) {
+		return time.Time{}, false
+	}
+	value, err := strconv.ParseInt(description, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	expr := strings.ToLower(strings.ReplaceAll(expression, " ", ""))
+	switch {
+	case strings.HasPrefix(expr, "to_days("):
+		return time.Unix((value-toDaysEpoch)*86400, 0).UTC(), true
+	case strings.HasPrefix(expr, "unix_timestamp("):
+		return time.Unix(value, 0), true
+	case strings.HasPrefix(expr, "year(") && strings.Count(expr, "(") == 1:
+		return time.Date(int(value), time.January, 1, 0, 0, 0, 0, time.Local), true
+	}
+	return time.Time{}, false
+}
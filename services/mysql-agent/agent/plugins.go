@@ -0,0 +1,220 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"mysql-agent/config"
+)
+
+const (
+	defaultPluginTimeout = 30 * time.Second
+	// pluginOutputLimit 插件标准输出的字节数上限，超出视为执行失败
+	pluginOutputLimit = 1 << 20
+	// pluginStderrTail 执行失败时错误信息中保留的标准错误末尾字节数
+	pluginStderrTail = 2048
+)
+
+var pluginNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,63}$`)
+
+// pluginTool 通过子进程执行的外部工具。参数 JSON 写入标准输入，
+// 插件须在标准输出中返回一个 JSON 值作为工具结果，退出码非 0 视为失败
+type pluginTool struct {
+	cfg  config.PluginConfig
+	info *schema.ToolInfo
+}
+
+// pluginParam 插件在配置中声明的参数 JSON Schema 中用到的部分
+type pluginParam struct {
+	Type        string                  `json:"type"`
+	Description string                  `json:"description"`
+	Properties  map[string]*pluginParam `json:"properties"`
+	Required    []string                `json:"required"`
+	Items       *pluginParam            `json:"items"`
+	Enum        []string                `json:"enum"`
+}
+
+func newPluginTool(cfg config.PluginConfig) (*pluginTool, error) {
+	if !pluginNamePattern.MatchString(cfg.Name) {
+		return nil, fmt.Errorf("插件名 %q 只能包含字母、数字和下划线且以字母开头", cfg.Name)
+	}
+	if strings.TrimSpace(cfg.Command) == "" {
+		return nil, fmt.Errorf("插件 %s 未配置 command", cfg.Name)
+	}
+	if strings.TrimSpace(cfg.Description) == "" {
+		return nil, fmt.Errorf("插件 %s 未配置 description", cfg.Name)
+	}
+
+	info := &schema.ToolInfo{Name: cfg.Name, Desc: cfg.Description}
+	if raw := strings.TrimSpace(cfg.Schema); raw != "" {
+		var root pluginParam
+		if err := json.Unmarshal([]byte(raw), &root); err != nil {
+			return nil, fmt.Errorf("解析插件 %s 的 schema 失败: %w", cfg.Name, err)
+		}
+		if root.Type != "" && root.Type != string(schema.Object) {
+			return nil, fmt.Errorf("插件 %s 的 schema 顶层类型必须是 object", cfg.Name)
+		}
+		params, err := root.toParams()
+		if err != nil {
+			return nil, fmt.Errorf("插件 %s 的 schema 无效: %w", cfg.Name, err)
+		}
+		info.ParamsOneOf = schema.NewParamsOneOfByParams(params)
+	} else {
+		info.ParamsOneOf = schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{})
+	}
+	return &pluginTool{cfg: cfg, info: info}, nil
+}
+
+func (p *pluginParam) toParams() (map[string]*schema.ParameterInfo, error) {
+	params := make(map[string]*schema.ParameterInfo, len(p.Properties))
+	for name, prop := range p.Properties {
+		if prop == nil {
+			continue
+		}
+		info, err := prop.toInfo()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		params[name] = info
+	}
+	for _, name := range p.Required {
+		info, ok := params[name]
+		if !ok {
+			return nil, fmt.Errorf("必填参数 %s 未在 properties 中声明", name)
+		}
+		info.Required = true
+	}
+	return params, nil
+}
+
+func (p *pluginParam) toInfo() (*schema.ParameterInfo, error) {
+	info := &schema.ParameterInfo{Type: schema.DataType(p.Type), Desc: p.Description, Enum: p.Enum}
+	switch info.Type {
+	case schema.String, schema.Number, schema.Integer, schema.Boolean:
+	case schema.Array:
+		if p.Items == nil {
+			return nil, fmt.Errorf("array 类型必须声明 items")
+		}
+		elem, err := p.Items.toInfo()
+		if err != nil {
+			return nil, err
+		}
+		info.ElemInfo = elem
+	case schema.Object:
+		sub, err := p.toParams()
+		if err != nil {
+			return nil, err
+		}
+		info.SubParams = sub
+	default:
+		return nil, fmt.Errorf("不支持的参数类型 %q", p.Type)
+	}
+	return info, nil
+}
+
+func (t *pluginTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+func (t *pluginTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	timeout := t.cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultPluginTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	args := strings.TrimSpace(argumentsInJSON)
+	if args == "" {
+		args = "{}"
+	}
+
+	cmd := exec.CommandContext(ctx, t.cfg.Command, t.cfg.Args...)
+	cmd.Stdin = strings.NewReader(args)
+	cmd.Env = append(os.Environ(), "MYSQL_AGENT_TOOL="+t.cfg.Name)
+	stdout := &limitedBuffer{limit: pluginOutputLimit}
+	stderr := &limitedBuffer{limit: pluginStderrTail, keepTail: true}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	log.Printf("[plugin] name=%s exit=%v elapsed=%s", t.cfg.Name, cmd.ProcessState, time.Since(start).Round(time.Millisecond))
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("插件 %s 执行超过 %s", t.cfg.Name, timeout)
+	}
+	if err != nil {
+		if tail := strings.TrimSpace(stderr.String()); tail != "" {
+			return "", fmt.Errorf("插件 %s 执行失败: %w: %s", t.cfg.Name, err, tail)
+		}
+		return "", fmt.Errorf("插件 %s 执行失败: %w", t.cfg.Name, err)
+	}
+	if stdout.overflow {
+		return "", fmt.Errorf("插件 %s 输出超过 %d 字节", t.cfg.Name, pluginOutputLimit)
+	}
+
+	out := bytes.TrimSpace(stdout.Bytes())
+	if !json.Valid(out) {
+		return "", fmt.Errorf("插件 %s 的输出不是合法的 JSON", t.cfg.Name)
+	}
+	return string(out), nil
+}
+
+// limitedBuffer 只保留前 limit 字节（keepTail 时保留最后 limit 字节）的输出缓冲
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	keepTail bool
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if b.keepTail {
+		b.Buffer.Write(p)
+		if extra := b.Len() - b.limit; extra > 0 {
+			b.Next(extra)
+		}
+		return n, nil
+	}
+	if room := b.limit - b.Len(); len(p) > room {
+		b.overflow = true
+		p = p[:max(room, 0)]
+	}
+	b.Buffer.Write(p)
+	return n, nil
+}
+
+// registerPlugins 注册 [[plugins]] 中配置的外部工具，插件名不能与内置工具重复
+func registerPlugins() error {
+	if config.AppConfig == nil {
+		return nil
+	}
+	for _, cfg := range config.AppConfig.Plugins {
+		if _, exists := toolMap[cfg.Name]; exists {
+			return fmt.Errorf("插件名 %s 与已注册的工具重复", cfg.Name)
+		}
+		pt, err := newPluginTool(cfg)
+		if err != nil {
+			return err
+		}
+		toolMap[cfg.Name] = pt
+		toolList = append(toolList, pt)
+		if cfg.Mutating {
+			mutatingTools[cfg.Name] = struct{}{}
+		}
+		log.Printf("[ensureTools] registered plugin %s command=%s", cfg.Name, cfg.Command)
+	}
+	return nil
+}
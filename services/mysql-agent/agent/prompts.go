@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"mysql-agent/config"
+	"mysql-agent/databases"
+)
+
+// 提示词模板名，对应 config/prompts 下的 <名称>.tmpl
+const (
+	promptPlannerSystem      = "planner_system"
+	promptFollowUpSystem     = "follow_up_system"
+	promptSummarySystem      = "summary_system"
+	promptSummaryInstruction = "summary_instruction"
+	promptSummaryStructured  = "summary_structured"
+)
+
+var promptNames = []string{promptPlannerSystem, promptFollowUpSystem, promptSummarySystem, promptSummaryInstruction, promptSummaryStructured}
+
+// promptData 模板中可用的变量
+type promptData struct {
+	InstanceName string
+	Language     string
+	MaxLines     int
+}
+
+var (
+	promptMu        sync.RWMutex
+	promptTemplates map[string]*template.Template
+)
+
+// LoadPrompts 加载内置提示词模板，prompt.dir 下存在同名 .tmpl 文件时以其覆盖；
+// 在启动时调用，任一模板解析失败即返回错误
+func LoadPrompts() error {
+	dir := ""
+	if config.AppConfig != nil {
+		dir = config.AppConfig.Prompt.Dir
+	}
+
+	loaded := make(map[string]*template.Template, len(promptNames))
+	for _, name := range promptNames {
+		text, err := fs.ReadFile(config.DefaultPrompts, "prompts/"+name+".tmpl")
+		if err != nil {
+			return fmt.Errorf("读取内置提示词 %s 失败: %w", name, err)
+		}
+		source := "内置"
+		if dir != "" {
+			path := filepath.Join(dir, name+".tmpl")
+			override, err := os.ReadFile(path)
+			switch {
+			case err == nil:
+				text, source = override, path
+			case !errors.Is(err, fs.ErrNotExist):
+				return fmt.Errorf("读取提示词 %s 失败: %w", path, err)
+			}
+		}
+
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(text))
+		if err != nil {
+			return fmt.Errorf("解析提示词 %s(%s) 失败: %w", name, source, err)
+		}
+		log.Printf("[prompts] %s 使用%s模板", name, source)
+		loaded[name] = tmpl
+	}
+
+	promptMu.Lock()
+	promptTemplates = loaded
+	promptMu.Unlock()
+	return nil
+}
+
+// renderPrompt 以 prompt.* 配置渲染指定模板，实例名称取 ctx 所属实例；尚未调用 LoadPrompts 时先加载
+func renderPrompt(ctx context.Context, name string) (string, error) {
+	promptMu.RLock()
+	tmpl := promptTemplates[name]
+	promptMu.RUnlock()
+	if tmpl == nil {
+		if err := LoadPrompts(); err != nil {
+			return "", err
+		}
+		promptMu.RLock()
+		tmpl = promptTemplates[name]
+		promptMu.RUnlock()
+		if tmpl == nil {
+			return "", fmt.Errorf("未知的提示词模板: %s", name)
+		}
+	}
+
+	data := promptData{Language: "中文"}
+	if cfg := config.AppConfig; cfg != nil {
+		data = promptData{
+			InstanceName: cfg.InstanceName(databases.InstanceFrom(ctx)),
+			Language:     cfg.Prompt.Language,
+			MaxLines:     cfg.Prompt.MaxLines,
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染提示词 %s 失败: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
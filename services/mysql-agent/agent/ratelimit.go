@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"mysql-agent/config"
+)
+
+// 限流范围
+const (
+	RateLimitGlobal = "global"
+	RateLimitCaller = "caller"
+)
+
+// anonymousCaller 未提供调用方标识的请求共用一个配额
+const anonymousCaller = "anonymous"
+
+// callerIdleTTL 调用方配额闲置超过该时长后回收
+const callerIdleTTL = 10 * time.Minute
+
+// RateLimitError 诊断请求超出限流配额。经 RPC 传递时只保留 Error() 文本，
+// 格式固定为 "rate limited (<scope>), retry after <ms>ms"，调用方可据此解析等待时间
+type RateLimitError struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited (%s), retry after %dms", e.Scope, e.RetryAfter.Milliseconds())
+}
+
+// tokenBucket 令牌桶，rate 为每秒补充的令牌数，burst 为桶容量
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time
+	lastSeen time.Time
+}
+
+// take 尝试取出一个令牌，失败时返回还需等待的时间
+func (b *tokenBucket) take(now time.Time, rate float64, burst int) (bool, time.Duration) {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last, b.lastSeen = now, now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// undo 归还 take 取出的令牌，用于全局配额检查失败时不占用调用方配额
+func (b *tokenBucket) undo(burst int) {
+	b.tokens = math.Min(float64(burst), b.tokens+1)
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	global    tokenBucket
+	callers   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+var limiter = &rateLimiter{callers: map[string]*tokenBucket{}}
+
+// allowQuery 按 rate_limit.* 检查调用方配额和全局配额，任一不足时返回 *RateLimitError；
+// 未启用限流或对应速率为 0 时不限制
+func allowQuery(caller string) error {
+	cfg := config.AppConfig
+	if cfg == nil || !cfg.RateLimit.Enabled {
+		return nil
+	}
+	return limiter.allow(caller, cfg.RateLimit, time.Now())
+}
+
+func (l *rateLimiter) allow(caller string, cfg config.RateLimitConfig, now time.Time) error {
+	caller = strings.TrimSpace(caller)
+	if caller == "" {
+		caller = anonymousCaller
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	var bucket *tokenBucket
+	if cfg.CallerRate > 0 {
+		bucket = l.callers[caller]
+		if bucket == nil {
+			bucket = &tokenBucket{}
+			l.callers[caller] = bucket
+		}
+		if ok, wait := bucket.take(now, cfg.CallerRate, burstOf(cfg.CallerBurst)); !ok {
+			return &RateLimitError{Scope: RateLimitCaller, RetryAfter: wait}
+		}
+	}
+	if cfg.GlobalRate > 0 {
+		if ok, wait := l.global.take(now, cfg.GlobalRate, burstOf(cfg.GlobalBurst)); !ok {
+			if bucket != nil {
+				bucket.undo(burstOf(cfg.CallerBurst))
+			}
+			return &RateLimitError{Scope: RateLimitGlobal, RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// sweep 回收长时间未访问的调用方配额，避免调用方标识过多时内存持续增长
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < callerIdleTTL {
+		return
+	}
+	l.lastSweep = now
+	for caller, bucket := range l.callers {
+		if now.Sub(bucket.lastSeen) > callerIdleTTL {
+			delete(l.callers, caller)
+		}
+	}
+}
+
+func burstOf(burst int) int {
+	if burst < 1 {
+		return 1
+	}
+	return burst
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+
+	"mysql-agent/config"
+)
+
+type fakeToolInput struct{}
+
+type fakeToolOutput struct {
+	OK bool `json:"ok"`
+}
+
+// setupFakeTools 用一个只读工具和一个 mutatingTools 中的工具替换注册表，跳过内置工具的注册
+func setupFakeTools(t *testing.T) (safe, mutating string) {
+	t.Helper()
+	toolOnce.Do(func() {})
+	prevMap, prevList, prevMutating, prevCfg := toolMap, toolList, mutatingTools, config.AppConfig
+	t.Cleanup(func() {
+		toolMap, toolList, mutatingTools, config.AppConfig = prevMap, prevList, prevMutating, prevCfg
+	})
+
+	safe, mutating = "test_safe_tool", "test_mutating_tool"
+	toolMap = make(map[string]tool.InvokableTool)
+	toolList = nil
+	mutatingTools = map[string]struct{}{mutating: {}}
+	for _, name := range []string{safe, mutating} {
+		tl, err := utils.InferTool(name, "test", func(context.Context, *fakeToolInput) (*fakeToolOutput, error) {
+			return &fakeToolOutput{OK: true}, nil
+		})
+		if err != nil {
+			t.Fatalf("InferTool(%s): %v", name, err)
+		}
+		toolMap[name] = tl
+		toolList = append(toolList, tl)
+	}
+	config.AppConfig = &config.Config{Agent: config.AgentConfig{ReadOnly: true}}
+	return safe, mutating
+}
+
+func TestDropMutatingToolsRejectsMutatingTool(t *testing.T) {
+	ctx := context.Background()
+	safe, mutating := setupFakeTools(t)
+	dropMutatingTools(ctx)
+
+	if toolEnabled(ctx, mutating) {
+		t.Fatalf("%s still enabled after dropMutatingTools", mutating)
+	}
+	if !toolEnabled(ctx, safe) {
+		t.Fatalf("%s dropped although it is not mutating", safe)
+	}
+
+	if _, err := CallTool(ctx, mutating, "{}"); err == nil || !strings.Contains(err.Error(), "未找到工具") {
+		t.Fatalf("CallTool(%s) err = %v, want 未找到工具", mutating, err)
+	}
+	var run ToolRun
+	if err := (RPCService{}).RunTool(RunToolRequest{Name: mutating}, &run); err == nil {
+		t.Fatalf("RunTool(%s) succeeded in read-only mode", mutating)
+	}
+	if err := (RPCService{}).RunTool(RunToolRequest{Name: safe}, &run); err != nil {
+		t.Fatalf("RunTool(%s): %v", safe, err)
+	}
+
+	specs, err := ToolSpecs(ctx)
+	if err != nil {
+		t.Fatalf("ToolSpecs: %v", err)
+	}
+	for _, spec := range specs {
+		if spec.Name == mutating {
+			t.Fatalf("ListTools still exposes %s", mutating)
+		}
+	}
+}
+
+func TestExecutePlanRejectsDroppedMutatingTool(t *testing.T) {
+	ctx := context.Background()
+	safe, mutating := setupFakeTools(t)
+	dropMutatingTools(ctx)
+
+	runs, _, _ := executePlan(ctx, []ToolCallSpec{{Name: mutating}}, nil)
+	if len(runs) != 1 || runs[0].Error == "" {
+		t.Fatalf("executePlan(%s) runs = %+v, want one failed run", mutating, runs)
+	}
+
+	runs, _, _ = executePlan(ctx, []ToolCallSpec{{Name: safe}}, nil)
+	if len(runs) != 1 || runs[0].Error != "" {
+		t.Fatalf("executePlan(%s) runs = %+v, want one successful run", safe, runs)
+	}
+}
@@ -0,0 +1,292 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"mysql-agent/databases"
+)
+
+// replicationTools 复制状态、复制拓扑与 binlog 相关的诊断工具
+func replicationTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolReplication, "执行 `SHOW REPLICA STATUS`(旧版本 `SHOW SLAVE STATUS`)，返回各复制通道的 IO/SQL 线程状态、Seconds_Behind_Source 延迟与最近错误；is_replica=false 表示未配置复制", replicationStatusTool),
+		inferTool(toolTopology, "组合 `SHOW REPLICAS`、`SHOW REPLICA STATUS` 与 server_id/report_host/read_only 等变量，输出本实例在复制拓扑中的角色、上游通道及延迟与下游从库列表", topologyTool),
+		inferTool(toolBinlogStatus, "读取 `SHOW MASTER STATUS`、`SHOW BINARY LOGS` 累计大小以及 binlog_format、binlog_row_image、过期清理与 sync_binlog 设置，评估 binlog 磁盘增长与基于时间点恢复(PITR)的可行性", binlogStatusTool),
+	}
+}
+
+type ReplicationChannel struct {
+	Channel        string `json:"channel,omitempty"`
+	SourceHost     string `json:"source_host"`
+	SourcePort     string `json:"source_port"`
+	IORunning      string `json:"io_running"`
+	SQLRunning     string `json:"sql_running"`
+	SecondsBehind  *int64 `json:"seconds_behind_source"`
+	IOState        string `json:"io_state,omitempty"`
+	SQLState       string `json:"sql_state,omitempty"`
+	LastIOError    string `json:"last_io_error,omitempty"`
+	LastSQLError   string `json:"last_sql_error,omitempty"`
+	AutoPosition   bool   `json:"auto_position"`
+	Healthy        bool   `json:"healthy"`
+	UnhealthyCause string `json:"unhealthy_cause,omitempty"`
+}
+
+type ReplicationStatusResult struct {
+	IsReplica bool                 `json:"is_replica"`
+	Channels  []ReplicationChannel `json:"channels"`
+}
+
+func replicationStatusTool(ctx context.Context, _ *emptyInput) (*ReplicationStatusResult, error) {
+	rows, err := databases.QueryReplicaStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReplicationStatusResult{IsReplica: len(rows) > 0, Channels: make([]ReplicationChannel, 0, len(rows))}
+	for _, row := range rows {
+		// 8.0.22 起列名改为 Source/Replica 术语，旧版本为 Master/Slave，NULL 按空串处理
+		col := func(names ...string) string {
+			for _, name := range names {
+				if v, ok := row[name]; ok && v != nil {
+					return fmt.Sprintf("%v", v)
+				}
+			}
+			return ""
+		}
+
+		ch := ReplicationChannel{
+			Channel:      col("Channel_Name"),
+			SourceHost:   col("Source_Host", "Master_Host"),
+			SourcePort:   col("Source_Port", "Master_Port"),
+			IORunning:    col("Replica_IO_Running", "Slave_IO_Running"),
+			SQLRunning:   col("Replica_SQL_Running", "Slave_SQL_Running"),
+			IOState:      col("Replica_IO_State", "Slave_IO_State"),
+			SQLState:     col("Replica_SQL_Running_State", "Slave_SQL_Running_State"),
+			LastIOError:  col("Last_IO_Error"),
+			LastSQLError: col("Last_SQL_Error"),
+			AutoPosition: col("Auto_Position") == "1",
+		}
+		// SQL 线程未运行时 Seconds_Behind_Source 为 NULL，表示延迟未知而不是 0
+		if behind := col("Seconds_Behind_Source", "Seconds_Behind_Master"); behind != "" {
+			v := parseInt(behind)
+			ch.SecondsBehind = &v
+		}
+
+		switch {
+		case !strings.EqualFold(ch.IORunning, "Yes"):
+			ch.UnhealthyCause = fmt.Sprintf("IO 线程状态为 %s", ch.IORunning)
+		case !strings.EqualFold(ch.SQLRunning, "Yes"):
+			ch.UnhealthyCause = fmt.Sprintf("SQL 线程状态为 %s", ch.SQLRunning)
+		}
+		ch.Healthy = ch.UnhealthyCause == ""
+		result.Channels = append(result.Channels, ch)
+	}
+
+	return result, nil
+}
+
+type TopologyNode struct {
+	ServerID      string `json:"server_id"`
+	ServerUUID    string `json:"server_uuid,omitempty"`
+	Host          string `json:"host,omitempty"`
+	Port          string `json:"port,omitempty"`
+	ReadOnly      bool   `json:"read_only"`
+	SuperReadOnly bool   `json:"super_read_only"`
+	GTIDMode      string `json:"gtid_mode,omitempty"`
+	Role          string `json:"role"` // standalone、source、replica 或 intermediate(既是从库又有下游从库)
+}
+
+type TopologyReplica struct {
+	ServerID string `json:"server_id"`
+	Host     string `json:"host,omitempty"` // 从库未设置 report_host 时为空
+	Port     string `json:"port,omitempty"`
+	UUID     string `json:"uuid,omitempty"`
+}
+
+type TopologyResult struct {
+	Self     TopologyNode         `json:"self"`
+	Sources  []ReplicationChannel `json:"sources"`  // 本实例作为从库时的上游通道及延迟
+	Replicas []TopologyReplica    `json:"replicas"` // 当前连接到本实例的下游从库
+	Notes    []string             `json:"notes,omitempty"`
+}
+
+func topologyTool(ctx context.Context, _ *emptyInput) (*TopologyResult, error) {
+	vars, err := databases.QueryGlobalVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	host := vars["report_host"]
+	if host == "" {
+		host = vars["hostname"]
+	}
+	port := vars["report_port"]
+	if port == "" || port == "0" {
+		port = vars["port"]
+	}
+	result := &TopologyResult{
+		Self: TopologyNode{
+			ServerID:      vars["server_id"],
+			ServerUUID:    vars["server_uuid"],
+			Host:          host,
+			Port:          port,
+			ReadOnly:      strings.EqualFold(vars["read_only"], "ON"),
+			SuperReadOnly: strings.EqualFold(vars["super_read_only"], "ON"),
+			GTIDMode:      vars["gtid_mode"],
+		},
+		Replicas: []TopologyReplica{},
+	}
+
+	status, err := replicationStatusTool(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.Sources = status.Channels
+
+	rows, err := databases.QueryReplicas(ctx)
+	if err != nil {
+		// 没有 REPLICATION SLAVE 权限时无法列出下游，保留上游信息
+		log.Printf("[topologyTool] show replicas failed: %v", err)
+		result.Notes = append(result.Notes, fmt.Sprintf("无法列出下游从库: %v", err))
+	}
+	missingHost := false
+	for _, row := range normalizeRows(rows) {
+		r := TopologyReplica{
+			ServerID: row["server_id"],
+			Host:     row["host"],
+			Port:     row["port"],
+			UUID:     firstNonEmpty(row["replica_uuid"], row["slave_uuid"]),
+		}
+		if r.Host == "" {
+			missingHost = true
+		}
+		result.Replicas = append(result.Replicas, r)
+	}
+	if missingHost {
+		result.Notes = append(result.Notes, "部分从库未设置 report_host，无法显示其地址")
+	}
+
+	switch {
+	case len(result.Sources) > 0 && len(result.Replicas) > 0:
+		result.Self.Role = "intermediate"
+	case len(result.Sources) > 0:
+		result.Self.Role = "replica"
+	case len(result.Replicas) > 0:
+		result.Self.Role = "source"
+	default:
+		result.Self.Role = "standalone"
+	}
+	if len(result.Sources) > 0 && !result.Self.ReadOnly {
+		result.Notes = append(result.Notes, "本实例是从库但 read_only=OFF，应用误写会导致主从数据不一致")
+	}
+	if len(result.Sources) == 0 && len(result.Replicas) > 0 && result.Self.ReadOnly {
+		result.Notes = append(result.Notes, "本实例有下游从库但 read_only=ON，可能是已切换但未关闭只读的旧从库")
+	}
+
+	return result, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+type BinlogFileInfo struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+type BinlogStatusResult struct {
+	LogBin          bool             `json:"log_bin"`
+	CurrentFile     string           `json:"current_file,omitempty"`
+	Position        int64            `json:"position,omitempty"`
+	ExecutedGTIDSet string           `json:"executed_gtid_set,omitempty"`
+	FileCount       int              `json:"file_count"`
+	TotalBytes      int64            `json:"total_bytes"`
+	RecentFiles     []BinlogFileInfo `json:"recent_files,omitempty"` // 最新的若干个文件
+	Format          string           `json:"binlog_format,omitempty"`
+	RowImage        string           `json:"binlog_row_image,omitempty"`
+	ExpireSeconds   int64            `json:"expire_seconds"` // 0 表示不自动清理
+	MaxBinlogSize   int64            `json:"max_binlog_size,omitempty"`
+	SyncBinlog      string           `json:"sync_binlog,omitempty"`
+	GTIDMode        string           `json:"gtid_mode,omitempty"`
+	PITRReady       bool             `json:"pitr_ready"`
+	Warnings        []string         `json:"warnings,omitempty"`
+}
+
+// binlogRecentFiles 返回给模型的最新 binlog 文件数量
+const binlogRecentFiles = 10
+
+func binlogStatusTool(ctx context.Context, _ *emptyInput) (*BinlogStatusResult, error) {
+	vars, err := databases.QueryGlobalVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BinlogStatusResult{
+		LogBin:        strings.EqualFold(vars["log_bin"], "ON") || vars["log_bin"] == "1",
+		Format:        vars["binlog_format"],
+		RowImage:      vars["binlog_row_image"],
+		MaxBinlogSize: parseInt(vars["max_binlog_size"]),
+		SyncBinlog:    vars["sync_binlog"],
+		GTIDMode:      vars["gtid_mode"],
+	}
+	// 8.0 起使用 binlog_expire_logs_seconds，旧版本只有 expire_logs_days
+	if secs := parseInt(vars["binlog_expire_logs_seconds"]); secs > 0 {
+		result.ExpireSeconds = secs
+	} else if days := parseInt(vars["expire_logs_days"]); days > 0 {
+		result.ExpireSeconds = days * 86400
+	}
+
+	if !result.LogBin {
+		result.Warnings = append(result.Warnings, "未开启 binlog(log_bin=OFF)，无法进行基于时间点的恢复，也无法作为复制主库")
+		return result, nil
+	}
+
+	statusRows, err := databases.QueryBinlogStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rows := normalizeRows(statusRows); len(rows) > 0 {
+		result.CurrentFile = rows[0]["file"]
+		result.Position = parseInt(rows[0]["position"])
+		result.ExecutedGTIDSet = rows[0]["executed_gtid_set"]
+	}
+
+	logRows, err := databases.QueryBinaryLogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files := normalizeRows(logRows)
+	result.FileCount = len(files)
+	for i, row := range files {
+		size := parseInt(row["file_size"])
+		result.TotalBytes += size
+		if i >= len(files)-binlogRecentFiles {
+			result.RecentFiles = append(result.RecentFiles, BinlogFileInfo{Name: row["log_name"], Size: size})
+		}
+	}
+
+	result.PITRReady = strings.EqualFold(result.Format, "ROW") && result.FileCount > 0
+	if !strings.EqualFold(result.Format, "ROW") {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("binlog_format=%s，非 ROW 格式回放时可能与原库结果不一致", result.Format))
+	}
+	if strings.EqualFold(result.RowImage, "MINIMAL") {
+		result.Warnings = append(result.Warnings, "binlog_row_image=MINIMAL，binlog 体积更小但无法用于闪回(flashback)回滚误操作")
+	}
+	if result.ExpireSeconds == 0 {
+		result.Warnings = append(result.Warnings, "未设置 binlog 过期时间，binlog 会持续占用磁盘，需要手动 PURGE")
+	}
+	if result.SyncBinlog != "1" {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("sync_binlog=%s，崩溃时可能丢失已提交事务的 binlog", result.SyncBinlog))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"mysql-agent/config"
+)
+
+const defaultSummaryRepairAttempts = 2
+
+// DiagnosisReport 结构化的诊断结论，字段与 summary_structured 模板中约定的 JSON 结构一致
+type DiagnosisReport struct {
+	TLDR    string         `json:"tldr"`
+	Metrics []ReportMetric `json:"metrics"`
+	Risks   []ReportRisk   `json:"risks"`
+	Actions []ReportAction `json:"actions"`
+	Sources []string       `json:"sources"`
+}
+
+type ReportMetric struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value"` // 模型可能返回数字或带单位的字符串
+	Status string      `json:"status,omitempty"`
+	Note   string      `json:"note,omitempty"`
+}
+
+type ReportRisk struct {
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+type ReportAction struct {
+	Priority    int    `json:"priority"`
+	Description string `json:"description"`
+	SQL         string `json:"sql,omitempty"`
+}
+
+func structuredSummaryEnabled() bool {
+	return config.AppConfig != nil && config.AppConfig.Agent.StructuredSummary
+}
+
+// parseReport 解析并校验模型返回的结构化报告，校验失败时把错误反馈给模型要求修正，
+// 最多 agent.summary_repair_attempts 次
+func parseReport(ctx context.Context, raw string, toolOutputs []map[string]interface{}) (*DiagnosisReport, error) {
+	attempts := defaultSummaryRepairAttempts
+	if cfg := config.AppConfig; cfg != nil {
+		attempts = cfg.Agent.SummaryRepairAttempts
+	}
+	schemaPrompt, err := renderPrompt(ctx, promptSummaryStructured)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]struct{}, len(toolOutputs))
+	for _, item := range toolOutputs {
+		if name, ok := item["name"].(string); ok {
+			sources[name] = struct{}{}
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		report, problems := validateReport(raw, sources)
+		if len(problems) == 0 {
+			return report, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("结构化报告校验失败: %s", strings.Join(problems, "; "))
+		}
+
+		log.Printf("[parseReport] attempt=%d invalid report: %v", attempt+1, problems)
+		messages := []*schema.Message{
+			{Role: schema.System, Content: "你负责修正不符合格式要求的诊断报告 JSON，只修正格式问题，不改变诊断内容。"},
+			{Role: schema.User, Content: buildRepairPrompt(schemaPrompt, raw, problems)},
+		}
+		result, err := Generate(ctx, messages)
+		if err != nil {
+			return nil, fmt.Errorf("请求 LLM 修正报告失败: %w", err)
+		}
+		if result == nil {
+			return nil, fmt.Errorf("LLM 返回为空")
+		}
+		raw = result.Content
+	}
+}
+
+// validateReport 返回解析出的报告以及所有不符合约定的问题，问题列表为空表示报告有效
+func validateReport(raw string, sources map[string]struct{}) (*DiagnosisReport, []string) {
+	var report DiagnosisReport
+	if err := decodeLLMJSON(raw, &report); err != nil {
+		return nil, []string{fmt.Sprintf("不是合法的 JSON: %v", err)}
+	}
+
+	var problems []string
+	if strings.TrimSpace(report.TLDR) == "" {
+		problems = append(problems, "tldr 不能为空")
+	}
+	for i, m := range report.Metrics {
+		if strings.TrimSpace(m.Name) == "" {
+			problems = append(problems, fmt.Sprintf("metrics[%d].name 不能为空", i))
+		}
+		switch m.Status {
+		case "", severityHigh, severityModerate, severityLow, "ok":
+		default:
+			problems = append(problems, fmt.Sprintf("metrics[%d].status 只能是 high/moderate/low/ok", i))
+		}
+	}
+	for i, r := range report.Risks {
+		switch r.Severity {
+		case severityHigh, severityModerate, severityLow:
+		default:
+			problems = append(problems, fmt.Sprintf("risks[%d].severity 只能是 high/moderate/low", i))
+		}
+		if strings.TrimSpace(r.Description) == "" {
+			problems = append(problems, fmt.Sprintf("risks[%d].description 不能为空", i))
+		}
+	}
+	for i, a := range report.Actions {
+		if strings.TrimSpace(a.Description) == "" {
+			problems = append(problems, fmt.Sprintf("actions[%d].description 不能为空", i))
+		}
+	}
+	for _, s := range report.Sources {
+		if _, ok := sources[s]; !ok {
+			problems = append(problems, fmt.Sprintf("sources 中的 %s 不是已执行的工具", s))
+		}
+	}
+
+	if report.Metrics == nil {
+		report.Metrics = []ReportMetric{}
+	}
+	if report.Risks == nil {
+		report.Risks = []ReportRisk{}
+	}
+	if report.Actions == nil {
+		report.Actions = []ReportAction{}
+	}
+	if report.Sources == nil {
+		report.Sources = []string{}
+	}
+	return &report, problems
+}
+
+func buildRepairPrompt(schemaPrompt, raw string, problems []string) string {
+	var sb strings.Builder
+	sb.WriteString("格式要求:\n")
+	sb.WriteString(schemaPrompt)
+	sb.WriteString("\n\n原始输出:\n")
+	sb.WriteString(raw)
+	sb.WriteString("\n\n存在的问题:\n")
+	for _, p := range problems {
+		sb.WriteString("- ")
+		sb.WriteString(p)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n请输出修正后的完整 JSON。")
+	return sb.String()
+}
+
+// renderReport 把结构化报告渲染为 markdown，填充 Analysis.Summary 供只展示文本的调用方使用；
+// instance 非空时写入数据来源行，便于区分不同实例的结论
+func renderReport(report *DiagnosisReport, instance string) string {
+	var sb strings.Builder
+	sb.WriteString("**结论**: ")
+	sb.WriteString(report.TLDR)
+	sb.WriteString("\n")
+
+	if len(report.Metrics) > 0 {
+		sb.WriteString("\n| 指标 | 取值 | 状态 | 说明 |\n| --- | --- | --- | --- |\n")
+		for _, m := range report.Metrics {
+			sb.WriteString(fmt.Sprintf("| %s | %v | %s | %s |\n", m.Name, m.Value, m.Status, m.Note))
+		}
+	}
+	if len(report.Risks) > 0 {
+		sb.WriteString("\n**风险**\n")
+		for _, r := range report.Risks {
+			sb.WriteString(fmt.Sprintf("- [%s] %s\n", r.Severity, r.Description))
+		}
+	}
+	if len(report.Actions) > 0 {
+		sb.WriteString("\n**建议**\n")
+		for i, a := range report.Actions {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, a.Description))
+			if a.SQL != "" {
+				sb.WriteString("   `" + a.SQL + "`\n")
+			}
+		}
+	}
+	if len(report.Sources) > 0 {
+		sb.WriteString("\n数据来源")
+		if instance != "" {
+			sb.WriteString("（实例 " + instance + "）")
+		}
+		sb.WriteString(": ")
+		sb.WriteString(strings.Join(report.Sources, ", "))
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
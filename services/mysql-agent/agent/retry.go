@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"mysql-agent/config"
+)
+
+const (
+	defaultLLMMaxRetries     = 3
+	defaultLLMRetryBaseDelay = 500 * time.Millisecond
+	defaultLLMRetryMaxDelay  = 10 * time.Second
+)
+
+// retryTransport 对 DeepSeek API 的瞬时失败（429、5xx、连接被重置等）按指数退避加抖动重试，
+// 响应带 Retry-After 时按其等待。流式请求只在拿到响应头之前重试，已开始输出的流不会重放
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+func newRetryTransport(next http.RoundTripper) *retryTransport {
+	t := &retryTransport{
+		next:       next,
+		maxRetries: defaultLLMMaxRetries,
+		baseDelay:  defaultLLMRetryBaseDelay,
+		maxDelay:   defaultLLMRetryMaxDelay,
+	}
+	if cfg := config.AppConfig; cfg != nil {
+		if cfg.LLM.MaxRetries >= 0 {
+			t.maxRetries = cfg.LLM.MaxRetries
+		}
+		if cfg.LLM.RetryBaseDelay > 0 {
+			t.baseDelay = cfg.LLM.RetryBaseDelay
+		}
+		if cfg.LLM.RetryMaxDelay > 0 {
+			t.maxDelay = cfg.LLM.RetryMaxDelay
+		}
+	}
+	return t
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		// 请求体无法重放时不重试
+		canRetry := attempt < t.maxRetries && (req.Body == nil || req.GetBody != nil)
+		if !canRetry || !retryable(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				delay = min(after, t.maxDelay)
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+			log.Printf("[retryTransport] status=%d attempt=%d retry in %s", resp.StatusCode, attempt+1, delay)
+		} else {
+			log.Printf("[retryTransport] error=%v attempt=%d retry in %s", err, attempt+1, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff 返回第 attempt 次重试前的等待时间：base*2^attempt 封顶 maxDelay，再取 [d/2, d) 的随机抖动
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	d := t.baseDelay << attempt
+	if d <= 0 || d > t.maxDelay {
+		d = t.maxDelay
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryable 判断一次请求结果是否值得重试：限流、服务端错误以及连接层的瞬时错误可以重试，
+// 调用方取消或超时、4xx 参数/鉴权/余额错误不重试
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true
+		}
+		return errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, syscall.EPIPE) ||
+			errors.Is(err, io.EOF) ||
+			errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期两种格式
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -3,12 +3,17 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudwego/eino/schema"
+
+	"mysql-agent/config"
+	"mysql-agent/databases"
 )
 
 type ToolCallSpec struct {
@@ -18,10 +23,28 @@ type ToolCallSpec struct {
 }
 
 type QueryRequest struct {
-	Query          string            `json:"query"`
-	Tools          []ToolCallSpec    `json:"tools,omitempty"`
-	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
-	Context        map[string]string `json:"context,omitempty"`
+	Query              string            `json:"query"`
+	Tools              []ToolCallSpec    `json:"tools,omitempty"`
+	TimeoutSeconds     int               `json:"timeout_seconds,omitempty"`
+	Context            map[string]string `json:"context,omitempty"`
+	IncludeRaw         *bool             `json:"include_raw,omitempty"`
+	IncludeToolOutputs *bool             `json:"include_tool_outputs,omitempty"`
+	// PlanOnly 为 true 时只返回规划出的工具计划而不执行，审核后通过 Agent.ExecutePlan 执行
+	PlanOnly bool `json:"plan_only,omitempty"`
+	// Caller 调用方标识，用于按调用方限流；为空时与其他匿名请求共用配额
+	Caller string `json:"caller,omitempty"`
+	// InstanceID 被诊断的实例（[[instances]] 中的 id），为空时使用 [database]
+	InstanceID string `json:"instance_id,omitempty"`
+	// Connection backend 登记实例的连接信息，开启 agent.accept_instance_connections 时生效
+	Connection *InstanceConnection `json:"connection,omitempty"`
+}
+
+// InstanceConnection backend 随请求下发的登记实例连接信息，未给出的库名、字符集与连接池参数沿用 [database]
+type InstanceConnection struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
 }
 
 type ToolRun struct {
@@ -31,45 +54,235 @@ type ToolRun struct {
 	Output     interface{} `json:"output,omitempty"`
 	Error      string      `json:"error,omitempty"`
 	DurationMs int64       `json:"duration_ms"`
+	// RejectedParams 未通过工具参数定义校验而被丢弃的参数
+	RejectedParams []RejectedParam `json:"rejected_params,omitempty"`
 }
 
 type AnalysisResult struct {
 	Summary string `json:"summary,omitempty"`
 	Error   string `json:"error,omitempty"`
+	// Report 结构化诊断报告，启用 agent.structured_summary 且校验通过时填充，Summary 为其 markdown 渲染
+	Report *DiagnosisReport `json:"report,omitempty"`
+	// Findings 规则诊断产出的结论，仅在 LLM 不可用而改用规则诊断时填充
+	Findings []Finding `json:"findings,omitempty"`
+	// Fallback 为 true 表示 Summary 由规则引擎生成而非 LLM
+	Fallback bool `json:"fallback,omitempty"`
+	// Anomalies 诊断时偏离指标基线的状态变量，启用 baseline.enabled 且基线样本足够时填充
+	Anomalies []Anomaly `json:"anomalies,omitempty"`
 }
 
 type QueryResponse struct {
 	Analysis AnalysisResult         `json:"analysis"`
+	Plan     []ToolCallSpec         `json:"plan,omitempty"`
 	ToolRuns []ToolRun              `json:"tool_runs"`
 	Raw      map[string]interface{} `json:"raw,omitempty"`
+	// Usage 本次请求所有 LLM 调用累计的 token 用量，未调用 LLM 时为空
+	Usage *TokenUsage `json:"usage,omitempty"`
+	// InstanceID 本次诊断所在的实例
+	InstanceID string `json:"instance_id"`
 }
 
-type RPCService struct{}
+// RPCService 以 "Agent" 名称注册的 RPC 服务。ctx 为调用所属连接（或 HTTP 请求）的上下文，
+// 连接断开时取消其上进行中的 SQL 和 LLM 调用；为空时不随调用方取消
+type RPCService struct {
+	ctx context.Context
+}
 
-const defaultQueryTimeout = 60 * time.Second
+// NewRPCService 返回绑定 ctx 的服务实例，供 HTTP 等能感知调用方断开的入口使用
+func NewRPCService(ctx context.Context) RPCService {
+	return RPCService{ctx: ctx}
+}
 
-func (RPCService) Query(req QueryRequest, resp *QueryResponse) error {
+func (s RPCService) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+const (
+	defaultQueryTimeout    = 60 * time.Second
+	defaultToolConcurrency = 4
+	defaultPlanTimeout     = 30 * time.Second
+)
+
+func (s RPCService) Query(req QueryRequest, resp *QueryResponse) error {
+	return runQuery(s.context(), req, resp, nil)
+}
+
+// ExecutePlan 执行经过审核（可能已修改）的工具计划并分析结果，req.Tools 为必填；
+// 不受 plan_only 和 agent.require_plan_approval 限制
+func (s RPCService) ExecutePlan(req QueryRequest, resp *QueryResponse) error {
 	if strings.TrimSpace(req.Query) == "" {
 		return fmt.Errorf("query 不能为空")
 	}
+	if len(req.Tools) == 0 {
+		return fmt.Errorf("tools 不能为空")
+	}
+	if err := checkInstance(s.context(), req.InstanceID, req.Connection); err != nil {
+		return err
+	}
+	if err := allowQuery(req.Caller); err != nil {
+		log.Printf("[ExecutePlan] caller=%q %v", req.Caller, err)
+		return err
+	}
+	release, err := acquireQuery(s.context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx, cancel := queryContext(s.context(), req)
+	defer cancel()
+	defer trimResponse(req, resp)
+	ctx, usage := withUsageTracker(ctx)
+	defer func() { resp.Usage = usage.snapshot() }()
+
+	resp.InstanceID = databases.InstanceFrom(ctx)
+	log.Printf("[ExecutePlan] instance=%s query=%q plan=%v", resp.InstanceID, req.Query, summarizePlan(req.Tools))
+	resp.Plan = req.Tools
+	// 审核过的计划按原样执行，不追加未经审核的工具
+	runPlan(ctx, req, req.Tools, resp, nil, planOptions{})
+	return nil
+}
+
+type RunToolRequest struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+	// TimeoutSeconds 单次执行的超时时间，默认使用 agent.plan_timeout
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// InstanceID 执行工具的实例，为空时使用 [database]
+	InstanceID string `json:"instance_id,omitempty"`
+	// Connection backend 登记实例的连接信息，见 QueryRequest.Connection
+	Connection *InstanceConnection `json:"connection,omitempty"`
+}
+
+// RunTool 直接执行单个已注册的工具并返回其结构化输出，不经过 LLM 规划与分析，
+// 适合仪表盘定期轮询 processlist、status 等数据。参数校验失败或工具执行失败时返回错误
+func (s RPCService) RunTool(req RunToolRequest, resp *ToolRun) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("name 不能为空")
+	}
+	if !toolEnabled(s.context(), req.Name) {
+		return fmt.Errorf("未找到工具: %s", req.Name)
+	}
+	if err := checkInstance(s.context(), req.InstanceID, req.Connection); err != nil {
+		return err
+	}
+
+	timeout := defaultPlanTimeout
+	if cfg := config.AppConfig; cfg != nil && cfg.Agent.PlanTimeout > 0 {
+		timeout = cfg.Agent.PlanTimeout
+	}
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(databases.WithInstance(s.context(), req.InstanceID), timeout)
+	defer cancel()
+
+	start := time.Now()
+	args, rejected, err := validateToolArgs(ctx, req.Name, string(req.Args))
+	if err != nil {
+		return fmt.Errorf("参数校验失败: %w", err)
+	}
+	output, err := CallTool(ctx, req.Name, args)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("工具 %s 执行超过 %s", req.Name, timeout)
+		}
+		return err
+	}
+
+	resp.Name = req.Name
+	resp.Input = safeParseJSON(args)
+	resp.Output = safeParseJSON(output)
+	resp.DurationMs = time.Since(start).Milliseconds()
+	resp.RejectedParams = rejected
+	return nil
+}
 
+func queryContext(parent context.Context, req QueryRequest) (context.Context, context.CancelFunc) {
 	timeout := defaultQueryTimeout
 	if req.TimeoutSeconds > 0 {
 		timeout = time.Duration(req.TimeoutSeconds) * time.Second
 	}
+	return context.WithTimeout(databases.WithInstance(parent, req.InstanceID), timeout)
+}
+
+// checkInstance 校验请求指定的实例已配置，为空表示 default；开启 agent.accept_instance_connections 时
+// 先按请求携带的连接信息登记实例
+func checkInstance(ctx context.Context, id string, conn *InstanceConnection) error {
+	if id != "" && conn != nil && config.AppConfig != nil && config.AppConfig.Agent.AcceptInstanceConnections {
+		if err := databases.RegisterInstance(ctx, id, conn.databaseConfig()); err != nil {
+			return err
+		}
+	}
+	if !databases.HasInstance(id) {
+		return fmt.Errorf("%w: %s", databases.ErrUnknownInstance, id)
+	}
+	return nil
+}
+
+// databaseConfig 以 [database] 为基础替换主机、端口与账号，不沿用 [database] 的从库
+func (c InstanceConnection) databaseConfig() config.DatabaseConfig {
+	cfg := config.AppConfig.Database
+	cfg.Host, cfg.Port, cfg.Username, cfg.Password = c.Host, c.Port, c.Username, c.Password
+	cfg.ReplicaDSNs = nil
+	if cfg.Port == 0 {
+		cfg.Port = 3306
+	}
+	return cfg
+}
+
+// planOnly 请求指定 plan_only 或配置要求审核计划时，Agent.Query 只规划不执行
+func planOnly(req QueryRequest) bool {
+	if req.PlanOnly {
+		return true
+	}
+	return config.AppConfig != nil && config.AppConfig.Agent.RequirePlanApproval
+}
+
+// runQuery 完成规划、执行工具和分析的完整流程；emit 非空时在各阶段推送增量事件
+func runQuery(parent context.Context, req QueryRequest, resp *QueryResponse, emit func(StreamEvent)) error {
+	if strings.TrimSpace(req.Query) == "" {
+		return fmt.Errorf("query 不能为空")
+	}
+	if err := checkInstance(parent, req.InstanceID, req.Connection); err != nil {
+		return err
+	}
+	if err := allowQuery(req.Caller); err != nil {
+		log.Printf("[Query] caller=%q %v", req.Caller, err)
+		return err
+	}
+	release, err := acquireQuery(parent)
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := queryContext(parent, req)
 	defer cancel()
+	defer trimResponse(req, resp)
+	ctx, usage := withUsageTracker(ctx)
+	defer func() { resp.Usage = usage.snapshot() }()
+	resp.InstanceID = databases.InstanceFrom(ctx)
 
 	plan := req.Tools
+	opts := planOptions{iterate: true}
 	if len(plan) == 0 {
 		var refusal string
 		var err error
 		plan, refusal, err = planWithLLM(ctx, req)
 		if err != nil {
 			log.Printf("[Query] planWithLLM error: %v", err)
-			resp.Analysis.Error = fmt.Sprintf("规划工具失败: %v", err)
-			return nil
+			if !rulesFallbackEnabled() {
+				resp.Analysis.Error = fmt.Sprintf("规划工具失败: %v", err)
+				return nil
+			}
+			// LLM 不可用时执行固定的指标采集计划，由规则引擎给出结论
+			log.Print("[Query] falling back to rule-based diagnostics")
+			plan, opts = ruleFallbackPlan(ctx), planOptions{rulesOnly: true}
+			resp.Raw = map[string]interface{}{"llm_error": err.Error()}
 		}
 		if refusal != "" {
 			log.Printf("[Query] planWithLLM refusal: %s", refusal)
@@ -85,55 +298,86 @@ func (RPCService) Query(req QueryRequest, resp *QueryResponse) error {
 
 	log.Printf("[Query] query=%q plan=%v", req.Query, summarizePlan(plan))
 
-	toolRuns := make([]ToolRun, 0, len(plan))
-	toolOutputs := make([]map[string]interface{}, 0, len(plan))
-	failure := ""
+	resp.Plan = plan
+	if emit != nil {
+		emit(StreamEvent{Type: EventPlan, Plan: plan})
+	}
+	if planOnly(req) {
+		log.Print("[Query] plan only, waiting for approval")
+		return nil
+	}
 
-	for _, spec := range plan {
-		argsStr := string(spec.Args)
-		if strings.TrimSpace(spec.Reason) != "" {
-			log.Printf("[Query] invoking tool=%s reason=%s", spec.Name, spec.Reason)
-		} else {
-			log.Printf("[Query] invoking tool=%s", spec.Name)
-		}
-		start := time.Now()
-		outputStr, err := CallTool(ctx, spec.Name, argsStr)
-		duration := time.Since(start).Milliseconds()
+	runPlan(ctx, req, plan, resp, emit, opts)
+	return nil
+}
 
-		run := ToolRun{Name: spec.Name, Reason: spec.Reason, Input: safeParseJSON(argsStr), DurationMs: duration}
-		if err != nil {
-			run.Error = err.Error()
-			failure = fmt.Sprintf("工具 %s 执行失败: %v", spec.Name, err)
-			toolRuns = append(toolRuns, run)
-			log.Printf("[Query] tool=%s failed: %v", spec.Name, err)
-			break
-		}
+// planOptions 控制 runPlan 的分析方式
+type planOptions struct {
+	// iterate 为 true 时在分析前允许 LLM 根据已有结果追加工具调用，轮数受 agent.max_iterations 限制
+	iterate bool
+	// rulesOnly 为 true 时不调用 LLM，直接由规则引擎分析工具输出
+	rulesOnly bool
+}
 
-		parsed := safeParseJSON(outputStr)
-		run.Output = parsed
-		toolRuns = append(toolRuns, run)
-		toolOutputs = append(toolOutputs, map[string]interface{}{
-			"name":   spec.Name,
-			"output": parsed,
-		})
+// runPlan 执行工具计划并由 LLM 分析结果，失败信息写入 resp.Analysis.Error；
+// LLM 分析失败且启用了 rules.enabled 时改用规则诊断
+func runPlan(ctx context.Context, req QueryRequest, plan []ToolCallSpec, resp *QueryResponse, emit func(StreamEvent), opts planOptions) {
+	var onToolDone func(ToolRun)
+	if emit != nil {
+		onToolDone = func(run ToolRun) {
+			if req.IncludeToolOutputs != nil && !*req.IncludeToolOutputs {
+				run.Input, run.Output = nil, nil
+			}
+			emit(StreamEvent{Type: EventTool, Tool: &run})
+		}
 	}
 
+	toolRuns, toolOutputs, failure := executePlan(ctx, plan, onToolDone)
 	resp.ToolRuns = toolRuns
-	resp.Raw = map[string]interface{}{
-		"tool_outputs": toolOutputs,
+	resp.Analysis.Anomalies = detectAnomalies(ctx)
+	if resp.Raw == nil {
+		resp.Raw = map[string]interface{}{}
 	}
+	resp.Raw["tool_outputs"] = toolOutputs
 
 	if failure != "" {
 		resp.Analysis.Error = failure
-		return nil
+		return
+	}
+
+	if opts.rulesOnly {
+		applyRuleDiagnosis(resp, toolOutputs, emit)
+		return
+	}
+
+	if opts.iterate {
+		toolOutputs = followUp(ctx, req, resp, toolOutputs, emit, onToolDone)
+		resp.Raw["tool_outputs"] = toolOutputs
+	}
+
+	var onDelta func(string)
+	if emit != nil {
+		onDelta = func(delta string) {
+			emit(StreamEvent{Type: EventSummary, Delta: delta})
+		}
 	}
 
-	analysis, err := analyzeWithLLM(ctx, req.Query, toolOutputs)
+	llmOutputs, truncated := budgetToolOutputs(toolOutputs)
+	if len(truncated) > 0 {
+		log.Printf("[Query] tool outputs truncated for LLM: %v", truncated)
+		resp.Raw["truncated_tools"] = truncated
+	}
+
+	analysis, err := analyzeWithLLM(ctx, req.Query, llmOutputs, onDelta)
 	if err != nil {
 		log.Printf("[Query] analyzeWithLLM failed: %v", err)
-		resp.Analysis.Error = err.Error()
 		resp.Raw["llm_error"] = err.Error()
-		return nil
+		if !rulesFallbackEnabled() {
+			resp.Analysis.Error = err.Error()
+			return
+		}
+		applyRuleDiagnosis(resp, toolOutputs, emit)
+		return
 	}
 
 	log.Print("[Query] analyzeWithLLM success")
@@ -141,15 +385,153 @@ func (RPCService) Query(req QueryRequest, resp *QueryResponse) error {
 	if analysis.ResponseMeta != nil {
 		resp.Raw["response_meta"] = analysis.ResponseMeta
 	}
-	return nil
+
+	if structuredSummaryEnabled() {
+		// 报告始终无法通过校验时保留模型原始输出作为 Summary
+		report, err := parseReport(ctx, analysis.Content, llmOutputs)
+		if err != nil {
+			log.Printf("[Query] parseReport failed: %v", err)
+			resp.Raw["report_error"] = err.Error()
+			return
+		}
+		resp.Analysis.Report = report
+		resp.Analysis.Summary = renderReport(report, instanceLabel(ctx))
+	}
+}
+
+// executePlan 并发执行计划中的工具，并发数受 agent.tool_concurrency 限制，总耗时受 agent.plan_timeout 限制；
+// 结果按计划顺序返回。任一工具失败后取消尚未完成的工具，未开始执行的工具不出现在结果中。
+// onDone 非空时在每个工具结束后按完成顺序串行回调
+func executePlan(ctx context.Context, plan []ToolCallSpec, onDone func(ToolRun)) ([]ToolRun, []map[string]interface{}, string) {
+	workers, budget := defaultToolConcurrency, defaultPlanTimeout
+	if cfg := config.AppConfig; cfg != nil {
+		if cfg.Agent.ToolConcurrency > 0 {
+			workers = cfg.Agent.ToolConcurrency
+		}
+		if cfg.Agent.PlanTimeout > 0 {
+			budget = cfg.Agent.PlanTimeout
+		}
+	}
+	// 会修改数据库状态的工具之间可能存在先后依赖，包含时退回顺序执行
+	for _, spec := range plan {
+		if _, ok := mutatingTools[spec.Name]; ok {
+			workers = 1
+			break
+		}
+	}
+
+	planCtx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	runs := make([]*ToolRun, len(plan))
+	outputs := make([]interface{}, len(plan))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := -1
+
+	for i, spec := range plan {
+		select {
+		case sem <- struct{}{}:
+		case <-planCtx.Done():
+		}
+		if planCtx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, spec ToolCallSpec) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			argsStr := string(spec.Args)
+			if strings.TrimSpace(spec.Reason) != "" {
+				log.Printf("[executePlan] invoking tool=%s reason=%s", spec.Name, spec.Reason)
+			} else {
+				log.Printf("[executePlan] invoking tool=%s", spec.Name)
+			}
+			start := time.Now()
+			var outputStr string
+			args, rejected, err := validateToolArgs(planCtx, spec.Name, argsStr)
+			if len(rejected) > 0 {
+				log.Printf("[executePlan] tool=%s rejected params: %v", spec.Name, rejected)
+			}
+			if err != nil {
+				err = fmt.Errorf("参数校验失败: %w", err)
+				args = argsStr
+			} else {
+				outputStr, err = CallTool(planCtx, spec.Name, args)
+			}
+			run := &ToolRun{Name: spec.Name, Reason: spec.Reason, Input: safeParseJSON(args), DurationMs: time.Since(start).Milliseconds(), RejectedParams: rejected}
+
+			mu.Lock()
+			defer mu.Unlock()
+			runs[i] = run
+			if onDone != nil {
+				defer func() { onDone(*run) }()
+			}
+			if err != nil {
+				run.Error = err.Error()
+				log.Printf("[executePlan] tool=%s failed: %v", spec.Name, err)
+				// 其他工具失败触发取消后产生的错误不作为失败原因
+				if failed < 0 {
+					failed = i
+				}
+				cancel()
+				return
+			}
+			run.Output = safeParseJSON(outputStr)
+			outputs[i] = run.Output
+		}(i, spec)
+	}
+	wg.Wait()
+
+	toolRuns := make([]ToolRun, 0, len(plan))
+	toolOutputs := make([]map[string]interface{}, 0, len(plan))
+	for i, run := range runs {
+		if run == nil {
+			continue
+		}
+		toolRuns = append(toolRuns, *run)
+		if run.Error == "" {
+			toolOutputs = append(toolOutputs, map[string]interface{}{
+				"name":   plan[i].Name,
+				"output": outputs[i],
+			})
+		}
+	}
+
+	switch {
+	case failed >= 0 && errors.Is(planCtx.Err(), context.DeadlineExceeded):
+		return toolRuns, toolOutputs, fmt.Sprintf("工具执行超出时间预算 %s", budget)
+	case failed >= 0:
+		return toolRuns, toolOutputs, fmt.Sprintf("工具 %s 执行失败: %s", plan[failed].Name, runs[failed].Error)
+	case len(toolRuns) < len(plan):
+		return toolRuns, toolOutputs, fmt.Sprintf("工具执行超出时间预算 %s", budget)
+	}
+	return toolRuns, toolOutputs, ""
 }
 
-func analyzeWithLLM(ctx context.Context, query string, toolOutputs []map[string]interface{}) (*schema.Message, error) {
+// analyzeWithLLM 根据工具输出生成诊断结论；onDelta 非空时以 stream 模式请求模型并逐块回调生成内容
+func analyzeWithLLM(ctx context.Context, query string, toolOutputs []map[string]interface{}, onDelta func(string)) (*schema.Message, error) {
 	log.Print("[analyzeWithLLM] start")
+	systemPrompt, err := renderPrompt(ctx, promptSummarySystem)
+	if err != nil {
+		return nil, err
+	}
+	instructionPrompt := promptSummaryInstruction
+	if structuredSummaryEnabled() {
+		instructionPrompt = promptSummaryStructured
+	}
+	instruction, err := renderPrompt(ctx, instructionPrompt)
+	if err != nil {
+		return nil, err
+	}
+
 	messages := []*schema.Message{
 		{
 			Role:    schema.System,
-			Content: "你是 MySQL 运维诊断助手，会根据工具返回的数据给出结论和建议。",
+			Content: systemPrompt,
 		},
 		{
 			Role:    schema.User,
@@ -168,10 +550,15 @@ func analyzeWithLLM(ctx context.Context, query string, toolOutputs []map[string]
 
 	messages = append(messages, &schema.Message{
 		Role:    schema.User,
-		Content: "请结合以上工具数据给出诊断以及后续建议，结构化输出结论和建议。",
+		Content: instruction,
 	})
 
-	result, err := Generate(ctx, messages)
+	var result *schema.Message
+	if onDelta != nil {
+		result, err = generateStreaming(ctx, messages, onDelta)
+	} else {
+		result, err = Generate(ctx, messages)
+	}
 	if err != nil {
 		log.Printf("[analyzeWithLLM] Generate error: %v", err)
 		return nil, fmt.Errorf("LLM 分析失败: %w", err)
@@ -188,10 +575,27 @@ func RegisterRPC(server RPCRegistrar) error {
 	return server.RegisterName("Agent", RPCService{})
 }
 
+// RegisterRPCWithContext 注册绑定 ctx 的服务实例，通常每个连接使用独立的 rpc.Server 并在连接断开时取消 ctx
+func RegisterRPCWithContext(server RPCRegistrar, ctx context.Context) error {
+	return server.RegisterName("Agent", NewRPCService(ctx))
+}
+
 type RPCRegistrar interface {
 	RegisterName(name string, rcvr interface{}) error
 }
 
+func trimResponse(req QueryRequest, resp *QueryResponse) {
+	if req.IncludeRaw != nil && !*req.IncludeRaw {
+		resp.Raw = nil
+	}
+	if req.IncludeToolOutputs != nil && !*req.IncludeToolOutputs {
+		for i := range resp.ToolRuns {
+			resp.ToolRuns[i].Input = nil
+			resp.ToolRuns[i].Output = nil
+		}
+	}
+}
+
 func safeParseJSON(raw string) interface{} {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" || trimmed == "{}" || trimmed == "null" {
@@ -234,11 +638,15 @@ func planWithLLM(ctx context.Context, req QueryRequest) ([]ToolCallSpec, string,
 		return nil, "", err
 	}
 
+	systemPrompt, err := renderPrompt(ctx, promptPlannerSystem)
+	if err != nil {
+		return nil, "", err
+	}
 	prompt := buildPlannerPrompt(descriptors, req.Query)
 	log.Printf("[planWithLLM] prompt=%s", truncate(prompt))
 
 	messages := []*schema.Message{
-		{Role: schema.System, Content: "你是一个数据库诊断工具调度助手，会根据用户需求在允许的工具中规划执行步骤。"},
+		{Role: schema.System, Content: systemPrompt},
 		{Role: schema.User, Content: prompt},
 	}
 
@@ -262,8 +670,16 @@ func planWithLLM(ctx context.Context, req QueryRequest) ([]ToolCallSpec, string,
 		return nil, "请求超出工具能力范围", nil
 	}
 
-	tools := make([]ToolCallSpec, 0, len(planResp.Tools))
-	for _, t := range planResp.Tools {
+	tools, err := toToolSpecs(planResp.Tools)
+	if err != nil {
+		return nil, "", err
+	}
+	return tools, "", nil
+}
+
+func toToolSpecs(planned []plannedToolCmd) ([]ToolCallSpec, error) {
+	tools := make([]ToolCallSpec, 0, len(planned))
+	for _, t := range planned {
 		if strings.TrimSpace(t.Name) == "" {
 			continue
 		}
@@ -271,14 +687,13 @@ func planWithLLM(ctx context.Context, req QueryRequest) ([]ToolCallSpec, string,
 		if t.Args != nil {
 			bytes, err := json.Marshal(t.Args)
 			if err != nil {
-				return nil, "", fmt.Errorf("序列化工具参数失败: %w", err)
+				return nil, fmt.Errorf("序列化工具参数失败: %w", err)
 			}
 			rawArgs = bytes
 		}
 		tools = append(tools, ToolCallSpec{Name: t.Name, Args: rawArgs, Reason: t.Reason})
 	}
-
-	return tools, "", nil
+	return tools, nil
 }
 
 func buildPlannerPrompt(descriptors []ToolDescriptor, query string) string {
@@ -301,15 +716,20 @@ func buildPlannerPrompt(descriptors []ToolDescriptor, query string) string {
 
 func parsePlanJSON(raw string) (llmPlanResponse, error) {
 	var plan llmPlanResponse
+	if err := decodeLLMJSON(raw, &plan); err != nil {
+		return plan, fmt.Errorf("解析 LLM 规划响应失败: %w", err)
+	}
+	return plan, nil
+}
+
+// decodeLLMJSON 去掉 markdown 代码块和 JSON 之前的说明文字后解析 LLM 输出
+func decodeLLMJSON(raw string, v interface{}) error {
 	raw = strings.TrimSpace(raw)
 	raw = stripMarkdownFence(raw)
 	if idx := strings.Index(raw, "{"); idx > 0 {
 		raw = raw[idx:]
 	}
-	if err := json.Unmarshal([]byte(raw), &plan); err != nil {
-		return plan, fmt.Errorf("解析 LLM 规划响应失败: %w", err)
-	}
-	return plan, nil
+	return json.Unmarshal([]byte(raw), v)
 }
 
 func truncate(s string) string {
@@ -0,0 +1,331 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mysql-agent/config"
+)
+
+// 规则诊断结论的严重程度，与各工具输出的 severity 取值一致
+const (
+	severityHigh     = "high"
+	severityModerate = "moderate"
+	severityLow      = "low"
+)
+
+// Finding 规则引擎产出的一条诊断结论
+type Finding struct {
+	Rule           string `json:"rule"`
+	Severity       string `json:"severity"`
+	Message        string `json:"message"`
+	Recommendation string `json:"recommendation,omitempty"`
+}
+
+// ruleFallbackPlan LLM 无法规划时执行的固定工具集合，覆盖规则引擎需要的全部指标；被配置禁用的工具不执行
+func ruleFallbackPlan(ctx context.Context) []ToolCallSpec {
+	plan := []ToolCallSpec{
+		{Name: toolGlobalStatus, Args: json.RawMessage(`{"keys":["Threads_running","Threads_connected","Created_tmp_tables","Created_tmp_disk_tables"]}`), Reason: "规则诊断: 并发线程数与磁盘临时表"},
+		{Name: toolInnoDBTrx, Reason: "规则诊断: 锁等待事务"},
+		{Name: toolRowLockStats, Reason: "规则诊断: 行锁争用"},
+		{Name: toolReplication, Reason: "规则诊断: 复制延迟"},
+		{Name: toolSlowQueries, Reason: "规则诊断: 慢查询"},
+		{Name: toolBufferPool, Reason: "规则诊断: 缓冲池命中率"},
+	}
+	kept := plan[:0]
+	for _, spec := range plan {
+		if toolEnabled(ctx, spec.Name) {
+			kept = append(kept, spec)
+		}
+	}
+	return kept
+}
+
+func rulesFallbackEnabled() bool {
+	return config.AppConfig == nil || config.AppConfig.Rules.Enabled
+}
+
+// diagnoseWithRules 不调用 LLM，按 rules.* 阈值检查工具输出并给出结论，结果按严重程度排序
+func diagnoseWithRules(toolOutputs []map[string]interface{}) []Finding {
+	thresholds := config.DefaultRulesConfig()
+	if config.AppConfig != nil {
+		thresholds = config.AppConfig.Rules
+	}
+
+	var findings []Finding
+	for _, item := range toolOutputs {
+		name, _ := item["name"].(string)
+		output, _ := item["output"].(map[string]interface{})
+		if output == nil {
+			continue
+		}
+		switch name {
+		case toolGlobalStatus:
+			findings = append(findings, threadsRunningRule(output, thresholds)...)
+			findings = append(findings, tmpDiskTableRule(output, thresholds)...)
+		case toolInnoDBTrx:
+			findings = append(findings, lockWaitTrxRule(output)...)
+		case toolRowLockStats:
+			findings = append(findings, rowLockRule(output)...)
+		case toolReplication:
+			findings = append(findings, replicationRule(output, thresholds)...)
+		case toolSlowQueries:
+			findings = append(findings, slowQueryRule(output, thresholds)...)
+		case toolBufferPool:
+			findings = append(findings, bufferPoolRule(output, thresholds)...)
+		}
+	}
+
+	rank := map[string]int{severityHigh: 0, severityModerate: 1, severityLow: 2}
+	sort.SliceStable(findings, func(i, j int) bool { return rank[findings[i].Severity] < rank[findings[j].Severity] })
+	return findings
+}
+
+func threadsRunningRule(output map[string]interface{}, t config.RulesConfig) []Finding {
+	for _, row := range rowsOf(output) {
+		if !strings.EqualFold(stringField(row, "variable_name"), "Threads_running") {
+			continue
+		}
+		running, ok := numberField(row, "value")
+		if !ok || t.ThreadsRunning <= 0 || running < float64(t.ThreadsRunning) {
+			return nil
+		}
+		severity := severityModerate
+		if running >= float64(t.ThreadsRunning*2) {
+			severity = severityHigh
+		}
+		return []Finding{{
+			Rule:           "threads_running",
+			Severity:       severity,
+			Message:        fmt.Sprintf("Threads_running=%.0f，超过阈值 %d", running, t.ThreadsRunning),
+			Recommendation: "查看 processlist 中正在执行的语句，排查慢 SQL、锁等待或突发流量",
+		}}
+	}
+	return nil
+}
+
+// tmpDiskTableRule 按实例启动以来的累计值计算磁盘临时表占比，临时表总数过少时不判断
+func tmpDiskTableRule(output map[string]interface{}, t config.RulesConfig) []Finding {
+	if t.TmpDiskTablePct <= 0 {
+		return nil
+	}
+	var tmp, disk float64
+	var hasTmp, hasDisk bool
+	for _, row := range rowsOf(output) {
+		switch strings.ToLower(stringField(row, "variable_name")) {
+		case "created_tmp_tables":
+			tmp, hasTmp = numberField(row, "value")
+		case "created_tmp_disk_tables":
+			disk, hasDisk = numberField(row, "value")
+		}
+	}
+	if !hasTmp || !hasDisk || tmp < 100 {
+		return nil
+	}
+	pct := disk / tmp * 100
+	if pct < t.TmpDiskTablePct {
+		return nil
+	}
+	severity := severityModerate
+	if pct >= t.TmpDiskTablePct*2 {
+		severity = severityHigh
+	}
+	return []Finding{{
+		Rule:           "tmp_disk_tables",
+		Severity:       severity,
+		Message:        fmt.Sprintf("%.1f%% 的内部临时表落盘（%.0f/%.0f），超过阈值 %.0f%%", pct, disk, tmp, t.TmpDiskTablePct),
+		Recommendation: "使用 mysql_tmp_sort_stats 找到产生磁盘临时表的语句，优化 GROUP BY/ORDER BY 或调大 tmp_table_size 与 max_heap_table_size",
+	}}
+}
+
+func lockWaitTrxRule(output map[string]interface{}) []Finding {
+	waiting := 0
+	for _, row := range rowsOf(output) {
+		if strings.EqualFold(stringField(row, "trx_state"), "LOCK WAIT") {
+			waiting++
+		}
+	}
+	if waiting == 0 {
+		return nil
+	}
+	return []Finding{{
+		Rule:           "lock_wait_transactions",
+		Severity:       severityModerate,
+		Message:        fmt.Sprintf("有 %d 个事务处于 LOCK WAIT 状态", waiting),
+		Recommendation: "结合 mysql_metadata_locks 与 innodb_trx 找到阻塞源事务，确认是否需要提交或终止",
+	}}
+}
+
+func rowLockRule(output map[string]interface{}) []Finding {
+	var findings []Finding
+	if current, ok := numberField(output, "innodb_row_lock_current_waits"); ok && current > 0 {
+		findings = append(findings, Finding{
+			Rule:           "row_lock_current_waits",
+			Severity:       severityModerate,
+			Message:        fmt.Sprintf("当前有 %.0f 个行锁等待", current),
+			Recommendation: "检查长事务与热点行更新，缩短事务持有锁的时间",
+		})
+	}
+	if stringField(output, "severity") == "high" {
+		findings = append(findings, Finding{
+			Rule:           "row_lock_contention",
+			Severity:       severityModerate,
+			Message:        "行锁争用程度为 high: " + stringField(output, "severity_reason"),
+			Recommendation: "排查高频更新同一批行的业务逻辑，必要时拆分热点或调整索引以缩小锁范围",
+		})
+	}
+	return findings
+}
+
+func replicationRule(output map[string]interface{}, t config.RulesConfig) []Finding {
+	channels, _ := output["channels"].([]interface{})
+	var findings []Finding
+	for _, c := range channels {
+		ch, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		label := stringField(ch, "channel")
+		if label == "" {
+			label = "默认通道"
+		}
+		if healthy, _ := ch["healthy"].(bool); !healthy {
+			findings = append(findings, Finding{
+				Rule:           "replication_broken",
+				Severity:       severityHigh,
+				Message:        fmt.Sprintf("复制通道 %s 异常: %s", label, stringField(ch, "unhealthy_cause")),
+				Recommendation: "查看 last_io_error/last_sql_error，修复后重新启动复制线程",
+			})
+			continue
+		}
+		lag, ok := numberField(ch, "seconds_behind_source")
+		if !ok || t.ReplicationLagWarn <= 0 || lag < float64(t.ReplicationLagWarn) {
+			continue
+		}
+		severity := severityModerate
+		if t.ReplicationLagCrit > 0 && lag >= float64(t.ReplicationLagCrit) {
+			severity = severityHigh
+		}
+		findings = append(findings, Finding{
+			Rule:           "replication_lag",
+			Severity:       severity,
+			Message:        fmt.Sprintf("复制通道 %s 延迟 %.0f 秒，超过阈值 %d 秒", label, lag, t.ReplicationLagWarn),
+			Recommendation: "检查从库上的大事务或长查询，确认是否开启并行复制",
+		})
+	}
+	return findings
+}
+
+func slowQueryRule(output map[string]interface{}, t config.RulesConfig) []Finding {
+	if t.SlowQueryAvgMs <= 0 {
+		return nil
+	}
+	slow := 0
+	worst, worstMs := "", 0.0
+	for _, row := range rowsOf(output) {
+		avg, ok := numberField(row, "avg_timer_wait")
+		if !ok {
+			continue
+		}
+		// performance_schema 计时单位为皮秒
+		ms := avg / 1e9
+		if ms < float64(t.SlowQueryAvgMs) {
+			continue
+		}
+		slow++
+		if ms > worstMs {
+			worst, worstMs = stringField(row, "digest_text"), ms
+		}
+	}
+	if slow == 0 {
+		return nil
+	}
+	return []Finding{{
+		Rule:           "slow_queries",
+		Severity:       severityModerate,
+		Message:        fmt.Sprintf("%d 类语句平均耗时超过 %dms，最慢的平均 %.0fms: %s", slow, t.SlowQueryAvgMs, worstMs, truncate(worst)),
+		Recommendation: "使用 mysql_explain_query 查看执行计划，补充索引或改写语句",
+	}}
+}
+
+func bufferPoolRule(output map[string]interface{}, t config.RulesConfig) []Finding {
+	hit, ok := numberField(output, "hit_ratio_pct")
+	if !ok || t.BufferPoolHitWarn <= 0 || hit >= t.BufferPoolHitWarn {
+		return nil
+	}
+	severity := severityModerate
+	if hit < t.BufferPoolHitCrit {
+		severity = severityHigh
+	}
+	return []Finding{{
+		Rule:           "buffer_pool_hit_ratio",
+		Severity:       severity,
+		Message:        fmt.Sprintf("缓冲池命中率 %.2f%%，低于阈值 %.2f%%", hit, t.BufferPoolHitWarn),
+		Recommendation: "评估增大 innodb_buffer_pool_size，或排查全表扫描导致的缓冲池污染",
+	}}
+}
+
+// applyRuleDiagnosis 用规则引擎的结论填充 resp.Analysis 并按 alert.* 配置告警，流式请求时把渲染后的结论作为一次 summary 事件推送
+func applyRuleDiagnosis(resp *QueryResponse, toolOutputs []map[string]interface{}, emit func(StreamEvent)) {
+	findings := diagnoseWithRules(toolOutputs)
+	log.Printf("[Query] rule-based diagnostics produced %d findings", len(findings))
+	resp.Analysis.Findings = findings
+	resp.Analysis.Fallback = true
+	resp.Analysis.Summary = renderFindings(findings)
+	fireAlerts(findings, AlertSourceQuery, resp.InstanceID)
+	if emit != nil {
+		emit(StreamEvent{Type: EventSummary, Delta: resp.Analysis.Summary})
+	}
+}
+
+// renderFindings 把规则结论渲染为与 LLM 结论相同位置展示的文本
+func renderFindings(findings []Finding) string {
+	var sb strings.Builder
+	sb.WriteString("LLM 不可用，以下为基于规则的诊断结果：\n")
+	if len(findings) == 0 {
+		sb.WriteString("未发现超出阈值的指标。")
+		return sb.String()
+	}
+	for i, f := range findings {
+		sb.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, f.Severity, f.Message))
+		if f.Recommendation != "" {
+			sb.WriteString("   建议：")
+			sb.WriteString(f.Recommendation)
+			sb.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func rowsOf(output map[string]interface{}) []map[string]interface{} {
+	raw, _ := output["rows"].([]interface{})
+	rows := make([]map[string]interface{}, 0, len(raw))
+	for _, r := range raw {
+		if row, ok := r.(map[string]interface{}); ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// numberField 读取数值字段，兼容 JSON 数字与 normalizeRows 转成的字符串
+func numberField(m map[string]interface{}, key string) (float64, bool) {
+	switch v := m[key].(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
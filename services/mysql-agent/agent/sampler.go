@@ -0,0 +1,354 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mysql-agent/config"
+	"mysql-agent/databases"
+)
+
+const (
+	defaultSampleInterval = 10 * time.Second
+	defaultSampleCapacity = 360
+	// samplePersistInterval 配置了 sampler.file 时写入文件的最短间隔，退出时总会写一次
+	samplePersistInterval = time.Minute
+)
+
+// MetricSample 一次采样：状态变量原始值（变量名小写）与各复制通道的延迟秒数
+type MetricSample struct {
+	At     time.Time          `json:"at"`
+	Status map[string]float64 `json:"status"`
+	// ReplicaLag 通道名 -> Seconds_Behind_Source，延迟未知（SQL 线程未运行）的通道不记录
+	ReplicaLag map[string]float64 `json:"replica_lag,omitempty"`
+}
+
+// sampleRing 固定容量的环形缓冲区，写满后覆盖最早的采样
+type sampleRing struct {
+	mu      sync.RWMutex
+	buf     []MetricSample
+	next    int
+	size    int
+	savedAt time.Time
+	path    string
+}
+
+var samples = &sampleRing{}
+
+func samplerConfig() config.SamplerConfig {
+	if config.AppConfig == nil {
+		return config.SamplerConfig{}
+	}
+	return config.AppConfig.Sampler
+}
+
+// SamplingActive 采样器是否运行；指标基线与指标导出依赖采样数据，启用任一项时采样器同样运行
+func SamplingActive() bool {
+	if config.AppConfig == nil {
+		return false
+	}
+	cfg := config.AppConfig
+	return cfg.Sampler.Enabled || cfg.Baseline.Enabled || cfg.Exporter.OpenMetrics || cfg.Exporter.RemoteWriteURL != ""
+}
+
+func sampleInterval() time.Duration {
+	if iv := samplerConfig().Interval; iv > 0 {
+		return iv
+	}
+	return defaultSampleInterval
+}
+
+// sampledMetrics 每次采样保留的状态变量：sampler.metrics、吞吐量计算所需的计数器与启用时的基线指标
+func sampledMetrics() map[string]bool {
+	keep := map[string]bool{}
+	for _, name := range samplerConfig().Metrics {
+		keep[strings.ToLower(name)] = true
+	}
+	for _, name := range throughputCounters {
+		keep[name] = true
+	}
+	keep["threads_running"] = true
+	if cfg := baselineConfig(); cfg.Enabled {
+		for _, name := range cfg.Metrics {
+			keep[strings.ToLower(name)] = true
+		}
+	}
+	return keep
+}
+
+// RunSampler 每隔 sampler.interval 采样一次写入环形缓冲区并更新指标基线，直到 ctx 结束
+func RunSampler(ctx context.Context) error {
+	cfg := samplerConfig()
+	capacity := cfg.Capacity
+	if capacity <= 0 {
+		capacity = defaultSampleCapacity
+	}
+	samples.init(capacity, cfg.File)
+	interval := sampleInterval()
+	log.Printf("[sampler] sampling every %s, keeping %d samples", interval, capacity)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s, err := collectSample(ctx)
+		switch {
+		case err == nil:
+			samples.add(s)
+			baseline.observe(s)
+		case ctx.Err() == nil:
+			log.Printf("[sampler] sample failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			samples.save(true)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func collectSample(ctx context.Context) (MetricSample, error) {
+	status, err := globalStatusValues(ctx)
+	if err != nil {
+		return MetricSample{}, err
+	}
+	s := MetricSample{At: time.Now(), Status: map[string]float64{}}
+	for name := range sampledMetrics() {
+		if v, ok := status[name]; ok {
+			s.Status[name] = parseFloat(v)
+		}
+	}
+	if samplerConfig().ReplicaLag {
+		lag, err := replicaLag(ctx)
+		if err != nil {
+			// 缺少 REPLICATION CLIENT 权限等情况只影响延迟采样
+			log.Printf("[sampler] replica lag failed: %v", err)
+		}
+		s.ReplicaLag = lag
+	}
+	return s, nil
+}
+
+func replicaLag(ctx context.Context) (map[string]float64, error) {
+	rows, err := databases.QueryReplicaStatus(ctx)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	lag := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		channel := fmt.Sprintf("%v", row["Channel_Name"])
+		if row["Channel_Name"] == nil {
+			channel = ""
+		}
+		for _, col := range []string{"Seconds_Behind_Source", "Seconds_Behind_Master"} {
+			if v, ok := row[col]; ok && v != nil {
+				lag[channel] = parseFloat(fmt.Sprintf("%v", v))
+				break
+			}
+		}
+	}
+	return lag, nil
+}
+
+// init 设置容量并恢复持久化的采样，超出容量时只保留最近的部分
+func (r *sampleRing) init(capacity int, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf, r.next, r.size, r.path = make([]MetricSample, capacity), 0, 0, path
+	if path == "" {
+		return
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("[sampler] read %s failed: %v", path, err)
+		return
+	}
+	var saved []MetricSample
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		log.Printf("[sampler] decode %s failed: %v", path, err)
+		return
+	}
+	if len(saved) > capacity {
+		saved = saved[len(saved)-capacity:]
+	}
+	for _, s := range saved {
+		r.push(s)
+	}
+	r.savedAt = time.Now()
+	log.Printf("[sampler] restored %d samples from %s", len(saved), path)
+}
+
+func (r *sampleRing) add(s MetricSample) {
+	r.mu.Lock()
+	r.push(s)
+	r.mu.Unlock()
+	r.save(false)
+}
+
+// push 调用方需持有 r.mu
+func (r *sampleRing) push(s MetricSample) {
+	if len(r.buf) == 0 {
+		return
+	}
+	r.buf[r.next] = s
+	r.next = (r.next + 1) % len(r.buf)
+	if r.size < len(r.buf) {
+		r.size++
+	}
+}
+
+// since 按时间顺序返回 at 之后（含）的采样，at 为零值时返回全部
+func (r *sampleRing) since(at time.Time) []MetricSample {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]MetricSample, 0, r.size)
+	start := (r.next - r.size + len(r.buf)) % max(len(r.buf), 1)
+	for i := 0; i < r.size; i++ {
+		s := r.buf[(start+i)%len(r.buf)]
+		if !s.At.Before(at) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// save 距上次写入不足 samplePersistInterval 时跳过，force 为 true 时总是写入；先写临时文件再重命名
+func (r *sampleRing) save(force bool) {
+	if r.path == "" || (!force && time.Since(r.savedAt) < samplePersistInterval) {
+		return
+	}
+	all := r.since(time.Time{})
+	raw, err := json.Marshal(all)
+	if err != nil {
+		log.Printf("[sampler] encode failed: %v", err)
+		return
+	}
+	r.savedAt = time.Now()
+	tmp := r.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		log.Printf("[sampler] create dir failed: %v", err)
+		return
+	}
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		log.Printf("[sampler] write %s failed: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		log.Printf("[sampler] rename %s failed: %v", tmp, err)
+	}
+}
+
+// counterRate 计算相邻两次采样间计数器的每秒增量，计数器变小（重启或 FLUSH STATUS）或缺失时 ok 为 false
+func counterRate(prev, cur MetricSample, name string) (float64, bool) {
+	a, okA := prev.Status[name]
+	b, okB := cur.Status[name]
+	elapsed := cur.At.Sub(prev.At).Seconds()
+	if !okA || !okB || elapsed <= 0 || b < a {
+		return 0, false
+	}
+	return (b - a) / elapsed, true
+}
+
+type SamplesRequest struct {
+	// Metrics 返回的状态变量，为空时返回 sampler.metrics；replica_lag 表示复制延迟
+	Metrics []string `json:"metrics,omitempty"`
+	// Seconds 返回最近多少秒的采样，0 表示缓冲区中的全部采样
+	Seconds int `json:"seconds,omitempty"`
+}
+
+// SamplePoint 时间序列中的一个点
+type SamplePoint struct {
+	At    string  `json:"at"`
+	Value float64 `json:"value"`
+}
+
+// SampleSeries 一个指标的时间序列。瞬时值（gauge）为采样值，计数器（rate）为相邻采样间的每秒增量
+type SampleSeries struct {
+	Metric string        `json:"metric"`
+	Kind   string        `json:"kind"`
+	Points []SamplePoint `json:"points"`
+}
+
+type SamplesResponse struct {
+	Enabled         bool           `json:"enabled"`
+	IntervalSeconds float64        `json:"interval_seconds"`
+	Series          []SampleSeries `json:"series"`
+}
+
+// Samples 返回采样器记录的时间序列
+func (RPCService) Samples(req SamplesRequest, resp *SamplesResponse) error {
+	resp.Enabled = SamplingActive()
+	resp.IntervalSeconds = sampleInterval().Seconds()
+	resp.Series = []SampleSeries{}
+
+	metrics := req.Metrics
+	if len(metrics) == 0 {
+		metrics = samplerConfig().Metrics
+	}
+	var since time.Time
+	if req.Seconds > 0 {
+		since = time.Now().Add(-time.Duration(req.Seconds) * time.Second)
+	}
+	data := samples.since(since)
+
+	for _, name := range metrics {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "replica_lag" {
+			resp.Series = append(resp.Series, replicaLagSeries(data)...)
+			continue
+		}
+		series := SampleSeries{Metric: name, Kind: baselineKind(key), Points: []SamplePoint{}}
+		for i, s := range data {
+			var v float64
+			var ok bool
+			if series.Kind == BaselineGauge {
+				v, ok = s.Status[key]
+			} else if i > 0 {
+				v, ok = counterRate(data[i-1], s, key)
+			}
+			if ok {
+				series.Points = append(series.Points, SamplePoint{At: s.At.Format(time.RFC3339), Value: round2(v)})
+			}
+		}
+		resp.Series = append(resp.Series, series)
+	}
+	return nil
+}
+
+// replicaLagSeries 每个复制通道一条序列，指标名为 replica_lag:<通道名>
+func replicaLagSeries(data []MetricSample) []SampleSeries {
+	byChannel := map[string]*SampleSeries{}
+	for _, s := range data {
+		for channel, lag := range s.ReplicaLag {
+			series, ok := byChannel[channel]
+			if !ok {
+				series = &SampleSeries{Metric: "replica_lag:" + channel, Kind: BaselineGauge, Points: []SamplePoint{}}
+				byChannel[channel] = series
+			}
+			series.Points = append(series.Points, SamplePoint{At: s.At.Format(time.RFC3339), Value: lag})
+		}
+	}
+	channels := make([]string, 0, len(byChannel))
+	for channel := range byChannel {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	out := make([]SampleSeries, 0, len(channels))
+	for _, channel := range channels {
+		out = append(out, *byChannel[channel])
+	}
+	return out
+}
@@ -0,0 +1,352 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"mysql-agent/config"
+	"mysql-agent/databases"
+)
+
+// schemaTools 字符集与外键等表结构相关的诊断工具
+func schemaTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolCharset, "对比服务器、库、表、列的字符集与排序规则(`information_schema.schemata`/`tables`/`columns`/`key_column_usage`)，找出 utf8mb3 列、与库默认不一致的表以及外键或同名索引列排序规则不一致等会导致隐式转换、索引失效的问题", charsetMismatchTool),
+		inferTool(toolFKGraph, "基于 `information_schema.referential_constraints` 与 `key_column_usage` 构建指定库的外键依赖图，返回外键边、引用已不存在表或列的孤立外键、父表优先的建表顺序与循环引用，用于迁移与归档规划", foreignKeyGraphTool),
+	}
+}
+
+type CharsetMismatchInput struct {
+	Schema string `json:"schema,omitempty" jsonschema:"description=指定数据库名,默认为配置中的库"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"description=返回的最大问题数,默认 50,minimum=1"`
+}
+
+type CharsetSetting struct {
+	Charset   string `json:"charset"`
+	Collation string `json:"collation"`
+}
+
+type CharsetFinding struct {
+	Level    string `json:"level"`  // schema、table、column 或 join
+	Object   string `json:"object"` // 库名、表名、表.列 或 两个关联列
+	Detail   string `json:"detail"`
+	Severity string `json:"severity"` // 关联列排序规则不一致会导致索引失效，记为 high
+}
+
+type CharsetMismatchResult struct {
+	Schema          string           `json:"schema"`
+	Server          CharsetSetting   `json:"server"`
+	SchemaDefault   CharsetSetting   `json:"schema_default"`
+	TablesChecked   int              `json:"tables_checked"`
+	ColumnsChecked  int              `json:"columns_checked"`
+	TotalFindings   int              `json:"total_findings"`
+	Findings        []CharsetFinding `json:"findings"`
+	Severity        string           `json:"severity"`
+	SeverityReasons []string         `json:"severity_reasons,omitempty"`
+}
+
+func charsetMismatchTool(ctx context.Context, input *CharsetMismatchInput) (*CharsetMismatchResult, error) {
+	schema, limit := "", 50
+	if input != nil {
+		schema = strings.TrimSpace(input.Schema)
+		if input.Limit > 0 {
+			limit = input.Limit
+		}
+	}
+	if schema == "" && config.AppConfig != nil {
+		schema = instanceDatabase(ctx).DBName
+	}
+
+	vars, err := databases.QueryGlobalVariables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schemaRows, tableRows, err := databases.QueryCharsetSettings(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	schemas := normalizeRows(schemaRows)
+	if len(schemas) == 0 {
+		return nil, fmt.Errorf("数据库 %s 不存在", schema)
+	}
+	columnRows, err := databases.QueryColumnCharsets(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CharsetMismatchResult{
+		Schema: schema,
+		Server: CharsetSetting{Charset: vars["character_set_server"], Collation: vars["collation_server"]},
+		SchemaDefault: CharsetSetting{
+			Charset:   schemas[0]["default_character_set_name"],
+			Collation: schemas[0]["default_collation_name"],
+		},
+		Findings: []CharsetFinding{},
+	}
+	var findings []CharsetFinding
+	add := func(level, object, severity, format string, args ...any) {
+		findings = append(findings, CharsetFinding{Level: level, Object: object, Detail: fmt.Sprintf(format, args...), Severity: severity})
+	}
+
+	if result.Server.Charset != "" && result.Server.Charset != result.SchemaDefault.Charset {
+		add("schema", schema, "low", "库默认字符集 %s 与服务器 character_set_server %s 不一致", result.SchemaDefault.Charset, result.Server.Charset)
+	}
+	if isUTF8MB3(result.SchemaDefault.Charset) {
+		add("schema", schema, "moderate", "库默认字符集为 %s，无法存储 4 字节字符(如 emoji)，新建表会继承该字符集", result.SchemaDefault.Charset)
+	}
+
+	tableCollations := make(map[string]string)
+	for _, row := range normalizeRows(tableRows) {
+		table := row["table_name"]
+		tableCollations[table] = row["table_collation"]
+		result.TablesChecked++
+		switch {
+		case row["character_set_name"] != result.SchemaDefault.Charset:
+			add("table", table, "moderate", "表字符集 %s 与库默认 %s 不一致", row["character_set_name"], result.SchemaDefault.Charset)
+		case row["table_collation"] != result.SchemaDefault.Collation:
+			add("table", table, "low", "表排序规则 %s 与库默认 %s 不一致", row["table_collation"], result.SchemaDefault.Collation)
+		}
+	}
+
+	// 同名且带索引的列通常用于关联，记录各排序规则对应的列，用于发现关联时的隐式转换
+	indexedByName := make(map[string]map[string][]string)
+	for _, row := range normalizeRows(columnRows) {
+		table, column := row["table_name"], row["column_name"]
+		object := table + "." + column
+		result.ColumnsChecked++
+		switch {
+		case isUTF8MB3(row["character_set_name"]) && !isUTF8MB3(result.SchemaDefault.Charset):
+			add("column", object, "moderate", "列字符集为 %s，而库默认为 %s，与其他列比较或关联时会发生字符集转换", row["character_set_name"], result.SchemaDefault.Charset)
+		case tableCollations[table] != "" && row["collation_name"] != tableCollations[table]:
+			add("column", object, "low", "列排序规则 %s 与表默认 %s 不一致", row["collation_name"], tableCollations[table])
+		}
+		if row["indexed"] == "1" {
+			if indexedByName[column] == nil {
+				indexedByName[column] = make(map[string][]string)
+			}
+			indexedByName[column][row["collation_name"]] = append(indexedByName[column][row["collation_name"]], table)
+		}
+	}
+
+	fkRows, err := databases.QueryForeignKeyCollations(ctx, schema)
+	if err != nil {
+		log.Printf("[charsetMismatchTool] query foreign keys failed: %v", err)
+	}
+	for _, row := range normalizeRows(fkRows) {
+		if row["collation_name"] == row["referenced_collation_name"] {
+			continue
+		}
+		add("join", fmt.Sprintf("%s.%s -> %s.%s.%s", row["table_name"], row["column_name"], row["referenced_table_schema"], row["referenced_table_name"], row["referenced_column_name"]),
+			"high", "外键 %s 两端排序规则不一致(%s / %s)，关联时被引用列的索引无法使用", row["constraint_name"], row["collation_name"], row["referenced_collation_name"])
+	}
+
+	columns := make([]string, 0, len(indexedByName))
+	for column := range indexedByName {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		groups := indexedByName[column]
+		if len(groups) < 2 {
+			continue
+		}
+		collations := make([]string, 0, len(groups))
+		for collation, tables := range groups {
+			collations = append(collations, fmt.Sprintf("%s(%s)", collation, strings.Join(tables, ", ")))
+		}
+		sort.Strings(collations)
+		add("join", column, "high", "多张表中带索引的同名列 %s 排序规则不一致: %s，按该列关联时会发生隐式转换导致索引失效", column, strings.Join(collations, "; "))
+	}
+
+	severityRank := map[string]int{"high": 0, "moderate": 1, "low": 2}
+	sort.SliceStable(findings, func(i, j int) bool { return severityRank[findings[i].Severity] < severityRank[findings[j].Severity] })
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	result.TotalFindings = len(findings)
+	if len(findings) > limit {
+		findings = findings[:limit]
+	}
+	result.Findings = append(result.Findings, findings...)
+
+	switch {
+	case counts["high"] > 0:
+		result.Severity = "high"
+		result.SeverityReasons = append(result.SeverityReasons, fmt.Sprintf("%d 处关联列排序规则不一致", counts["high"]))
+	case counts["moderate"] > 0:
+		result.Severity = "moderate"
+		result.SeverityReasons = append(result.SeverityReasons, fmt.Sprintf("%d 处字符集不一致或使用 utf8mb3", counts["moderate"]))
+	default:
+		result.Severity = "low"
+	}
+
+	return result, nil
+}
+
+// isUTF8MB3 utf8 在 MySQL 中是 utf8mb3 的别名
+func isUTF8MB3(charset string) bool {
+	return charset == "utf8" || charset == "utf8mb3"
+}
+
+type ForeignKeyGraphInput struct {
+	Schema string `json:"schema,omitempty" jsonschema:"description=指定数据库名,默认为配置中的库"`
+}
+
+type ForeignKeyEdge struct {
+	Constraint        string   `json:"constraint"`
+	Table             string   `json:"table"` // 子表，跨库时为 schema.table
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"` // 父表，跨库时为 schema.table
+	ReferencedColumns []string `json:"referenced_columns"`
+	OnUpdate          string   `json:"on_update"`
+	OnDelete          string   `json:"on_delete"`
+}
+
+type OrphanReference struct {
+	Constraint      string `json:"constraint"`
+	Table           string `json:"table"`
+	ReferencedTable string `json:"referenced_table"`
+	Reason          string `json:"reason"`
+}
+
+type ForeignKeyGraphResult struct {
+	Schema           string            `json:"schema"`
+	Tables           int               `json:"tables"`
+	Edges            []ForeignKeyEdge  `json:"edges"`
+	Orphans          []OrphanReference `json:"orphans"`
+	CreationOrder    []string          `json:"creation_order"`            // 父表在前；归档或删除数据时按逆序处理
+	Cycles           []string          `json:"cycles,omitempty"`          // 存在循环引用、无法确定先后顺序的表
+	StandaloneTables []string          `json:"standalone_tables"`         // 既不引用也不被引用的表
+	CascadeDeletes   []string          `json:"cascade_deletes,omitempty"` // ON DELETE CASCADE 的外键，删除父表数据会连带删除子表
+}
+
+func foreignKeyGraphTool(ctx context.Context, input *ForeignKeyGraphInput) (*ForeignKeyGraphResult, error) {
+	schema := ""
+	if input != nil {
+		schema = strings.TrimSpace(input.Schema)
+	}
+	if schema == "" && config.AppConfig != nil {
+		schema = instanceDatabase(ctx).DBName
+	}
+
+	tableRows, err := databases.QueryBaseTables(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	fkRows, err := databases.QueryForeignKeys(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ForeignKeyGraphResult{
+		Schema:           schema,
+		Edges:            []ForeignKeyEdge{},
+		Orphans:          []OrphanReference{},
+		CreationOrder:    []string{},
+		StandaloneTables: []string{},
+	}
+	tables := make([]string, 0)
+	for _, row := range normalizeRows(tableRows) {
+		tables = append(tables, row["table_name"])
+	}
+	result.Tables = len(tables)
+
+	// 本库的表直接用表名，其他库的表带上库名
+	qualify := func(tableSchema, table string) string {
+		if tableSchema == schema {
+			return table
+		}
+		return tableSchema + "." + table
+	}
+
+	edgeIndex := make(map[string]int)
+	orphaned := make(map[string]bool)
+	for _, row := range normalizeRows(fkRows) {
+		key := row["constraint_schema"] + "." + row["table_name"] + "." + row["constraint_name"]
+		idx, ok := edgeIndex[key]
+		if !ok {
+			idx = len(result.Edges)
+			edgeIndex[key] = idx
+			result.Edges = append(result.Edges, ForeignKeyEdge{
+				Constraint:      row["constraint_name"],
+				Table:           qualify(row["constraint_schema"], row["table_name"]),
+				ReferencedTable: qualify(row["referenced_table_schema"], row["referenced_table_name"]),
+				OnUpdate:        row["update_rule"],
+				OnDelete:        row["delete_rule"],
+			})
+		}
+		edge := &result.Edges[idx]
+		edge.Columns = append(edge.Columns, row["column_name"])
+		edge.ReferencedColumns = append(edge.ReferencedColumns, row["referenced_column_name"])
+
+		if row["referenced_exists"] != "1" && !orphaned[key] {
+			orphaned[key] = true
+			result.Orphans = append(result.Orphans, OrphanReference{
+				Constraint:      edge.Constraint,
+				Table:           edge.Table,
+				ReferencedTable: edge.ReferencedTable,
+				Reason:          fmt.Sprintf("被引用的表或列 %s.%s 不存在", edge.ReferencedTable, row["referenced_column_name"]),
+			})
+		}
+	}
+
+	// 按父表优先做拓扑排序，只考虑本库内的表，自引用不影响顺序
+	known := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		known[t] = true
+	}
+	linked := make(map[string]bool)
+	indegree := make(map[string]int, len(tables))
+	children := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, edge := range result.Edges {
+		linked[edge.Table] = true
+		linked[edge.ReferencedTable] = true
+		if strings.EqualFold(edge.OnDelete, "CASCADE") {
+			result.CascadeDeletes = append(result.CascadeDeletes, fmt.Sprintf("%s.%s -> %s", edge.Table, edge.Constraint, edge.ReferencedTable))
+		}
+		if edge.Table == edge.ReferencedTable || !known[edge.Table] || !known[edge.ReferencedTable] {
+			continue
+		}
+		pair := edge.ReferencedTable + "\x00" + edge.Table
+		if seen[pair] {
+			continue
+		}
+		seen[pair] = true
+		children[edge.ReferencedTable] = append(children[edge.ReferencedTable], edge.Table)
+		indegree[edge.Table]++
+	}
+
+	queue := make([]string, 0)
+	for _, t := range tables {
+		if !linked[t] {
+			result.StandaloneTables = append(result.StandaloneTables, t)
+			continue
+		}
+		if indegree[t] == 0 {
+			queue = append(queue, t)
+		}
+	}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		result.CreationOrder = append(result.CreationOrder, t)
+		for _, child := range children[t] {
+			indegree[child]--
+			if indegree[child] == 0 {
+				queue = append(queue, child)
+			}
+		}
+	}
+	for _, t := range tables {
+		if linked[t] && indegree[t] > 0 {
+			result.Cycles = append(result.Cycles, t)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"mysql-agent/config"
+	"mysql-agent/databases"
+)
+
+// statisticsTools 统计信息诊断工具
+func statisticsTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolStaleStats, "对比 `information_schema.tables.update_time`、`mysql.innodb_table_stats.last_update` 与 `performance_schema` 中的表写入行数，找出统计信息过期的表并给出针对性的 ANALYZE TABLE 语句", staleStatisticsTool),
+	}
+}
+
+type StaleStatisticsInput struct {
+	Schema    string  `json:"schema,omitempty" jsonschema:"description=指定数据库名,默认为配置中的库"`
+	ChangePct float64 `json:"change_pct,omitempty" jsonschema:"description=统计信息更新后写入行数超过统计行数的该百分比时视为过期,默认 10(与 InnoDB 自动重算阈值一致),minimum=1"`
+	Limit     int     `json:"limit,omitempty" jsonschema:"description=返回的最大表数量,默认 20,minimum=1"`
+}
+
+type StaleTable struct {
+	Table           string  `json:"table"`
+	TableRows       int64   `json:"table_rows"`
+	StatsRows       int64   `json:"stats_rows"` // -1 表示没有持久化统计信息
+	StatsLastUpdate string  `json:"stats_last_update,omitempty"`
+	StatsAgeHours   float64 `json:"stats_age_hours"`
+	UpdateTime      string  `json:"update_time,omitempty"`
+	ChangedRows     int64   `json:"changed_rows"` // 自实例启动以来的增删改行数，可能包含统计信息更新前的写入
+	ChangePct       float64 `json:"change_pct"`
+	Reason          string  `json:"reason"`
+	Severity        string  `json:"severity"`
+	Recommendation  string  `json:"recommendation"`
+}
+
+type StaleStatisticsResult struct {
+	Schema        string       `json:"schema"`
+	TablesChecked int          `json:"tables_checked"`
+	TotalStale    int          `json:"total_stale"`
+	Tables        []StaleTable `json:"tables"`
+	Notes         []string     `json:"notes,omitempty"`
+}
+
+func staleStatisticsTool(ctx context.Context, input *StaleStatisticsInput) (*StaleStatisticsResult, error) {
+	schema, changePct, limit := "", 10.0, 20
+	if input != nil {
+		schema = strings.TrimSpace(input.Schema)
+		if input.ChangePct > 0 {
+			changePct = input.ChangePct
+		}
+		if input.Limit > 0 {
+			limit = input.Limit
+		}
+	}
+	if schema == "" && config.AppConfig != nil {
+		schema = instanceDatabase(ctx).DBName
+	}
+
+	rows, err := databases.QueryTableStatistics(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StaleStatisticsResult{Schema: schema, Tables: []StaleTable{}}
+	analyze := fmt.Sprintf("ANALYZE TABLE `%s`.", strings.ReplaceAll(schema, "`", "``"))
+	missingUpdateTime := 0
+	for _, row := range normalizeRows(rows) {
+		result.TablesChecked++
+		t := StaleTable{
+			Table:           row["table_name"],
+			TableRows:       parseInt(row["table_rows"]),
+			StatsRows:       parseInt(row["stats_rows"]),
+			StatsLastUpdate: row["stats_last_update"],
+			UpdateTime:      row["update_time"],
+			ChangedRows:     parseInt(row["changed_rows"]),
+		}
+		if age := parseInt(row["stats_age"]); age >= 0 {
+			t.StatsAgeHours = float64(age) / 3600
+		}
+		if t.UpdateTime == "" {
+			missingUpdateTime++
+		}
+
+		switch {
+		case t.StatsRows < 0:
+			t.Reason = "没有持久化统计信息，可能设置了 STATS_PERSISTENT=0 或从未收集"
+			t.Severity = "moderate"
+		case t.UpdateTime == "" || parseInt(row["modified_after_stats"]) <= 0:
+			// 统计信息收集后没有写入，或无法判断最后写入时间
+			continue
+		default:
+			if t.StatsRows > 0 {
+				t.ChangePct = 100 * float64(t.ChangedRows) / float64(t.StatsRows)
+			}
+			switch {
+			case t.StatsRows == 0 && t.ChangedRows > 0:
+				t.Reason = fmt.Sprintf("统计信息记录为空表，之后写入了 %d 行", t.ChangedRows)
+				t.Severity = "high"
+			case t.StatsRows > 0 && t.ChangePct >= 5*changePct:
+				t.Reason = fmt.Sprintf("统计信息更新后表有写入，写入行数约为统计行数的 %.0f%%", t.ChangePct)
+				t.Severity = "high"
+			case t.StatsRows > 0 && t.ChangePct >= changePct:
+				t.Reason = fmt.Sprintf("统计信息更新后表有写入，写入行数约为统计行数的 %.0f%%", t.ChangePct)
+				t.Severity = "moderate"
+			default:
+				continue
+			}
+		}
+		t.Recommendation = analyze + "`" + strings.ReplaceAll(t.Table, "`", "``") + "`"
+		result.Tables = append(result.Tables, t)
+	}
+
+	severityRank := map[string]int{"high": 0, "moderate": 1}
+	sort.SliceStable(result.Tables, func(i, j int) bool {
+		a, b := result.Tables[i], result.Tables[j]
+		if severityRank[a.Severity] != severityRank[b.Severity] {
+			return severityRank[a.Severity] < severityRank[b.Severity]
+		}
+		return a.ChangedRows > b.ChangedRows
+	})
+	result.TotalStale = len(result.Tables)
+	if len(result.Tables) > limit {
+		result.Tables = result.Tables[:limit]
+	}
+
+	if missingUpdateTime > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d 张表的 update_time 为空(重启后未写入或受 information_schema_stats_expiry 缓存影响)，无法判断其统计信息是否过期", missingUpdateTime))
+	}
+	result.Notes = append(result.Notes, "写入行数来自 performance_schema 自实例启动以来的累计值，ANALYZE 后不会清零，比例偏高时请结合 stats_last_update 判断")
+
+	return result, nil
+}
@@ -0,0 +1,235 @@
+package agent
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"mysql-agent/databases"
+)
+
+// storageTools 表碎片与磁盘占用相关的诊断工具
+func storageTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolFragment, "根据 `information_schema.tables` 的 DATA_FREE 与数据/索引大小计算表碎片率，标记超过阈值(threshold_pct，默认 20%)的表并估算 OPTIMIZE TABLE 可回收的空间，支持 schema/limit", fragmentationTool),
+		inferTool(toolDiskUsage, "汇总 `information_schema.tables` 中各库、各存储引擎的数据/索引/DATA_FREE 大小及占比，并列出占用空间最大的表，用于容量评估", diskUsageTool),
+	}
+}
+
+type FragmentationInput struct {
+	Schema       string  `json:"schema,omitempty" jsonschema:"description=指定数据库名,默认为配置中的库"`
+	Limit        int     `json:"limit,omitempty" jsonschema:"description=返回的最大表数量,默认 20,minimum=1"`
+	ThresholdPct float64 `json:"threshold_pct,omitempty" jsonschema:"description=碎片率(DATA_FREE 占比)超过该百分比的表被标记,默认 20,minimum=0,maximum=100"`
+}
+
+type TableFragmentation struct {
+	Schema           string  `json:"schema"`
+	Table            string  `json:"table"`
+	Engine           string  `json:"engine"`
+	DataBytes        int64   `json:"data_bytes"`
+	IndexBytes       int64   `json:"index_bytes"`
+	FreeBytes        int64   `json:"free_bytes"`
+	FragmentPct      float64 `json:"fragment_pct"`
+	Flagged          bool    `json:"flagged"`
+	ReclaimableBytes int64   `json:"reclaimable_bytes"` // OPTIMIZE TABLE 预计可回收的空间，仅统计被标记的表
+}
+
+type FragmentationResult struct {
+	Schema           string               `json:"schema"`
+	ThresholdPct     float64              `json:"threshold_pct"`
+	Tables           []TableFragmentation `json:"tables"`
+	FlaggedCount     int                  `json:"flagged_count"`
+	ReclaimableBytes int64                `json:"reclaimable_bytes"`
+	Notes            []string             `json:"notes,omitempty"`
+}
+
+// 碎片分析的默认参数
+const (
+	defaultFragmentLimit     = 20
+	defaultFragmentThreshold = 20.0
+	// minReclaimableBytes 小于该值的 DATA_FREE 不值得 OPTIMIZE，不做标记
+	minReclaimableBytes = 10 << 20
+)
+
+func fragmentationTool(ctx context.Context, input *FragmentationInput) (*FragmentationResult, error) {
+	schema := ""
+	limit := defaultFragmentLimit
+	threshold := defaultFragmentThreshold
+	if input != nil {
+		schema = strings.TrimSpace(input.Schema)
+		if input.Limit > 0 {
+			limit = input.Limit
+		}
+		if input.ThresholdPct > 0 {
+			threshold = input.ThresholdPct
+		}
+	}
+
+	if schema == "" {
+		schema = instanceDatabase(ctx).DBName
+	}
+
+	rows, err := databases.QueryTableFragmentation(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FragmentationResult{Schema: schema, ThresholdPct: threshold, Tables: make([]TableFragmentation, 0, limit)}
+	sharedTablespace := false
+	for _, row := range normalizeRows(rows) {
+		t := TableFragmentation{
+			Schema:     row["table_schema"],
+			Table:      row["table_name"],
+			Engine:     row["engine"],
+			DataBytes:  parseInt(row["data_length"]),
+			IndexBytes: parseInt(row["index_length"]),
+			FreeBytes:  parseInt(row["data_free"]),
+		}
+		if total := t.DataBytes + t.IndexBytes + t.FreeBytes; total > 0 {
+			t.FragmentPct = 100 * float64(t.FreeBytes) / float64(total)
+		}
+		// 共享表空间中的表 DATA_FREE 报告的是整个表空间的空闲空间，OPTIMIZE 无法归还给文件系统
+		if t.FreeBytes > t.DataBytes+t.IndexBytes && t.FreeBytes >= 1<<30 {
+			sharedTablespace = true
+		}
+		t.Flagged = t.FragmentPct >= threshold && t.FreeBytes >= minReclaimableBytes
+		if t.Flagged {
+			t.ReclaimableBytes = t.FreeBytes
+			result.FlaggedCount++
+			result.ReclaimableBytes += t.FreeBytes
+		}
+		if len(result.Tables) < limit {
+			result.Tables = append(result.Tables, t)
+		}
+	}
+
+	if sharedTablespace {
+		result.Notes = append(result.Notes, "部分表的 DATA_FREE 远大于数据量，可能位于共享表空间(innodb_file_per_table=OFF 或 general tablespace)，OPTIMIZE 后空间不会归还给文件系统")
+	}
+	if result.FlaggedCount > 0 {
+		result.Notes = append(result.Notes, "OPTIMIZE TABLE 会重建表，大表执行期间占用额外磁盘空间与 IO，建议在低峰期进行")
+	}
+
+	return result, nil
+}
+
+type DiskUsageInput struct {
+	Top           int  `json:"top,omitempty" jsonschema:"description=返回占用空间最大的表数量,默认 10,minimum=1"`
+	IncludeSystem bool `json:"include_system,omitempty" jsonschema:"description=是否统计 mysql/sys 等系统库"`
+}
+
+type DiskUsageGroup struct {
+	Name       string  `json:"name"`
+	Tables     int64   `json:"tables"`
+	DataBytes  int64   `json:"data_bytes"`
+	IndexBytes int64   `json:"index_bytes"`
+	FreeBytes  int64   `json:"free_bytes"`
+	TotalBytes int64   `json:"total_bytes"` // 数据 + 索引
+	SharePct   float64 `json:"share_pct"`   // 占全部数据 + 索引的百分比
+}
+
+type DiskUsageTable struct {
+	Schema     string `json:"schema"`
+	Table      string `json:"table"`
+	Engine     string `json:"engine"`
+	Rows       int64  `json:"rows"`
+	DataBytes  int64  `json:"data_bytes"`
+	IndexBytes int64  `json:"index_bytes"`
+	FreeBytes  int64  `json:"free_bytes"`
+	TotalBytes int64  `json:"total_bytes"`
+}
+
+type DiskUsageResult struct {
+	DataBytes  int64            `json:"data_bytes"`
+	IndexBytes int64            `json:"index_bytes"`
+	FreeBytes  int64            `json:"free_bytes"`
+	TotalBytes int64            `json:"total_bytes"`
+	BySchema   []DiskUsageGroup `json:"by_schema"`
+	ByEngine   []DiskUsageGroup `json:"by_engine"`
+	TopTables  []DiskUsageTable `json:"top_tables"`
+}
+
+func diskUsageTool(ctx context.Context, input *DiskUsageInput) (*DiskUsageResult, error) {
+	top := 10
+	includeSystem := false
+	if input != nil {
+		if input.Top > 0 {
+			top = input.Top
+		}
+		includeSystem = input.IncludeSystem
+	}
+
+	rows, err := databases.QueryDiskUsageBySchemaEngine(ctx, includeSystem)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DiskUsageResult{}
+	schemas := make(map[string]*DiskUsageGroup)
+	engines := make(map[string]*DiskUsageGroup)
+	group := func(groups map[string]*DiskUsageGroup, name string) *DiskUsageGroup {
+		g, ok := groups[name]
+		if !ok {
+			g = &DiskUsageGroup{Name: name}
+			groups[name] = g
+		}
+		return g
+	}
+	for _, row := range normalizeRows(rows) {
+		tables := parseInt(row["table_count"])
+		data := parseInt(row["data_length"])
+		index := parseInt(row["index_length"])
+		free := parseInt(row["data_free"])
+		result.DataBytes += data
+		result.IndexBytes += index
+		result.FreeBytes += free
+		for _, g := range []*DiskUsageGroup{group(schemas, row["table_schema"]), group(engines, row["engine"])} {
+			g.Tables += tables
+			g.DataBytes += data
+			g.IndexBytes += index
+			g.FreeBytes += free
+			g.TotalBytes += data + index
+		}
+	}
+	result.TotalBytes = result.DataBytes + result.IndexBytes
+	result.BySchema = sortedUsageGroups(schemas, result.TotalBytes)
+	result.ByEngine = sortedUsageGroups(engines, result.TotalBytes)
+
+	tableRows, err := databases.QueryLargestTables(ctx, includeSystem, top)
+	if err != nil {
+		return nil, err
+	}
+	result.TopTables = make([]DiskUsageTable, 0, len(tableRows))
+	for _, row := range normalizeRows(tableRows) {
+		result.TopTables = append(result.TopTables, DiskUsageTable{
+			Schema:     row["table_schema"],
+			Table:      row["table_name"],
+			Engine:     row["engine"],
+			Rows:       parseInt(row["table_rows"]),
+			DataBytes:  parseInt(row["data_length"]),
+			IndexBytes: parseInt(row["index_length"]),
+			FreeBytes:  parseInt(row["data_free"]),
+			TotalBytes: parseInt(row["total_length"]),
+		})
+	}
+
+	return result, nil
+}
+
+// sortedUsageGroups 按总大小降序返回分组并计算占比
+func sortedUsageGroups(groups map[string]*DiskUsageGroup, total int64) []DiskUsageGroup {
+	result := make([]DiskUsageGroup, 0, len(groups))
+	for _, g := range groups {
+		if total > 0 {
+			g.SharePct = 100 * float64(g.TotalBytes) / float64(total)
+		}
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalBytes != result[j].TotalBytes {
+			return result[i].TotalBytes > result[j].TotalBytes
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
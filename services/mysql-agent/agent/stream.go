@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 流式诊断中推送的事件类型
+const (
+	EventPlan    = "plan"
+	EventTool    = "tool"
+	EventSummary = "summary"
+	EventDone    = "done"
+)
+
+// StreamEvent 流式诊断的增量事件：plan 为规划完成的工具列表（Iteration 大于 0 时为追加的工具），
+// tool 为单个工具执行结果，summary 为分析结论的增量文本（启用结构化报告时为模型输出的原始 JSON 片段），
+// done 携带与 Agent.Query 相同的完整响应
+type StreamEvent struct {
+	Type      string         `json:"type"`
+	Plan      []ToolCallSpec `json:"plan,omitempty"`
+	Iteration int            `json:"iteration,omitempty"`
+	Tool      *ToolRun       `json:"tool,omitempty"`
+	Delta     string         `json:"delta,omitempty"`
+	Result    *QueryResponse `json:"result,omitempty"`
+}
+
+// QueryStream 与 Agent.Query 执行相同的流程，但在规划完成、每个工具结束以及生成结论时通过 emit 推送事件，
+// 最后推送 done 事件。emit 会被串行调用；ctx 取消时中止诊断
+func QueryStream(ctx context.Context, req QueryRequest, emit func(StreamEvent)) error {
+	var resp QueryResponse
+	if err := runQuery(ctx, req, &resp, emit); err != nil {
+		return err
+	}
+	emit(StreamEvent{Type: EventDone, Result: &resp})
+	return nil
+}
+
+// generateStreaming 以 stream 模式请求模型，逐块回调增量内容并返回拼接后的完整消息
+func generateStreaming(ctx context.Context, messages []*schema.Message, onDelta func(string)) (_ *schema.Message, err error) {
+	start := time.Now()
+	defer func() {
+		if !errors.Is(err, errTokenBudgetExceeded) {
+			observeLLM("stream", start, err)
+		}
+	}()
+
+	reader, err := Stream(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var chunks []*schema.Message
+	for {
+		chunk, err := reader.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取模型流失败: %w", err)
+		}
+		if chunk == nil {
+			continue
+		}
+		chunks = append(chunks, chunk)
+		if chunk.Content != "" {
+			onDelta(chunk.Content)
+		}
+	}
+	if len(chunks) == 0 {
+		log.Print("[generateStreaming] empty stream")
+		recordUsage(ctx, nil)
+		return nil, nil
+	}
+	msg, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		return nil, err
+	}
+	recordUsage(ctx, msg)
+	return msg, nil
+}
@@ -2,12 +2,15 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
@@ -25,6 +28,30 @@ const (
 	toolSlowQueries  = "mysql_slow_queries"
 	toolSchemaStats  = "mysql_schema_stats"
 	toolConfigDiff   = "mysql_config_diff"
+	toolCrashSafety  = "mysql_crash_safety"
+	toolRowLockStats = "mysql_row_lock_stats"
+	toolReplication  = "mysql_replication_status"
+	toolDeadlockInfo = "mysql_deadlock_info"
+	toolBufferPool   = "mysql_buffer_pool_stats"
+	toolFragment     = "mysql_table_fragmentation"
+	toolExplainQuery = "mysql_explain_query"
+	toolBinlogStatus = "mysql_binlog_status"
+	toolDiskUsage    = "mysql_disk_usage"
+	toolTmpSort      = "mysql_tmp_sort_stats"
+	toolConnErrors   = "mysql_connection_errors"
+	toolMetadataLock = "mysql_metadata_locks"
+	toolTableCache   = "mysql_table_cache"
+	toolHostSummary  = "mysql_host_summary"
+	toolUserSummary  = "mysql_user_summary"
+	toolPurgeLag     = "mysql_purge_lag"
+	toolThroughput   = "mysql_throughput"
+	toolTopology     = "mysql_replication_topology"
+	toolWaitEvents   = "mysql_wait_events"
+	toolCharset      = "mysql_charset_mismatch"
+	toolIdleConns    = "mysql_idle_connections"
+	toolFKGraph      = "mysql_foreign_key_graph"
+	toolPartitions   = "mysql_partitions"
+	toolStaleStats   = "mysql_stale_statistics"
 )
 
 type ProcessListInput struct {
@@ -33,6 +60,8 @@ type ProcessListInput struct {
 
 type tableResult struct {
 	Rows []map[string]string `json:"rows"`
+	// Note 实例不支持该查询（如 MariaDB 缺少 events_statements_summary_by_digest）时说明原因，Rows 为空
+	Note string `json:"note,omitempty"`
 }
 
 type GlobalStatusInput struct {
@@ -45,12 +74,15 @@ type InnoDBTrxInput struct {
 
 type SlowQueriesInput struct {
 	Limit  int    `json:"limit,omitempty" jsonschema:"description=返回的最大行数,minimum=1"`
+	Offset int    `json:"offset,omitempty" jsonschema:"description=跳过的行数，用于分页,minimum=0"`
 	Schema string `json:"schema,omitempty" jsonschema:"description=只返回指定数据库的结果"`
+	SortBy string `json:"sort_by,omitempty" jsonschema:"description=排序方式,enum=total_latency,enum=rows_examined,enum=errors"`
 }
 
 type SchemaStatsInput struct {
 	Schema string `json:"schema,omitempty" jsonschema:"description=指定数据库名,默认为配置中的库"`
 	Limit  int    `json:"limit,omitempty" jsonschema:"description=返回的最大表数量,minimum=1"`
+	Offset int    `json:"offset,omitempty" jsonschema:"description=跳过的表数量，用于分页,minimum=0"`
 }
 
 type ConfigDiffInput struct {
@@ -69,8 +101,63 @@ type ConfigDiffResult struct {
 	Missing []string          `json:"missing,omitempty"`
 }
 
+const (
+	verdictOK      = "ok"
+	verdictWarning = "warning"
+	verdictUnknown = "unknown"
+)
+
 type emptyInput struct{}
 
+// mutatingTools 记录会修改数据库状态的工具，agent.read_only 开启时不注册。内置工具只执行只读白名单内的语句
+// （见 databases.checkReadOnly），不会出现在这里；只有 [[plugins]] 中声明 mutating = true 的插件工具会被记录
+var mutatingTools = map[string]struct{}{}
+
+// 工具依赖的实例前提条件，即 ToolSpec.RequiredSignals 的取值
+const (
+	signalPerformanceSchema = "performance_schema"
+	signalSysSchema         = "sys_schema"
+)
+
+// toolRequirements 工具名 -> 依赖的前提条件，实例不满足时工具标记为不支持，不参与规划也不执行
+var toolRequirements = map[string][]string{
+	toolSlowQueries:  {signalPerformanceSchema},
+	toolMetadataLock: {signalPerformanceSchema},
+	toolWaitEvents:   {signalPerformanceSchema},
+	toolHostSummary:  {signalPerformanceSchema, signalSysSchema},
+	toolUserSummary:  {signalPerformanceSchema, signalSysSchema},
+}
+
+// unsupportedReason 返回工具在 ctx 所属实例上缺少的前提条件，满足或无法检测实例能力时为空，
+// 检测失败时由工具执行时报告真实错误
+func unsupportedReason(ctx context.Context, name string) string {
+	required := toolRequirements[name]
+	if len(required) == 0 {
+		return ""
+	}
+	caps, err := databases.ServerCapabilities(ctx)
+	if err != nil {
+		return ""
+	}
+	var missing []string
+	for _, signal := range required {
+		switch signal {
+		case signalPerformanceSchema:
+			if !caps.PerformanceSchema {
+				missing = append(missing, "performance_schema 未开启")
+			}
+		case signalSysSchema:
+			if !caps.SysSchema {
+				missing = append(missing, "sys schema 不存在")
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s %s 不支持: %s", caps.Flavor, caps.Version, strings.Join(missing, "，"))
+}
+
 var (
 	toolOnce sync.Once
 	toolErr  error
@@ -79,81 +166,84 @@ var (
 	toolList []tool.InvokableTool
 )
 
-func ensureTools(ctx context.Context) ([]tool.InvokableTool, error) {
-	toolOnce.Do(func() {
-		toolMap = make(map[string]tool.InvokableTool)
+// toolRegistration 一个内置工具的名称与构建方式，由各工具所在文件提供，ensureTools 按顺序注册
+type toolRegistration struct {
+	name  string
+	build func() (tool.InvokableTool, error)
+}
 
-		procTool, err := utils.InferTool(toolProcessList, "执行 `SHOW FULL PROCESSLIST`(必要时 `SHOW PROCESSLIST`) 以获取当前连接、状态与阻塞情况", processListTool)
-		if err != nil {
-			toolErr = fmt.Errorf("注册 processlist 工具失败: %w", err)
-			return
-		}
-		toolMap[toolProcessList] = procTool
-		toolList = append(toolList, procTool)
-		log.Print("[ensureTools] registered mysql_processlist")
+// inferTool 由工具函数的入参与出参推导参数 schema
+func inferTool[T, D any](name, desc string, fn utils.InvokeFunc[T, D]) toolRegistration {
+	return toolRegistration{name: name, build: func() (tool.InvokableTool, error) {
+		return utils.InferTool(name, desc, fn)
+	}}
+}
 
-		innodbTool, err := utils.InferTool(toolInnoDBStatus, "执行 `SHOW ENGINE INNODB STATUS` 汇总锁等待、事务与缓冲区信息", innodbStatusTool)
+// registerTools 依次构建并注册工具，任一工具构建失败时返回错误
+func registerTools(regs []toolRegistration) error {
+	for _, r := range regs {
+		t, err := r.build()
 		if err != nil {
-			toolErr = fmt.Errorf("注册 innodb status 工具失败: %w", err)
-			return
+			return fmt.Errorf("注册 %s 工具失败: %w", r.name, err)
 		}
-		toolMap[toolInnoDBStatus] = innodbTool
-		toolList = append(toolList, innodbTool)
-		log.Print("[ensureTools] registered mysql_innodb_status")
+		toolMap[r.name] = t
+		toolList = append(toolList, t)
+		log.Printf("[ensureTools] registered %s", r.name)
+	}
+	return nil
+}
 
-		globalStatus, err := utils.InferTool(toolGlobalStatus, "执行 `SHOW GLOBAL STATUS` 返回 Threads_running、Connections 等指标，可按 keys 过滤", globalStatusTool)
-		if err != nil {
-			toolErr = fmt.Errorf("注册 global status 工具失败: %w", err)
-			return
-		}
-		toolMap[toolGlobalStatus] = globalStatus
-		toolList = append(toolList, globalStatus)
-		log.Print("[ensureTools] registered mysql_global_status")
+// coreTools 连接、状态、事务、慢查询与配置等基础诊断工具
+func coreTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolProcessList, "执行 `SHOW FULL PROCESSLIST`(必要时 `SHOW PROCESSLIST`) 以获取当前连接、状态与阻塞情况", processListTool),
+		inferTool(toolInnoDBStatus, "执行 `SHOW ENGINE INNODB STATUS` 汇总锁等待、事务与缓冲区信息", innodbStatusTool),
+		inferTool(toolGlobalStatus, "执行 `SHOW GLOBAL STATUS` 返回 Threads_running、Connections 等指标，可按 keys 过滤", globalStatusTool),
+		inferTool(toolInnoDBTrx, "查询 `information_schema.innodb_trx`(可加 LIMIT) 查看长事务与等待信息", innodbTrxTool),
+		inferTool(toolInnoDBMutex, "执行 `SHOW ENGINE INNODB MUTEX` 识别热点互斥锁", innodbMutexTool),
+		inferTool(toolSlowQueries, "统计 `performance_schema.events_statements_summary_by_digest` 中 TOP 慢 SQL (默认按总耗时排序，sort_by 可选 total_latency/rows_examined/errors，支持 schema 过滤与 limit/offset 分页)", slowQueriesTool),
+		inferTool(toolSchemaStats, "查询 `information_schema.tables` 计算数据/索引大小及 TOTAL_LENGTH，可按 schema/limit/offset 分页", schemaStatsTool),
+		inferTool(toolConfigDiff, "读取 `SHOW VARIABLES` 并与配置文件及连接池参数对比 (涵盖 character_set_server、collation_server、max_connections 等)", configDiffTool),
+	}
+}
 
-		innodbTrx, err := utils.InferTool(toolInnoDBTrx, "查询 `information_schema.innodb_trx`(可加 LIMIT) 查看长事务与等待信息", innodbTrxTool)
-		if err != nil {
-			toolErr = fmt.Errorf("注册 innodb trx 工具失败: %w", err)
-			return
-		}
-		toolMap[toolInnoDBTrx] = innodbTrx
-		toolList = append(toolList, innodbTrx)
-		log.Print("[ensureTools] registered mysql_innodb_trx")
+func ensureTools(ctx context.Context) ([]tool.InvokableTool, error) {
+	toolOnce.Do(func() {
+		toolMap = make(map[string]tool.InvokableTool)
 
-		innodbMutex, err := utils.InferTool(toolInnoDBMutex, "执行 `SHOW ENGINE INNODB MUTEX` 识别热点互斥锁", innodbMutexTool)
-		if err != nil {
-			toolErr = fmt.Errorf("注册 innodb mutex 工具失败: %w", err)
-			return
+		groups := [][]toolRegistration{
+			coreTools(),
+			crashSafetyTools(),
+			lockTools(),
+			replicationTools(),
+			deadlockTools(),
+			memoryTools(),
+			storageTools(),
+			explainTools(),
+			connectionTools(),
+			workloadTools(),
+			schemaTools(),
+			partitionTools(),
+			statisticsTools(),
 		}
-		toolMap[toolInnoDBMutex] = innodbMutex
-		toolList = append(toolList, innodbMutex)
-		log.Print("[ensureTools] registered mysql_innodb_mutex")
-
-		slowQueries, err := utils.InferTool(toolSlowQueries, "统计 `performance_schema.events_statements_summary_by_digest` 中 TOP 慢 SQL (按 SUM_TIMER_WAIT 排序)", slowQueriesTool)
-		if err != nil {
-			toolErr = fmt.Errorf("注册 slow queries 工具失败: %w", err)
-			return
+		for _, regs := range groups {
+			if err := registerTools(regs); err != nil {
+				toolErr = err
+				return
+			}
 		}
-		toolMap[toolSlowQueries] = slowQueries
-		toolList = append(toolList, slowQueries)
-		log.Print("[ensureTools] registered mysql_slow_queries")
 
-		schemaStats, err := utils.InferTool(toolSchemaStats, "查询 `information_schema.tables` 计算数据/索引大小及 TOTAL_LENGTH，可按 schema/limit", schemaStatsTool)
-		if err != nil {
-			toolErr = fmt.Errorf("注册 schema stats 工具失败: %w", err)
+		if err := registerPlugins(); err != nil {
+			toolErr = fmt.Errorf("注册插件工具失败: %w", err)
 			return
 		}
-		toolMap[toolSchemaStats] = schemaStats
-		toolList = append(toolList, schemaStats)
-		log.Print("[ensureTools] registered mysql_schema_stats")
 
-		configDiff, err := utils.InferTool(toolConfigDiff, "读取 `SHOW VARIABLES` 并与配置文件及连接池参数对比 (涵盖 character_set_server、collation_server、max_connections 等)", configDiffTool)
-		if err != nil {
-			toolErr = fmt.Errorf("注册 config diff 工具失败: %w", err)
-			return
+		if config.AppConfig != nil && config.AppConfig.Agent.ReadOnly {
+			dropMutatingTools(ctx)
+		}
+		if config.AppConfig != nil {
+			applyToolFilter(ctx, config.AppConfig.Agent.EnabledTools, config.AppConfig.Agent.DisabledTools)
 		}
-		toolMap[toolConfigDiff] = configDiff
-		toolList = append(toolList, configDiff)
-		log.Print("[ensureTools] registered mysql_config_diff")
 	})
 
 	if toolErr != nil {
@@ -162,6 +252,73 @@ func ensureTools(ctx context.Context) ([]tool.InvokableTool, error) {
 	return toolList, nil
 }
 
+// dropMutatingTools 移除 mutatingTools 中的工具，之后规划、RunTool 与 MCP 都找不到这些工具
+func dropMutatingTools(ctx context.Context) {
+	dropTools(ctx, "read_only", func(name string) bool {
+		_, mutating := mutatingTools[name]
+		return mutating
+	})
+}
+
+// dropTools 从注册表中移除 drop 返回 true 的工具，reason 用于日志
+func dropTools(ctx context.Context, reason string, drop func(name string) bool) {
+	kept := toolList[:0]
+	for _, tl := range toolList {
+		info, err := tl.Info(ctx)
+		if err == nil && drop(info.Name) {
+			delete(toolMap, info.Name)
+			log.Printf("[ensureTools] %s: skipped %s", reason, info.Name)
+			continue
+		}
+		kept = append(kept, tl)
+	}
+	toolList = kept
+}
+
+// applyToolFilter 按 agent.enabled_tools（非空时只保留列出的工具）和 agent.disabled_tools 裁剪注册表，
+// 被裁剪的工具不会出现在规划提示词中，也无法被直接调用。配置中不存在的工具名只记录日志
+func applyToolFilter(ctx context.Context, enabled, disabled []string) {
+	known := make(map[string]struct{}, len(toolMap))
+	for name := range toolMap {
+		known[name] = struct{}{}
+	}
+	toSet := func(key string, names []string) map[string]struct{} {
+		set := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			if _, ok := known[name]; !ok {
+				log.Printf("[ensureTools] %s: unknown tool %q", key, name)
+			}
+			set[name] = struct{}{}
+		}
+		return set
+	}
+
+	if len(enabled) > 0 {
+		allow := toSet("enabled_tools", enabled)
+		dropTools(ctx, "enabled_tools", func(name string) bool {
+			_, ok := allow[name]
+			return !ok
+		})
+	}
+	if len(disabled) > 0 {
+		deny := toSet("disabled_tools", disabled)
+		dropTools(ctx, "disabled_tools", func(name string) bool {
+			_, ok := deny[name]
+			return ok
+		})
+	}
+}
+
+// toolEnabled 工具是否已注册且未被配置禁用
+func toolEnabled(ctx context.Context, name string) bool {
+	if _, err := ensureTools(ctx); err != nil {
+		return false
+	}
+	_, ok := toolMap[name]
+	return ok
+}
+
 func processListTool(ctx context.Context, input *ProcessListInput) (*tableResult, error) {
 	rows, err := databases.QueryProcessList(ctx)
 	if err != nil {
@@ -267,41 +424,43 @@ func innodbMutexTool(ctx context.Context, _ *emptyInput) (*tableResult, error) {
 
 func slowQueriesTool(ctx context.Context, input *SlowQueriesInput) (*tableResult, error) {
 	limit := 0
-	if input != nil && input.Limit > 0 {
-		limit = input.Limit
+	offset := 0
+	schema := ""
+	sortBy := ""
+	if input != nil {
+		if input.Limit > 0 {
+			limit = input.Limit
+		}
+		offset = input.Offset
+		schema = strings.TrimSpace(input.Schema)
+		sortBy = strings.ToLower(strings.TrimSpace(input.SortBy))
 	}
 
-	rows, err := databases.QuerySlowQueries(ctx, limit)
+	rows, err := databases.QuerySlowQueries(ctx, schema, sortBy, limit, offset)
+	if errors.Is(err, databases.ErrUnsupported) {
+		return &tableResult{Rows: []map[string]string{}, Note: err.Error()}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	normalized := normalizeRows(rows)
-	if input != nil && strings.TrimSpace(input.Schema) != "" {
-		target := strings.ToLower(strings.TrimSpace(input.Schema))
-		filtered := normalized[:0]
-		for _, row := range normalized {
-			if strings.EqualFold(row["schema_name"], target) {
-				filtered = append(filtered, row)
-			}
-		}
-		normalized = filtered
-	}
-
 	return &tableResult{Rows: normalized}, nil
 }
 
 func schemaStatsTool(ctx context.Context, input *SchemaStatsInput) (*tableResult, error) {
 	schema := ""
 	limit := 0
+	offset := 0
 	if input != nil {
 		schema = input.Schema
 		if input.Limit > 0 {
 			limit = input.Limit
 		}
+		offset = input.Offset
 	}
 
-	rows, err := databases.QuerySchemaStats(ctx, schema, limit)
+	rows, err := databases.QuerySchemaStats(ctx, schema, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -326,7 +485,7 @@ func configDiffTool(ctx context.Context, input *ConfigDiffInput) (*ConfigDiffRes
 		if runtime == "" {
 			missing = append(missing, name)
 		}
-		configVal, hasConfig := configValueFor(key)
+		configVal, hasConfig := configValueFor(ctx, key)
 		match := hasConfig && runtime != "" && strings.EqualFold(runtime, configVal)
 		items = append(items, ConfigDiffEntry{
 			Parameter:    name,
@@ -336,7 +495,7 @@ func configDiffTool(ctx context.Context, input *ConfigDiffInput) (*ConfigDiffRes
 		})
 	}
 
-	poolEntries := poolDiffEntries()
+	poolEntries := poolDiffEntries(ctx)
 	if len(poolEntries) > 0 {
 		items = append(items, poolEntries...)
 	}
@@ -344,6 +503,36 @@ func configDiffTool(ctx context.Context, input *ConfigDiffInput) (*ConfigDiffRes
 	return &ConfigDiffResult{Items: items, Missing: missing}, nil
 }
 
+// globalStatusValues 返回 SHOW GLOBAL STATUS 的 变量名(小写) -> 值 映射
+func globalStatusValues(ctx context.Context) (map[string]string, error) {
+	rows, err := databases.QueryGlobalStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(rows))
+	for _, row := range normalizeRows(rows) {
+		values[strings.ToLower(row["variable_name"])] = row["value"]
+	}
+	return values, nil
+}
+
+func parseInt(raw string) int64 {
+	v, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func parseFloat(raw string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
 func inputVariables(input *ConfigDiffInput) []string {
 	if input != nil && len(input.Variables) > 0 {
 		cleaned := make([]string, 0, len(input.Variables))
@@ -367,52 +556,58 @@ func inputVariables(input *ConfigDiffInput) []string {
 	return []string{"character_set_server", "port"}
 }
 
-func configValueFor(name string) (string, bool) {
-	cfg := config.AppConfig
+// instanceDatabase 返回 ctx 所属实例的连接配置
+func instanceDatabase(ctx context.Context) config.DatabaseConfig {
+	cfg, _ := databases.InstanceConfig(databases.InstanceFrom(ctx))
+	return cfg
+}
+
+func configValueFor(ctx context.Context, name string) (string, bool) {
+	cfg := instanceDatabase(ctx)
 	switch name {
 	case "character_set_server", "character_set_database":
-		return cfg.Database.Charset, true
+		return cfg.Charset, true
 	case "port":
-		return strconv.Itoa(cfg.Database.Port), true
+		return strconv.Itoa(cfg.Port), true
 	case "host", "hostname":
-		return cfg.Database.Host, true
+		return cfg.Host, true
 	default:
 		return "", false
 	}
 }
 
-func poolDiffEntries() []ConfigDiffEntry {
-	db, err := databases.GetDB()
+func poolDiffEntries(ctx context.Context) []ConfigDiffEntry {
+	db, err := databases.GetDB(ctx)
 	if err != nil {
 		return nil
 	}
 
-	cfg := config.AppConfig
+	cfg := instanceDatabase(ctx)
 	stats := db.Stats()
 
 	entries := []ConfigDiffEntry{
 		{
 			Parameter:    "connection_pool.max_open_conns",
-			ConfigValue:  strconv.Itoa(cfg.Database.MaxOpenConns),
+			ConfigValue:  strconv.Itoa(cfg.MaxOpenConns),
 			RuntimeValue: strconv.Itoa(stats.MaxOpenConnections),
-			Match:        cfg.Database.MaxOpenConns == stats.MaxOpenConnections,
+			Match:        cfg.MaxOpenConns == stats.MaxOpenConnections,
 		},
 	}
 
-	if cfg.Database.MaxIdleConns > 0 {
+	if cfg.MaxIdleConns > 0 {
 		entries = append(entries, ConfigDiffEntry{
 			Parameter:    "connection_pool.max_idle_conns",
-			ConfigValue:  strconv.Itoa(cfg.Database.MaxIdleConns),
-			RuntimeValue: strconv.Itoa(cfg.Database.MaxIdleConns),
+			ConfigValue:  strconv.Itoa(cfg.MaxIdleConns),
+			RuntimeValue: strconv.Itoa(cfg.MaxIdleConns),
 			Match:        true,
 		})
 	}
 
-	if cfg.Database.ConnMaxLifetime > 0 {
+	if cfg.ConnMaxLifetime > 0 {
 		entries = append(entries, ConfigDiffEntry{
 			Parameter:    "connection_pool.conn_max_lifetime",
-			ConfigValue:  cfg.Database.ConnMaxLifetime.String(),
-			RuntimeValue: cfg.Database.ConnMaxLifetime.String(),
+			ConfigValue:  cfg.ConnMaxLifetime.String(),
+			RuntimeValue: cfg.ConnMaxLifetime.String(),
 			Match:        true,
 		})
 	}
@@ -462,11 +657,94 @@ func ToolDescriptors(ctx context.Context) ([]ToolDescriptor, error) {
 		if err != nil {
 			return nil, err
 		}
+		// 实例不支持的工具不提供给规划，避免 LLM 选中后执行失败
+		if unsupportedReason(ctx, info.Name) != "" {
+			continue
+		}
 		result = append(result, ToolDescriptor{Name: info.Name, Desc: info.Desc})
 	}
 	return result, nil
 }
 
+// ToolSpec 工具的名称、说明与参数 JSON Schema，供调用方构建工具选择界面或在转发前校验工具参数
+type ToolSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+	// Mutating 为 true 表示工具会修改数据库状态
+	Mutating bool `json:"mutating,omitempty"`
+	// RequiredSignals 工具依赖的实例前提条件，如 performance_schema、sys_schema
+	RequiredSignals []string `json:"required_signals,omitempty"`
+	// Supported 为 false 表示实例不满足 RequiredSignals，UnsupportedReason 说明缺少的条件
+	Supported         bool   `json:"supported"`
+	UnsupportedReason string `json:"unsupported_reason,omitempty"`
+}
+
+type ListToolsRequest struct {
+	// InstanceID 按该实例的能力判断工具是否可用，为空时使用 [database]
+	InstanceID string `json:"instance_id,omitempty"`
+}
+
+type ListToolsResponse struct {
+	Tools []ToolSpec `json:"tools"`
+}
+
+// ToolSpecs 返回当前注册的全部工具及其参数 JSON Schema，工具未声明参数时为空 object；
+// 按 ctx 所属实例的能力标记工具是否可用
+func ToolSpecs(ctx context.Context) ([]ToolSpec, error) {
+	tools, err := ensureTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ToolSpec, 0, len(tools))
+	for _, tl := range tools {
+		info, err := tl.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		params := json.RawMessage(`{"type":"object","properties":{}}`)
+		if info.ParamsOneOf != nil {
+			js, err := info.ParamsOneOf.ToJSONSchema()
+			if err != nil {
+				return nil, fmt.Errorf("读取工具 %s 参数定义失败: %w", info.Name, err)
+			}
+			if js != nil {
+				raw, err := json.Marshal(js)
+				if err != nil {
+					return nil, err
+				}
+				params = raw
+			}
+		}
+		_, mutating := mutatingTools[info.Name]
+		reason := unsupportedReason(ctx, info.Name)
+		result = append(result, ToolSpec{
+			Name:              info.Name,
+			Description:       info.Desc,
+			Parameters:        params,
+			Mutating:          mutating,
+			RequiredSignals:   toolRequirements[info.Name],
+			Supported:         reason == "",
+			UnsupportedReason: reason,
+		})
+	}
+	return result, nil
+}
+
+// ListTools 返回当前注册（未被 read_only、enabled_tools/disabled_tools 裁剪）的工具，并按 req.InstanceID 标记是否可用
+func (s RPCService) ListTools(req ListToolsRequest, resp *ListToolsResponse) error {
+	if err := checkInstance(s.context(), req.InstanceID, nil); err != nil {
+		return err
+	}
+	tools, err := ToolSpecs(databases.WithInstance(s.context(), req.InstanceID))
+	if err != nil {
+		return err
+	}
+	resp.Tools = tools
+	return nil
+}
+
 func CallTool(ctx context.Context, name string, rawArgs string) (string, error) {
 	_, err := ensureTools(ctx)
 	if err != nil {
@@ -477,6 +755,9 @@ func CallTool(ctx context.Context, name string, rawArgs string) (string, error)
 	if !ok {
 		return "", fmt.Errorf("未找到工具: %s", name)
 	}
+	if reason := unsupportedReason(ctx, name); reason != "" {
+		return "", fmt.Errorf("工具 %s 在当前实例不可用，%s", name, reason)
+	}
 
 	args := strings.TrimSpace(rawArgs)
 	if args == "" {
@@ -485,7 +766,9 @@ func CallTool(ctx context.Context, name string, rawArgs string) (string, error)
 
 	log.Printf("[CallTool] name=%s args=%s", name, truncate(args))
 
+	start := time.Now()
 	output, err := tl.InvokableRun(ctx, args)
+	observeTool(name, start, err)
 	if err != nil {
 		return "", err
 	}
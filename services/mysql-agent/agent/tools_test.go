@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"mysql-agent/config"
+)
+
+func TestEnsureToolsRegistersBuiltinTools(t *testing.T) {
+	prevMap, prevList, prevErr, prevCfg := toolMap, toolList, toolErr, config.AppConfig
+	t.Cleanup(func() {
+		toolMap, toolList, toolErr, config.AppConfig = prevMap, prevList, prevErr, prevCfg
+		toolOnce = sync.Once{}
+		toolOnce.Do(func() {})
+	})
+	toolOnce = sync.Once{}
+	toolMap, toolList, toolErr, config.AppConfig = nil, nil, nil, nil
+
+	tools, err := ensureTools(context.Background())
+	if err != nil {
+		t.Fatalf("ensureTools: %v", err)
+	}
+
+	builtin := []string{
+		toolProcessList, toolInnoDBStatus, toolGlobalStatus, toolInnoDBTrx, toolInnoDBMutex, toolSlowQueries,
+		toolSchemaStats, toolConfigDiff, toolCrashSafety, toolRowLockStats, toolReplication, toolDeadlockInfo,
+		toolBufferPool, toolFragment, toolExplainQuery, toolBinlogStatus, toolDiskUsage, toolTmpSort,
+		toolConnErrors, toolMetadataLock, toolTableCache, toolHostSummary, toolUserSummary, toolPurgeLag,
+		toolThroughput, toolTopology, toolWaitEvents, toolCharset, toolIdleConns, toolFKGraph,
+		toolPartitions, toolStaleStats,
+	}
+	if len(tools) != len(builtin) || len(toolMap) != len(builtin) {
+		t.Fatalf("registered %d tools (%d names), want %d", len(tools), len(toolMap), len(builtin))
+	}
+	for _, name := range builtin {
+		tl, ok := toolMap[name]
+		if !ok {
+			t.Errorf("tool %s not registered", name)
+			continue
+		}
+		info, err := tl.Info(context.Background())
+		if err != nil || info.Name != name {
+			t.Errorf("tool %s info = %+v, %v", name, info, err)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"mysql-agent/config"
+)
+
+const (
+	usageDateLayout   = "2006-01-02"
+	usageRetainDays   = 31
+	defaultUsageDays  = 7
+	maxUsageQueryDays = usageRetainDays
+)
+
+// errTokenBudgetExceeded 当日 token 用量已达到 llm.daily_token_budget，
+// 规划和分析阶段遇到该错误时按 rules.enabled 改用规则诊断
+var errTokenBudgetExceeded = errors.New("当日 LLM token 预算已用完")
+
+// TokenUsage 一段时间或一次请求内累计的 LLM token 用量
+type TokenUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+	Calls            int64 `json:"calls"`
+}
+
+func (u *TokenUsage) add(other TokenUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Calls += other.Calls
+}
+
+// DailyUsage 某一天（本地时区）的累计用量
+type DailyUsage struct {
+	Date string `json:"date"`
+	TokenUsage
+}
+
+type UsageRequest struct {
+	// Days 返回最近多少天的用量，默认 7 天，最多 31 天
+	Days int `json:"days,omitempty"`
+}
+
+type UsageResponse struct {
+	Days []DailyUsage `json:"days"`
+	// DailyBudget 每日 token 预算，0 表示不限制
+	DailyBudget int64 `json:"daily_budget"`
+	// Remaining 当日剩余 token，未设置预算时为 -1
+	Remaining int64 `json:"remaining"`
+	Exceeded  bool  `json:"exceeded"`
+}
+
+// usageLedger 按天累计 token 用量，配置了 llm.usage_file 时每次变化后写入文件，重启后恢复当日预算
+type usageLedger struct {
+	mu    sync.Mutex
+	days  map[string]*TokenUsage
+	path  string
+	ready bool
+}
+
+var ledger = &usageLedger{days: map[string]*TokenUsage{}}
+
+func (l *usageLedger) load() {
+	if l.ready {
+		return
+	}
+	l.ready = true
+	if config.AppConfig == nil || config.AppConfig.LLM.UsageFile == "" {
+		return
+	}
+	l.path = config.AppConfig.LLM.UsageFile
+	raw, err := os.ReadFile(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("[usage] read %s failed: %v", l.path, err)
+		return
+	}
+	if err := json.Unmarshal(raw, &l.days); err != nil {
+		log.Printf("[usage] decode %s failed: %v", l.path, err)
+		l.days = map[string]*TokenUsage{}
+	}
+}
+
+// save 先写临时文件再重命名，避免进程中途退出留下不完整的文件
+func (l *usageLedger) save() {
+	if l.path == "" {
+		return
+	}
+	raw, err := json.Marshal(l.days)
+	if err != nil {
+		log.Printf("[usage] encode failed: %v", err)
+		return
+	}
+	tmp := l.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		log.Printf("[usage] create dir failed: %v", err)
+		return
+	}
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		log.Printf("[usage] write %s failed: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		log.Printf("[usage] rename %s failed: %v", tmp, err)
+	}
+}
+
+func (l *usageLedger) record(u TokenUsage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.load()
+
+	today := time.Now().Format(usageDateLayout)
+	day := l.days[today]
+	if day == nil {
+		day = &TokenUsage{}
+		l.days[today] = day
+	}
+	day.add(u)
+
+	cutoff := time.Now().AddDate(0, 0, -usageRetainDays).Format(usageDateLayout)
+	for date := range l.days {
+		if date < cutoff {
+			delete(l.days, date)
+		}
+	}
+	l.save()
+}
+
+func (l *usageLedger) today() TokenUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.load()
+	if day := l.days[time.Now().Format(usageDateLayout)]; day != nil {
+		return *day
+	}
+	return TokenUsage{}
+}
+
+// recent 返回最近 days 天的用量，按日期倒序，没有调用的日期记为 0
+func (l *usageLedger) recent(days int) []DailyUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.load()
+
+	result := make([]DailyUsage, 0, days)
+	now := time.Now()
+	for i := 0; i < days; i++ {
+		date := now.AddDate(0, 0, -i).Format(usageDateLayout)
+		item := DailyUsage{Date: date}
+		if day := l.days[date]; day != nil {
+			item.TokenUsage = *day
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+func dailyTokenBudget() int64 {
+	if config.AppConfig == nil {
+		return 0
+	}
+	return config.AppConfig.LLM.DailyTokenBudget
+}
+
+// checkTokenBudget 在每次请求模型前调用，当日用量达到预算时返回 errTokenBudgetExceeded
+func checkTokenBudget() error {
+	budget := dailyTokenBudget()
+	if budget <= 0 {
+		return nil
+	}
+	if used := ledger.today().TotalTokens; used >= budget {
+		return fmt.Errorf("%w: 已用 %d / %d", errTokenBudgetExceeded, used, budget)
+	}
+	return nil
+}
+
+type usageKey struct{}
+
+// usageTracker 累计单次诊断请求内所有模型调用的用量
+type usageTracker struct {
+	mu    sync.Mutex
+	usage TokenUsage
+}
+
+// withUsageTracker 返回携带用量累计器的 ctx，ctx 内的模型调用都会计入该累计器
+func withUsageTracker(ctx context.Context) (context.Context, *usageTracker) {
+	tracker := &usageTracker{}
+	return context.WithValue(ctx, usageKey{}, tracker), tracker
+}
+
+func (t *usageTracker) snapshot() *TokenUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.usage.Calls == 0 {
+		return nil
+	}
+	u := t.usage
+	return &u
+}
+
+// recordUsage 把模型响应中的用量计入当日总量和 ctx 中的请求累计器；
+// 流式响应不返回用量，此时只计调用次数
+func recordUsage(ctx context.Context, msg *schema.Message) {
+	u := TokenUsage{Calls: 1}
+	if msg != nil && msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
+		usage := msg.ResponseMeta.Usage
+		u.PromptTokens = int64(usage.PromptTokens)
+		u.CompletionTokens = int64(usage.CompletionTokens)
+		u.TotalTokens = int64(usage.TotalTokens)
+	}
+	ledger.record(u)
+	llmTokens.add(float64(u.PromptTokens), "prompt")
+	llmTokens.add(float64(u.CompletionTokens), "completion")
+	if tracker, ok := ctx.Value(usageKey{}).(*usageTracker); ok {
+		tracker.mu.Lock()
+		tracker.usage.add(u)
+		tracker.mu.Unlock()
+	}
+}
+
+// Usage 返回最近若干天的 LLM token 用量以及当日预算余量
+func (RPCService) Usage(req UsageRequest, resp *UsageResponse) error {
+	days := req.Days
+	if days <= 0 {
+		days = defaultUsageDays
+	}
+	if days > maxUsageQueryDays {
+		return fmt.Errorf("days 不能超过 %d", maxUsageQueryDays)
+	}
+
+	resp.Days = ledger.recent(days)
+	resp.DailyBudget = dailyTokenBudget()
+	resp.Remaining = -1
+	if resp.DailyBudget > 0 {
+		used := ledger.today().TotalTokens
+		resp.Remaining = resp.DailyBudget - used
+		if resp.Remaining < 0 {
+			resp.Remaining = 0
+		}
+		resp.Exceeded = used >= resp.DailyBudget
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"mysql-agent/databases"
+)
+
+// workloadTools 吞吐量与等待事件相关的诊断工具
+func workloadTools() []toolRegistration {
+	return []toolRegistration{
+		inferTool(toolThroughput, "计算实时 QPS/TPS 与各类语句、行操作的每秒速率；后台采样开启时直接使用采样数据并返回最近的 QPS/TPS 序列，否则间隔 window_seconds(默认 5 秒)现场两次采样 Questions、Com_commit、Com_rollback 等状态计数", throughputTool),
+		inferTool(toolWaitEvents, "查询 `performance_schema.events_waits_summary_global_by_event_name`(与 `sys.waits_global_by_latency` 同口径)，按等待类别与具体事件汇总自启动以来的累计等待耗时，定位 IO、锁、互斥量等真实瓶颈", waitEventsTool),
+	}
+}
+
+type ThroughputInput struct {
+	WindowSeconds  int `json:"window_seconds,omitempty" jsonschema:"description=两次采样的间隔秒数,默认 5；后台采样开启时为计算速率的时间窗口,minimum=1,maximum=60"`
+	HistoryMinutes int `json:"history_minutes,omitempty" jsonschema:"description=后台采样开启时返回最近多少分钟的 QPS/TPS 序列,默认 15,minimum=1"`
+}
+
+type ThroughputResult struct {
+	WindowSeconds  float64            `json:"window_seconds"` // 实际采样间隔
+	QPS            float64            `json:"qps"`            // Questions 每秒增量，只统计客户端发送的语句
+	TPS            float64            `json:"tps"`            // (Com_commit + Com_rollback) 每秒增量，不含 autocommit 的单语句事务
+	HandlerCommits float64            `json:"handler_commits_per_sec"`
+	Rates          map[string]float64 `json:"rates"` // 各计数器每秒增量
+	ThreadsRunning int64              `json:"threads_running"`
+	// Source sampler 表示基于后台采样计算，live 表示本次现场间隔两次采样
+	Source string            `json:"source"`
+	Series []ThroughputPoint `json:"series,omitempty"` // 后台采样开启时最近 history_minutes 分钟的序列
+}
+
+// ThroughputPoint 相邻两次后台采样之间的吞吐量
+type ThroughputPoint struct {
+	At             string  `json:"at"`
+	QPS            float64 `json:"qps"`
+	TPS            float64 `json:"tps"`
+	ThreadsRunning float64 `json:"threads_running"`
+}
+
+// throughputCounters 采样计算速率的状态计数器
+var throughputCounters = []string{
+	"questions", "queries", "com_select", "com_insert", "com_update", "com_delete", "com_replace",
+	"com_commit", "com_rollback", "handler_commit", "bytes_received", "bytes_sent",
+	"innodb_rows_read", "innodb_rows_inserted", "innodb_rows_updated", "innodb_rows_deleted",
+}
+
+const (
+	defaultThroughputWindow  = 5
+	maxThroughputWindow      = 60
+	defaultThroughputHistory = 15
+)
+
+func throughputTool(ctx context.Context, input *ThroughputInput) (*ThroughputResult, error) {
+	window := defaultThroughputWindow
+	if input != nil && input.WindowSeconds > 0 {
+		window = input.WindowSeconds
+	}
+	if window > maxThroughputWindow {
+		window = maxThroughputWindow
+	}
+	if result, ok := sampledThroughput(input, window); ok {
+		return result, nil
+	}
+
+	first, err := globalStatusValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	timer := time.NewTimer(time.Duration(window) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("采样被取消: %w", ctx.Err())
+	case <-timer.C:
+	}
+
+	second, err := globalStatusValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 用实际间隔计算速率，避免查询耗时带来的偏差
+	elapsed := time.Since(start).Seconds()
+
+	result := &ThroughputResult{
+		WindowSeconds:  elapsed,
+		Rates:          make(map[string]float64, len(throughputCounters)),
+		ThreadsRunning: parseInt(second["threads_running"]),
+		Source:         "live",
+	}
+	for _, name := range throughputCounters {
+		delta := parseInt(second[name]) - parseInt(first[name])
+		if delta < 0 {
+			// FLUSH STATUS 会重置计数器
+			delta = 0
+		}
+		result.Rates[name] = float64(delta) / elapsed
+	}
+	result.QPS = result.Rates["questions"]
+	result.TPS = result.Rates["com_commit"] + result.Rates["com_rollback"]
+	result.HandlerCommits = result.Rates["handler_commit"]
+
+	return result, nil
+}
+
+// sampledThroughput 后台采样在运行且最近一次采样不超过两个采样间隔时，直接用缓冲区中的数据计算，不再现场等待。
+// 速率取最近一次采样与其之前至少 window 秒的采样之差，缓冲区不足 window 时使用最早的采样
+func sampledThroughput(input *ThroughputInput, window int) (*ThroughputResult, bool) {
+	if !SamplingActive() {
+		return nil, false
+	}
+	history := defaultThroughputHistory
+	if input != nil && input.HistoryMinutes > 0 {
+		history = input.HistoryMinutes
+	}
+	since := time.Now().Add(-time.Duration(history) * time.Minute)
+	if w := time.Now().Add(-time.Duration(window) * time.Second); w.Before(since) {
+		since = w
+	}
+	data := samples.since(since.Add(-sampleInterval()))
+	if len(data) < 2 {
+		return nil, false
+	}
+	latest := data[len(data)-1]
+	if time.Since(latest.At) > 2*sampleInterval() {
+		return nil, false
+	}
+
+	ref := data[0]
+	for i := len(data) - 2; i >= 0; i-- {
+		if latest.At.Sub(data[i].At) >= time.Duration(window)*time.Second {
+			ref = data[i]
+			break
+		}
+	}
+	result := &ThroughputResult{
+		WindowSeconds:  latest.At.Sub(ref.At).Seconds(),
+		Rates:          make(map[string]float64, len(throughputCounters)),
+		ThreadsRunning: int64(latest.Status["threads_running"]),
+		Source:         "sampler",
+		Series:         []ThroughputPoint{},
+	}
+	for _, name := range throughputCounters {
+		// 计数器被重置时速率按 0 处理
+		rate, _ := counterRate(ref, latest, name)
+		result.Rates[name] = rate
+	}
+	result.QPS = result.Rates["questions"]
+	result.TPS = result.Rates["com_commit"] + result.Rates["com_rollback"]
+	result.HandlerCommits = result.Rates["handler_commit"]
+
+	cutoff := time.Now().Add(-time.Duration(history) * time.Minute)
+	for i := 1; i < len(data); i++ {
+		if data[i].At.Before(cutoff) {
+			continue
+		}
+		qps, ok := counterRate(data[i-1], data[i], "questions")
+		if !ok {
+			continue
+		}
+		commit, _ := counterRate(data[i-1], data[i], "com_commit")
+		rollback, _ := counterRate(data[i-1], data[i], "com_rollback")
+		result.Series = append(result.Series, ThroughputPoint{
+			At:             data[i].At.Format(time.RFC3339),
+			QPS:            round2(qps),
+			TPS:            round2(commit + rollback),
+			ThreadsRunning: data[i].Status["threads_running"],
+		})
+	}
+	return result, true
+}
+
+type WaitEventsInput struct {
+	Limit int `json:"limit,omitempty" jsonschema:"description=返回耗时最高的等待事件数,默认 10,minimum=1"`
+}
+
+type WaitClass struct {
+	Class      string  `json:"class"` // event_name 前三段，如 wait/io/file、wait/synch/mutex
+	Count      int64   `json:"count"`
+	TotalMs    float64 `json:"total_latency_ms"`
+	LatencyPct float64 `json:"latency_pct"` // 占全部等待耗时的百分比
+}
+
+type WaitEvent struct {
+	EventName  string  `json:"event_name"`
+	Count      int64   `json:"count"`
+	TotalMs    float64 `json:"total_latency_ms"`
+	AvgMs      float64 `json:"avg_latency_ms"`
+	MaxMs      float64 `json:"max_latency_ms"`
+	LatencyPct float64 `json:"latency_pct"`
+}
+
+type WaitEventsResult struct {
+	TotalLatencyMs float64     `json:"total_latency_ms"`
+	Classes        []WaitClass `json:"classes"`
+	TopEvents      []WaitEvent `json:"top_events"`
+	Notes          []string    `json:"notes,omitempty"`
+}
+
+func waitEventsTool(ctx context.Context, input *WaitEventsInput) (*WaitEventsResult, error) {
+	const picosPerMs = 1e9
+	limit := 10
+	if input != nil && input.Limit > 0 {
+		limit = input.Limit
+	}
+
+	rows, err := databases.QueryWaitEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WaitEventsResult{Classes: []WaitClass{}, TopEvents: []WaitEvent{}}
+	classIndex := make(map[string]int)
+	for _, row := range normalizeRows(rows) {
+		ev := WaitEvent{
+			EventName: row["event_name"],
+			Count:     parseInt(row["count_star"]),
+			TotalMs:   parseFloat(row["sum_timer_wait"]) / picosPerMs,
+			AvgMs:     parseFloat(row["avg_timer_wait"]) / picosPerMs,
+			MaxMs:     parseFloat(row["max_timer_wait"]) / picosPerMs,
+		}
+		result.TotalLatencyMs += ev.TotalMs
+
+		class := waitClass(ev.EventName)
+		idx, ok := classIndex[class]
+		if !ok {
+			idx = len(result.Classes)
+			classIndex[class] = idx
+			result.Classes = append(result.Classes, WaitClass{Class: class})
+		}
+		result.Classes[idx].Count += ev.Count
+		result.Classes[idx].TotalMs += ev.TotalMs
+
+		// 查询结果已按耗时降序，只保留前 limit 个事件
+		if len(result.TopEvents) < limit {
+			result.TopEvents = append(result.TopEvents, ev)
+		}
+	}
+
+	sort.Slice(result.Classes, func(i, j int) bool { return result.Classes[i].TotalMs > result.Classes[j].TotalMs })
+	if result.TotalLatencyMs > 0 {
+		for i := range result.Classes {
+			result.Classes[i].LatencyPct = 100 * result.Classes[i].TotalMs / result.TotalLatencyMs
+		}
+		for i := range result.TopEvents {
+			result.TopEvents[i].LatencyPct = 100 * result.TopEvents[i].TotalMs / result.TotalLatencyMs
+		}
+	}
+
+	// mutex、rwlock 等同步类 instrument 默认关闭，缺失的类别并不代表没有等待
+	instruments, err := databases.QueryEnabledWaitInstruments(ctx)
+	if err != nil {
+		log.Printf("[waitEventsTool] query setup_instruments failed: %v", err)
+		return result, nil
+	}
+	disabled := make([]string, 0)
+	for _, row := range normalizeRows(instruments) {
+		if parseInt(row["enabled"]) == 0 {
+			disabled = append(disabled, row["wait_class"])
+		}
+	}
+	sort.Strings(disabled)
+	if len(disabled) > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("以下等待类别的 instrument 未开启计时，结果中不会出现: %s", strings.Join(disabled, ", ")))
+	}
+	if len(result.Classes) == 0 {
+		result.Notes = append(result.Notes, "没有任何等待事件计时数据，请确认 performance_schema 已开启")
+	}
+
+	return result, nil
+}
+
+// waitClass 取 event_name 的前三段作为等待类别，例如 wait/io/file/innodb/innodb_data_file -> wait/io/file
+func waitClass(eventName string) string {
+	parts := strings.SplitN(eventName, "/", 4)
+	if len(parts) < 3 {
+		return eventName
+	}
+	return strings.Join(parts[:3], "/")
+}
@@ -1,25 +1,62 @@
 package config
 
 import (
+	"embed"
 	"fmt"
 	"log"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server    ServerConfig     `mapstructure:"server"`
+	Database  DatabaseConfig   `mapstructure:"database"`
+	Instances []InstanceConfig `mapstructure:"instances"`
+	Log       LogConfig        `mapstructure:"log"`
+	Agent     AgentConfig      `mapstructure:"agent"`
+	LLM       LLMConfig        `mapstructure:"llm"`
+	Rules     RulesConfig      `mapstructure:"rules"`
+	Prompt    PromptConfig     `mapstructure:"prompt"`
+	RateLimit RateLimitConfig  `mapstructure:"rate_limit"`
+	Plugins   []PluginConfig   `mapstructure:"plugins"`
+	MCP       MCPConfig        `mapstructure:"mcp"`
+	Baseline  BaselineConfig   `mapstructure:"baseline"`
+	Alert     AlertConfig      `mapstructure:"alert"`
+	Sampler   SamplerConfig    `mapstructure:"sampler"`
+	Exporter  ExporterConfig   `mapstructure:"exporter"`
 }
 
 type ServerConfig struct {
-	Host string `mapstructure:"host"`
+	Host      string `mapstructure:"host"`
+	Port      string `mapstructure:"port"`
+	Mode      string `mapstructure:"mode"`
+	Transport string `mapstructure:"transport"`
+	HTTPPort  string `mapstructure:"http_port"`
+}
+
+const (
+	TransportRPC  = "rpc"
+	TransportHTTP = "http"
+	TransportBoth = "both"
+)
+
+// MCPConfig 以 Model Context Protocol 对外提供诊断工具，供 Claude Desktop 等 MCP 客户端直接调用
+type MCPConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Transport stdio（由客户端以子进程启动，日志输出到 stderr）或 sse
+	Transport string `mapstructure:"transport"`
+	// Port SSE 模式监听的端口
 	Port string `mapstructure:"port"`
-	Mode string `mapstructure:"mode"`
 }
 
+const (
+	MCPTransportStdio = "stdio"
+	MCPTransportSSE   = "sse"
+)
+
 type DatabaseConfig struct {
 	Host            string        `mapstructure:"host"`
 	Port            int           `mapstructure:"port"`
@@ -30,6 +67,257 @@ type DatabaseConfig struct {
 	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	// IdleTimeout 连接池持续未被使用超过该时长后关闭，下次使用时重新打开，0 表示不关闭；default 实例的连接池始终保留
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+	// HealthCheckInterval 后台检查已打开连接池（ping 与空闲关闭）的间隔，0 表示不检查；只读取 [database] 中的值
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+	// ReplicaDSNs 只读从库的 DSN，information_schema 元数据扫描按顺序选择第一个可达的从库执行，均不可达时回退到本实例；
+	// [[instances]] 不继承 [database] 的从库
+	ReplicaDSNs []string `mapstructure:"replica_dsns"`
+	// SSH 经跳板机访问实例，未配置 host 时直连；从库经同一跳板机访问
+	SSH SSHConfig `mapstructure:"ssh"`
+}
+
+// SSHConfig SSH 跳板机配置，连接池经跳板机转发到实例的 host:port
+type SSHConfig struct {
+	Host string `mapstructure:"host"`
+	Port int    `mapstructure:"port"`
+	User string `mapstructure:"user"`
+	// KeyFile 私钥文件路径，KeyPassphrase 为私钥口令，私钥未加密时留空
+	KeyFile       string `mapstructure:"key_file"`
+	KeyPassphrase string `mapstructure:"key_passphrase"`
+	// KnownHostsFile 校验跳板机主机密钥的 known_hosts 文件，为空时使用 ~/.ssh/known_hosts
+	KnownHostsFile string `mapstructure:"known_hosts_file"`
+	// InsecureIgnoreHostKey 为 true 时不校验跳板机主机密钥，仅用于测试环境
+	InsecureIgnoreHostKey bool `mapstructure:"insecure_ignore_host_key"`
+}
+
+// DefaultInstanceID [database] 对应的实例ID，请求未指定 instance_id 时使用
+const DefaultInstanceID = "default"
+
+// InstanceConfig [[instances]] 中的一个被诊断实例，请求通过 instance_id 选择；
+// 未填写的连接参数（端口、账号、字符集、连接池设置等）沿用 [database]
+type InstanceConfig struct {
+	ID string `mapstructure:"id"`
+	// Name 写入提示词的实例名称，为空时使用 ID
+	Name           string `mapstructure:"name"`
+	DatabaseConfig `mapstructure:",squash"`
+}
+
+type AgentConfig struct {
+	// ReadOnly 为 true 时不注册任何会修改数据库状态的工具
+	ReadOnly bool `mapstructure:"read_only"`
+	// RequireReadOnlyAccount 为 true 时启动前检查 agent 账号不具备写权限，不满足则拒绝启动
+	RequireReadOnlyAccount bool `mapstructure:"require_read_only_account"`
+	// ToolConcurrency 执行计划时同时运行的工具数上限
+	ToolConcurrency int `mapstructure:"tool_concurrency"`
+	// PlanTimeout 单次计划中所有工具执行的总时长预算
+	PlanTimeout time.Duration `mapstructure:"plan_timeout"`
+	// RequirePlanApproval 为 true 时 Agent.Query 只返回工具计划，必须经 Agent.ExecutePlan 执行
+	RequirePlanApproval bool `mapstructure:"require_plan_approval"`
+	// MaxIterations 首批工具执行后 LLM 可追加工具调用的最大轮数，0 表示不追加
+	MaxIterations int `mapstructure:"max_iterations"`
+	// ToolOutputBudget 发送给 LLM 的工具输出序列化后的总字节数上限，超出时按工具截断行数
+	ToolOutputBudget int `mapstructure:"tool_output_budget"`
+	// EnabledTools 非空时只注册列出的工具
+	EnabledTools []string `mapstructure:"enabled_tools"`
+	// DisabledTools 不注册的工具，例如没有文件访问权限时禁用读取错误日志的工具
+	DisabledTools []string `mapstructure:"disabled_tools"`
+	// PriorityTools 裁剪工具输出时优先保留的工具
+	PriorityTools []string `mapstructure:"priority_tools"`
+	// MaxConcurrentQueries 同时执行的诊断请求数上限
+	MaxConcurrentQueries int `mapstructure:"max_concurrent_queries"`
+	// MaxQueuedQueries 达到并发上限后允许排队的请求数，队列已满时立即拒绝，0 表示不排队
+	MaxQueuedQueries int `mapstructure:"max_queued_queries"`
+	// QueueTimeout 单个请求排队等待的最长时间
+	QueueTimeout time.Duration `mapstructure:"queue_timeout"`
+	// StructuredSummary 为 true 时要求诊断结论以 JSON 报告返回，校验失败时请求模型修正
+	StructuredSummary bool `mapstructure:"structured_summary"`
+	// SummaryRepairAttempts 结构化报告校验失败后请求模型修正的最大次数
+	SummaryRepairAttempts int `mapstructure:"summary_repair_attempts"`
+	// AcceptInstanceConnections 为 true 时接受 backend 随请求下发的登记实例连接信息，
+	// 为该 instance_id 建立连接池；[[instances]] 中已配置的实例始终使用本地配置。
+	// 任何能调用 agent 的一方都可以让 agent 连接指定的主机，只有 InstanceHostAllowlist 中的主机允许登记
+	AcceptInstanceConnections bool `mapstructure:"accept_instance_connections"`
+	// InstanceHostAllowlist 允许登记的实例主机，元素为主机名、IP 或 CIDR（如 10.0.0.0/24）；为空时拒绝所有登记
+	InstanceHostAllowlist []string `mapstructure:"instance_host_allowlist"`
+}
+
+type LLMConfig struct {
+	// MaxRetries DeepSeek API 瞬时失败（429、5xx、连接重置）时的最大重试次数，0 表示不重试
+	MaxRetries int `mapstructure:"max_retries"`
+	// RetryBaseDelay 首次重试前的基础等待时间，之后每次翻倍并加随机抖动
+	RetryBaseDelay time.Duration `mapstructure:"retry_base_delay"`
+	// RetryMaxDelay 单次等待的上限，同样限制 Retry-After 指定的等待时间
+	RetryMaxDelay time.Duration `mapstructure:"retry_max_delay"`
+	// DailyTokenBudget 每日（本地时区）token 用量上限，用尽后规划与分析改用规则诊断，0 表示不限制
+	DailyTokenBudget int64 `mapstructure:"daily_token_budget"`
+	// UsageFile 持久化每日 token 用量的文件，为空时只保存在内存中，重启后当日用量清零
+	UsageFile string `mapstructure:"usage_file"`
+}
+
+// RateLimitConfig 诊断请求（Query、ExecutePlan、流式诊断）的令牌桶限流，速率为 0 表示不限制对应范围
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// GlobalRate/GlobalBurst 所有调用方合计每秒允许的请求数及突发容量
+	GlobalRate  float64 `mapstructure:"global_rate"`
+	GlobalBurst int     `mapstructure:"global_burst"`
+	// CallerRate/CallerBurst 单个调用方（QueryRequest.Caller，HTTP 默认取客户端地址）每秒允许的请求数及突发容量
+	CallerRate  float64 `mapstructure:"caller_rate"`
+	CallerBurst int     `mapstructure:"caller_burst"`
+}
+
+// PluginConfig 以子进程方式执行的外部工具。参数 JSON 写入标准输入，插件在标准输出返回 JSON 结果
+type PluginConfig struct {
+	// Name 工具名，出现在规划提示词中，不能与内置工具重复
+	Name string `mapstructure:"name"`
+	// Description 告诉模型该工具的用途与适用场景
+	Description string   `mapstructure:"description"`
+	Command     string   `mapstructure:"command"`
+	Args        []string `mapstructure:"args"`
+	// Timeout 单次执行的超时时间，默认 30s
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Schema 参数的 JSON Schema（顶层为 object），为空表示无参数
+	Schema string `mapstructure:"schema"`
+	// Mutating 为 true 时视为会修改数据库状态的工具，agent.read_only 开启时不注册
+	Mutating bool `mapstructure:"mutating"`
+}
+
+// SamplerConfig 后台定时采样状态计数器与复制延迟，保存在内存环形缓冲区中，供吞吐量工具、指标基线与 Agent.Samples 使用
+type SamplerConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`
+	// Capacity 环形缓冲区保留的采样数，写满后覆盖最早的采样
+	Capacity int `mapstructure:"capacity"`
+	// Metrics 额外采样的状态变量，吞吐量工具需要的计数器与启用时的基线指标总会采样
+	Metrics []string `mapstructure:"metrics"`
+	// ReplicaLag 为 true 时同时采样各复制通道的延迟
+	ReplicaLag bool `mapstructure:"replica_lag"`
+	// File 持久化采样的文件，为空时只保存在内存中
+	File string `mapstructure:"file"`
+}
+
+// ExporterConfig 把采样器收集的 MySQL 指标以 OpenMetrics 格式暴露（HTTP /metrics/mysql）或推送到 Prometheus remote-write，
+// 启用任一方式时采样器随之运行
+type ExporterConfig struct {
+	// OpenMetrics 为 true 时在 HTTP 服务上提供 /metrics/mysql
+	OpenMetrics bool `mapstructure:"openmetrics"`
+	// Job 导出指标的 job 标签
+	Job string `mapstructure:"job"`
+	// Labels 附加到所有导出指标上的固定标签，如 instance、cluster
+	Labels map[string]string `mapstructure:"labels"`
+	// RemoteWriteURL 非空时定时推送采样数据，如 http://prometheus:9090/api/v1/write
+	RemoteWriteURL      string        `mapstructure:"remote_write_url"`
+	RemoteWriteInterval time.Duration `mapstructure:"remote_write_interval"`
+	RemoteWriteTimeout  time.Duration `mapstructure:"remote_write_timeout"`
+	// Username/Password 基本认证，BearerToken 非空时优先使用
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	BearerToken string `mapstructure:"bearer_token"`
+}
+
+// BaselineConfig 用采样器的数据建立指标基线（启用时采样器随之运行），诊断时把偏离基线的当前值作为异常返回
+type BaselineConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval 写入基线的间隔，数据取自采样器，实际间隔为 sampler.interval 的整数倍
+	Interval time.Duration `mapstructure:"interval"`
+	// Window 每个指标保留的最近采样数，均值与标准差按窗口内的采样计算
+	Window int `mapstructure:"window"`
+	// MinSamples 窗口内采样数达到该值后才参与异常判断
+	MinSamples int `mapstructure:"min_samples"`
+	// Sigma 当前值与均值的差超过该倍数的标准差时记为异常
+	Sigma float64 `mapstructure:"sigma"`
+	// Metrics 跟踪的状态变量，Threads_connected 等瞬时值直接比较，其余按两次采样间的每秒增量比较
+	Metrics []string `mapstructure:"metrics"`
+	// File 持久化基线的文件，为空时只保存在内存中，重启后重新积累
+	File string `mapstructure:"file"`
+}
+
+// AlertConfig 规则引擎结论的告警推送：定时检查与规则诊断命中阈值时按渠道发送通知
+type AlertConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// CheckInterval 定时执行规则检查的间隔，0 表示不定时检查，只在诊断请求走规则诊断时告警
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+	// MinSeverity 低于该级别（high/moderate/low）的结论不告警
+	MinSeverity string `mapstructure:"min_severity"`
+	// Rules 非空时只对列出的规则告警，如 replication_lag、threads_running、tmp_disk_tables
+	Rules []string `mapstructure:"rules"`
+	// DedupWindow 同一规则同一级别的告警在窗口内只发送一次，级别升高时立即发送
+	DedupWindow time.Duration `mapstructure:"dedup_window"`
+	// SilenceStart/SilenceEnd 每日静默时段（本地时区 HH:MM），可跨零点，任一为空表示不静默
+	SilenceStart string               `mapstructure:"silence_start"`
+	SilenceEnd   string               `mapstructure:"silence_end"`
+	Channels     []AlertChannelConfig `mapstructure:"channels"`
+}
+
+// AlertChannelConfig 一个告警渠道
+type AlertChannelConfig struct {
+	Name string `mapstructure:"name"`
+	// Type webhook（POST 告警 JSON）、dingtalk、feishu 或 slack
+	Type string `mapstructure:"type"`
+	URL  string `mapstructure:"url"`
+	// Secret 钉钉、飞书机器人的加签密钥，为空表示未开启加签
+	Secret string `mapstructure:"secret"`
+	// Template 消息模板（text/template），为空使用内置模板
+	Template string `mapstructure:"template"`
+	// MinSeverity 覆盖 alert.min_severity
+	MinSeverity string `mapstructure:"min_severity"`
+}
+
+const (
+	AlertChannelWebhook  = "webhook"
+	AlertChannelDingTalk = "dingtalk"
+	AlertChannelFeishu   = "feishu"
+	AlertChannelSlack    = "slack"
+)
+
+// PromptConfig 提示词模板及模板变量，模板使用 text/template 语法
+type PromptConfig struct {
+	// Dir 覆盖内置模板的目录，文件名为 <模板名>.tmpl；相对路径相对配置文件所在目录
+	Dir string `mapstructure:"dir"`
+	// InstanceName 被诊断实例的名称，写入提示词便于模型在结论中引用
+	InstanceName string `mapstructure:"instance_name"`
+	// Language 诊断结论使用的语言
+	Language string `mapstructure:"language"`
+	// MaxLines 诊断结论的行数上限，0 表示不限制
+	MaxLines int `mapstructure:"max_lines"`
+}
+
+// DefaultPrompts 内置的提示词模板，与 config/prompts 目录下的文件内容相同
+//
+//go:embed prompts/*.tmpl
+var DefaultPrompts embed.FS
+
+// RulesConfig LLM 不可用时规则诊断使用的阈值，阈值为 0 表示关闭对应规则
+type RulesConfig struct {
+	// Enabled 为 true 时规划或分析阶段的 LLM 调用失败后改用规则诊断
+	Enabled bool `mapstructure:"enabled"`
+	// ThreadsRunning Threads_running 达到该值记为 moderate，达到两倍记为 high
+	ThreadsRunning int `mapstructure:"threads_running"`
+	// ReplicationLagWarn/ReplicationLagCrit 复制延迟秒数阈值
+	ReplicationLagWarn int `mapstructure:"replication_lag_warn"`
+	ReplicationLagCrit int `mapstructure:"replication_lag_crit"`
+	// SlowQueryAvgMs 语句平均耗时超过该毫秒数视为慢查询
+	SlowQueryAvgMs int `mapstructure:"slow_query_avg_ms"`
+	// BufferPoolHitWarn/BufferPoolHitCrit 缓冲池命中率（百分比）低于该值时告警
+	BufferPoolHitWarn float64 `mapstructure:"buffer_pool_hit_warn"`
+	BufferPoolHitCrit float64 `mapstructure:"buffer_pool_hit_crit"`
+	// TmpDiskTablePct 磁盘临时表占内部临时表的百分比达到该值记为 moderate，达到两倍记为 high
+	TmpDiskTablePct float64 `mapstructure:"tmp_disk_table_pct"`
+}
+
+// DefaultRulesConfig 未加载配置时使用的规则阈值，与 setDefaults 保持一致
+func DefaultRulesConfig() RulesConfig {
+	return RulesConfig{
+		Enabled:            true,
+		ThreadsRunning:     32,
+		ReplicationLagWarn: 30,
+		ReplicationLagCrit: 300,
+		SlowQueryAvgMs:     1000,
+		BufferPoolHitWarn:  99,
+		BufferPoolHitCrit:  95,
+		TmpDiskTablePct:    25,
+	}
 }
 
 type LogConfig struct {
@@ -61,14 +349,43 @@ func InitConfig() {
 		log.Fatalf("解析配置失败: %v", err)
 	}
 
+	if err := validateInstances(cfg.Instances); err != nil {
+		log.Fatalf("实例配置无效: %v", err)
+	}
+
+	if dir := cfg.Prompt.Dir; dir != "" && !filepath.IsAbs(dir) && viper.ConfigFileUsed() != "" {
+		cfg.Prompt.Dir = filepath.Join(filepath.Dir(viper.ConfigFileUsed()), dir)
+	}
+
 	AppConfig = cfg
 	log.Print("配置加载完成")
 }
 
+func validateInstances(instances []InstanceConfig) error {
+	seen := map[string]bool{DefaultInstanceID: true}
+	for i, inst := range instances {
+		id := strings.TrimSpace(inst.ID)
+		if id == "" {
+			return fmt.Errorf("instances[%d].id 不能为空", i)
+		}
+		if seen[id] {
+			return fmt.Errorf("实例ID重复或与保留的 %s 冲突: %s", DefaultInstanceID, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
 func setDefaults() {
 	viper.SetDefault("server.host", "localhost")
 	viper.SetDefault("server.port", "8081")
 	viper.SetDefault("server.mode", "debug")
+	viper.SetDefault("server.transport", TransportRPC)
+	viper.SetDefault("server.http_port", "8082")
+
+	viper.SetDefault("mcp.enabled", false)
+	viper.SetDefault("mcp.transport", MCPTransportStdio)
+	viper.SetDefault("mcp.port", "8083")
 
 	viper.SetDefault("database.host", "localhost")
 	viper.SetDefault("database.port", 3306)
@@ -79,23 +396,174 @@ func setDefaults() {
 	viper.SetDefault("database.max_idle_conns", 10)
 	viper.SetDefault("database.max_open_conns", 100)
 	viper.SetDefault("database.conn_max_lifetime", "1h")
+	viper.SetDefault("database.idle_timeout", "10m")
+	viper.SetDefault("database.health_check_interval", "30s")
+	viper.SetDefault("database.replica_dsns", []string{})
+	viper.SetDefault("database.ssh.port", 22)
 
 	viper.SetDefault("log.level", "info")
 	viper.SetDefault("log.format", "json")
 	viper.SetDefault("log.output", "stdout")
+
+	viper.SetDefault("llm.max_retries", 3)
+	viper.SetDefault("llm.retry_base_delay", "500ms")
+	viper.SetDefault("llm.retry_max_delay", "10s")
+	viper.SetDefault("llm.daily_token_budget", 0)
+	viper.SetDefault("llm.usage_file", "")
+
+	viper.SetDefault("rate_limit.enabled", false)
+	viper.SetDefault("rate_limit.global_rate", 2)
+	viper.SetDefault("rate_limit.global_burst", 10)
+	viper.SetDefault("rate_limit.caller_rate", 0.2)
+	viper.SetDefault("rate_limit.caller_burst", 3)
+
+	viper.SetDefault("sampler.enabled", false)
+	viper.SetDefault("sampler.interval", "10s")
+	viper.SetDefault("sampler.capacity", 360)
+	viper.SetDefault("sampler.metrics", []string{"Threads_connected", "Threads_running", "Questions", "Com_commit", "Com_rollback", "Slow_queries", "Innodb_row_lock_waits", "Created_tmp_disk_tables"})
+	viper.SetDefault("sampler.replica_lag", true)
+	viper.SetDefault("sampler.file", "")
+
+	viper.SetDefault("exporter.openmetrics", false)
+	viper.SetDefault("exporter.job", "mysql-agent")
+	viper.SetDefault("exporter.remote_write_url", "")
+	viper.SetDefault("exporter.remote_write_interval", "30s")
+	viper.SetDefault("exporter.remote_write_timeout", "10s")
+
+	viper.SetDefault("baseline.enabled", false)
+	viper.SetDefault("baseline.interval", "1m")
+	viper.SetDefault("baseline.window", 1440)
+	viper.SetDefault("baseline.min_samples", 30)
+	viper.SetDefault("baseline.sigma", 3.0)
+	viper.SetDefault("baseline.metrics", []string{"Threads_connected", "Threads_running", "Questions", "Com_select", "Com_insert", "Com_update", "Com_delete", "Slow_queries", "Innodb_row_lock_waits", "Innodb_rows_read", "Created_tmp_disk_tables", "Aborted_connects"})
+	viper.SetDefault("baseline.file", "")
+
+	viper.SetDefault("alert.enabled", false)
+	viper.SetDefault("alert.check_interval", "5m")
+	viper.SetDefault("alert.min_severity", "moderate")
+	viper.SetDefault("alert.rules", []string{})
+	viper.SetDefault("alert.dedup_window", "30m")
+	viper.SetDefault("alert.silence_start", "")
+	viper.SetDefault("alert.silence_end", "")
+
+	viper.SetDefault("prompt.dir", "prompts")
+	viper.SetDefault("prompt.instance_name", "")
+	viper.SetDefault("prompt.language", "中文")
+	viper.SetDefault("prompt.max_lines", 40)
+
+	viper.SetDefault("rules.enabled", true)
+	viper.SetDefault("rules.threads_running", 32)
+	viper.SetDefault("rules.replication_lag_warn", 30)
+	viper.SetDefault("rules.replication_lag_crit", 300)
+	viper.SetDefault("rules.slow_query_avg_ms", 1000)
+	viper.SetDefault("rules.buffer_pool_hit_warn", 99)
+	viper.SetDefault("rules.buffer_pool_hit_crit", 95)
+	viper.SetDefault("rules.tmp_disk_table_pct", 25)
+
+	viper.SetDefault("agent.read_only", false)
+	viper.SetDefault("agent.require_read_only_account", false)
+	viper.SetDefault("agent.tool_concurrency", 4)
+	viper.SetDefault("agent.plan_timeout", "30s")
+	viper.SetDefault("agent.require_plan_approval", false)
+	viper.SetDefault("agent.max_iterations", 2)
+	viper.SetDefault("agent.tool_output_budget", 60000)
+	viper.SetDefault("agent.max_concurrent_queries", 4)
+	viper.SetDefault("agent.max_queued_queries", 16)
+	viper.SetDefault("agent.queue_timeout", "30s")
+	viper.SetDefault("agent.structured_summary", true)
+	viper.SetDefault("agent.summary_repair_attempts", 2)
+	viper.SetDefault("agent.accept_instance_connections", false)
+	viper.SetDefault("agent.instance_host_allowlist", []string{})
+	viper.SetDefault("agent.enabled_tools", []string{})
+	viper.SetDefault("agent.disabled_tools", []string{})
+	viper.SetDefault("agent.priority_tools", []string{"mysql_deadlock_info", "mysql_innodb_trx", "mysql_metadata_locks", "mysql_replication_status"})
 }
 
 func (c *Config) GetDSN() string {
+	return c.Database.DSN()
+}
+
+// DSN 按连接参数生成 go-sql-driver 的 DSN
+func (d DatabaseConfig) DSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
-		c.Database.Username,
-		c.Database.Password,
-		c.Database.Host,
-		c.Database.Port,
-		c.Database.DBName,
-		c.Database.Charset,
+		d.Username,
+		d.Password,
+		d.Host,
+		d.Port,
+		d.DBName,
+		d.Charset,
 	)
 }
 
+// InstanceIDs 返回全部实例ID，default 在最前
+func (c *Config) InstanceIDs() []string {
+	ids := []string{DefaultInstanceID}
+	for _, inst := range c.Instances {
+		ids = append(ids, inst.ID)
+	}
+	return ids
+}
+
+// InstanceDatabase 返回实例的连接参数，id 为空或 default 时为 [database]；
+// [[instances]] 中未填写的字段取 [database] 的值，未配置 ssh.host 时沿用 [database] 的跳板机。实例不存在时 ok 为 false
+func (c *Config) InstanceDatabase(id string) (db DatabaseConfig, ok bool) {
+	if id == "" || id == DefaultInstanceID {
+		return c.Database, true
+	}
+	for _, inst := range c.Instances {
+		if inst.ID != id {
+			continue
+		}
+		db = inst.DatabaseConfig
+		base := c.Database
+		if db.Host == "" {
+			db.Host = base.Host
+		}
+		if db.Port == 0 {
+			db.Port = base.Port
+		}
+		if db.Username == "" {
+			db.Username, db.Password = base.Username, base.Password
+		}
+		if db.DBName == "" {
+			db.DBName = base.DBName
+		}
+		if db.Charset == "" {
+			db.Charset = base.Charset
+		}
+		if db.MaxIdleConns == 0 {
+			db.MaxIdleConns = base.MaxIdleConns
+		}
+		if db.MaxOpenConns == 0 {
+			db.MaxOpenConns = base.MaxOpenConns
+		}
+		if db.ConnMaxLifetime == 0 {
+			db.ConnMaxLifetime = base.ConnMaxLifetime
+		}
+		if db.IdleTimeout == 0 {
+			db.IdleTimeout = base.IdleTimeout
+		}
+		if db.SSH.Host == "" {
+			db.SSH = base.SSH
+		}
+		return db, true
+	}
+	return DatabaseConfig{}, false
+}
+
+// InstanceName 返回写入提示词与告警的实例名称：default 使用 prompt.instance_name，其余实例使用 name，为空时使用 ID
+func (c *Config) InstanceName(id string) string {
+	if id == "" || id == DefaultInstanceID {
+		return c.Prompt.InstanceName
+	}
+	for _, inst := range c.Instances {
+		if inst.ID == id && inst.Name != "" {
+			return inst.Name
+		}
+	}
+	return id
+}
+
 func (c *Config) GetAdminDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/?charset=%s&parseTime=True&loc=Local",
 		c.Database.Username,
@@ -109,3 +577,17 @@ func (c *Config) GetAdminDSN() string {
 func (c *Config) GetServerAddr() string {
 	return fmt.Sprintf("%s:%s", c.Server.Host, c.Server.Port)
 }
+
+func (c *Config) GetHTTPAddr() string {
+	return fmt.Sprintf("%s:%s", c.Server.Host, c.Server.HTTPPort)
+}
+
+func (c *Config) RPCEnabled() bool {
+	t := strings.ToLower(strings.TrimSpace(c.Server.Transport))
+	return t == "" || t == TransportRPC || t == TransportBoth
+}
+
+func (c *Config) HTTPEnabled() bool {
+	t := strings.ToLower(strings.TrimSpace(c.Server.Transport))
+	return t == TransportHTTP || t == TransportBoth
+}
@@ -0,0 +1,97 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Capabilities 实例的版本与诊断工具依赖的前提条件
+type Capabilities struct {
+	// Version VERSION() 的原始返回值，如 8.0.36、10.11.6-MariaDB
+	Version string
+	Flavor  Flavor
+	// Major、Minor 从 Version 解析出的主次版本号，解析失败时为 0
+	Major, Minor int
+	// PerformanceSchema @@performance_schema 是否开启，关闭时 performance_schema 下的表存在但没有数据
+	PerformanceSchema bool
+	// SysSchema 是否存在 sys 库
+	SysSchema bool
+}
+
+// AtLeast 判断版本是否不低于 major.minor，用于区分 MySQL 与 MariaDB 版本号时需先判断 Flavor
+func (c Capabilities) AtLeast(major, minor int) bool {
+	return c.Major > major || (c.Major == major && c.Minor >= minor)
+}
+
+// ServerCapabilities 返回 ctx 所属实例的能力。default 实例在 InitDB 时检测，其余实例在连接池打开后首次调用时检测并缓存，
+// 连接池因空闲关闭或重新登记后重新检测；检测失败时不缓存
+func ServerCapabilities(ctx context.Context) (Capabilities, error) {
+	id := InstanceFrom(ctx)
+	manager.mu.Lock()
+	if p, ok := manager.pools[id]; ok && p.caps != nil {
+		caps := *p.caps
+		manager.mu.Unlock()
+		return caps, nil
+	}
+	manager.mu.Unlock()
+
+	db, err := GetDB(ctx)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	caps, err := detectCapabilities(ctx, db)
+	if err != nil {
+		log.Printf("[databases] instance=%s %v", id, err)
+		return Capabilities{}, err
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if p, ok := manager.pools[id]; ok && p.db == db {
+		if p.caps == nil {
+			logCapabilities(id, caps)
+		}
+		p.caps = &caps
+	}
+	return caps, nil
+}
+
+func detectCapabilities(ctx context.Context, db *sql.DB) (Capabilities, error) {
+	var (
+		caps              Capabilities
+		comment           string
+		performanceSchema int64
+		sysSchema         int64
+	)
+	query := "SELECT VERSION(), @@version_comment, @@performance_schema," +
+		" (SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = 'sys')"
+	if err := guardedQueryRow(ctx, db, query, nil, &caps.Version, &comment, &performanceSchema, &sysSchema); err != nil {
+		return Capabilities{}, fmt.Errorf("检测实例能力失败: %w", err)
+	}
+	caps.Flavor = parseFlavor(caps.Version, comment)
+	caps.Major, caps.Minor = parseVersion(caps.Version)
+	caps.PerformanceSchema = performanceSchema != 0
+	caps.SysSchema = sysSchema > 0
+	return caps, nil
+}
+
+// parseVersion 解析 VERSION() 开头的主次版本号，如 8.0.36-28 返回 8, 0；5.5.5-10.11.6-MariaDB 之类带兼容前缀的写法
+// 只出现在握手包中，VERSION() 不受影响
+func parseVersion(version string) (major, minor int) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0
+	}
+	major, _ = strconv.Atoi(parts[0])
+	minor, _ = strconv.Atoi(parts[1])
+	return major, minor
+}
+
+func logCapabilities(id string, caps Capabilities) {
+	log.Printf("[databases] instance=%s version=%s flavor=%s performance_schema=%t sys_schema=%t",
+		id, caps.Version, caps.Flavor, caps.PerformanceSchema, caps.SysSchema)
+}
@@ -0,0 +1,56 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	mysql "github.com/go-sql-driver/mysql"
+)
+
+// Flavor MySQL 发行版，部分诊断 SQL 按发行版选择不同写法
+type Flavor string
+
+const (
+	FlavorMySQL   Flavor = "mysql"
+	FlavorPercona Flavor = "percona"
+	FlavorMariaDB Flavor = "mariadb"
+)
+
+// ErrUnsupported 实例上不存在诊断所需的对象（如 sys schema、performance_schema 表），工具据此降级而不是报错
+var ErrUnsupported = errors.New("当前实例不支持")
+
+// ServerFlavor 返回 ctx 所属实例的发行版，能力检测失败时按 MySQL 处理
+func ServerFlavor(ctx context.Context) Flavor {
+	caps, err := ServerCapabilities(ctx)
+	if err != nil {
+		return FlavorMySQL
+	}
+	return caps.Flavor
+}
+
+// parseFlavor 根据 VERSION() 与 @@version_comment 判断发行版：MariaDB 的版本号带 -MariaDB 后缀，
+// Percona Server 的 version_comment 含 Percona
+func parseFlavor(version, comment string) Flavor {
+	switch {
+	case strings.Contains(strings.ToLower(version), "mariadb"):
+		return FlavorMariaDB
+	case strings.Contains(strings.ToLower(comment), "percona"):
+		return FlavorPercona
+	default:
+		return FlavorMySQL
+	}
+}
+
+// unsupportedError 把表、库或列不存在的错误转换为 ErrUnsupported，what 说明缺少的对象
+func unsupportedError(err error, what string) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1049, 1054, 1146: // ER_BAD_DB_ERROR、ER_BAD_FIELD_ERROR、ER_NO_SUCH_TABLE
+			return fmt.Errorf("%w: %s", ErrUnsupported, what)
+		}
+	}
+	return err
+}
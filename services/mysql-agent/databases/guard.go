@@ -0,0 +1,215 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"mysql-agent/config"
+)
+
+// ErrStatementNotAllowed 工具执行了只读白名单之外的语句
+var ErrStatementNotAllowed = errors.New("只允许执行 SELECT、SHOW、EXPLAIN 语句")
+
+var (
+	// leadingCommentPattern 匹配语句开头的空白与注释
+	leadingCommentPattern = regexp.MustCompile(`^(?:\s+|/\*.*?\*/|(?:--|#)[^\n]*\n?)+`)
+	// lockingReadPattern 匹配会加锁或写文件的 SELECT 子句
+	lockingReadPattern = regexp.MustCompile(`(?i)\bFOR\s+(?:UPDATE|SHARE)\b|\bLOCK\s+IN\s+SHARE\s+MODE\b|\bINTO\s+(?:OUTFILE|DUMPFILE|@)`)
+	// explainPrefixPattern 匹配 EXPLAIN 及可选的 FORMAT=... 前缀
+	explainPrefixPattern = regexp.MustCompile(`(?i)^EXPLAIN\s+(?:FORMAT\s*=\s*\w+\s+)?`)
+	// explainAnalyzePattern EXPLAIN ANALYZE 会真正执行语句
+	explainAnalyzePattern = regexp.MustCompile(`(?i)^EXPLAIN\s+ANALYZE\b`)
+)
+
+// readOnlyKeywords 工具允许执行的语句类型
+var readOnlyKeywords = map[string]struct{}{
+	"SELECT":  {},
+	"SHOW":    {},
+	"EXPLAIN": {},
+}
+
+// explainTargetKeywords EXPLAIN 之后允许的语句类型，与 agent 的 explain_query 工具一致
+var explainTargetKeywords = map[string]struct{}{
+	"SELECT": {},
+	"WITH":   {},
+	"TABLE":  {},
+}
+
+// queryer 同时适用于 *sql.DB 与独占的 *sql.Conn
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// checkReadOnly 检查语句是否在只读白名单内，并拒绝加锁读与 SELECT ... INTO；
+// EXPLAIN 只允许后接 SELECT/WITH/TABLE，且拒绝会真正执行语句的 EXPLAIN ANALYZE。
+// DSN 未开启 multiStatements，多条语句会被驱动直接拒绝
+func checkReadOnly(query string) error {
+	stmt := strings.TrimSpace(leadingCommentPattern.ReplaceAllString(query, ""))
+	keyword := leadingKeyword(stmt)
+	if _, ok := readOnlyKeywords[keyword]; !ok {
+		return fmt.Errorf("%w: %s", ErrStatementNotAllowed, truncateSQL(stmt))
+	}
+
+	target := stmt
+	if keyword == "EXPLAIN" {
+		if explainAnalyzePattern.MatchString(stmt) {
+			return fmt.Errorf("%w: 不允许 EXPLAIN ANALYZE", ErrStatementNotAllowed)
+		}
+		target = strings.TrimSpace(explainPrefixPattern.ReplaceAllString(stmt, ""))
+		if _, ok := explainTargetKeywords[leadingKeyword(strings.TrimLeft(target, "( \t\r\n"))]; !ok {
+			return fmt.Errorf("%w: EXPLAIN 只允许 SELECT/WITH/TABLE 语句: %s", ErrStatementNotAllowed, truncateSQL(stmt))
+		}
+	} else if keyword != "SELECT" {
+		return nil
+	}
+	if lockingReadPattern.MatchString(target) {
+		return fmt.Errorf("%w: 不允许加锁读或 SELECT ... INTO", ErrStatementNotAllowed)
+	}
+	return nil
+}
+
+// leadingKeyword 返回语句的第一个关键字（大写）
+func leadingKeyword(stmt string) string {
+	if idx := strings.IndexFunc(stmt, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '(' }); idx >= 0 {
+		stmt = stmt[:idx]
+	}
+	return strings.ToUpper(stmt)
+}
+
+// guardedQuery 所有工具查询的统一入口，执行前校验语句只读
+func guardedQuery(ctx context.Context, q queryer, query string, args ...any) (*sql.Rows, error) {
+	if err := checkReadOnly(query); err != nil {
+		return nil, err
+	}
+	return q.QueryContext(ctx, query, args...)
+}
+
+// guardedQueryRow 单行查询的统一入口，执行前校验语句只读
+func guardedQueryRow(ctx context.Context, q queryer, query string, args []any, dest ...any) error {
+	if err := checkReadOnly(query); err != nil {
+		return err
+	}
+	return q.QueryRowContext(ctx, query, args...).Scan(dest...)
+}
+
+// useSchema 切换独占连接的默认库，USE 只影响当前会话，是白名单之外唯一允许的语句
+func useSchema(ctx context.Context, conn *sql.Conn, schema string) error {
+	_, err := conn.ExecContext(ctx, "USE `"+strings.ReplaceAll(schema, "`", "``")+"`")
+	return err
+}
+
+func truncateSQL(stmt string) string {
+	const limit = 64
+	if len(stmt) <= limit {
+		return stmt
+	}
+	return stmt[:limit] + "..."
+}
+
+// writePrivileges 会修改数据、结构或服务器状态的权限
+var writePrivileges = map[string]struct{}{
+	"ALL":                     {},
+	"ALL PRIVILEGES":          {},
+	"INSERT":                  {},
+	"UPDATE":                  {},
+	"DELETE":                  {},
+	"CREATE":                  {},
+	"DROP":                    {},
+	"ALTER":                   {},
+	"INDEX":                   {},
+	"REFERENCES":              {},
+	"CREATE VIEW":             {},
+	"CREATE ROUTINE":          {},
+	"ALTER ROUTINE":           {},
+	"EXECUTE":                 {},
+	"TRIGGER":                 {},
+	"EVENT":                   {},
+	"CREATE TEMPORARY TABLES": {},
+	"LOCK TABLES":             {},
+	"CREATE TABLESPACE":       {},
+	"CREATE USER":             {},
+	"CREATE ROLE":             {},
+	"DROP ROLE":               {},
+	"GRANT OPTION":            {},
+	"PROXY":                   {},
+	"FILE":                    {},
+	"SUPER":                   {},
+	"RELOAD":                  {},
+	"SHUTDOWN":                {},
+	"REPLICATION_SLAVE_ADMIN": {},
+	"SYSTEM_VARIABLES_ADMIN":  {},
+	"CONNECTION_ADMIN":        {},
+	"BINLOG_ADMIN":            {},
+	"ROLE_ADMIN":              {},
+	"SYSTEM_USER":             {},
+}
+
+var (
+	grantPrivilegePattern = regexp.MustCompile(`(?i)^GRANT\s+(.+?)\s+ON\s+(\S+)\s+TO\s`)
+	columnListPattern     = regexp.MustCompile(`\s*\([^)]*\)`)
+)
+
+// VerifyReadOnlyAccount 检查 ctx 所属实例上的账号 SHOW GRANTS 中不含写权限；
+// 授予了角色时无法确认角色内的权限，同样视为不满足
+func VerifyReadOnlyAccount(ctx context.Context) error {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return err
+	}
+	return verifyReadOnly(ctx, db)
+}
+
+// verifyReadOnlyConfig 在按 cfg 打开的临时连接池上检查账号是否只读，检查结束即关闭，不影响已登记的连接池
+func verifyReadOnlyConfig(ctx context.Context, cfg config.DatabaseConfig) error {
+	db, err := openPool(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return verifyReadOnly(ctx, db)
+}
+
+func verifyReadOnly(ctx context.Context, db *sql.DB) error {
+	rows, err := guardedQuery(ctx, db, "SHOW GRANTS")
+	if err != nil {
+		return fmt.Errorf("读取账号权限失败: %w", err)
+	}
+	defer rows.Close()
+
+	var violations []string
+	for rows.Next() {
+		var grant string
+		if err := rows.Scan(&grant); err != nil {
+			return err
+		}
+		m := grantPrivilegePattern.FindStringSubmatch(grant)
+		if m == nil {
+			if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(grant)), "GRANT ") {
+				violations = append(violations, "授予了角色，无法确认角色权限: "+grant)
+			}
+			continue
+		}
+		for _, priv := range strings.Split(columnListPattern.ReplaceAllString(m[1], ""), ",") {
+			priv = strings.ToUpper(strings.TrimSpace(priv))
+			if _, ok := writePrivileges[priv]; ok {
+				violations = append(violations, fmt.Sprintf("%s ON %s", priv, m[2]))
+			}
+		}
+		if strings.Contains(strings.ToUpper(grant), "WITH GRANT OPTION") {
+			violations = append(violations, "GRANT OPTION ON "+m[2])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("agent 账号具有写权限: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package databases
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		ok    bool
+	}{
+		{"select", "SELECT 1", true},
+		{"show", "SHOW GLOBAL STATUS", true},
+		{"leading comment", "/* tool */ SELECT * FROM t", true},
+		{"explain select", "EXPLAIN SELECT * FROM t", true},
+		{"explain format json", "EXPLAIN FORMAT=JSON SELECT * FROM t WHERE id = 1", true},
+		{"explain with", "EXPLAIN FORMAT = TREE WITH c AS (SELECT 1) SELECT * FROM c", true},
+		{"explain parenthesized union", "EXPLAIN (SELECT 1) UNION (SELECT 2)", true},
+		{"update", "UPDATE t SET a = 1", false},
+		{"select for update", "SELECT * FROM t FOR UPDATE", false},
+		{"select into outfile", "SELECT * FROM t INTO OUTFILE '/tmp/x'", false},
+		{"explain analyze update", "EXPLAIN ANALYZE UPDATE t1 JOIN t2 ON t1.id = t2.id SET t1.a = 1", false},
+		{"explain analyze select for update", "EXPLAIN ANALYZE SELECT * FROM t FOR UPDATE", false},
+		{"explain analyze select", "explain analyze select * from t", false},
+		{"explain update", "EXPLAIN UPDATE t SET a = 1", false},
+		{"explain format delete", "EXPLAIN FORMAT=JSON DELETE FROM t", false},
+		{"explain select for update", "EXPLAIN SELECT * FROM t FOR UPDATE", false},
+		{"explain select lock in share mode", "EXPLAIN FORMAT=TREE SELECT * FROM t LOCK IN SHARE MODE", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkReadOnly(c.query)
+			if c.ok && err != nil {
+				t.Fatalf("checkReadOnly(%q) = %v, want nil", c.query, err)
+			}
+			if !c.ok && !errors.Is(err, ErrStatementNotAllowed) {
+				t.Fatalf("checkReadOnly(%q) = %v, want ErrStatementNotAllowed", c.query, err)
+			}
+		})
+	}
+}
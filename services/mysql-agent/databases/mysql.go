@@ -3,84 +3,63 @@ package databases
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"strings"
-	"sync"
 
 	mysql "github.com/go-sql-driver/mysql"
-
-	"mysql-agent/config"
-)
-
-var (
-	dbInstance *sql.DB
-	dbMu       sync.RWMutex
 )
 
-func InitDB() error {
-	dbMu.Lock()
-	defer dbMu.Unlock()
-	if dbInstance != nil {
-		return nil
-	}
-
-	dsn := config.AppConfig.GetDSN()
-	conn, err := sql.Open("mysql", dsn)
+// Ping 检查数据库连通性并返回服务端版本
+func Ping(ctx context.Context) (string, error) {
+	db, err := GetDB(ctx)
 	if err != nil {
-		return fmt.Errorf("打开mysql失败: %w", err)
-	}
-
-	if config.AppConfig.Database.MaxIdleConns > 0 {
-		conn.SetMaxIdleConns(config.AppConfig.Database.MaxIdleConns)
+		return "", err
 	}
-	if config.AppConfig.Database.MaxOpenConns > 0 {
-		conn.SetMaxOpenConns(config.AppConfig.Database.MaxOpenConns)
+	if err := db.PingContext(ctx); err != nil {
+		return "", err
 	}
-	if config.AppConfig.Database.ConnMaxLifetime > 0 {
-		conn.SetConnMaxLifetime(config.AppConfig.Database.ConnMaxLifetime)
+	var version string
+	if err := guardedQueryRow(ctx, db, "SELECT VERSION()", nil, &version); err != nil {
+		return "", err
 	}
-
-	if err := conn.Ping(); err != nil {
-		_ = conn.Close()
-		return fmt.Errorf("尝试ping数据库失败: %w", err)
-	}
-
-	dbInstance = conn
-	return nil
+	return version, nil
 }
 
-func GetDB() (*sql.DB, error) {
-	dbMu.RLock()
-	defer dbMu.RUnlock()
-	if dbInstance == nil {
-		return nil, fmt.Errorf("数据库未初始化")
+func QueryProcessList(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return dbInstance, nil
-}
 
-func CloseDB() error {
-	dbMu.Lock()
-	defer dbMu.Unlock()
-	if dbInstance == nil {
-		return nil
-	}
-	err := dbInstance.Close()
-	dbInstance = nil
-	return err
+	return queryWithFallback(ctx, db, "SHOW FULL PROCESSLIST", "SHOW PROCESSLIST", shouldFallback)
 }
 
-func QueryProcessList(ctx context.Context) ([]map[string]any, error) {
-	db, err := GetDB()
+// QueryConnectionStates 查询 information_schema.processlist 中的客户端连接并关联 innodb_trx，
+// 排除后台线程、复制 dump 线程以及当前连接；未开启事务的连接 TRX_AGE 为 -1
+func QueryConnectionStates(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return queryWithFallback(ctx, db, "SHOW FULL PROCESSLIST", "SHOW PROCESSLIST", shouldFallback)
+	query := `SELECT p.ID, p.USER, SUBSTRING_INDEX(p.HOST, ':', 1) AS HOST, COALESCE(p.DB, '') AS DB, p.COMMAND, p.TIME,
+	COALESCE(p.STATE, '') AS STATE, COALESCE(LEFT(p.INFO, 512), '') AS INFO,
+	COALESCE(CAST(t.trx_started AS CHAR), '') AS TRX_STARTED,
+	COALESCE(TIMESTAMPDIFF(SECOND, t.trx_started, NOW()), -1) AS TRX_AGE,
+	COALESCE(t.trx_rows_modified, 0) AS TRX_ROWS_MODIFIED,
+	COALESCE(t.trx_rows_locked, 0) AS TRX_ROWS_LOCKED
+FROM information_schema.processlist p
+LEFT JOIN information_schema.innodb_trx t ON t.trx_mysql_thread_id = p.ID
+WHERE p.ID <> CONNECTION_ID() AND p.USER NOT IN ('system user', 'event_scheduler')
+	AND p.COMMAND NOT IN ('Daemon', 'Binlog Dump', 'Binlog Dump GTID')`
+
+	return querySimple(ctx, db, query)
 }
 
 func QueryInnoDBStatus(ctx context.Context) ([]map[string]any, error) {
-	db, err := GetDB()
+	db, err := GetDB(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +68,7 @@ func QueryInnoDBStatus(ctx context.Context) ([]map[string]any, error) {
 }
 
 func QueryGlobalStatus(ctx context.Context) ([]map[string]any, error) {
-	db, err := GetDB()
+	db, err := GetDB(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +77,7 @@ func QueryGlobalStatus(ctx context.Context) ([]map[string]any, error) {
 }
 
 func QueryInnoDBTrx(ctx context.Context, limit int) ([]map[string]any, error) {
-	db, err := GetDB()
+	db, err := GetDB(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +93,7 @@ func QueryInnoDBTrx(ctx context.Context, limit int) ([]map[string]any, error) {
 }
 
 func QueryInnoDBMutex(ctx context.Context) ([]map[string]any, error) {
-	db, err := GetDB()
+	db, err := GetDB(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -122,8 +101,17 @@ func QueryInnoDBMutex(ctx context.Context) ([]map[string]any, error) {
 	return querySimple(ctx, db, "SHOW ENGINE INNODB MUTEX")
 }
 
-func QuerySlowQueries(ctx context.Context, limit int) ([]map[string]any, error) {
-	db, err := GetDB()
+// slowQuerySortColumns 慢查询支持的排序方式 -> 排序列
+var slowQuerySortColumns = map[string]string{
+	"total_latency": "SUM_TIMER_WAIT",
+	"rows_examined": "SUM_ROWS_EXAMINED",
+	"errors":        "SUM_ERRORS",
+}
+
+// QuerySlowQueries 按 sortBy(total_latency/rows_examined/errors，默认 total_latency)排序返回 digest 统计，
+// schema 非空时只统计该库的语句
+func QuerySlowQueries(ctx context.Context, schema, sortBy string, limit, offset int) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -131,24 +119,39 @@ func QuerySlowQueries(ctx context.Context, limit int) ([]map[string]any, error)
 	if limit <= 0 {
 		limit = 20
 	}
+	if offset < 0 {
+		return nil, fmt.Errorf("offset 不能为负数: %d", offset)
+	}
+	if sortBy == "" {
+		sortBy = "total_latency"
+	}
+	orderColumn, ok := slowQuerySortColumns[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("不支持的排序方式: %s，可选 total_latency、rows_examined、errors", sortBy)
+	}
 
 	query := `SELECT DIGEST_TEXT, SCHEMA_NAME, COUNT_STAR, SUM_TIMER_WAIT, AVG_TIMER_WAIT, SUM_ERRORS, SUM_WARNINGS, SUM_ROWS_AFFECTED, SUM_ROWS_SENT, SUM_ROWS_EXAMINED, FIRST_SEEN, LAST_SEEN` +
 		" FROM performance_schema.events_statements_summary_by_digest\n" +
-		"WHERE DIGEST_TEXT IS NOT NULL\n" +
-		"ORDER BY SUM_TIMER_WAIT DESC\n" +
-		"LIMIT ?"
+		"WHERE DIGEST_TEXT IS NOT NULL\n"
+	var args []any
+	if schema != "" {
+		query += "AND SCHEMA_NAME = ?\n"
+		args = append(args, schema)
+	}
+	query += "ORDER BY " + orderColumn + " DESC\n" +
+		"LIMIT ?, ?"
+	args = append(args, offset, limit)
 
-	return querySimple(ctx, db, query, limit)
+	rows, err := querySimple(ctx, db, query, args...)
+	return rows, unsupportedError(err, "performance_schema.events_statements_summary_by_digest 不存在")
 }
 
-func QuerySchemaStats(ctx context.Context, schema string, limit int) ([]map[string]any, error) {
-	db, err := GetDB()
-	if err != nil {
-		return nil, err
+func QuerySchemaStats(ctx context.Context, schema string, limit, offset int) ([]map[string]any, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("offset 不能为负数: %d", offset)
 	}
-
 	if strings.TrimSpace(schema) == "" {
-		schema = config.AppConfig.Database.DBName
+		schema = instanceSchema(ctx)
 	}
 
 	query := `SELECT TABLE_SCHEMA, TABLE_NAME, ENGINE, TABLE_ROWS, DATA_LENGTH, INDEX_LENGTH, DATA_LENGTH + INDEX_LENGTH AS TOTAL_LENGTH, AUTO_INCREMENT, UPDATE_TIME` +
@@ -157,21 +160,456 @@ func QuerySchemaStats(ctx context.Context, schema string, limit int) ([]map[stri
 		"ORDER BY TOTAL_LENGTH DESC"
 
 	args := []any{schema}
-	if limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, limit)
+	switch {
+	case limit > 0:
+		query += " LIMIT ?, ?"
+		args = append(args, offset, limit)
+	case offset > 0:
+		// MySQL 不支持单独的 OFFSET，用最大值表示不限行数
+		query += " LIMIT ?, 18446744073709551615"
+		args = append(args, offset)
 	}
 
-	return querySimple(ctx, db, query, args...)
+	return queryReplica(ctx, query, args...)
+}
+
+// QueryExplainJSON 在指定库下执行 EXPLAIN FORMAT=JSON 并返回 JSON 文本，schema 为空时使用所属实例配置中的库；
+// USE 只对单个连接生效，执行后丢弃该连接，避免默认库残留在连接池中
+func QueryExplainJSON(ctx context.Context, schema, stmt string) (string, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(schema) == "" {
+		schema = instanceSchema(ctx)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		_ = conn.Close()
+	}()
+
+	if err := useSchema(ctx, conn, schema); err != nil {
+		return "", fmt.Errorf("切换数据库 %s 失败: %w", schema, err)
+	}
+
+	var plan string
+	if err := guardedQueryRow(ctx, conn, "EXPLAIN FORMAT=JSON "+stmt, nil, &plan); err != nil {
+		return "", err
+	}
+	return plan, nil
+}
+
+// QueryTableFragmentation 查询指定库中 DATA_FREE 大于 0 的表，按 DATA_FREE 降序
+func QueryTableFragmentation(ctx context.Context, schema string) ([]map[string]any, error) {
+	if strings.TrimSpace(schema) == "" {
+		schema = instanceSchema(ctx)
+	}
+
+	query := "SELECT TABLE_SCHEMA, TABLE_NAME, ENGINE, DATA_LENGTH, INDEX_LENGTH, DATA_FREE" +
+		" FROM information_schema.tables\n" +
+		"WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' AND DATA_FREE > 0\n" +
+		"ORDER BY DATA_FREE DESC"
+
+	return queryReplica(ctx, query, schema)
+}
+
+// QueryCharsetSettings 查询库的默认字符集与排序规则，以及其下基础表的排序规则与对应字符集
+func QueryCharsetSettings(ctx context.Context, schema string) ([]map[string]any, []map[string]any, error) {
+	schemaRows, err := queryReplica(ctx, "SELECT SCHEMA_NAME, DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.schemata WHERE SCHEMA_NAME = ?", schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := "SELECT t.TABLE_NAME, t.TABLE_COLLATION, c.CHARACTER_SET_NAME\n" +
+		"FROM information_schema.tables t\n" +
+		"JOIN information_schema.collation_character_set_applicability c ON c.COLLATION_NAME = t.TABLE_COLLATION\n" +
+		"WHERE t.TABLE_SCHEMA = ? AND t.TABLE_TYPE = 'BASE TABLE'"
+	tableRows, err := queryReplica(ctx, query, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schemaRows, tableRows, nil
+}
+
+// QueryColumnCharsets 查询基础表中字符类型列的字符集与排序规则，INDEXED 表示该列出现在任意索引中
+func QueryColumnCharsets(ctx context.Context, schema string) ([]map[string]any, error) {
+	query := "SELECT c.TABLE_NAME, c.COLUMN_NAME, c.CHARACTER_SET_NAME, c.COLLATION_NAME,\n" +
+		"	EXISTS (SELECT 1 FROM information_schema.statistics s WHERE s.TABLE_SCHEMA = c.TABLE_SCHEMA AND s.TABLE_NAME = c.TABLE_NAME AND s.COLUMN_NAME = c.COLUMN_NAME) AS INDEXED\n" +
+		"FROM information_schema.columns c\n" +
+		"JOIN information_schema.tables t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME AND t.TABLE_TYPE = 'BASE TABLE'\n" +
+		"WHERE c.TABLE_SCHEMA = ? AND c.COLLATION_NAME IS NOT NULL\n" +
+		"ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION"
+
+	return queryReplica(ctx, query, schema)
+}
+
+// QueryForeignKeyCollations 查询库中外键列与被引用列的排序规则，用于发现关联列排序规则不一致
+func QueryForeignKeyCollations(ctx context.Context, schema string) ([]map[string]any, error) {
+	query := "SELECT k.CONSTRAINT_NAME, k.TABLE_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_SCHEMA, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME,\n" +
+		"	c.COLLATION_NAME, rc.COLLATION_NAME AS REFERENCED_COLLATION_NAME\n" +
+		"FROM information_schema.key_column_usage k\n" +
+		"JOIN information_schema.columns c ON c.TABLE_SCHEMA = k.TABLE_SCHEMA AND c.TABLE_NAME = k.TABLE_NAME AND c.COLUMN_NAME = k.COLUMN_NAME\n" +
+		"JOIN information_schema.columns rc ON rc.TABLE_SCHEMA = k.REFERENCED_TABLE_SCHEMA AND rc.TABLE_NAME = k.REFERENCED_TABLE_NAME AND rc.COLUMN_NAME = k.REFERENCED_COLUMN_NAME\n" +
+		"WHERE k.TABLE_SCHEMA = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL AND c.COLLATION_NAME IS NOT NULL"
+
+	return queryReplica(ctx, query, schema)
+}
+
+// QueryForeignKeys 查询库中定义的外键以及其他库引用该库的外键，每列一行；
+// REFERENCED_EXISTS 为 0 表示被引用的表或列已不存在(常见于关闭 foreign_key_checks 后删表)
+func QueryForeignKeys(ctx context.Context, schema string) ([]map[string]any, error) {
+	query := "SELECT rc.CONSTRAINT_SCHEMA, rc.CONSTRAINT_NAME, rc.TABLE_NAME, rc.UNIQUE_CONSTRAINT_SCHEMA AS REFERENCED_TABLE_SCHEMA, rc.REFERENCED_TABLE_NAME,\n" +
+		"	rc.UPDATE_RULE, rc.DELETE_RULE, k.COLUMN_NAME, COALESCE(k.REFERENCED_COLUMN_NAME, '') AS REFERENCED_COLUMN_NAME,\n" +
+		"	EXISTS (SELECT 1 FROM information_schema.columns c WHERE c.TABLE_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA AND c.TABLE_NAME = rc.REFERENCED_TABLE_NAME AND c.COLUMN_NAME = k.REFERENCED_COLUMN_NAME) AS REFERENCED_EXISTS\n" +
+		"FROM information_schema.referential_constraints rc\n" +
+		"JOIN information_schema.key_column_usage k ON k.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA AND k.CONSTRAINT_NAME = rc.CONSTRAINT_NAME AND k.TABLE_NAME = rc.TABLE_NAME\n" +
+		"WHERE rc.CONSTRAINT_SCHEMA = ? OR rc.UNIQUE_CONSTRAINT_SCHEMA = ?\n" +
+		"ORDER BY rc.CONSTRAINT_SCHEMA, rc.TABLE_NAME, rc.CONSTRAINT_NAME, k.ORDINAL_POSITION"
+
+	return queryReplica(ctx, query, schema, schema)
+}
+
+// QueryBaseTables 列出库中的基础表名
+func QueryBaseTables(ctx context.Context, schema string) ([]map[string]any, error) {
+	return queryReplica(ctx, "SELECT TABLE_NAME FROM information_schema.tables WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME", schema)
+}
+
+// QueryPartitions 查询库中分区表的各分区(子分区汇总到所属分区)，按表与分区序号排序
+func QueryPartitions(ctx context.Context, schema string) ([]map[string]any, error) {
+	query := "SELECT TABLE_NAME, PARTITION_NAME, PARTITION_ORDINAL_POSITION, PARTITION_METHOD,\n" +
+		"	COALESCE(PARTITION_EXPRESSION, '') AS PARTITION_EXPRESSION, COALESCE(PARTITION_DESCRIPTION, '') AS PARTITION_DESCRIPTION,\n" +
+		"	SUM(TABLE_ROWS) AS TABLE_ROWS, SUM(DATA_LENGTH) AS DATA_LENGTH, SUM(INDEX_LENGTH) AS INDEX_LENGTH\n" +
+		"FROM information_schema.partitions\n" +
+		"WHERE TABLE_SCHEMA = ? AND PARTITION_NAME IS NOT NULL\n" +
+		"GROUP BY TABLE_NAME, PARTITION_NAME, PARTITION_ORDINAL_POSITION, PARTITION_METHOD, PARTITION_EXPRESSION, PARTITION_DESCRIPTION\n" +
+		"ORDER BY TABLE_NAME, PARTITION_ORDINAL_POSITION"
+
+	return queryReplica(ctx, query, schema)
+}
+
+// QueryTableStatistics 关联 information_schema.tables、mysql.innodb_table_stats 与
+// performance_schema.table_io_waits_summary_by_table，返回 InnoDB 表的持久化统计信息时间与启动以来的写入行数；
+// 分区表的统计信息按分区存储，不在此列出
+func QueryTableStatistics(ctx context.Context, schema string) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT t.TABLE_NAME, COALESCE(t.TABLE_ROWS, 0) AS TABLE_ROWS,
+	COALESCE(CAST(t.UPDATE_TIME AS CHAR), '') AS UPDATE_TIME,
+	COALESCE(CAST(s.last_update AS CHAR), '') AS STATS_LAST_UPDATE,
+	COALESCE(s.n_rows, -1) AS STATS_ROWS,
+	COALESCE(TIMESTAMPDIFF(SECOND, s.last_update, NOW()), -1) AS STATS_AGE,
+	COALESCE(TIMESTAMPDIFF(SECOND, s.last_update, t.UPDATE_TIME), 0) AS MODIFIED_AFTER_STATS,
+	COALESCE(io.COUNT_INSERT + io.COUNT_UPDATE + io.COUNT_DELETE, 0) AS CHANGED_ROWS
+FROM information_schema.tables t
+LEFT JOIN mysql.innodb_table_stats s ON s.database_name = t.TABLE_SCHEMA AND s.table_name = t.TABLE_NAME
+LEFT JOIN performance_schema.table_io_waits_summary_by_table io ON io.OBJECT_SCHEMA = t.TABLE_SCHEMA AND io.OBJECT_NAME = t.TABLE_NAME
+WHERE t.TABLE_SCHEMA = ? AND t.TABLE_TYPE = 'BASE TABLE' AND t.ENGINE = 'InnoDB'
+	AND COALESCE(t.CREATE_OPTIONS, '') NOT LIKE '%partitioned%'
+ORDER BY t.TABLE_NAME`
+
+	return querySimple(ctx, db, query, schema)
+}
+
+// systemSchemaFilter 排除系统库的条件
+const systemSchemaFilter = "TABLE_SCHEMA NOT IN ('mysql', 'sys', 'information_schema', 'performance_schema')"
+
+// QueryDiskUsageBySchemaEngine 按库与存储引擎汇总表数量及数据、索引、DATA_FREE 大小
+func QueryDiskUsageBySchemaEngine(ctx context.Context, includeSystem bool) ([]map[string]any, error) {
+	query := "SELECT TABLE_SCHEMA, COALESCE(ENGINE, '') AS ENGINE, COUNT(*) AS TABLE_COUNT, SUM(DATA_LENGTH) AS DATA_LENGTH, SUM(INDEX_LENGTH) AS INDEX_LENGTH, SUM(DATA_FREE) AS DATA_FREE" +
+		" FROM information_schema.tables\n" +
+		"WHERE TABLE_TYPE = 'BASE TABLE'"
+	if !includeSystem {
+		query += " AND " + systemSchemaFilter
+	}
+	query += "\nGROUP BY TABLE_SCHEMA, ENGINE"
+
+	return queryReplica(ctx, query)
+}
+
+// QueryLargestTables 返回所有库中数据与索引总大小最大的 limit 张表
+func QueryLargestTables(ctx context.Context, includeSystem bool, limit int) ([]map[string]any, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	query := "SELECT TABLE_SCHEMA, TABLE_NAME, ENGINE, TABLE_ROWS, DATA_LENGTH, INDEX_LENGTH, DATA_FREE, DATA_LENGTH + INDEX_LENGTH AS TOTAL_LENGTH" +
+		" FROM information_schema.tables\n" +
+		"WHERE TABLE_TYPE = 'BASE TABLE'"
+	if !includeSystem {
+		query += " AND " + systemSchemaFilter
+	}
+	query += "\nORDER BY TOTAL_LENGTH DESC\n" +
+		"LIMIT ?"
+
+	return queryReplica(ctx, query, limit)
+}
+
+// QueryBufferPoolStats 查询 information_schema.innodb_buffer_pool_stats，每个缓冲池实例一行
+func QueryBufferPoolStats(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return querySimple(ctx, db, "SELECT POOL_ID, POOL_SIZE, FREE_BUFFERS, DATABASE_PAGES, MODIFIED_DATABASE_PAGES, HIT_RATE, PAGES_MADE_YOUNG, PAGES_NOT_MADE_YOUNG FROM information_schema.innodb_buffer_pool_stats ORDER BY POOL_ID")
+}
+
+// QueryBinlogStatus 执行 SHOW BINARY LOG STATUS(MySQL 8.2+)，旧版本回退到 SHOW MASTER STATUS，MariaDB 直接使用 SHOW MASTER STATUS
+func QueryBinlogStatus(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if ServerFlavor(ctx) == FlavorMariaDB {
+		return querySimple(ctx, db, "SHOW MASTER STATUS")
+	}
+	return queryWithFallback(ctx, db, "SHOW BINARY LOG STATUS", "SHOW MASTER STATUS", shouldFallbackInnoDBSyntax)
+}
+
+// QueryBinaryLogs 执行 SHOW BINARY LOGS，未开启 binlog 时返回错误
+func QueryBinaryLogs(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return querySimple(ctx, db, "SHOW BINARY LOGS")
+}
+
+// QueryHostCacheErrors 查询 performance_schema.host_cache 中有连接错误的主机，按错误数降序
+func QueryHostCacheErrors(ctx context.Context, limit int) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := "SELECT IP, COALESCE(HOST, '') AS HOST, SUM_CONNECT_ERRORS, COUNT_HOST_BLOCKED_ERRORS, COUNT_HANDSHAKE_ERRORS, COUNT_AUTHENTICATION_ERRORS, COUNT_SSL_ERRORS," +
+		" COUNT_MAX_USER_CONNECTIONS_ERRORS, COUNT_DEFAULT_DATABASE_ERRORS, COUNT_LOCAL_ERRORS, COUNT_UNKNOWN_ERRORS, FIRST_ERROR_SEEN, LAST_ERROR_SEEN" +
+		" FROM performance_schema.host_cache\n" +
+		"WHERE SUM_CONNECT_ERRORS > 0 OR COUNT_HOST_BLOCKED_ERRORS > 0 OR COUNT_AUTHENTICATION_ERRORS > 0 OR COUNT_MAX_USER_CONNECTIONS_ERRORS > 0\n" +
+		"ORDER BY SUM_CONNECT_ERRORS + COUNT_AUTHENTICATION_ERRORS DESC\n" +
+		"LIMIT ?"
+
+	rows, err := querySimple(ctx, db, query, limit)
+	return rows, unsupportedError(err, "performance_schema.host_cache 不存在")
+}
+
+// QueryMetadataLocks 查询 performance_schema.metadata_locks 并关联线程与 InnoDB 事务，排除当前连接自身的锁
+func QueryMetadataLocks(ctx context.Context, schema string) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT ml.OBJECT_TYPE, COALESCE(ml.OBJECT_SCHEMA, '') AS OBJECT_SCHEMA, COALESCE(ml.OBJECT_NAME, '') AS OBJECT_NAME, ml.LOCK_TYPE, ml.LOCK_DURATION, ml.LOCK_STATUS," +
+		" COALESCE(CAST(t.PROCESSLIST_ID AS CHAR), '') AS PROCESSLIST_ID, COALESCE(t.PROCESSLIST_USER, '') AS PROCESSLIST_USER, COALESCE(t.PROCESSLIST_HOST, '') AS PROCESSLIST_HOST," +
+		" COALESCE(t.PROCESSLIST_COMMAND, '') AS PROCESSLIST_COMMAND, COALESCE(t.PROCESSLIST_TIME, 0) AS PROCESSLIST_TIME," +
+		" COALESCE(t.PROCESSLIST_STATE, '') AS PROCESSLIST_STATE, COALESCE(LEFT(t.PROCESSLIST_INFO, 512), '') AS PROCESSLIST_INFO," +
+		" COALESCE(trx.trx_started, '') AS TRX_STARTED" +
+		" FROM performance_schema.metadata_locks ml\n" +
+		"JOIN performance_schema.threads t ON t.THREAD_ID = ml.OWNER_THREAD_ID\n" +
+		"LEFT JOIN information_schema.innodb_trx trx ON trx.trx_mysql_thread_id = t.PROCESSLIST_ID\n" +
+		"WHERE ml.OBJECT_TYPE IN ('TABLE', 'SCHEMA', 'FUNCTION', 'PROCEDURE', 'TRIGGER', 'EVENT')" +
+		" AND COALESCE(ml.OBJECT_SCHEMA, '') NOT IN ('performance_schema', 'mysql')" +
+		" AND (t.PROCESSLIST_ID IS NULL OR t.PROCESSLIST_ID <> CONNECTION_ID())"
+	var args []any
+	if strings.TrimSpace(schema) != "" {
+		query += " AND ml.OBJECT_SCHEMA = ?"
+		args = append(args, schema)
+	}
+	query += "\nORDER BY ml.OBJECT_SCHEMA, ml.OBJECT_NAME, ml.LOCK_STATUS, t.PROCESSLIST_TIME DESC"
+
+	rows, err := querySimple(ctx, db, query, args...)
+	return rows, unsupportedError(err, "performance_schema.metadata_locks 不存在(MariaDB 10.5 之前的版本)")
+}
+
+// QueryMDLInstrumentEnabled 判断 metadata lock 的 performance_schema instrument 是否开启(5.7 默认关闭)
+func QueryMDLInstrumentEnabled(ctx context.Context) (bool, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var enabled string
+	err = guardedQueryRow(ctx, db, "SELECT ENABLED FROM performance_schema.setup_instruments WHERE NAME = 'wait/lock/metadata/sql/mdl'", nil, &enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(enabled, "YES"), nil
+}
+
+// QueryWaitEvents 查询 performance_schema.events_waits_summary_global_by_event_name 中累计耗时不为 0 的等待事件，
+// 与 sys.x$waits_global_by_latency 口径一致(排除 idle)，耗时单位为皮秒
+func QueryWaitEvents(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT EVENT_NAME AS event_name, COUNT_STAR AS count_star, SUM_TIMER_WAIT AS sum_timer_wait,
+	AVG_TIMER_WAIT AS avg_timer_wait, MAX_TIMER_WAIT AS max_timer_wait
+FROM performance_schema.events_waits_summary_global_by_event_name
+WHERE EVENT_NAME <> 'idle' AND SUM_TIMER_WAIT > 0
+ORDER BY SUM_TIMER_WAIT DESC`
+
+	return querySimple(ctx, db, query)
+}
+
+// QueryEnabledWaitInstruments 统计 setup_instruments 中各等待类别开启计时的 instrument 数量
+func QueryEnabledWaitInstruments(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT SUBSTRING_INDEX(NAME, '/', 3) AS wait_class, COUNT(*) AS total,
+	SUM(ENABLED = 'YES' AND TIMED = 'YES') AS enabled
+FROM performance_schema.setup_instruments
+WHERE NAME LIKE 'wait/%'
+GROUP BY wait_class`
+
+	return querySimple(ctx, db, query)
+}
+
+// sysSummaryOrderColumns sys 汇总视图支持的排序方式 -> 排序列
+var sysSummaryOrderColumns = map[string]string{
+	"statement_latency":   "statement_latency",
+	"statements":          "statements",
+	"file_io_latency":     "file_io_latency",
+	"current_connections": "current_connections",
+}
+
+// QueryHostSummary 查询 sys.x$host_summary(原始数值版本)，按 orderBy 降序
+func QueryHostSummary(ctx context.Context, orderBy string, limit int) ([]map[string]any, error) {
+	return querySysSummary(ctx, "sys.`x$host_summary`", orderBy, limit)
+}
+
+// QueryUserSummary 查询 sys.x$user_summary(原始数值版本)，按 orderBy 降序
+func QueryUserSummary(ctx context.Context, orderBy string, limit int) ([]map[string]any, error) {
+	return querySysSummary(ctx, "sys.`x$user_summary`", orderBy, limit)
+}
+
+func querySysSummary(ctx context.Context, view, orderBy string, limit int) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if orderBy == "" {
+		orderBy = "statement_latency"
+	}
+	column, ok := sysSummaryOrderColumns[orderBy]
+	if !ok {
+		return nil, fmt.Errorf("不支持的排序方式: %s，可选 statement_latency、statements、file_io_latency、current_connections", orderBy)
+	}
+
+	query := "SELECT * FROM " + view + "\n" +
+		"ORDER BY " + column + " DESC\n" +
+		"LIMIT ?"
+
+	rows, err := querySimple(ctx, db, query, limit)
+	return rows, unsupportedError(err, "sys schema 不存在(MariaDB 10.6 之前的版本或未安装 sys)")
+}
+
+// QueryHistoryListLength 从 information_schema.innodb_metrics 读取 trx_rseg_history_len，
+// 计数器未开启时返回 ok=false，由调用方回退到解析 InnoDB 状态
+func QueryHistoryListLength(ctx context.Context) (int64, bool, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var count int64
+	var status string
+	err = guardedQueryRow(ctx, db, "SELECT COUNT, STATUS FROM information_schema.innodb_metrics WHERE NAME = 'trx_rseg_history_len'", nil, &count, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return count, strings.EqualFold(status, "enabled"), nil
+}
+
+// QueryOldestTransaction 返回开始时间最早的 InnoDB 事务，没有活跃事务时返回空
+func QueryOldestTransaction(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT trx_id, trx_state, trx_started, TIMESTAMPDIFF(SECOND, trx_started, NOW()) AS age_seconds, trx_mysql_thread_id," +
+		" trx_rows_modified, trx_isolation_level, COALESCE(LEFT(trx_query, 512), '') AS trx_query" +
+		" FROM information_schema.innodb_trx\n" +
+		"ORDER BY trx_started\n" +
+		"LIMIT 1"
+
+	return querySimple(ctx, db, query)
+}
+
+// QueryReplicas 执行 SHOW REPLICAS 列出已连接的从库，MySQL 8.0.22 之前回退到 SHOW SLAVE HOSTS，MariaDB 直接使用 SHOW SLAVE HOSTS
+func QueryReplicas(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if ServerFlavor(ctx) == FlavorMariaDB {
+		return querySimple(ctx, db, "SHOW SLAVE HOSTS")
+	}
+	return queryWithFallback(ctx, db, "SHOW REPLICAS", "SHOW SLAVE HOSTS", shouldFallbackInnoDBSyntax)
+}
+
+// QueryReplicaStatus 执行 SHOW REPLICA STATUS，MySQL 8.0.22 之前的版本不支持该语法时回退到 SHOW SLAVE STATUS；
+// MariaDB 使用 SHOW ALL SLAVES STATUS 列出全部多源复制连接，连接名 Connection_name 同时写入 Channel_Name
+func QueryReplicaStatus(ctx context.Context) ([]map[string]any, error) {
+	db, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if ServerFlavor(ctx) == FlavorMariaDB {
+		rows, err := querySimple(ctx, db, "SHOW ALL SLAVES STATUS")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if _, ok := row["Channel_Name"]; !ok {
+				row["Channel_Name"] = row["Connection_name"]
+			}
+		}
+		return rows, nil
+	}
+	return queryWithFallback(ctx, db, "SHOW REPLICA STATUS", "SHOW SLAVE STATUS", shouldFallbackInnoDBSyntax)
 }
 
 func QueryGlobalVariables(ctx context.Context) (map[string]string, error) {
-	db, err := GetDB()
+	db, err := GetDB(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := db.QueryContext(ctx, "SHOW VARIABLES")
+	rows, err := guardedQuery(ctx, db, "SHOW VARIABLES")
 	if err != nil {
 		return nil, err
 	}
@@ -219,12 +657,12 @@ func shouldFallbackInnoDBSyntax(err error) bool {
 }
 
 func queryWithFallback(ctx context.Context, db *sql.DB, primary, fallback string, fallbackCond func(error) bool) ([]map[string]any, error) {
-	rows, err := db.QueryContext(ctx, primary)
+	rows, err := guardedQuery(ctx, db, primary)
 	if err != nil {
 		if fallback == "" || fallbackCond == nil || !fallbackCond(err) {
 			return nil, err
 		}
-		rows, err = db.QueryContext(ctx, fallback)
+		rows, err = guardedQuery(ctx, db, fallback)
 		if err != nil {
 			return nil, err
 		}
@@ -235,7 +673,7 @@ func queryWithFallback(ctx context.Context, db *sql.DB, primary, fallback string
 }
 
 func querySimple(ctx context.Context, db *sql.DB, query string, args ...any) ([]map[string]any, error) {
-	rows, err := db.QueryContext(ctx, query, args...)
+	rows, err := guardedQuery(ctx, db, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,401 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mysql-agent/config"
+)
+
+// poolPingTimeout 后台健康检查单次 ping 的超时
+const poolPingTimeout = 5 * time.Second
+
+// ErrUnknownInstance 请求的 instance_id 不在配置中
+var ErrUnknownInstance = errors.New("未知的实例")
+
+// ErrInstanceHostNotAllowed 登记实例的主机不在 agent.instance_host_allowlist 中
+var ErrInstanceHostNotAllowed = errors.New("实例主机不在允许登记的列表中")
+
+// instancePool 一个实例的连接池及其最近一次健康检查结果
+type instancePool struct {
+	db       *sql.DB
+	cfg      config.DatabaseConfig
+	lastUsed time.Time
+	// healthy 最近一次后台 ping 是否成功，尚未检查时为 true
+	healthy bool
+	lastErr string
+	// caps 实例能力，default 在 InitDB 时检测，其余实例在首次调用 ServerCapabilities 时检测
+	caps *Capabilities
+	// replicas 按 replica_dsns 顺序排列的从库，见 queryReplica
+	replicas []*replicaPool
+}
+
+// poolManager 按实例ID管理连接池：首次使用时打开，后台定期 ping 并关闭空闲超时的连接池
+type poolManager struct {
+	mu    sync.Mutex
+	ready bool
+	pools map[string]*instancePool
+	// registered 由 backend 随请求下发连接信息的登记实例 -> 连接参数，见 RegisterInstance
+	registered map[string]config.DatabaseConfig
+}
+
+var manager = &poolManager{}
+
+// PoolStatus 一个已打开连接池的状态
+type PoolStatus struct {
+	ID        string
+	Stats     sql.DBStats
+	Healthy   bool
+	LastError string
+	LastUsed  time.Time
+}
+
+type instanceKey struct{}
+
+// WithInstance 把实例ID放入 ctx，之后经 ctx 执行的查询都在该实例上进行；id 为空表示 default
+func WithInstance(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, instanceKey{}, id)
+}
+
+// InstanceFrom 返回 ctx 中的实例ID，未设置时为 default
+func InstanceFrom(ctx context.Context) string {
+	if id, _ := ctx.Value(instanceKey{}).(string); id != "" {
+		return id
+	}
+	return config.DefaultInstanceID
+}
+
+// HasInstance 判断实例是否已配置或已由 backend 登记，id 为空视为 default
+func HasInstance(id string) bool {
+	if id == "" {
+		return true
+	}
+	_, ok := InstanceConfig(id)
+	return ok
+}
+
+// InstanceConfig 返回实例的连接参数，包括本地配置的实例与 backend 登记的实例
+func InstanceConfig(id string) (config.DatabaseConfig, bool) {
+	if cfg, ok := config.AppConfig.InstanceDatabase(id); ok {
+		return cfg, true
+	}
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	cfg, ok := manager.registered[id]
+	return cfg, ok
+}
+
+// instanceSchema 返回 ctx 所属实例配置中的库，未指定 schema 的查询使用该库
+func instanceSchema(ctx context.Context) string {
+	cfg, _ := InstanceConfig(InstanceFrom(ctx))
+	return cfg.DBName
+}
+
+// InitDB 初始化连接池管理器，打开 default 实例的连接池并检测其版本与能力，连接失败时返回错误；
+// [[instances]] 与 backend 登记的实例在首次使用时打开
+func InitDB() error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if manager.ready {
+		return nil
+	}
+
+	cfg := config.AppConfig.Database
+	conn, err := openPool(cfg)
+	if err != nil {
+		return err
+	}
+	if err := conn.Ping(); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("尝试ping数据库失败: %w", err)
+	}
+	p := &instancePool{db: conn, cfg: cfg, lastUsed: time.Now(), healthy: true, replicas: newReplicaPools(config.DefaultInstanceID, cfg)}
+	// 检测失败不影响启动，首次调用 ServerCapabilities 时重试
+	if caps, err := detectCapabilities(context.Background(), conn); err != nil {
+		log.Printf("[databases] instance=%s %v", config.DefaultInstanceID, err)
+	} else {
+		logCapabilities(config.DefaultInstanceID, caps)
+		p.caps = &caps
+	}
+	manager.pools = map[string]*instancePool{config.DefaultInstanceID: p}
+	manager.ready = true
+	return nil
+}
+
+func openPool(cfg config.DatabaseConfig) (*sql.DB, error) {
+	return openDSN(cfg.DSN(), cfg)
+}
+
+// openDSN 打开 dsn 对应的连接池并按 cfg 设置连接池参数，配置了 ssh 时经跳板机连接；从库与主库共用同一组参数和跳板机
+func openDSN(dsn string, cfg config.DatabaseConfig) (*sql.DB, error) {
+	dsn, err := tunnelDSN(dsn, cfg.SSH)
+	if err != nil {
+		return nil, fmt.Errorf("配置跳板机失败: %w", err)
+	}
+	conn, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开mysql失败: %w", err)
+	}
+	if cfg.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.MaxOpenConns > 0 {
+		conn.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		conn.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	return conn, nil
+}
+
+// GetDB 返回 ctx 所属实例（见 WithInstance）的连接池，尚未打开或已因空闲关闭时按实例配置打开
+func GetDB(ctx context.Context) (*sql.DB, error) {
+	id := InstanceFrom(ctx)
+	cfg, known := InstanceConfig(id)
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if !manager.ready {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+	if p, ok := manager.pools[id]; ok {
+		p.lastUsed = time.Now()
+		return p.db, nil
+	}
+	if !known {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownInstance, id)
+	}
+	conn, err := openPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+	manager.pools[id] = &instancePool{db: conn, cfg: cfg, lastUsed: time.Now(), healthy: true, replicas: newReplicaPools(id, cfg)}
+	log.Printf("[databases] instance=%s pool opened %s:%d", id, cfg.Host, cfg.Port)
+	return conn, nil
+}
+
+// RegisterInstance 登记 backend 下发的实例连接参数，参数变化时关闭已打开的连接池，下次使用时按新参数打开；
+// default 与 [[instances]] 中配置的实例始终使用本地配置，忽略下发的连接信息。
+// 主机须在 agent.instance_host_allowlist 中；
+// agent.require_read_only_account 开启时新参数先在临时连接池上通过只读账号检查再登记，未通过时保留原登记
+func RegisterInstance(ctx context.Context, id string, cfg config.DatabaseConfig) error {
+	if _, ok := config.AppConfig.InstanceDatabase(id); ok {
+		return nil
+	}
+	if !instanceHostAllowed(cfg.Host, config.AppConfig.Agent.InstanceHostAllowlist) {
+		return fmt.Errorf("%w: %s", ErrInstanceHostNotAllowed, cfg.Host)
+	}
+
+	// 比较完整参数而不是 DSN：跳板机、连接池参数与从库不在 DSN 中
+	manager.mu.Lock()
+	prev, ok := manager.registered[id]
+	manager.mu.Unlock()
+	if ok && reflect.DeepEqual(prev, cfg) {
+		return nil
+	}
+
+	if config.AppConfig.Agent.RequireReadOnlyAccount {
+		if err := verifyReadOnlyConfig(ctx, cfg); err != nil {
+			return fmt.Errorf("实例 %s 只读账号检查失败: %w", id, err)
+		}
+	}
+
+	manager.mu.Lock()
+	if manager.registered == nil {
+		manager.registered = make(map[string]config.DatabaseConfig)
+	}
+	manager.registered[id] = cfg
+	manager.closeLocked(id)
+	manager.mu.Unlock()
+	log.Printf("[databases] instance=%s registered %s:%d", id, cfg.Host, cfg.Port)
+	return nil
+}
+
+// instanceHostAllowed 判断主机是否匹配允许列表中的主机名（不区分大小写）、IP 或 CIDR；
+// 不解析域名，主机名只能按名称放行
+func instanceHostAllowed(host string, allowlist []string) bool {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	for _, entry := range allowlist {
+		entry = strings.TrimSpace(entry)
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil {
+			if ip != nil && allowed.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(entry, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// closeLocked 关闭并移除实例的连接池及其从库连接池，调用方需持有 m.mu；进行中的查询完成后连接才会真正关闭
+func (m *poolManager) closeLocked(id string) {
+	if p, ok := m.pools[id]; ok {
+		if err := p.db.Close(); err != nil {
+			log.Printf("[databases] instance=%s close pool failed: %v", id, err)
+		}
+		for _, r := range p.replicas {
+			r.close(id)
+		}
+		delete(m.pools, id)
+	}
+}
+
+// Stats 返回 ctx 所属实例的连接池统计，连接池未打开时 ok 为 false
+func Stats(ctx context.Context) (stats sql.DBStats, ok bool) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	p, ok := manager.pools[InstanceFrom(ctx)]
+	if !ok {
+		return sql.DBStats{}, false
+	}
+	return p.db.Stats(), true
+}
+
+// InstanceIDs 返回已打开连接池的实例ID，default 在前，其余按ID排序
+func InstanceIDs() []string {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	ids := make([]string, 0, len(manager.pools))
+	for id := range manager.pools {
+		if id != config.DefaultInstanceID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if _, ok := manager.pools[config.DefaultInstanceID]; ok {
+		ids = append([]string{config.DefaultInstanceID}, ids...)
+	}
+	return ids
+}
+
+// PoolStatuses 返回已打开连接池的状态，顺序同 InstanceIDs
+func PoolStatuses() []PoolStatus {
+	ids := InstanceIDs()
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	out := make([]PoolStatus, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := manager.pools[id]; ok {
+			out = append(out, PoolStatus{ID: id, Stats: p.db.Stats(), Healthy: p.healthy, LastError: p.lastErr, LastUsed: p.lastUsed})
+		}
+	}
+	return out
+}
+
+// RunPoolManager 每隔 database.health_check_interval 关闭空闲超过 idle_timeout 的非 default 连接池，
+// 并 ping 其余连接池及已打开的从库记录健康状态、关闭空闲的跳板机连接，直到 ctx 结束
+func RunPoolManager(ctx context.Context) error {
+	interval := config.AppConfig.Database.HealthCheckInterval
+	if interval <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		manager.check(ctx)
+	}
+}
+
+func (m *poolManager) check(ctx context.Context) {
+	type target struct {
+		id      string
+		db      *sql.DB
+		replica *replicaPool
+	}
+	var targets []target
+	m.mu.Lock()
+	for id, p := range m.pools {
+		idle := p.cfg.IdleTimeout
+		if id != config.DefaultInstanceID && idle > 0 && time.Since(p.lastUsed) > idle && p.db.Stats().InUse == 0 {
+			m.closeLocked(id)
+			log.Printf("[databases] instance=%s pool closed after %s idle", id, idle)
+			continue
+		}
+		targets = append(targets, target{id: id, db: p.db})
+		for _, r := range p.replicas {
+			r.mu.Lock()
+			if r.db != nil {
+				targets = append(targets, target{id: id, db: r.db, replica: r})
+			}
+			r.mu.Unlock()
+		}
+	}
+	m.mu.Unlock()
+	// 连接池关闭后经跳板机转发的连接随之关闭，跳板机连接同样按 idle_timeout 关闭
+	if idle := config.AppConfig.Database.IdleTimeout; idle > 0 {
+		closeIdleTunnels(idle)
+	}
+
+	for _, t := range targets {
+		pingCtx, cancel := context.WithTimeout(ctx, poolPingTimeout)
+		err := t.db.PingContext(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if t.replica != nil {
+			t.replica.setHealth(t.id, err)
+			continue
+		}
+
+		m.mu.Lock()
+		// ping 期间连接池可能已被替换或关闭
+		if p, ok := m.pools[t.id]; ok && p.db == t.db {
+			switch {
+			case err != nil && p.healthy:
+				log.Printf("[databases] instance=%s health check failed: %v", t.id, err)
+			case err == nil && !p.healthy:
+				log.Printf("[databases] instance=%s recovered", t.id)
+			}
+			p.healthy, p.lastErr = err == nil, ""
+			if err != nil {
+				p.lastErr = err.Error()
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// CloseDB 关闭全部连接池，包括从库与跳板机连接
+func CloseDB() error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	var errs []error
+	for id, p := range manager.pools {
+		errs = append(errs, p.db.Close())
+		for _, r := range p.replicas {
+			r.close(id)
+		}
+	}
+	manager.pools, manager.registered, manager.ready = nil, nil, false
+	closeTunnels()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,94 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mysql-agent/config"
+)
+
+func TestInstanceSchemaPerInstance(t *testing.T) {
+	prevCfg, prevRegistered := config.AppConfig, manager.registered
+	t.Cleanup(func() {
+		config.AppConfig = prevCfg
+		manager.mu.Lock()
+		manager.registered = prevRegistered
+		manager.mu.Unlock()
+	})
+	config.AppConfig = &config.Config{
+		Database:  config.DatabaseConfig{Host: "10.0.0.1", Port: 3306, DBName: "app"},
+		Agent:     config.AgentConfig{InstanceHostAllowlist: []string{"10.0.0.0/24"}},
+		Instances: []config.InstanceConfig{{ID: "local", DatabaseConfig: config.DatabaseConfig{DBName: "reporting"}}},
+	}
+	manager.mu.Lock()
+	manager.registered = nil
+	manager.mu.Unlock()
+
+	ctx := context.Background()
+	if err := RegisterInstance(ctx, "orders", config.DatabaseConfig{Host: "10.0.0.2", Port: 3306, DBName: "orders_db"}); err != nil {
+		t.Fatalf("RegisterInstance: %v", err)
+	}
+
+	cases := map[string]string{
+		"":        "app",
+		"default": "app",
+		"local":   "reporting",
+		"orders":  "orders_db",
+		"unknown": "",
+	}
+	for id, want := range cases {
+		if got := instanceSchema(WithInstance(ctx, id)); got != want {
+			t.Errorf("instanceSchema(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestInstanceHostAllowed(t *testing.T) {
+	allowlist := []string{"10.0.0.0/24", "192.168.1.10", "DB1.internal", "fd00::/8"}
+	cases := []struct {
+		host string
+		want bool
+	}{
+		{"10.0.0.25", true},
+		{"10.0.1.25", false},
+		{"192.168.1.10", true},
+		{"192.168.1.11", false},
+		{"db1.internal", true},
+		{"db1.internal.evil.com", false},
+		{"fd00::1", true},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := instanceHostAllowed(c.host, allowlist); got != c.want {
+			t.Errorf("instanceHostAllowed(%q) = %v, want %v", c.host, got, c.want)
+		}
+	}
+	if instanceHostAllowed("10.0.0.25", nil) {
+		t.Error("empty allowlist must reject every host")
+	}
+}
+
+func TestRegisterInstanceRejectsHostOutsideAllowlist(t *testing.T) {
+	prevCfg, prevRegistered := config.AppConfig, manager.registered
+	t.Cleanup(func() {
+		config.AppConfig = prevCfg
+		manager.mu.Lock()
+		manager.registered = prevRegistered
+		manager.mu.Unlock()
+	})
+	config.AppConfig = &config.Config{Agent: config.AgentConfig{InstanceHostAllowlist: []string{"10.0.0.0/24"}}}
+	manager.mu.Lock()
+	manager.registered = nil
+	manager.mu.Unlock()
+
+	err := RegisterInstance(context.Background(), "metadata", config.DatabaseConfig{Host: "169.254.169.254", Port: 80})
+	if !errors.Is(err, ErrInstanceHostNotAllowed) {
+		t.Fatalf("RegisterInstance = %v, want ErrInstanceHostNotAllowed", err)
+	}
+	if HasInstance("metadata") {
+		t.Fatal("rejected instance must not be registered")
+	}
+}
@@ -0,0 +1,165 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+
+	"mysql-agent/config"
+)
+
+// replicaRetryInterval 从库被标记为不可达后，再次尝试前至少等待的时长；开启后台健康检查时也会在检查中恢复
+const replicaRetryInterval = 30 * time.Second
+
+// replicaPingTimeout 选择从库时单次 ping 的超时，超时即回退到主库，避免拖慢诊断
+const replicaPingTimeout = 2 * time.Second
+
+// replicaPool 实例的一个只读从库连接池，首次选择时打开
+type replicaPool struct {
+	mu  sync.Mutex
+	dsn string
+	// addr 从库地址，日志中代替 DSN 以免输出密码
+	addr    string
+	cfg     config.DatabaseConfig
+	db      *sql.DB
+	healthy bool
+	checked time.Time
+}
+
+// newReplicaPools 按实例配置的 replica_dsns 创建从库连接池（此时不连接）；DSN 的时间解析、时区与字符集按实例配置覆盖，
+// 保证同一查询在主从上返回的值格式一致
+func newReplicaPools(id string, cfg config.DatabaseConfig) []*replicaPool {
+	replicas := make([]*replicaPool, 0, len(cfg.ReplicaDSNs))
+	for _, raw := range cfg.ReplicaDSNs {
+		dsnCfg, err := mysql.ParseDSN(raw)
+		if err != nil {
+			log.Printf("[databases] instance=%s ignore invalid replica dsn: %v", id, err)
+			continue
+		}
+		dsnCfg.ParseTime, dsnCfg.Loc = true, time.Local
+		if dsnCfg.DBName == "" {
+			dsnCfg.DBName = cfg.DBName
+		}
+		if cfg.Charset != "" {
+			if dsnCfg.Params == nil {
+				dsnCfg.Params = make(map[string]string)
+			}
+			dsnCfg.Params["charset"] = cfg.Charset
+		}
+		replicas = append(replicas, &replicaPool{dsn: dsnCfg.FormatDSN(), addr: dsnCfg.Addr, cfg: cfg})
+	}
+	return replicas
+}
+
+// acquire 返回可用的从库连接，从库不可达时返回 nil。尚未检查过或距上次失败超过 replicaRetryInterval 时先 ping
+func (r *replicaPool) acquire(ctx context.Context, id string) *sql.DB {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.checked.IsZero() && (r.healthy || time.Since(r.checked) < replicaRetryInterval) {
+		if r.healthy {
+			return r.db
+		}
+		return nil
+	}
+	if r.db == nil {
+		db, err := openDSN(r.dsn, r.cfg)
+		if err != nil {
+			r.setHealthLocked(id, err)
+			return nil
+		}
+		r.db = db
+	}
+	// 调用方取消不代表从库不可达，ping 只受自身超时限制
+	pingCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), replicaPingTimeout)
+	err := r.db.PingContext(pingCtx)
+	cancel()
+	r.setHealthLocked(id, err)
+	if err != nil {
+		return nil
+	}
+	return r.db
+}
+
+// setHealth 记录后台检查或查询失败得到的从库状态
+func (r *replicaPool) setHealth(id string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setHealthLocked(id, err)
+}
+
+func (r *replicaPool) setHealthLocked(id string, err error) {
+	switch {
+	case err != nil && (r.healthy || r.checked.IsZero()):
+		log.Printf("[databases] instance=%s replica=%s unreachable, falling back to primary: %v", id, r.addr, err)
+	case err == nil && !r.healthy:
+		log.Printf("[databases] instance=%s replica=%s available", id, r.addr)
+	}
+	r.healthy, r.checked = err == nil, time.Now()
+}
+
+func (r *replicaPool) close(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.db == nil {
+		return
+	}
+	if err := r.db.Close(); err != nil {
+		log.Printf("[databases] instance=%s replica=%s close pool failed: %v", id, r.addr, err)
+	}
+	r.db = nil
+}
+
+// replicaDB 返回 ctx 所属实例第一个可达的从库连接池，未配置从库或均不可达时返回主库连接池且 replica 为 nil
+func replicaDB(ctx context.Context) (db *sql.DB, replica *replicaPool, err error) {
+	primary, err := GetDB(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	id := InstanceFrom(ctx)
+	manager.mu.Lock()
+	var replicas []*replicaPool
+	if p, ok := manager.pools[id]; ok && p.db == primary {
+		replicas = p.replicas
+	}
+	manager.mu.Unlock()
+
+	for _, r := range replicas {
+		if db := r.acquire(ctx, id); db != nil {
+			return db, r, nil
+		}
+	}
+	return primary, nil, nil
+}
+
+// queryReplica 在从库上执行 information_schema 元数据扫描，减轻主库负担；这类数据经复制在主从间一致，
+// performance_schema、processlist 与状态变量只反映所在实例，不应使用。从库在查询中途断开时标记为不可达并在主库重试
+func queryReplica(ctx context.Context, query string, args ...any) ([]map[string]any, error) {
+	db, replica, err := replicaDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := querySimple(ctx, db, query, args...)
+	if err == nil || replica == nil || ctx.Err() != nil || !isConnError(err) {
+		return rows, err
+	}
+
+	replica.setHealth(InstanceFrom(ctx), err)
+	primary, err := GetDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return querySimple(ctx, primary, query, args...)
+}
+
+// isConnError 判断错误是否由连接不可用引起，而非 SQL 本身出错
+func isConnError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.As(err, &netErr)
+}
@@ -0,0 +1,240 @@
+package databases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	mysql "github.com/go-sql-driver/mysql"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"mysql-agent/config"
+)
+
+// sshHandshakeTimeout 连接跳板机（TCP 建连与 SSH 握手）的超时
+const sshHandshakeTimeout = 10 * time.Second
+
+// sshTunnel 一个跳板机的 SSH 连接，经它转发的 MySQL 连接共用，首次拨号时建立，断开后下次拨号时重连
+type sshTunnel struct {
+	mu     sync.Mutex
+	name   string
+	cfg    config.SSHConfig
+	client *ssh.Client
+	// active 经该跳板机转发且尚未关闭的 MySQL 连接数，idleSince 为其降为 0 的时间
+	active    int
+	idleSince time.Time
+}
+
+var tunnels = struct {
+	mu sync.Mutex
+	// m 驱动中注册的 network 名称 -> 跳板机
+	m map[string]*sshTunnel
+}{m: make(map[string]*sshTunnel)}
+
+// tunnelDSN 实例配置了跳板机时把 dsn 的 tcp 连接改为经跳板机转发，其余情况原样返回
+func tunnelDSN(dsn string, cfg config.SSHConfig) (string, error) {
+	if cfg.Host == "" {
+		return dsn, nil
+	}
+	dsnCfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if dsnCfg.Net != "tcp" {
+		return dsn, nil
+	}
+	dsnCfg.Net = registerTunnel(cfg)
+	return dsnCfg.FormatDSN(), nil
+}
+
+// registerTunnel 返回跳板机在驱动中的 network 名称，首次出现时注册拨号函数；配置相同的实例共用同一个 SSH 连接
+func registerTunnel(cfg config.SSHConfig) string {
+	if cfg.Port == 0 {
+		cfg.Port = 22
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%+v", cfg)))
+	name := "ssh-" + hex.EncodeToString(sum[:6])
+
+	tunnels.mu.Lock()
+	defer tunnels.mu.Unlock()
+	if _, ok := tunnels.m[name]; !ok {
+		t := &sshTunnel{name: name, cfg: cfg}
+		tunnels.m[name] = t
+		mysql.RegisterDialContext(name, t.dial)
+	}
+	return name
+}
+
+func (t *sshTunnel) bastion() string {
+	return net.JoinHostPort(t.cfg.Host, strconv.Itoa(t.cfg.Port))
+}
+
+// dial 经跳板机连接 addr；跳板机连接已失效时转发失败，关闭后重连一次
+func (t *sshTunnel) dial(ctx context.Context, addr string) (net.Conn, error) {
+	for attempt := 0; ; attempt++ {
+		client, err := t.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := client.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return t.track(conn), nil
+		}
+		if attempt > 0 || ctx.Err() != nil {
+			return nil, fmt.Errorf("经跳板机 %s 连接 %s 失败: %w", t.bastion(), addr, err)
+		}
+		t.reset(client, err)
+	}
+}
+
+// connect 返回已建立的 SSH 连接，尚未建立或已被重置时重新连接跳板机
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	clientCfg, err := t.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, sshHandshakeTimeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(dialCtx, "tcp", t.bastion())
+	if err != nil {
+		return nil, fmt.Errorf("连接跳板机 %s 失败: %w", t.bastion(), err)
+	}
+	// 握手不接受 ctx，用连接截止时间限制其耗时
+	if deadline, ok := dialCtx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, t.bastion(), clientCfg)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("跳板机 %s SSH 握手失败: %w", t.bastion(), err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	t.client = ssh.NewClient(c, chans, reqs)
+	log.Printf("[databases] ssh tunnel %s@%s connected", t.cfg.User, t.bastion())
+	return t.client, nil
+}
+
+func (t *sshTunnel) clientConfig() (*ssh.ClientConfig, error) {
+	key, err := os.ReadFile(t.cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取跳板机私钥失败: %w", err)
+	}
+	var signer ssh.Signer
+	if t.cfg.KeyPassphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(t.cfg.KeyPassphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析跳板机私钥失败: %w", err)
+	}
+
+	hostKey := ssh.InsecureIgnoreHostKey()
+	if !t.cfg.InsecureIgnoreHostKey {
+		path := t.cfg.KnownHostsFile
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("定位 known_hosts 失败: %w", err)
+			}
+			path = filepath.Join(home, ".ssh", "known_hosts")
+		}
+		if hostKey, err = knownhosts.New(path); err != nil {
+			return nil, fmt.Errorf("读取 known_hosts 失败: %w", err)
+		}
+	}
+	return &ssh.ClientConfig{
+		User:            t.cfg.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKey,
+		Timeout:         sshHandshakeTimeout,
+	}, nil
+}
+
+// reset 关闭失效的 SSH 连接，client 已被其他调用重置时不做处理
+func (t *sshTunnel) reset(client *ssh.Client, cause error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client != client {
+		return
+	}
+	log.Printf("[databases] ssh tunnel %s@%s reset: %v", t.cfg.User, t.bastion(), cause)
+	_ = t.client.Close()
+	t.client = nil
+}
+
+// track 记录经跳板机转发的连接数，供 closeIdleTunnels 判断跳板机连接是否空闲
+func (t *sshTunnel) track(conn net.Conn) net.Conn {
+	t.mu.Lock()
+	t.active++
+	t.mu.Unlock()
+	return &tunnelConn{Conn: conn, tunnel: t}
+}
+
+type tunnelConn struct {
+	net.Conn
+	tunnel *sshTunnel
+	once   sync.Once
+}
+
+func (c *tunnelConn) Close() error {
+	c.once.Do(func() {
+		t := c.tunnel
+		t.mu.Lock()
+		if t.active--; t.active == 0 {
+			t.idleSince = time.Now()
+		}
+		t.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
+
+// closeIdleTunnels 关闭已没有转发连接且空闲超过 idle 的跳板机连接，由连接池管理器在连接池空闲关闭后调用
+func closeIdleTunnels(idle time.Duration) {
+	tunnels.mu.Lock()
+	list := make([]*sshTunnel, 0, len(tunnels.m))
+	for _, t := range tunnels.m {
+		list = append(list, t)
+	}
+	tunnels.mu.Unlock()
+
+	for _, t := range list {
+		t.mu.Lock()
+		if t.client != nil && t.active == 0 && time.Since(t.idleSince) > idle {
+			_ = t.client.Close()
+			t.client = nil
+			log.Printf("[databases] ssh tunnel %s@%s closed after %s idle", t.cfg.User, t.bastion(), idle)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// closeTunnels 关闭全部跳板机连接，驱动中注册的拨号函数保留，下次使用时重连
+func closeTunnels() {
+	tunnels.mu.Lock()
+	defer tunnels.mu.Unlock()
+	for _, t := range tunnels.m {
+		t.mu.Lock()
+		if t.client != nil {
+			_ = t.client.Close()
+			t.client = nil
+		}
+		t.mu.Unlock()
+	}
+}
@@ -7,6 +7,7 @@ require (
 	github.com/cloudwego/eino-ext/components/model/deepseek v0.0.0-20251017093230-97f74acce637
 	github.com/go-sql-driver/mysql v1.8.1
 	github.com/spf13/viper v1.20.1
+	golang.org/x/crypto v0.33.0
 )
 
 require (
@@ -63,7 +64,6 @@ require (
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.9.0 // indirect
 	golang.org/x/arch v0.11.0 // indirect
-	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/exp v0.0.0-20250218142911-aa4b98e5adaa // indirect
 	golang.org/x/net v0.35.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
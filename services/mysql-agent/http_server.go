@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mysql-agent/agent"
+	"mysql-agent/config"
+	"mysql-agent/databases"
+)
+
+const httpShutdownTimeout = 5 * time.Second
+
+type httpErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func runHTTPServer(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /query", handleQuery)
+	mux.HandleFunc("POST /query/stream", handleQueryStream)
+	mux.HandleFunc("POST /plan/execute", handleExecutePlan)
+	mux.HandleFunc("GET /usage", handleUsage)
+	mux.HandleFunc("GET /health", handleHealth)
+	mux.HandleFunc("GET /baseline", handleBaseline)
+	mux.HandleFunc("GET /samples", handleSamples)
+	mux.HandleFunc("GET /tools", handleListTools)
+	mux.HandleFunc("POST /tools/run", handleRunTool)
+	mux.HandleFunc("GET /metrics", handleMetrics)
+	if config.AppConfig.Exporter.OpenMetrics {
+		mux.HandleFunc("GET /metrics/mysql", handleMySQLMetrics)
+	}
+
+	srv := &http.Server{
+		Addr:    ":" + config.AppConfig.Server.HTTPPort,
+		Handler: countRequests(mux),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+func handleQuery(w http.ResponseWriter, r *http.Request) {
+	serveQuery(w, r, agent.NewRPCService(r.Context()).Query)
+}
+
+// handleExecutePlan 执行审核后的工具计划，对应 RPC 的 Agent.ExecutePlan
+func handleExecutePlan(w http.ResponseWriter, r *http.Request) {
+	serveQuery(w, r, agent.NewRPCService(r.Context()).ExecutePlan)
+}
+
+func serveQuery(w http.ResponseWriter, r *http.Request, call func(agent.QueryRequest, *agent.QueryResponse) error) {
+	var req agent.QueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: err.Error()})
+		return
+	}
+
+	req.Caller = requestCaller(r, req.Caller)
+
+	var resp agent.QueryResponse
+	if err := call(req, &resp); err != nil {
+		writeQueryError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// requestCaller 请求体未指定 caller 时以 X-Caller 头或客户端地址作为限流使用的调用方标识
+func requestCaller(r *http.Request, caller string) string {
+	if strings.TrimSpace(caller) != "" {
+		return caller
+	}
+	if h := strings.TrimSpace(r.Header.Get("X-Caller")); h != "" {
+		return h
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// writeQueryError 限流错误返回 429 并设置 Retry-After（秒，向上取整），并发已满返回 503，实例不存在返回 404，其余错误返回 400
+func writeQueryError(w http.ResponseWriter, err error) {
+	var limited *agent.RateLimitError
+	if errors.As(err, &limited) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		writeJSON(w, http.StatusTooManyRequests, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	if errors.Is(err, agent.ErrAgentBusy) {
+		writeJSON(w, http.StatusServiceUnavailable, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	if errors.Is(err, databases.ErrUnknownInstance) {
+		writeJSON(w, http.StatusNotFound, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: err.Error()})
+}
+
+// handleUsage 返回 LLM token 用量，对应 RPC 的 Agent.Usage；days 为查询天数
+func handleUsage(w http.ResponseWriter, r *http.Request) {
+	var req agent.UsageRequest
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: "days 必须是整数"})
+			return
+		}
+		req.Days = days
+	}
+
+	var resp agent.UsageResponse
+	if err := agent.NewRPCService(r.Context()).Usage(req, &resp); err != nil {
+		writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleHealth 返回健康检查结果，对应 RPC 的 Agent.Health；不可用（ready=false）时返回 503。
+// skip_llm=true 时不检查 LLM 连通性，instance_id 指定检查的实例
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	req := agent.HealthRequest{
+		SkipLLM:    r.URL.Query().Get("skip_llm") == "true",
+		InstanceID: r.URL.Query().Get("instance_id"),
+	}
+	var resp agent.HealthResponse
+	if err := agent.NewRPCService(r.Context()).Health(req, &resp); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, databases.ErrUnknownInstance) {
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	status := http.StatusOK
+	if !resp.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+// handleBaseline 返回各指标的基线统计，对应 RPC 的 Agent.Baseline
+func handleBaseline(w http.ResponseWriter, r *http.Request) {
+	var resp agent.BaselineResponse
+	if err := agent.NewRPCService(r.Context()).Baseline(agent.BaselineRequest{}, &resp); err != nil {
+		writeJSON(w, http.StatusInternalServerError, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleSamples 返回后台采样的时间序列，对应 RPC 的 Agent.Samples；metrics 以逗号分隔，seconds 为最近秒数
+func handleSamples(w http.ResponseWriter, r *http.Request) {
+	var req agent.SamplesRequest
+	if raw := r.URL.Query().Get("metrics"); raw != "" {
+		req.Metrics = strings.Split(raw, ",")
+	}
+	if raw := r.URL.Query().Get("seconds"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: "seconds 必须是整数"})
+			return
+		}
+		req.Seconds = seconds
+	}
+	var resp agent.SamplesResponse
+	if err := agent.NewRPCService(r.Context()).Samples(req, &resp); err != nil {
+		writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleMetrics 以 Prometheus 文本格式输出 agent 运行指标
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := agent.WriteMetrics(w); err != nil {
+		log.Printf("[HTTP] write metrics failed: %v", err)
+	}
+}
+
+// handleMySQLMetrics 以 OpenMetrics 格式输出采样器最近一次采集的 MySQL 指标，供 Prometheus 直接抓取
+func handleMySQLMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	if err := agent.WriteOpenMetrics(w); err != nil {
+		log.Printf("[HTTP] write mysql metrics failed: %v", err)
+	}
+}
+
+// countRequests 按路由模式与响应状态码记录请求数，未匹配任何路由的请求计为 unmatched
+func countRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		pattern := r.Pattern
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+		agent.ObserveRequest("http", pattern, strconv.Itoa(rec.status))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush 保证 SSE 流式响应经过包装后仍能逐条推送
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// handleListTools 返回已注册工具及其参数定义，可用 ?instance_id= 指定判断能力的实例，对应 RPC 的 Agent.ListTools
+func handleListTools(w http.ResponseWriter, r *http.Request) {
+	var resp agent.ListToolsResponse
+	if err := agent.NewRPCService(r.Context()).ListTools(agent.ListToolsRequest{InstanceID: r.URL.Query().Get("instance_id")}, &resp); err != nil {
+		writeJSON(w, http.StatusInternalServerError, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleRunTool 直接执行单个工具，对应 RPC 的 Agent.RunTool
+func handleRunTool(w http.ResponseWriter, r *http.Request) {
+	var req agent.RunToolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	var resp agent.ToolRun
+	if err := agent.NewRPCService(r.Context()).RunTool(req, &resp); err != nil {
+		writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleQueryStream 以 Server-Sent Events 推送诊断过程中的增量事件，事件名为 StreamEvent.Type
+func handleQueryStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, httpErrorResponse{Error: "不支持流式响应"})
+		return
+	}
+
+	var req agent.QueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: "query 不能为空"})
+		return
+	}
+	req.Caller = requestCaller(r, req.Caller)
+
+	// 第一个事件到达时才写响应头，在此之前的失败（如限流）仍以普通 JSON 错误返回
+	started := false
+	err := agent.QueryStream(r.Context(), req, func(event agent.StreamEvent) {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("[HTTP] 序列化事件失败: %v", err)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+			log.Printf("[HTTP] 写入事件失败: %v", err)
+			return
+		}
+		flusher.Flush()
+	})
+	if err != nil && !started {
+		writeQueryError(w, err)
+		return
+	}
+	if err != nil {
+		data, _ := json.Marshal(httpErrorResponse{Error: err.Error()})
+		fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+		flusher.Flush()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("[HTTP] 写入响应失败: %v", err)
+	}
+}
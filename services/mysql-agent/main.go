@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -27,6 +28,18 @@ func main() {
 		}
 	}()
 
+	if config.AppConfig.Agent.RequireReadOnlyAccount {
+		for _, id := range config.AppConfig.InstanceIDs() {
+			if err := databases.VerifyReadOnlyAccount(databases.WithInstance(ctx, id)); err != nil {
+				log.Fatalf("实例 %s 只读账号检查失败: %v", id, err)
+			}
+		}
+		log.Print("只读账号检查通过")
+	}
+
+	if err := agent.LoadPrompts(); err != nil {
+		log.Fatalf("加载提示词模板失败: %v", err)
+	}
 	if _, err := agent.ChatModel(ctx); err != nil {
 		log.Fatalf("初始化deepseek模型失败: %v", err)
 	}
@@ -36,10 +49,63 @@ func main() {
 		log.Printf("已注册工具: %v", names)
 	}
 
-	log.Printf("RPC 服务监听: %s", config.AppConfig.GetServerAddr())
 	log.Printf("数据库DSN: %s", config.AppConfig.GetDSN())
+	if len(config.AppConfig.Instances) > 0 {
+		log.Printf("已配置实例: %v", config.AppConfig.InstanceIDs())
+	}
 
-	if err := runRPCServer(ctx); err != nil {
+	if err := runServers(ctx); err != nil {
 		log.Fatalf("服务运行失败: %v", err)
 	}
 }
+
+// runServers 按 server.transport 启动 RPC 和/或 HTTP 服务，任一服务退出即整体退出
+func runServers(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var runners []func(context.Context) error
+	if config.AppConfig.RPCEnabled() {
+		log.Printf("RPC 服务监听: %s", config.AppConfig.GetServerAddr())
+		runners = append(runners, runRPCServer)
+	}
+	if config.AppConfig.HTTPEnabled() {
+		log.Printf("HTTP 服务监听: %s", config.AppConfig.GetHTTPAddr())
+		runners = append(runners, runHTTPServer)
+	}
+	if config.AppConfig.MCP.Enabled {
+		log.Printf("MCP 服务启动: transport=%s", config.AppConfig.MCP.Transport)
+		runners = append(runners, runMCPServer)
+	}
+	if len(runners) == 0 {
+		return fmt.Errorf("未知的 server.transport: %s", config.AppConfig.Server.Transport)
+	}
+	if config.AppConfig.Database.HealthCheckInterval > 0 {
+		runners = append(runners, databases.RunPoolManager)
+	}
+	if agent.SamplingActive() {
+		runners = append(runners, agent.RunSampler)
+	}
+	if config.AppConfig.Exporter.RemoteWriteURL != "" {
+		runners = append(runners, agent.RunRemoteWrite)
+	}
+	if alert := config.AppConfig.Alert; alert.Enabled && alert.CheckInterval > 0 {
+		runners = append(runners, agent.RunAlertChecks)
+	}
+
+	errCh := make(chan error, len(runners))
+	for _, run := range runners {
+		go func(run func(context.Context) error) {
+			errCh <- run(ctx)
+		}(run)
+	}
+
+	err := <-errCh
+	cancel()
+	for i := 1; i < len(runners); i++ {
+		if e := <-errCh; err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"mysql-agent/agent"
+	"mysql-agent/config"
+)
+
+// mcpMaxMessageSize 单条 MCP 消息的字节数上限
+const mcpMaxMessageSize = 4 << 20
+
+// runMCPServer 按 mcp.transport 以 stdio 或 SSE 方式提供 MCP 服务
+func runMCPServer(ctx context.Context) error {
+	switch strings.ToLower(strings.TrimSpace(config.AppConfig.MCP.Transport)) {
+	case "", config.MCPTransportStdio:
+		return runMCPStdio(ctx, os.Stdin, os.Stdout)
+	case config.MCPTransportSSE:
+		return runMCPSSE(ctx)
+	default:
+		return fmt.Errorf("未知的 mcp.transport: %s", config.AppConfig.MCP.Transport)
+	}
+}
+
+// runMCPStdio 从 in 逐行读取 JSON-RPC 消息，响应逐行写入 out。客户端关闭输入后等待进行中的调用返回再退出
+func runMCPStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	session := agent.NewMCPSession(ctx, func(msg []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := out.Write(append(msg, '\n')); err != nil {
+			log.Printf("[MCP] 写入响应失败: %v", err)
+		}
+	})
+
+	lines := make(chan []byte)
+	errCh := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), mcpMaxMessageSize)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			if len(strings.TrimSpace(string(line))) == 0 {
+				continue
+			}
+			lines <- line
+		}
+		errCh <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			session.Wait()
+			return nil
+		case line := <-lines:
+			session.Handle(line)
+		case err := <-errCh:
+			session.Wait()
+			log.Print("[MCP] stdin closed")
+			return err
+		}
+	}
+}
+
+// mcpSSESession 一个 SSE 连接，POST /message 收到的请求的响应通过该连接推送
+type mcpSSESession struct {
+	session *agent.MCPSession
+	events  chan []byte
+}
+
+type mcpSSEServer struct {
+	mu       sync.Mutex
+	sessions map[string]*mcpSSESession
+}
+
+// runMCPSSE 提供 MCP 的 HTTP+SSE 传输：GET /sse 建立事件流并下发 endpoint，
+// 客户端向 endpoint（/message?sessionId=...）POST 请求，响应以 message 事件推送
+func runMCPSSE(ctx context.Context) error {
+	s := &mcpSSEServer{sessions: map[string]*mcpSSESession{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", s.handleStream)
+	mux.HandleFunc("POST /message", s.handleMessage)
+
+	srv := &http.Server{
+		Addr:    ":" + config.AppConfig.MCP.Port,
+		Handler: mux,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+func (s *mcpSSEServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, httpErrorResponse{Error: "不支持流式响应"})
+		return
+	}
+
+	id, err := newMCPSessionID()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	sse := &mcpSSESession{events: make(chan []byte, 16)}
+	sse.session = agent.NewMCPSession(ctx, func(msg []byte) {
+		select {
+		case sse.events <- msg:
+		case <-ctx.Done():
+		}
+	})
+	s.mu.Lock()
+	s.sessions[id] = sse
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, id)
+		s.mu.Unlock()
+		cancel()
+		sse.session.Wait()
+		log.Printf("[MCP] session %s closed", id)
+	}()
+	log.Printf("[MCP] session %s opened from %s", id, r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "event: endpoint\ndata: /message?sessionId=%s\n\n", id)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-sse.events:
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg); err != nil {
+				log.Printf("[MCP] 写入事件失败: %v", err)
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *mcpSSEServer) handleMessage(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("sessionId")
+	s.mu.Lock()
+	sse := s.sessions[id]
+	s.mu.Unlock()
+	if sse == nil {
+		writeJSON(w, http.StatusNotFound, httpErrorResponse{Error: "会话不存在或已关闭"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, mcpMaxMessageSize))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, httpErrorResponse{Error: err.Error()})
+		return
+	}
+	sse.session.Handle(body)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func newMCPSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成会话 ID 失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"log"
 	"net"
 	"net/rpc"
 	"net/rpc/jsonrpc"
@@ -19,15 +20,15 @@ func runRPCServer(ctx context.Context) error {
 	}
 	defer listener.Close()
 
-	srv := rpc.NewServer()
-	if err := agent.RegisterRPC(srv); err != nil {
+	// 启动时先注册一次，方法签名有误时尽早失败
+	if err := agent.RegisterRPC(rpc.NewServer()); err != nil {
 		return err
 	}
 
 	errCh := make(chan error, 1)
 
 	go func() {
-		errCh <- acceptLoop(ctx, srv, listener)
+		errCh <- acceptLoop(ctx, listener)
 	}()
 
 	select {
@@ -45,7 +46,7 @@ func runRPCServer(ctx context.Context) error {
 	}
 }
 
-func acceptLoop(ctx context.Context, srv *rpc.Server, listener net.Listener) error {
+func acceptLoop(ctx context.Context, listener net.Listener) error {
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
@@ -61,6 +62,46 @@ func acceptLoop(ctx context.Context, srv *rpc.Server, listener net.Listener) err
 			return err
 		}
 
-		go srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+		go serveConn(ctx, conn)
+	}
+}
+
+// serveConn 为每个连接创建独立的 rpc.Server，服务实例绑定的上下文在连接断开或服务退出时取消，
+// 使客户端断开后其进行中的诊断不再继续执行 SQL 和 LLM 调用
+func serveConn(ctx context.Context, conn net.Conn) {
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	srv := rpc.NewServer()
+	if err := agent.RegisterRPCWithContext(srv, connCtx); err != nil {
+		log.Printf("[RPC] 注册服务失败: %v", err)
+		_ = conn.Close()
+		return
+	}
+	srv.ServeCodec(&cancelOnCloseCodec{ServerCodec: jsonrpc.NewServerCodec(conn), cancel: cancel})
+}
+
+// cancelOnCloseCodec 读取请求失败（客户端断开）时立即取消连接上下文；
+// rpc.Server 会等待进行中的调用返回后才结束 ServeCodec，因此不能等到其返回再取消
+type cancelOnCloseCodec struct {
+	rpc.ServerCodec
+	cancel context.CancelFunc
+}
+
+// WriteResponse 在返回响应时按方法与结果计数
+func (c *cancelOnCloseCodec) WriteResponse(r *rpc.Response, body any) error {
+	status := "success"
+	if r.Error != "" {
+		status = "error"
+	}
+	agent.ObserveRequest("rpc", r.ServiceMethod, status)
+	return c.ServerCodec.WriteResponse(r, body)
+}
+
+func (c *cancelOnCloseCodec) ReadRequestHeader(r *rpc.Request) error {
+	err := c.ServerCodec.ReadRequestHeader(r)
+	if err != nil {
+		c.cancel()
 	}
+	return err
 }
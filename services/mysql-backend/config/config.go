@@ -15,6 +15,14 @@ type Config struct {
 	Redis    RedisConfig    `mapstructure:"redis"`
 	Log      LogConfig      `mapstructure:"log"`
 	Agent    AgentConfig    `mapstructure:"agent"`
+
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"password_policy"`
+	Migration      MigrationConfig      `mapstructure:"migration"`
+	QueryConsole   QueryConsoleConfig   `mapstructure:"query_console"`
+	Variables      VariablesConfig      `mapstructure:"variables"`
+	Backup         BackupConfig         `mapstructure:"backup"`
+
+	InstanceRegistry InstanceRegistryConfig `mapstructure:"instance_registry"`
 }
 
 // ServerConfig 服务器配置
@@ -22,6 +30,8 @@ type ServerConfig struct {
 	Port string `mapstructure:"port"`
 	Host string `mapstructure:"host"`
 	Mode string `mapstructure:"mode"`
+	// TrustedProxies 信任其 X-Forwarded-For 的反向代理地址（IP 或 CIDR），为空时客户端IP取连接的对端地址
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // DatabaseConfig 数据库配置
@@ -35,23 +45,81 @@ type DatabaseConfig struct {
 	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	// IdleTimeout 登记实例的连接池持续未被使用超过该时长后关闭，下次使用时重新打开，0 表示不关闭
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+	// HealthCheckInterval 后台 ping 已打开连接池并关闭空闲连接池的间隔，0 表示不检查
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
 }
 
 // RedisConfig Redis配置
 type RedisConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Password string `mapstructure:"password"`
-	DB       int    `mapstructure:"db"`
-	PoolSize int    `mapstructure:"pool_size"`
+	Enabled     bool          `mapstructure:"enabled"` // 是否启用 Redis，启用后持久化 agent 会话、工具输出与诊断报告
+	Host        string        `mapstructure:"host"`
+	Port        int           `mapstructure:"port"`
+	Password    string        `mapstructure:"password"`
+	DB          int           `mapstructure:"db"`
+	PoolSize    int           `mapstructure:"pool_size"`
+	SessionTTL  time.Duration `mapstructure:"session_ttl"`   // agent 会话及其工具输出、报告的保留时长，每次更新会话时刷新
+	MaxToolRuns int64         `mapstructure:"max_tool_runs"` // 每个会话保留的最近工具输出条数
 }
 
 // AgentConfig mysql-agent服务配置
 type AgentConfig struct {
-	Host    string        `mapstructure:"host"`
-	Port    string        `mapstructure:"port"`
-	BaseURL string        `mapstructure:"base_url"`
-	Timeout time.Duration `mapstructure:"timeout"`
+	Host      string        `mapstructure:"host"`
+	Port      string        `mapstructure:"port"`
+	BaseURL   string        `mapstructure:"base_url"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+	Transport string        `mapstructure:"transport"` // rpc 或 http
+	ReadOnly  bool          `mapstructure:"read_only"` // 只读部署：拒绝所有会修改 MySQL 或元数据的接口
+	// ReportHistory 为 true 时把每次完成的诊断写入元数据库的 agent_reports 表
+	ReportHistory bool `mapstructure:"report_history"`
+	// HTTPPort agent HTTP 服务端口（agent 侧的 server.http_port），未配置 base_url 时与 host 组成 http 传输的地址；
+	// port 是 jsonrpc 端口，不能用于 http 传输
+	HTTPPort string `mapstructure:"http_port"`
+}
+
+// PasswordPolicyConfig 创建用户与修改密码时的密码强度策略
+type PasswordPolicyConfig struct {
+	MinLength        int      `mapstructure:"min_length"`        // 最短长度
+	RequireUppercase bool     `mapstructure:"require_uppercase"` // 必须包含大写字母
+	RequireLowercase bool     `mapstructure:"require_lowercase"` // 必须包含小写字母
+	RequireDigit     bool     `mapstructure:"require_digit"`     // 必须包含数字
+	RequireSymbol    bool     `mapstructure:"require_symbol"`    // 必须包含特殊字符
+	DenyList         []string `mapstructure:"deny_list"`         // 禁止使用的密码，不区分大小写
+}
+
+// MigrationConfig 数据库迁移配置
+type MigrationConfig struct {
+	Dir string `mapstructure:"dir"` // 迁移文件目录，文件名格式 <版本号>_<名称>.sql
+}
+
+// QueryConsoleConfig 只读 SQL 控制台配置
+type QueryConsoleConfig struct {
+	DefaultRows      int           `mapstructure:"default_rows"`       // 未指定 limit 时返回的行数
+	MaxRows          int           `mapstructure:"max_rows"`           // 单次最多返回行数
+	MaxExecutionTime time.Duration `mapstructure:"max_execution_time"` // 单条语句最长执行时间
+}
+
+// VariablesConfig 全局变量修改配置
+type VariablesConfig struct {
+	Allowed []string `mapstructure:"allowed"` // 允许通过接口 SET GLOBAL 的动态变量白名单
+}
+
+// BackupConfig 逻辑备份配置
+type BackupConfig struct {
+	Dir              string `mapstructure:"dir"`               // 备份文件目录
+	MysqldumpPath    string `mapstructure:"mysqldump_path"`    // mysqldump 可执行文件路径
+	SchedulerEnabled bool   `mapstructure:"scheduler_enabled"` // 是否在本实例运行备份定时任务，多实例部署时只开启一个
+}
+
+// InstanceRegistryConfig 被监控实例登记配置
+type InstanceRegistryConfig struct {
+	// SecretKey 加密实例密码的密钥，为空时只能登记使用 credential_ref 的实例
+	SecretKey string `mapstructure:"secret_key"`
+	// CredentialDir credential_ref 中 file: 引用允许读取的目录，为空时不允许 file: 引用
+	CredentialDir string `mapstructure:"credential_dir"`
+	// CredentialEnvPrefix credential_ref 中 env: 引用的变量名必须带的前缀，为空时不允许 env: 引用
+	CredentialEnvPrefix string `mapstructure:"credential_env_prefix"`
 }
 
 // LogConfig 日志配置
@@ -104,6 +172,7 @@ func setDefaults() {
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.host", "localhost")
 	viper.SetDefault("server.mode", "debug")
+	viper.SetDefault("server.trusted_proxies", []string{})
 
 	// 数据库默认配置
 	viper.SetDefault("database.host", "localhost")
@@ -115,6 +184,8 @@ func setDefaults() {
 	viper.SetDefault("database.max_idle_conns", 10)
 	viper.SetDefault("database.max_open_conns", 100)
 	viper.SetDefault("database.conn_max_lifetime", "1h")
+	viper.SetDefault("database.idle_timeout", "10m")
+	viper.SetDefault("database.health_check_interval", "30s")
 
 	// Redis默认配置
 	viper.SetDefault("redis.host", "localhost")
@@ -122,6 +193,9 @@ func setDefaults() {
 	viper.SetDefault("redis.password", "")
 	viper.SetDefault("redis.db", 0)
 	viper.SetDefault("redis.pool_size", 10)
+	viper.SetDefault("redis.enabled", false)
+	viper.SetDefault("redis.session_ttl", "72h")
+	viper.SetDefault("redis.max_tool_runs", 50)
 
 	// 日志默认配置
 	viper.SetDefault("log.level", "info")
@@ -136,7 +210,51 @@ func setDefaults() {
 	viper.SetDefault("agent.host", "localhost")
 	viper.SetDefault("agent.port", "8081")
 	viper.SetDefault("agent.base_url", "")
+	viper.SetDefault("agent.http_port", "8082")
 	viper.SetDefault("agent.timeout", "5s")
+	viper.SetDefault("agent.transport", "rpc")
+	viper.SetDefault("agent.read_only", false)
+	viper.SetDefault("agent.report_history", true)
+
+	// 密码策略默认配置
+	viper.SetDefault("password_policy.min_length", 8)
+	viper.SetDefault("password_policy.require_uppercase", false)
+	viper.SetDefault("password_policy.require_lowercase", false)
+	viper.SetDefault("password_policy.require_digit", false)
+	viper.SetDefault("password_policy.require_symbol", false)
+	viper.SetDefault("password_policy.deny_list", []string{})
+
+	// 迁移默认配置
+	viper.SetDefault("migration.dir", "./migrations")
+
+	// 只读控制台默认配置
+	viper.SetDefault("query_console.default_rows", 100)
+	viper.SetDefault("query_console.max_rows", 1000)
+	viper.SetDefault("query_console.max_execution_time", "5s")
+
+	// 备份默认配置
+	viper.SetDefault("backup.dir", "./backups")
+	viper.SetDefault("backup.mysqldump_path", "mysqldump")
+	viper.SetDefault("backup.scheduler_enabled", true)
+
+	// 实例登记默认配置
+	viper.SetDefault("instance_registry.secret_key", "")
+	viper.SetDefault("instance_registry.credential_dir", "")
+	viper.SetDefault("instance_registry.credential_env_prefix", "MYSQL_CREDENTIAL_")
+
+	// 可修改的全局变量默认白名单
+	viper.SetDefault("variables.allowed", []string{
+		"max_connections",
+		"long_query_time",
+		"slow_query_log",
+		"log_queries_not_using_indexes",
+		"innodb_buffer_pool_size",
+		"innodb_io_capacity",
+		"innodb_io_capacity_max",
+		"table_open_cache",
+		"thread_cache_size",
+		"max_execution_time",
+	})
 }
 
 // GetDSN 获取数据库连接字符串
@@ -153,12 +271,17 @@ func (c *Config) GetDSN() string {
 
 // GetAdminDSN 获取不带数据库名的连接字符串
 func (c *Config) GetAdminDSN() string {
+	return c.Database.AdminDSN()
+}
+
+// AdminDSN 返回不带数据库名的连接字符串
+func (d DatabaseConfig) AdminDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/?charset=%s&parseTime=True&loc=Local",
-		c.Database.Username,
-		c.Database.Password,
-		c.Database.Host,
-		c.Database.Port,
-		c.Database.Charset,
+		d.Username,
+		d.Password,
+		d.Host,
+		d.Port,
+		d.Charset,
 	)
 }
 
@@ -172,12 +295,12 @@ func (c *Config) GetRedisAddr() string {
 	return fmt.Sprintf("%s:%d", c.Redis.Host, c.Redis.Port)
 }
 
-// GetAgentBaseURL 获取mysql-agent服务的基础URL
+// GetAgentBaseURL 获取mysql-agent HTTP服务的基础URL，未配置 base_url 时使用 host 与 http_port
 func (c *Config) GetAgentBaseURL() string {
 	if c.Agent.BaseURL != "" {
 		return c.Agent.BaseURL
 	}
-	return fmt.Sprintf("http://%s:%s", c.Agent.Host, c.Agent.Port)
+	return fmt.Sprintf("http://%s:%s", c.Agent.Host, c.Agent.HTTPPort)
 }
 
 // GetAgentRPCAddr 返回 mysql-agent 的 RPC 地址
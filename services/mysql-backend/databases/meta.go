@@ -0,0 +1,85 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"mysql-backend/config"
+	"mysql-backend/helper"
+)
+
+var (
+	metaMu     sync.Mutex
+	metaReady  bool
+	metaTables = make(map[string]struct{})
+)
+
+// MetaTable 返回后端元数据表的完整表名，元数据统一存放在 database.dbname 库中
+func MetaTable(name string) string {
+	return helper.TableScope(metaSchema(), name)
+}
+
+// EnsureMetaTable 确保元数据库以及指定的元数据表存在，ddl 为使用 MetaTable 表名的 CREATE TABLE IF NOT EXISTS 语句，
+// 同一条 ddl 在进程内只执行一次
+func EnsureMetaTable(ctx context.Context, ddl string) error {
+	db, err := GetAdminDB()
+	if err != nil {
+		return err
+	}
+
+	metaMu.Lock()
+	defer metaMu.Unlock()
+	if _, ok := metaTables[ddl]; ok {
+		return nil
+	}
+	if !metaReady {
+		stmt := "CREATE DATABASE IF NOT EXISTS " + helper.QuoteIdent(metaSchema())
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("创建元数据库失败: %w", err)
+		}
+		metaReady = true
+	}
+
+	if _, err := db.ExecContext(ctx, ddl); err != nil {
+		return fmt.Errorf("创建元数据表失败: %w", err)
+	}
+	metaTables[ddl] = struct{}{}
+	return nil
+}
+
+// EnsureMetaColumn 为已存在的元数据表补充后来新增的列，definition 为列类型及属性，如 `VARCHAR(64) NOT NULL`；
+// 调用前需先通过 EnsureMetaTable 建表，同一列在进程内只检查一次
+func EnsureMetaColumn(ctx context.Context, table, column, definition string) error {
+	db, err := GetAdminDB()
+	if err != nil {
+		return err
+	}
+
+	key := "column:" + table + "." + column
+	metaMu.Lock()
+	defer metaMu.Unlock()
+	if _, ok := metaTables[key]; ok {
+		return nil
+	}
+
+	var count int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+		metaSchema(), table, column).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("检查元数据表列失败: %w", err)
+	}
+	if count == 0 {
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", MetaTable(table), helper.QuoteIdent(column), definition)
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("添加元数据表列失败: %w", err)
+		}
+	}
+	metaTables[key] = struct{}{}
+	return nil
+}
+
+func metaSchema() string {
+	return strings.ReplaceAll(config.AppConfig.Database.DBName, "`", "")
+}
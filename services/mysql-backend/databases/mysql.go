@@ -1,8 +1,11 @@
 package databases
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
@@ -11,61 +14,227 @@ import (
 	"mysql-backend/config"
 )
 
+// adminInstance 连接池管理器中 [database] 管理连接的键
+const adminInstance = ""
+
+// poolPingTimeout 后台健康检查单次 ping 的超时
+const poolPingTimeout = 5 * time.Second
+
+// ErrUnknownInstance 实例未登记
+var ErrUnknownInstance = errors.New("unknown instance")
+
+// InstanceResolver 按实例ID返回登记实例的连接参数，未登记时 ok 为 false
+type InstanceResolver func(ctx context.Context, id string) (cfg config.DatabaseConfig, ok bool, err error)
+
+// instancePool 一个实例的连接池及其最近一次健康检查结果
+type instancePool struct {
+	db       *sql.DB
+	cfg      config.DatabaseConfig
+	lastUsed time.Time
+	healthy  bool
+}
+
 var (
-	adminDB *sql.DB
-	dbMu    sync.RWMutex
+	// pools 实例ID -> 连接池，adminInstance 为 [database] 的管理连接，其余为登记实例，首次使用时打开
+	pools    = make(map[string]*instancePool)
+	resolver InstanceResolver
+	dbMu     sync.Mutex
 )
 
 // 初始化
 func InitAdminDB() error {
 	dbMu.Lock()
 	defer dbMu.Unlock()
-	if adminDB != nil {
+	if _, ok := pools[adminInstance]; ok {
 		return nil
 	}
 
-	dsn := config.AppConfig.GetAdminDSN()
-	db, err := sql.Open("mysql", dsn)
+	cfg := config.AppConfig.Database
+	db, err := openPool(cfg)
 	if err != nil {
-		return fmt.Errorf("打开mysql失败: %w", err)
-	}
-
-	// 设置连接池参数
-	if config.AppConfig.Database.MaxIdleConns > 0 {
-		db.SetMaxIdleConns(config.AppConfig.Database.MaxIdleConns)
+		return err
 	}
-	if config.AppConfig.Database.MaxOpenConns > 0 {
-		db.SetMaxOpenConns(config.AppConfig.Database.MaxOpenConns)
-	}
-	if config.AppConfig.Database.ConnMaxLifetime > 0 {
-		db.SetConnMaxLifetime(time.Duration(config.AppConfig.Database.ConnMaxLifetime))
-	}
-
 	if err := db.Ping(); err != nil {
 		_ = db.Close()
 		return fmt.Errorf("尝试ping数据库失败: %w", err)
 	}
 
-	adminDB = db
+	pools[adminInstance] = &instancePool{db: db, cfg: cfg, lastUsed: time.Now(), healthy: true}
 	return nil
 }
 
+func openPool(cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("mysql", cfg.AdminDSN())
+	if err != nil {
+		return nil, fmt.Errorf("打开mysql失败: %w", err)
+	}
+
+	// 设置连接池参数
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	return db, nil
+}
+
 func GetAdminDB() (*sql.DB, error) {
-	dbMu.RLock()
-	defer dbMu.RUnlock()
-	if adminDB == nil {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	p, ok := pools[adminInstance]
+	if !ok {
 		return nil, fmt.Errorf("没有生成adminDB")
 	}
-	return adminDB, nil
+	p.lastUsed = time.Now()
+	return p.db, nil
+}
+
+// SetInstanceResolver 设置登记实例连接参数的来源，未设置时只能访问 [database]
+func SetInstanceResolver(r InstanceResolver) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	resolver = r
 }
 
+// GetInstanceDB 返回登记实例的连接池，id 为空时返回 [database] 的管理连接；
+// 连接池尚未打开或已因空闲关闭时按登记的连接参数打开
+func GetInstanceDB(ctx context.Context, id string) (*sql.DB, error) {
+	if id == adminInstance {
+		return GetAdminDB()
+	}
+
+	dbMu.Lock()
+	if p, ok := pools[id]; ok {
+		p.lastUsed = time.Now()
+		dbMu.Unlock()
+		return p.db, nil
+	}
+	resolve := resolver
+	dbMu.Unlock()
+
+	if resolve == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownInstance, id)
+	}
+	cfg, ok, err := resolve(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownInstance, id)
+	}
+
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	// 解析连接参数期间其他请求可能已打开同一实例
+	if p, ok := pools[id]; ok {
+		p.lastUsed = time.Now()
+		return p.db, nil
+	}
+	db, err := openPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+	pools[id] = &instancePool{db: db, cfg: cfg, lastUsed: time.Now(), healthy: true}
+	log.Printf("[databases] instance=%s pool opened %s:%d", id, cfg.Host, cfg.Port)
+	return db, nil
+}
+
+// CloseInstanceDB 关闭登记实例的连接池，实例连接参数变化或被删除时调用，下次使用时重新打开；
+// 进行中的查询完成后连接才会真正关闭
+func CloseInstanceDB(id string) {
+	if id == adminInstance {
+		return
+	}
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	closePoolLocked(id)
+}
+
+func closePoolLocked(id string) {
+	p, ok := pools[id]
+	if !ok {
+		return
+	}
+	if err := p.db.Close(); err != nil {
+		log.Printf("[databases] instance=%s close pool failed: %v", id, err)
+	}
+	delete(pools, id)
+}
+
+// StartPoolManager 每隔 database.health_check_interval 关闭空闲超过 database.idle_timeout 的登记实例连接池，
+// 并 ping 其余连接池记录健康状态，ctx 取消时退出
+func StartPoolManager(ctx context.Context) {
+	interval := config.AppConfig.Database.HealthCheckInterval
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			checkPools(ctx)
+		}
+	}()
+}
+
+func checkPools(ctx context.Context) {
+	type target struct {
+		id string
+		db *sql.DB
+	}
+	var targets []target
+	dbMu.Lock()
+	for id, p := range pools {
+		idle := p.cfg.IdleTimeout
+		if id != adminInstance && idle > 0 && time.Since(p.lastUsed) > idle && p.db.Stats().InUse == 0 {
+			closePoolLocked(id)
+			log.Printf("[databases] instance=%s pool closed after %s idle", id, idle)
+			continue
+		}
+		targets = append(targets, target{id: id, db: p.db})
+	}
+	dbMu.Unlock()
+
+	for _, t := range targets {
+		pingCtx, cancel := context.WithTimeout(ctx, poolPingTimeout)
+		err := t.db.PingContext(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		dbMu.Lock()
+		// ping 期间连接池可能已被关闭
+		if p, ok := pools[t.id]; ok && p.db == t.db {
+			switch {
+			case err != nil && p.healthy:
+				log.Printf("[databases] instance=%q health check failed: %v", t.id, err)
+			case err == nil && !p.healthy:
+				log.Printf("[databases] instance=%q recovered", t.id)
+			}
+			p.healthy = err == nil
+		}
+		dbMu.Unlock()
+	}
+}
+
+// CloseAdminDB 关闭全部连接池
 func CloseAdminDB() error {
 	dbMu.Lock()
 	defer dbMu.Unlock()
-	if adminDB == nil {
-		return nil
+	var errs []error
+	for id, p := range pools {
+		errs = append(errs, p.db.Close())
+		delete(pools, id)
 	}
-	err := adminDB.Close()
-	adminDB = nil
-	return err
+	return errors.Join(errs...)
 }
@@ -0,0 +1,61 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"mysql-backend/config"
+)
+
+var (
+	redisClient *redis.Client
+	redisMu     sync.RWMutex
+)
+
+// InitRedis 按 redis 配置建立连接池，redis.enabled 关闭时不做任何事
+func InitRedis(ctx context.Context) error {
+	redisMu.Lock()
+	defer redisMu.Unlock()
+	if redisClient != nil || !config.AppConfig.Redis.Enabled {
+		return nil
+	}
+
+	cfg := config.AppConfig.Redis
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.AppConfig.GetRedisAddr(),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		PoolSize: cfg.PoolSize,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return fmt.Errorf("尝试ping redis失败: %w", err)
+	}
+
+	redisClient = client
+	return nil
+}
+
+// GetRedis 返回 Redis 客户端，未启用或未初始化时返回错误
+func GetRedis() (*redis.Client, error) {
+	redisMu.RLock()
+	defer redisMu.RUnlock()
+	if redisClient == nil {
+		return nil, fmt.Errorf("redis 未启用")
+	}
+	return redisClient, nil
+}
+
+func CloseRedis() error {
+	redisMu.Lock()
+	defer redisMu.Unlock()
+	if redisClient == nil {
+		return nil
+	}
+	err := redisClient.Close()
+	redisClient = nil
+	return err
+}
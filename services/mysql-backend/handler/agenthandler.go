@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// ExecuteAgentPlan 处理执行审核后工具计划的请求
+func ExecuteAgentPlan(c *gin.Context) {
+	req := &request.AgentQueryRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.ValidateExecutePlan(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	if err := service.ValidateAgentTools(req.Ctx, req.Tools); err != nil {
+		writeBadRequest(c, "INVALID_TOOL_SPEC", err)
+		return
+	}
+	writeResponse(c, service.ExecuteAgentPlan(*req))
+}
+
+// ListAgentSessions 处理分页列出 agent 会话的请求，需要启用 Redis
+func ListAgentSessions(c *gin.Context) {
+	req := &request.AgentSessionListRequest{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.ListAgentSessions(*req))
+}
+
+// GetAgentUsage 处理查询 LLM token 用量与当日预算的请求
+func GetAgentUsage(c *gin.Context) {
+	req := &request.AgentUsageRequest{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.GetAgentUsage(*req))
+}
+
+// GetAgentHealth 处理 agent 健康检查请求，agent 不可用或无法连接时返回 503
+func GetAgentHealth(c *gin.Context) {
+	req := &request.AgentHealthRequest{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.GetAgentHealth(*req))
+}
+
+// ListAgentTools 处理查询 agent 工具列表及参数定义的请求
+func ListAgentTools(c *gin.Context) {
+	req := &request.AgentToolsRequest{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.ListAgentTools(*req))
+}
+
+// RunAgentTool 处理直接执行单个 agent 工具的请求，工具定义校验失败时返回 400
+func RunAgentTool(c *gin.Context) {
+	req := &request.AgentRunToolRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	if err := service.ValidateAgentTools(req.Ctx, []request.AgentToolCall{{Name: req.Name, Args: req.Args}}); err != nil {
+		writeBadRequest(c, "INVALID_TOOL_SPEC", err)
+		return
+	}
+	writeResponse(c, service.RunAgentTool(*req))
+}
+
+// ListAgentReports 处理分页查询诊断报告的请求
+func ListAgentReports(c *gin.Context) {
+	req := &request.AgentReportListRequest{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.ListAgentReports(*req))
+}
+
+// GetAgentReport 处理查询诊断报告详情的请求
+func GetAgentReport(c *gin.Context) {
+	req := &request.AgentReportRequest{}
+	if err := c.ShouldBindUri(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.GetAgentReport(*req))
+}
+
+// DiffAgentReports 处理对比两份诊断报告的请求
+func DiffAgentReports(c *gin.Context) {
+	req := &request.AgentReportDiffRequest{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.DiffAgentReports(*req))
+}
+
+// DeleteAgentReport 处理删除诊断报告的请求
+func DeleteAgentReport(c *gin.Context) {
+	req := &request.AgentReportRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.DeleteAgentReport(*req))
+}
+
+// GetAgentSession 处理查询 agent 会话详情的请求
+func GetAgentSession(c *gin.Context) {
+	req := &request.AgentSessionRequest{}
+	if !bindAgentSession(c, req) {
+		return
+	}
+	writeResponse(c, service.GetAgentSession(*req))
+}
+
+// ResumeAgentSession 处理重新执行会话中最近一轮失败提问的请求
+func ResumeAgentSession(c *gin.Context) {
+	req := &request.AgentSessionRequest{}
+	if !bindAgentSession(c, req) {
+		return
+	}
+	writeResponse(c, service.ResumeAgentSession(*req))
+}
+
+func bindAgentSession(c *gin.Context, req *request.AgentSessionRequest) bool {
+	if err := c.ShouldBindUri(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return false
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return false
+	}
+	req.Ctx = c.Request.Context()
+	return true
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// AuditActor 将操作人与客户端IP写入请求上下文。操作人优先使用 X-Operator 头，否则使用客户端IP，
+// 只用于审计标记；X-Operator 由客户端任意填写，agent 按调用方限流使用客户端IP
+func AuditActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		actor := strings.TrimSpace(c.GetHeader("X-Operator"))
+		if actor == "" {
+			actor = ip
+		}
+		ctx := service.WithActor(c.Request.Context(), actor)
+		c.Request = c.Request.WithContext(service.WithClientIP(ctx, ip))
+		c.Next()
+	}
+}
+
+// QueryAuditLog 处理查询审计日志的请求
+func QueryAuditLog(c *gin.Context) {
+	req := &request.AuditQueryRequest{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.QueryAudit(*req))
+}
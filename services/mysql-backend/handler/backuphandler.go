@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// TriggerMySQLBackup 处理触发逻辑备份的请求，备份在后台执行
+func TriggerMySQLBackup(c *gin.Context) {
+	req := &request.BackupRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.TriggerBackup(*req))
+}
+
+// GetMySQLBackup 处理查询备份任务状态的请求
+func GetMySQLBackup(c *gin.Context) {
+	req := &request.BackupStatusRequest{}
+	if err := c.ShouldBindUri(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.BackupStatus(*req))
+}
+
+// ListMySQLBackupSchedules 处理列出备份定时任务的请求
+func ListMySQLBackupSchedules(c *gin.Context) {
+	writeResponse(c, service.ListBackupSchedules(c.Request.Context()))
+}
+
+// CreateMySQLBackupSchedule 处理创建备份定时任务的请求
+func CreateMySQLBackupSchedule(c *gin.Context) {
+	req := &request.BackupScheduleRequest{}
+	if !bindBackupSchedule(c, req, false) {
+		return
+	}
+	writeResponse(c, service.CreateBackupSchedule(*req))
+}
+
+// UpdateMySQLBackupSchedule 处理更新备份定时任务的请求
+func UpdateMySQLBackupSchedule(c *gin.Context) {
+	req := &request.BackupScheduleRequest{}
+	if !bindBackupSchedule(c, req, true) {
+		return
+	}
+	writeResponse(c, service.UpdateBackupSchedule(*req))
+}
+
+// DeleteMySQLBackupSchedule 处理删除备份定时任务的请求
+func DeleteMySQLBackupSchedule(c *gin.Context) {
+	req := &request.DeleteBackupScheduleRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.RemoveBackupSchedule(*req))
+}
+
+func bindBackupSchedule(c *gin.Context, req *request.BackupScheduleRequest, requireID bool) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return false
+	}
+	if err := req.Validate(requireID); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return false
+	}
+	req.Ctx = c.Request.Context()
+	return true
+}
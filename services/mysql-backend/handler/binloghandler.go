@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// ListMySQLBinlogs 处理查看 binlog 文件与当前位置的请求
+func ListMySQLBinlogs(c *gin.Context) {
+	writeResponse(c, service.BinaryLogs(c.Request.Context()))
+}
+
+// PurgeMySQLBinlogs 处理清理 binlog 的请求
+func PurgeMySQLBinlogs(c *gin.Context) {
+	req := &request.PurgeBinlogRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.PurgeBinlog(*req))
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// CreateMySQLDatabase 处理创建数据库的请求
+func CreateMySQLDatabase(c *gin.Context) {
+	req := &request.CreateDatabaseRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.CreateDatabase(*req))
+}
+
+// DropMySQLDatabase 处理删除数据库的请求，未携带确认令牌时返回 428 与新签发的令牌
+func DropMySQLDatabase(c *gin.Context) {
+	req := &request.DropDatabaseRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+
+	response := service.DropDatabase(*req)
+	if response.Error == "CONFIRMATION_REQUIRED" {
+		c.JSON(http.StatusPreconditionRequired, response)
+		return
+	}
+	writeResponse(c, response)
+}
+
+// ListMySQLTables 处理列出库中表统计信息的请求
+func ListMySQLTables(c *gin.Context) {
+	req := &request.ListTablesRequest{}
+	if err := c.ShouldBindUri(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.ListTables(*req))
+}
+
+// ConvertMySQLCharset 处理转换库或表字符集的请求
+func ConvertMySQLCharset(c *gin.Context) {
+	req := &request.ConvertCharsetRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.ConvertCharset(*req))
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// ListMySQLInstances 处理列出登记实例的请求
+func ListMySQLInstances(c *gin.Context) {
+	writeResponse(c, service.ListInstances(c.Request.Context()))
+}
+
+// GetMySQLInstance 处理查询登记实例的请求
+func GetMySQLInstance(c *gin.Context) {
+	req := &request.MySQLInstanceIDRequest{}
+	if err := c.ShouldBindUri(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.GetInstance(*req))
+}
+
+// CreateMySQLInstance 处理登记实例的请求
+func CreateMySQLInstance(c *gin.Context) {
+	req := &request.MySQLInstanceRequest{}
+	if !bindMySQLInstance(c, req) {
+		return
+	}
+	writeResponse(c, service.CreateInstance(*req))
+}
+
+// UpdateMySQLInstance 处理更新登记实例的请求
+func UpdateMySQLInstance(c *gin.Context) {
+	req := &request.MySQLInstanceRequest{}
+	if !bindMySQLInstance(c, req) {
+		return
+	}
+	writeResponse(c, service.UpdateInstance(*req))
+}
+
+// DeleteMySQLInstance 处理删除登记实例的请求
+func DeleteMySQLInstance(c *gin.Context) {
+	req := &request.MySQLInstanceIDRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.RemoveInstance(*req))
+}
+
+func bindMySQLInstance(c *gin.Context, req *request.MySQLInstanceRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return false
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return false
+	}
+	req.Ctx = c.Request.Context()
+	return true
+}
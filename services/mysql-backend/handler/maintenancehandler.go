@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// MaintainMySQLTables 处理 OPTIMIZE/ANALYZE/CHECK TABLE 请求，async 时在后台执行
+func MaintainMySQLTables(c *gin.Context) {
+	req := &request.TableMaintenanceRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.MaintainTable(*req))
+}
+
+// GetMySQLMaintenanceJob 处理查询表维护任务的请求
+func GetMySQLMaintenanceJob(c *gin.Context) {
+	req := &request.MaintenanceJobRequest{}
+	if err := c.ShouldBindUri(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.MaintenanceStatus(*req))
+}
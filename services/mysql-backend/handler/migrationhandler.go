@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// ListPendingMigrations 处理查看待执行迁移的请求
+func ListPendingMigrations(c *gin.Context) {
+	req := &request.MigrationStatusRequest{}
+	if !bindMigrationStatus(c, req) {
+		return
+	}
+	writeResponse(c, service.PendingMigrations(*req))
+}
+
+// ListMigrationHistory 处理查看迁移历史的请求
+func ListMigrationHistory(c *gin.Context) {
+	req := &request.MigrationStatusRequest{}
+	if !bindMigrationStatus(c, req) {
+		return
+	}
+	writeResponse(c, service.MigrationHistory(*req))
+}
+
+// ApplyMigrations 处理执行迁移的请求
+func ApplyMigrations(c *gin.Context) {
+	req := &request.ApplyMigrationRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.ApplyMigration(*req))
+}
+
+func bindMigrationStatus(c *gin.Context, req *request.MigrationStatusRequest) bool {
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return false
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return false
+	}
+	req.Ctx = c.Request.Context()
+	return true
+}
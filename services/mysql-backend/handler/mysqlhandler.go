@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -27,6 +30,9 @@ func CreateMySQLUser(c *gin.Context) {
 
 	// 验证请求参数
 	if err := req.Validate(); err != nil {
+		if writePasswordPolicyViolation(c, err) {
+			return
+		}
 		response := models.StandardResponse{
 			Data:         models.CreateUserResponse{Success: false},
 			Error:        "VALIDATION_ERROR",
@@ -51,10 +57,122 @@ func CreateMySQLUser(c *gin.Context) {
 	c.JSON(statusCode, response)
 }
 
+// DropMySQLUser 处理删除MySQL用户的请求
+func DropMySQLUser(c *gin.Context) {
+	req := &request.DropUserRequest{}
+
+	if err := c.ShouldBindJSON(req); err != nil {
+		response := models.StandardResponse{
+			Data:         models.DropUserResponse{Success: false},
+			Error:        "INVALID_REQUEST",
+			ErrorMessage: err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		response := models.StandardResponse{
+			Data:         models.DropUserResponse{Success: false},
+			Error:        "VALIDATION_ERROR",
+			ErrorMessage: err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	req.Ctx = c.Request.Context()
+
+	response := service.DropUser(*req)
+	statusCode := http.StatusOK
+	if response.Error != "NO_ERROR" {
+		statusCode = http.StatusInternalServerError
+	}
+
+	// 返回统一响应格式
+	c.JSON(statusCode, response)
+}
+
+// ChangeMySQLUserPassword 处理修改MySQL用户密码的请求
+func ChangeMySQLUserPassword(c *gin.Context) {
+	req := &request.ChangePasswordRequest{}
+
+	if err := c.ShouldBindJSON(req); err != nil {
+		response := models.StandardResponse{
+			Data:         models.ChangePasswordResponse{Success: false},
+			Error:        "INVALID_REQUEST",
+			ErrorMessage: err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		if writePasswordPolicyViolation(c, err) {
+			return
+		}
+		response := models.StandardResponse{
+			Data:         models.ChangePasswordResponse{Success: false},
+			Error:        "VALIDATION_ERROR",
+			ErrorMessage: err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	req.Ctx = c.Request.Context()
+
+	response := service.ChangePassword(*req)
+	statusCode := http.StatusOK
+	if response.Error != "NO_ERROR" {
+		statusCode = http.StatusInternalServerError
+	}
+
+	// 返回统一响应格式
+	c.JSON(statusCode, response)
+}
+
+// CloneMySQLUser 处理从已有用户复制授权的请求
+func CloneMySQLUser(c *gin.Context) {
+	req := &request.CloneUserRequest{}
+
+	if err := c.ShouldBindJSON(req); err != nil {
+		response := models.StandardResponse{
+			Data:         models.CloneUserResponse{Success: false},
+			Error:        "INVALID_REQUEST",
+			ErrorMessage: err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		if writePasswordPolicyViolation(c, err) {
+			return
+		}
+		response := models.StandardResponse{
+			Data:         models.CloneUserResponse{Success: false},
+			Error:        "VALIDATION_ERROR",
+			ErrorMessage: err.Error(),
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	req.Ctx = c.Request.Context()
+
+	// 返回统一响应格式
+	writeResponse(c, service.CloneUser(*req))
+}
+
 func CheckMySQLUser(c *gin.Context) {
 	req := &request.CheckUserRequst{}
 
-	if err := c.ShouldBindJSON(req); err != nil {
+	// 优先读取 ?usernames=a,b,c，GET 请求体常被客户端和代理丢弃；无查询参数时兼容旧的 JSON 请求体
+	if usernames := c.QueryArray("usernames"); len(usernames) > 0 {
+		req.AddUsernames(usernames)
+		req.ExpandAll, _ = strconv.ParseBool(c.Query("expand_all"))
+	} else if err := c.ShouldBindJSON(req); err != nil {
 		response := models.StandardResponse{
 			Data:         nil,
 			Error:        "INVALID_REQUEST",
@@ -77,23 +195,33 @@ func CheckMySQLUser(c *gin.Context) {
 	c.JSON(statusCode, response)
 }
 
-func QueryAgent(c *gin.Context) {
-	req := &request.AgentQueryRequest{}
+// ListMySQLUsers 处理分页列出MySQL用户的请求
+func ListMySQLUsers(c *gin.Context) {
+	req := &request.ListUsersRequest{}
 
-	if err := c.ShouldBindJSON(req); err != nil {
+	if err := c.ShouldBindQuery(req); err != nil {
 		response := models.StandardResponse{
 			Data:         nil,
 			Error:        "INVALID_REQUEST",
 			ErrorMessage: err.Error(),
 		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
 
+	if err := req.Validate(); err != nil {
+		response := models.StandardResponse{
+			Data:         nil,
+			Error:        "VALIDATION_ERROR",
+			ErrorMessage: err.Error(),
+		}
 		c.JSON(http.StatusBadRequest, response)
 		return
 	}
 
 	req.Ctx = c.Request.Context()
 
-	response := service.QueryAgent(*req)
+	response := service.ListUsers(*req)
 	statusCode := http.StatusOK
 	if response.Error != "NO_ERROR" {
 		statusCode = http.StatusInternalServerError
@@ -102,3 +230,102 @@ func QueryAgent(c *gin.Context) {
 	// 返回统一响应格式
 	c.JSON(statusCode, response)
 }
+
+func QueryAgent(c *gin.Context) {
+	req := &request.AgentQueryRequest{}
+
+	if err := c.ShouldBindJSON(req); err != nil {
+		response := models.StandardResponse{
+			Data:         nil,
+			Error:        "INVALID_REQUEST",
+			ErrorMessage: err.Error(),
+		}
+
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	req.Ctx = c.Request.Context()
+	if err := service.ValidateAgentTools(req.Ctx, req.Tools); err != nil {
+		writeBadRequest(c, "INVALID_TOOL_SPEC", err)
+		return
+	}
+
+	// 返回统一响应格式
+	writeResponse(c, service.QueryAgent(*req))
+}
+
+// QueryAgentStream 以 Server-Sent Events 转发 mysql-agent 的诊断增量事件（plan/tool/summary/done）
+func QueryAgentStream(c *gin.Context) {
+	req := &request.AgentQueryRequest{}
+
+	if err := c.ShouldBindJSON(req); err != nil {
+		response := models.StandardResponse{
+			Data:         nil,
+			Error:        "INVALID_REQUEST",
+			ErrorMessage: err.Error(),
+		}
+
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	req.Ctx = c.Request.Context()
+	if err := service.ValidateAgentTools(req.Ctx, req.Tools); err != nil {
+		writeBadRequest(c, "INVALID_TOOL_SPEC", err)
+		return
+	}
+
+	started := false
+	err := service.StreamAgent(*req, func(event string, data json.RawMessage) {
+		if !started {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			started = true
+		}
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+	})
+	if err == nil {
+		return
+	}
+
+	// 尚未推送任何事件时仍可返回统一响应格式，否则以 error 事件结束流
+	if limit, ok := service.AgentRateLimitFromError(err); ok && !started {
+		writeResponse(c, models.StandardResponse{
+			Data:         limit,
+			Error:        "RATE_LIMITED",
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+	if service.IsAgentBusy(err) && !started {
+		writeResponse(c, models.StandardResponse{Data: nil, Error: "AGENT_BUSY", ErrorMessage: err.Error()})
+		return
+	}
+	if !started {
+		c.JSON(http.StatusInternalServerError, models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+	c.SSEvent("error", gin.H{"error": err.Error()})
+	c.Writer.Flush()
+}
+
+// writePasswordPolicyViolation 密码未通过策略校验时返回 PASSWORD_POLICY_VIOLATION 及未通过的规则列表
+func writePasswordPolicyViolation(c *gin.Context, err error) bool {
+	var policyErr *request.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, models.StandardResponse{
+		Data:         models.PasswordPolicyViolation{Success: false, Violations: policyErr.Violations},
+		Error:        "PASSWORD_POLICY_VIOLATION",
+		ErrorMessage: err.Error(),
+	})
+	return true
+}
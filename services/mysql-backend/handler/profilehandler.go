@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// ListPrivilegeProfiles 处理列出权限模板的请求
+func ListPrivilegeProfiles(c *gin.Context) {
+	writeResponse(c, service.ListProfiles(c.Request.Context()))
+}
+
+// CreatePrivilegeProfile 处理创建权限模板的请求
+func CreatePrivilegeProfile(c *gin.Context) {
+	req := &request.PrivilegeProfileRequest{}
+	if !bindProfileRequest(c, req) {
+		return
+	}
+	writeResponse(c, service.CreateProfile(*req))
+}
+
+// UpdatePrivilegeProfile 处理更新权限模板的请求
+func UpdatePrivilegeProfile(c *gin.Context) {
+	req := &request.PrivilegeProfileRequest{}
+	if !bindProfileRequest(c, req) {
+		return
+	}
+	writeResponse(c, service.UpdateProfile(*req))
+}
+
+// DeletePrivilegeProfile 处理删除权限模板的请求
+func DeletePrivilegeProfile(c *gin.Context) {
+	req := &request.DeleteProfileRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.DeleteProfile(*req))
+}
+
+func bindProfileRequest(c *gin.Context, req *request.PrivilegeProfileRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return false
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return false
+	}
+	req.Ctx = c.Request.Context()
+	return true
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/config"
+	"mysql-backend/models"
+)
+
+// RejectWhenReadOnly 在只读部署下拒绝所有会修改 MySQL 或元数据的请求
+func RejectWhenReadOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.AppConfig != nil && config.AppConfig.Agent.ReadOnly {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.StandardResponse{
+				Data:         nil,
+				Error:        "READ_ONLY_MODE",
+				ErrorMessage: "server is running in read-only mode",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/config"
+	"mysql-backend/models"
+)
+
+func TestRejectWhenReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prev := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = prev })
+
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		wantCode int
+		wantNext bool
+	}{
+		{name: "read_only", cfg: &config.Config{Agent: config.AgentConfig{ReadOnly: true}}, wantCode: http.StatusForbidden},
+		{name: "writable", cfg: &config.Config{Agent: config.AgentConfig{ReadOnly: false}}, wantCode: http.StatusOK, wantNext: true},
+		{name: "config not loaded", cfg: nil, wantCode: http.StatusOK, wantNext: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig = tt.cfg
+			called := false
+			r := gin.New()
+			r.POST("/write", RejectWhenReadOnly(), func(c *gin.Context) {
+				called = true
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/write", nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if called != tt.wantNext {
+				t.Fatalf("next handler called = %v, want %v", called, tt.wantNext)
+			}
+			if tt.wantNext {
+				return
+			}
+			var resp models.StandardResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Error != "READ_ONLY_MODE" {
+				t.Fatalf("error = %q, want READ_ONLY_MODE", resp.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// GetMySQLReplicationStatus 处理查看复制状态的请求
+func GetMySQLReplicationStatus(c *gin.Context) {
+	req := &request.ReplicationStatusRequest{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.ReplicationStatus(*req))
+}
+
+// ChangeMySQLReplicationSource 处理配置复制源的请求
+func ChangeMySQLReplicationSource(c *gin.Context) {
+	req := &request.ChangeSourceRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.ChangeSource(*req))
+}
+
+// StartMySQLReplication 处理启动复制的请求
+func StartMySQLReplication(c *gin.Context) {
+	req, ok := bindReplicationControl(c)
+	if !ok {
+		return
+	}
+	writeResponse(c, service.StartReplication(*req))
+}
+
+// StopMySQLReplication 处理停止复制的请求
+func StopMySQLReplication(c *gin.Context) {
+	req, ok := bindReplicationControl(c)
+	if !ok {
+		return
+	}
+	writeResponse(c, service.StopReplication(*req))
+}
+
+// SkipMySQLReplicationError 处理跳过复制错误的请求
+func SkipMySQLReplicationError(c *gin.Context) {
+	req := &request.SkipReplicationErrorRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return
+	}
+	req.Ctx = c.Request.Context()
+	writeResponse(c, service.SkipReplication(*req))
+}
+
+func bindReplicationControl(c *gin.Context) (*request.ReplicationControlRequest, bool) {
+	req := &request.ReplicationControlRequest{}
+	// 请求体可以为空，表示操作全部通道的两个线程
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(req); err != nil {
+			writeBadRequest(c, "INVALID_REQUEST", err)
+			return nil, false
+		}
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return nil, false
+	}
+	req.Ctx = c.Request.Context()
+	return req, true
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/models"
+	"mysql-backend/request"
+	"mysql-backend/service"
+)
+
+// CreateMySQLRole 处理创建角色的请求
+func CreateMySQLRole(c *gin.Context) {
+	req := &request.RoleRequest{}
+	if !bindRoleRequest(c, req) {
+		return
+	}
+	writeResponse(c, service.CreateRole(*req))
+}
+
+// DropMySQLRole 处理删除角色的请求
+func DropMySQLRole(c *gin.Context) {
+	req := &request.RoleRequest{}
+	if !bindRoleRequest(c, req) {
+		return
+	}
+	writeResponse(c, service.DropRole(*req))
+}
+
+// GrantMySQLRole 处理将角色授予用户的请求
+func GrantMySQLRole(c *gin.Context) {
+	req := &request.GrantRoleRequest{}
+	if !bindGrantRoleRequest(c, req, false) {
+		return
+	}
+	writeResponse(c, service.GrantRole(*req))
+}
+
+// SetMySQLDefaultRole 处理设置用户默认角色的请求
+func SetMySQLDefaultRole(c *gin.Context) {
+	req := &request.GrantRoleRequest{}
+	if !bindGrantRoleRequest(c, req, true) {
+		return
+	}
+	writeResponse(c, service.SetDefaultRole(*req))
+}
+
+func bindRoleRequest(c *gin.Context, req *request.RoleRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return false
+	}
+	if err := req.Validate(); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return false
+	}
+	req.Ctx = c.Request.Context()
+	return true
+}
+
+func bindGrantRoleRequest(c *gin.Context, req *request.GrantRoleRequest, allowEmptyRoles bool) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeBadRequest(c, "INVALID_REQUEST", err)
+		return false
+	}
+	if err := req.Validate(allowEmptyRoles); err != nil {
+		writeBadRequest(c, "VALIDATION_ERROR", err)
+		return false
+	}
+	req.Ctx = c.Request.Context()
+	return true
+}
+
+func writeBadRequest(c *gin.Context, code string, err error) {
+	c.JSON(http.StatusBadRequest, models.StandardResponse{
+		Data:         nil,
+		Error:        code,
+		ErrorMessage: err.Error(),
+	})
+}
+
+// writeResponse 根据响应中的error字段决定HTTP状态码
+func writeResponse(c *gin.Context, response models.StandardResponse) {
+	statusCode := http.StatusOK
+	if response.Error != "NO_ERROR" {
+		statusCode = http.StatusInternalServerError
+	}
+	if response.Error == "AGENT_BUSY" || response.Error == "AGENT_UNHEALTHY" {
+		statusCode = http.StatusServiceUnavailable
+	}
+	// agent 限流时返回 429，Retry-After 为向上取整的秒数
+	if limit, ok := response.Data.(models.AgentRateLimit); ok && response.Error == "RATE_LIMITED" {
+		statusCode = http.StatusTooManyRequests
+		c.Header("Retry-After", strconv.FormatInt((limit.RetryAfterMs+999)/1000, 10))
+	}
+	c.JSON(statusCode, response)
+}
@@ -0,0 +1,75 @@
+package helper
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	kolkata := mustLocation(t, "Asia/Kolkata")
+	kathmandu := mustLocation(t, "Asia/Kathmandu")
+
+	cases := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// 步长
+		{"minute step", "*/15 * * * *", time.Date(2026, 10, 17, 10, 7, 0, 0, time.UTC), time.Date(2026, 10, 17, 10, 15, 0, 0, time.UTC)},
+		{"hour step", "0 */6 * * *", time.Date(2026, 10, 17, 7, 0, 0, 0, time.UTC), time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)},
+		{"range step", "10-40/15 * * * *", time.Date(2026, 10, 17, 10, 26, 0, 0, time.UTC), time.Date(2026, 10, 17, 10, 40, 0, 0, time.UTC)},
+		{"value step", "0 0 3/10 * *", time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)},
+		{"strictly after", "30 10 * * *", time.Date(2026, 10, 17, 10, 30, 0, 0, time.UTC), time.Date(2026, 10, 18, 10, 30, 0, 0, time.UTC)},
+
+		// 日与星期：都受限制时任一满足即可，覆盖全部取值的字段视为不限制
+		{"dom or dow", "0 0 13 * 5", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{"dom step or dow", "0 0 */10 * 1", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)},
+		{"dom step only", "0 0 */10 * *", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)},
+		{"full dom range is unrestricted", "0 0 1-31 * 1", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)},
+		{"full dow range is unrestricted", "0 0 20 * 0-7", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{"dow step one is unrestricted", "0 0 20 * */1", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"impossible date", "0 0 31 2 *", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}},
+
+		// 夏令时：2026-03-08 02:00 跳到 03:00，2026-11-01 02:00 回拨到 01:00
+		{"skipped local time", "30 2 * * *", time.Date(2026, 3, 7, 3, 0, 0, 0, newYork), time.Date(2026, 3, 9, 2, 30, 0, 0, newYork)},
+		{"hour after spring forward", "0 4 * * *", time.Date(2026, 3, 8, 0, 30, 0, 0, newYork), time.Date(2026, 3, 8, 4, 0, 0, 0, newYork)},
+		{"hour after fall back", "0 3 * * *", time.Date(2026, 11, 1, 0, 30, 0, 0, newYork), time.Date(2026, 11, 1, 3, 0, 0, 0, newYork)},
+
+		// 非整点时区偏移
+		{"half hour offset", "15 10 * * *", time.Date(2026, 10, 17, 8, 50, 0, 0, kolkata), time.Date(2026, 10, 17, 10, 15, 0, 0, kolkata)},
+		{"half hour offset top of hour", "0 9 * * *", time.Date(2026, 10, 17, 7, 40, 0, 0, kolkata), time.Date(2026, 10, 17, 9, 0, 0, 0, kolkata)},
+		{"quarter hour offset", "5 3 * * *", time.Date(2026, 10, 17, 1, 59, 0, 0, kathmandu), time.Date(2026, 10, 17, 3, 5, 0, 0, kathmandu)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sched, err := ParseCron(c.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", c.expr, err)
+			}
+			if got := sched.Next(c.from); !got.Equal(c.want) {
+				t.Fatalf("Next(%s) for %q = %s, want %s", c.from, c.expr, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package helper
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// 生成密码使用的字符集，特殊字符避开引号、反斜杠与 shell 元字符，便于客户端直接使用
+const (
+	passwordUpper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordLower  = "abcdefghijkmnopqrstuvwxyz"
+	passwordDigits = "23456789"
+	passwordSymbol = "!#%+-.:=?@^_~"
+)
+
+// GeneratePassword 使用 crypto/rand 生成指定长度的随机密码，保证大小写字母、数字与特殊字符各至少出现一次
+func GeneratePassword(length int) (string, error) {
+	classes := []string{passwordUpper, passwordLower, passwordDigits, passwordSymbol}
+	if length < len(classes) {
+		return "", errors.New("password length too short")
+	}
+	all := passwordUpper + passwordLower + passwordDigits + passwordSymbol
+
+	buf := make([]byte, length)
+	for i := range buf {
+		charset := all
+		if i < len(classes) {
+			charset = classes[i]
+		}
+		ch, err := randomChar(charset)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = ch
+	}
+
+	// 打乱顺序，避免固定位置出现固定字符类别
+	for i := len(buf) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		buf[i], buf[j.Int64()] = buf[j.Int64()], buf[i]
+	}
+	return string(buf), nil
+}
+
+func randomChar(charset string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+	if err != nil {
+		return 0, err
+	}
+	return charset[n.Int64()], nil
+}
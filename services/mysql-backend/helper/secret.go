@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrNoSecretKey 未配置加密密钥时无法加解密
+var ErrNoSecretKey = errors.New("secret key is not configured")
+
+// EncryptSecret 使用 AES-256-GCM 加密 plaintext，密钥为 passphrase 的 SHA-256，
+// 返回 base64(nonce|密文)，每次加密使用随机 nonce
+func EncryptSecret(passphrase, plaintext string) (string, error) {
+	gcm, err := secretCipher(passphrase)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 的输出，密钥不一致或内容被篡改时返回错误
+func DecryptSecret(passphrase, encoded string) (string, error) {
+	gcm, err := secretCipher(passphrase)
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode secret: %w", err)
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("secret is too short")
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret: %w", err)
+	}
+	return string(plain), nil
+}
+
+func secretCipher(passphrase string) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, ErrNoSecretKey
+	}
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,51 @@
+package helper
+
+import "strings"
+
+// sqlStringReplacer 转义单引号字符串字面量中的特殊字符，与 mysql_real_escape_string 一致
+var sqlStringReplacer = strings.NewReplacer(
+	"\\", "\\\\",
+	"'", "\\'",
+	"\"", "\\\"",
+	"\x00", "\\0",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\x1a", "\\Z",
+)
+
+// QuoteString 返回单引号包裹并转义后的字符串字面量，用于用户名、host、密码等
+func QuoteString(s string) string {
+	return "'" + EscapeSQLString(s) + "'"
+}
+
+// QuoteIdent 返回反引号包裹的标识符，名称中的反引号按 MySQL 规则双写
+func QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Account 返回 'user'@'host' 形式的账号名
+func Account(user, host string) string {
+	return QuoteString(user) + "@" + QuoteString(host)
+}
+
+// DatabaseScope 返回库级授权范围，"*" 表示全局 *.*
+func DatabaseScope(db string) string {
+	if db == "*" {
+		return "*.*"
+	}
+	return QuoteIdent(db) + ".*"
+}
+
+// TableScope 返回表级授权范围 `db`.`table`
+func TableScope(db, table string) string {
+	return QuoteIdent(db) + "." + QuoteIdent(table)
+}
+
+// QuoteIdentList 返回逗号分隔的标识符列表，用于列级授权
+func QuoteIdentList(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, QuoteIdent(name))
+	}
+	return strings.Join(quoted, ", ")
+}
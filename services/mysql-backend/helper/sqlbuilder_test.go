@@ -0,0 +1,150 @@
+package helper
+
+import "testing"
+
+func TestQuoteString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "app", want: `'app'`},
+		{name: "empty", in: "", want: `''`},
+		{name: "single quote", in: "o'brien", want: `'o\'brien'`},
+		{name: "quote breakout", in: "x' OR '1'='1", want: `'x\' OR \'1\'=\'1'`},
+		{name: "backslash", in: `a\b`, want: `'a\\b'`},
+		{name: "backslash before quote", in: `a\'; DROP USER root; --`, want: `'a\\\'; DROP USER root; --'`},
+		{name: "double quote", in: `say "hi"`, want: `'say \"hi\"'`},
+		{name: "backtick untouched", in: "a`b", want: "'a`b'"},
+		{name: "nul", in: "a\x00b", want: `'a\0b'`},
+		{name: "newline and carriage return", in: "a\nb\rc", want: `'a\nb\rc'`},
+		{name: "ctrl-z", in: "a\x1ab", want: `'a\Zb'`},
+		{name: "like wildcards kept", in: "10.0.%._", want: `'10.0.%._'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuoteString(tt.in); got != tt.want {
+				t.Fatalf("QuoteString(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "orders", want: "`orders`"},
+		{name: "empty", in: "", want: "``"},
+		{name: "backtick doubled", in: "a`b", want: "`a``b`"},
+		{name: "backtick breakout", in: "x`; DROP DATABASE prod; --", want: "`x``; DROP DATABASE prod; --`"},
+		{name: "only backticks", in: "``", want: "``````"},
+		{name: "single quote untouched", in: "o'brien", want: "`o'brien`"},
+		{name: "backslash untouched", in: `a\b`, want: "`a\\b`"},
+		{name: "nul passed through", in: "a\x00b", want: "`a\x00b`"},
+		{name: "newline passed through", in: "a\nb\rc", want: "`a\nb\rc`"},
+		{name: "wildcards literal", in: "db_%", want: "`db_%`"},
+		{name: "star literal", in: "*", want: "`*`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuoteIdent(tt.in); got != tt.want {
+				t.Fatalf("QuoteIdent(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccount(t *testing.T) {
+	tests := []struct {
+		name       string
+		user, host string
+		want       string
+	}{
+		{name: "plain", user: "app", host: "localhost", want: `'app'@'localhost'`},
+		{name: "any host", user: "app", host: "%", want: `'app'@'%'`},
+		{name: "host wildcards kept", user: "app", host: "10.0.%.1_", want: `'app'@'10.0.%.1_'`},
+		{name: "quote in user", user: "a'b", host: "%", want: `'a\'b'@'%'`},
+		{name: "at sign breakout in user", user: "x'@'%' IDENTIFIED BY 'p", host: "h", want: `'x\'@\'%\' IDENTIFIED BY \'p'@'h'`},
+		{name: "quote and backslash in host", user: "app", host: `h\'`, want: `'app'@'h\\\''`},
+		{name: "nul and newline", user: "a\x00", host: "h\n", want: `'a\0'@'h\n'`},
+		{name: "empty user", user: "", host: "%", want: `''@'%'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Account(tt.user, tt.host); got != tt.want {
+				t.Fatalf("Account(%q, %q) = %s, want %s", tt.user, tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDatabaseScope(t *testing.T) {
+	tests := []struct {
+		name string
+		db   string
+		want string
+	}{
+		{name: "global", db: "*", want: "*.*"},
+		{name: "database", db: "shop", want: "`shop`.*"},
+		{name: "star inside name is literal", db: "shop*", want: "`shop*`.*"},
+		{name: "double star is literal", db: "**", want: "`**`.*"},
+		{name: "star dot star is literal", db: "*.*", want: "`*.*`.*"},
+		{name: "wildcards literal", db: "shop_%", want: "`shop_%`.*"},
+		{name: "backtick breakout", db: "x`.* TO root; --", want: "`x``.* TO root; --`.*"},
+		{name: "quote and backslash", db: `a'\`, want: "`a'\\`.*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DatabaseScope(tt.db); got != tt.want {
+				t.Fatalf("DatabaseScope(%q) = %s, want %s", tt.db, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		db, table string
+		want      string
+	}{
+		{name: "plain", db: "shop", table: "orders", want: "`shop`.`orders`"},
+		{name: "star database is literal", db: "*", table: "orders", want: "`*`.`orders`"},
+		{name: "star table is literal", db: "shop", table: "*", want: "`shop`.`*`"},
+		{name: "backtick in both", db: "a`b", table: "c`d", want: "`a``b`.`c``d`"},
+		{name: "dot in table", db: "shop", table: "a.b", want: "`shop`.`a.b`"},
+		{name: "nul and newline", db: "a\x00", table: "b\n", want: "`a\x00`.`b\n`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TableScope(tt.db, tt.table); got != tt.want {
+				t.Fatalf("TableScope(%q, %q) = %s, want %s", tt.db, tt.table, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteIdentList(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{name: "empty", names: nil, want: ""},
+		{name: "single", names: []string{"id"}, want: "`id`"},
+		{name: "multiple", names: []string{"id", "name"}, want: "`id`, `name`"},
+		{name: "comma inside name", names: []string{"a, b"}, want: "`a, b`"},
+		{name: "paren breakout", names: []string{"id`) ON *.* TO x; --"}, want: "`id``) ON *.* TO x; --`"},
+		{name: "quote backslash star", names: []string{"o'b", `c\d`, "*"}, want: "`o'b`, `c\\d`, `*`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuoteIdentList(tt.names); got != tt.want {
+				t.Fatalf("QuoteIdentList(%q) = %s, want %s", tt.names, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// readOnlyKeywords 只读控制台允许的语句起始关键字
+var readOnlyKeywords = map[string]struct{}{
+	"SELECT":   {},
+	"SHOW":     {},
+	"EXPLAIN":  {},
+	"DESC":     {},
+	"DESCRIBE": {},
+}
+
+// forbiddenReadOnlyPattern 即使以 SELECT 开头也会写文件、加锁或执行语句的子句
+var forbiddenReadOnlyPattern = regexp.MustCompile(`(?i)\b(INTO\s+(OUTFILE|DUMPFILE|@)|FOR\s+UPDATE|FOR\s+SHARE|LOCK\s+IN\s+SHARE\s+MODE|ANALYZE)\b`)
+
+// ReadOnlyStatement validates that script is exactly one read-only statement
+// (SELECT/SHOW/EXPLAIN/DESC) and returns it with comments stripped.
+func ReadOnlyStatement(script string) (string, error) {
+	stmts := SplitSQLStatements(script)
+	if len(stmts) == 0 {
+		return "", errors.New("sql is empty")
+	}
+	if len(stmts) > 1 {
+		return "", errors.New("only a single statement is allowed")
+	}
+	stmt := stmts[0]
+
+	keyword := LeadingKeyword(stmt)
+	if _, ok := readOnlyKeywords[keyword]; !ok {
+		return "", fmt.Errorf("statement %s is not allowed, only SELECT/SHOW/EXPLAIN/DESC", keyword)
+	}
+	// 去掉字符串字面量后再检查，避免误判 WHERE note = 'for update'
+	if m := forbiddenReadOnlyPattern.FindString(stripStringLiterals(stmt)); m != "" {
+		return "", fmt.Errorf("clause %q is not allowed in read-only queries", m)
+	}
+	return stmt, nil
+}
+
+// stripStringLiterals replaces the contents of quoted strings with empty quotes.
+func stripStringLiterals(stmt string) string {
+	var sb strings.Builder
+	for i := 0; i < len(stmt); i++ {
+		ch := stmt[i]
+		if ch != '\'' && ch != '"' {
+			sb.WriteByte(ch)
+			continue
+		}
+		j := i + 1
+		for j < len(stmt) && stmt[j] != ch {
+			if stmt[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		sb.WriteString("''")
+		i = j
+	}
+	return sb.String()
+}
+
+// LeadingKeyword returns the first keyword of stmt in upper case, skipping opening parentheses.
+func LeadingKeyword(stmt string) string {
+	stmt = strings.TrimLeft(stmt, "( \t\r\n")
+	end := strings.IndexFunc(stmt, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if end == -1 {
+		end = len(stmt)
+	}
+	return strings.ToUpper(stmt[:end])
+}
@@ -0,0 +1,80 @@
+package helper
+
+import "strings"
+
+// SplitSQLStatements splits a SQL script into statements on top-level semicolons,
+// ignoring semicolons inside quotes, backticks and comments. Comment-only fragments are dropped.
+// DELIMITER directives are not supported.
+func SplitSQLStatements(script string) []string {
+	var stmts []string
+	var sb strings.Builder
+	hasCode := false
+
+	flush := func() {
+		if stmt := strings.TrimSpace(sb.String()); stmt != "" && hasCode {
+			stmts = append(stmts, stmt)
+		}
+		sb.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case ch == '\'' || ch == '"' || ch == '`':
+			end := i + 1
+			for end < len(script) {
+				if script[end] == '\\' && ch != '`' {
+					end += 2
+					continue
+				}
+				if script[end] == ch {
+					// 连续两个引号表示转义
+					if end+1 < len(script) && script[end+1] == ch {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end, len(script)-1)
+			sb.WriteString(script[i : end+1])
+			hasCode = true
+			i = end
+		case ch == '-' && isLineComment(script[i:]), ch == '#':
+			nl := strings.IndexByte(script[i:], '\n')
+			if nl == -1 {
+				i = len(script)
+			} else {
+				i += nl
+				sb.WriteByte('\n')
+			}
+		case ch == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end == -1 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+			sb.WriteByte(' ')
+		case ch == ';':
+			flush()
+		default:
+			sb.WriteByte(ch)
+			if ch != ' ' && ch != '\t' && ch != '\n' && ch != '\r' {
+				hasCode = true
+			}
+		}
+	}
+	flush()
+	return stmts
+}
+
+// isLineComment reports whether s starts with "--" followed by whitespace or end of input.
+func isLineComment(s string) bool {
+	if !strings.HasPrefix(s, "--") {
+		return false
+	}
+	return len(s) == 2 || strings.ContainsRune(" \t\r\n", rune(s[2]))
+}
@@ -1,12 +1,13 @@
 package helper
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
-// escapeSQLString 简单转义用于单引号包裹的 MySQL 字符串字面量
+// EscapeSQLString 转义用于单引号包裹的 MySQL 字符串字面量，不含外层引号
 func EscapeSQLString(s string) string {
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "'", "\\'")
-	return s
+	return sqlStringReplacer.Replace(s)
 }
 
 // UniqueStrings returns a new slice with duplicates removed, preserving the first-seen order.
@@ -28,6 +29,7 @@ func UniqueStrings(in []string) []string {
 // - "ON *.*" => returns "*" indicating global privileges.
 // - "ON `db`.*" or "ON db.*" => returns "db".
 // - "ON `db`.`table`" or "ON db.table" => returns "db".
+// Lines that do not start with "GRANT " (e.g. partial-revoke "REVOKE ... FROM" lines) are skipped.
 // It preserves discovery order and de-duplicates results.
 func ParseDatabasesFromGrants(grants []string) []string {
 	if len(grants) == 0 {
@@ -57,7 +59,7 @@ func ParseDatabasesFromGrants(grants []string) []string {
 
 		lower := strings.ToLower(s)
 		idx := strings.Index(lower, " on ")
-		if idx == -1 {
+		if !strings.HasPrefix(lower, "grant ") || idx == -1 || isProxyGrant(lower) {
 			continue
 		}
 
@@ -102,6 +104,8 @@ func ParseDatabasesFromGrants(grants []string) []string {
 
 // ParsePrivilegesFromGrants parses SHOW GRANTS lines and extracts individual privilege names.
 // Converts "GRANT SELECT, INSERT ON *.* TO 'user'@'host'" to ["SELECT", "INSERT"]
+// Column privileges keep their column list: "GRANT SELECT (`email`, `name`) ON ..." yields "SELECT (email, name)".
+// Lines that do not start with "GRANT " (e.g. "REVOKE GRANT OPTION ON ...") are skipped.
 func ParsePrivilegesFromGrants(grants []string) []string {
 	if len(grants) == 0 {
 		return nil
@@ -116,29 +120,27 @@ func ParsePrivilegesFromGrants(grants []string) []string {
 			continue
 		}
 
-		// Find "GRANT" and "ON" positions
+		// Only GRANT lines carry privileges; "ON" must follow the leading "GRANT"
 		lower := strings.ToLower(grant)
-		grantIdx := strings.Index(lower, "grant ")
 		onIdx := strings.Index(lower, " on ")
 
-		if grantIdx == -1 || onIdx == -1 || grantIdx >= onIdx {
+		if !strings.HasPrefix(lower, "grant ") || onIdx == -1 || isProxyGrant(lower) {
 			continue
 		}
 
 		// Extract the privileges part between "GRANT" and "ON"
-		privPart := grant[grantIdx+6 : onIdx] // +6 for "grant "
+		privPart := grant[6:onIdx] // 6 for "grant "
 		privPart = strings.TrimSpace(privPart)
 
-		// Split by comma and clean up each privilege
-		privs := strings.Split(privPart, ",")
+		// Split by top-level comma and clean up each privilege
+		privs := splitTopLevel(privPart)
 		for _, priv := range privs {
 			priv = strings.TrimSpace(priv)
 			if priv == "" {
 				continue
 			}
 
-			// Convert to uppercase for consistency
-			priv = strings.ToUpper(priv)
+			priv = normalizePrivilege(priv)
 
 			// Skip if already seen
 			if _, exists := seen[priv]; exists {
@@ -152,3 +154,230 @@ func ParsePrivilegesFromGrants(grants []string) []string {
 
 	return allPrivileges
 }
+
+// ParsePrivilegesByScope groups SHOW GRANTS privileges by scope with backticks stripped:
+// "*" for global, "db" for database-level and "db.table" for table-level grants.
+func ParsePrivilegesByScope(grants []string) map[string][]string {
+	out := make(map[string][]string)
+	seen := make(map[string]struct{})
+
+	for _, grant := range grants {
+		grant = strings.TrimSpace(grant)
+		lower := strings.ToLower(grant)
+		onIdx := strings.Index(lower, " on ")
+		if !strings.HasPrefix(lower, "grant ") || onIdx == -1 || isProxyGrant(lower) {
+			continue
+		}
+
+		onPart := grant[onIdx+4:]
+		if j := strings.Index(strings.ToLower(onPart), " to "); j != -1 {
+			onPart = onPart[:j]
+		}
+		scope := strings.ReplaceAll(strings.TrimSpace(onPart), "`", "")
+		switch {
+		case scope == "*.*":
+			scope = "*"
+		case strings.HasSuffix(scope, ".*"):
+			scope = strings.TrimSuffix(scope, ".*")
+		}
+
+		for _, priv := range splitTopLevel(grant[6:onIdx]) {
+			priv = strings.TrimSpace(priv)
+			if priv == "" {
+				continue
+			}
+			priv = normalizePrivilege(priv)
+			key := scope + "\x00" + priv
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			out[scope] = append(out[scope], priv)
+		}
+	}
+
+	return out
+}
+
+// ParseRolesFromGrants extracts role grants from SHOW GRANTS lines.
+// Role grants have no ON clause: "GRANT `r1`@`%`,`r2`@`%` TO `u`@`%`" yields ["r1@%", "r2@%"].
+func ParseRolesFromGrants(grants []string) []string {
+	var roles []string
+	seen := make(map[string]struct{})
+
+	for _, grant := range grants {
+		grant = strings.TrimSpace(grant)
+		lower := strings.ToLower(grant)
+		if !strings.HasPrefix(lower, "grant ") || strings.Contains(lower, " on ") {
+			continue
+		}
+		toIdx := strings.Index(lower, " to ")
+		if toIdx == -1 {
+			continue
+		}
+
+		for _, role := range splitTopLevel(grant[6:toIdx]) {
+			role = strings.TrimSpace(role)
+			if role == "" {
+				continue
+			}
+			role = strings.NewReplacer("`", "", "'", "").Replace(role)
+			if _, ok := seen[role]; ok {
+				continue
+			}
+			seen[role] = struct{}{}
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+// ParseProxiesFromGrants extracts proxied accounts from SHOW GRANTS lines.
+// "GRANT PROXY ON `base`@`%` TO `u`@`%`" yields ["base@%"].
+func ParseProxiesFromGrants(grants []string) []string {
+	var proxies []string
+	seen := make(map[string]struct{})
+
+	for _, grant := range grants {
+		grant = strings.TrimSpace(grant)
+		lower := strings.ToLower(grant)
+		if !isProxyGrant(lower) {
+			continue
+		}
+		toIdx := strings.LastIndex(lower, " to ")
+		if toIdx == -1 {
+			continue
+		}
+
+		account := strings.TrimSpace(grant[len("grant proxy on "):toIdx])
+		account = strings.NewReplacer("`", "", "'", "").Replace(account)
+		if _, ok := seen[account]; ok {
+			continue
+		}
+		seen[account] = struct{}{}
+		proxies = append(proxies, account)
+	}
+
+	return proxies
+}
+
+// SplitAccount splits "user@host" into its parts; the host defaults to "%" when omitted.
+func SplitAccount(account string) (user, host string) {
+	account = strings.TrimSpace(account)
+	if at := strings.LastIndex(account, "@"); at != -1 {
+		user, host = account[:at], account[at+1:]
+	} else {
+		user = account
+	}
+	if host == "" {
+		host = "%"
+	}
+	return user, host
+}
+
+func isProxyGrant(lower string) bool {
+	return strings.HasPrefix(lower, "grant proxy on ")
+}
+
+// RewriteGrantee replaces the grantee of a SHOW GRANTS line with newIdent, keeping
+// trailing "WITH GRANT OPTION" / "WITH ADMIN OPTION". Partial revokes ("REVOKE ... FROM")
+// are rewritten on their FROM clause. It reports false when no grantee clause exists.
+func RewriteGrantee(grant, newIdent string) (string, bool) {
+	upper := strings.ToUpper(grant)
+	keyword := " TO "
+	if strings.HasPrefix(strings.TrimSpace(upper), "REVOKE ") {
+		keyword = " FROM "
+	}
+	idx := strings.LastIndex(upper, keyword)
+	if idx == -1 {
+		return "", false
+	}
+
+	tail := ""
+	if withIdx := strings.Index(upper[idx:], " WITH "); withIdx != -1 {
+		tail = grant[idx+withIdx:]
+	}
+	return grant[:idx+len(keyword)] + newIdent + tail, true
+}
+
+// RewriteCreateUser replaces the account in a SHOW CREATE USER statement with newIdent.
+func RewriteCreateUser(stmt, newIdent string) (string, bool) {
+	const prefix = "CREATE USER "
+	if !strings.HasPrefix(strings.ToUpper(stmt), prefix) {
+		return "", false
+	}
+
+	rest := stmt[len(prefix):]
+	end := accountIdentEnd(rest)
+	if end == -1 {
+		return "", false
+	}
+	return prefix + newIdent + rest[end:], true
+}
+
+// accountIdentEnd returns the index just past a quoted 'user'@'host' or `user`@`host` identifier.
+func accountIdentEnd(s string) int {
+	pos := 0
+	for part := 0; part < 2; part++ {
+		if pos >= len(s) {
+			return -1
+		}
+		quote := s[pos]
+		if quote != '`' && quote != '\'' {
+			return -1
+		}
+		closeIdx := strings.IndexByte(s[pos+1:], quote)
+		if closeIdx == -1 {
+			return -1
+		}
+		pos += closeIdx + 2
+		if part == 0 {
+			if pos >= len(s) || s[pos] != '@' {
+				return -1
+			}
+			pos++
+		}
+	}
+	return pos
+}
+
+// splitTopLevel splits s by commas that are not inside parentheses,
+// so column lists like "SELECT (a, b), INSERT" stay intact.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// normalizePrivilege uppercases the privilege name and strips backticks from an optional column list.
+func normalizePrivilege(priv string) string {
+	open := strings.Index(priv, "(")
+	if open == -1 {
+		return strings.ToUpper(priv)
+	}
+
+	name := strings.ToUpper(strings.TrimSpace(priv[:open]))
+	colPart := strings.TrimSuffix(strings.TrimSpace(priv[open+1:]), ")")
+	cols := strings.Split(colPart, ",")
+	for i, col := range cols {
+		cols[i] = strings.Trim(strings.TrimSpace(col), "`")
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(cols, ", "))
+}
@@ -0,0 +1,56 @@
+package helper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRewriteGrantee(t *testing.T) {
+	const target = "'bob'@'10.%'"
+	cases := []struct {
+		name  string
+		grant string
+		want  string
+		ok    bool
+	}{
+		{"grant", "GRANT SELECT ON `app`.* TO `alice`@`%`", "GRANT SELECT ON `app`.* TO 'bob'@'10.%'", true},
+		{"with grant option", "GRANT ALL PRIVILEGES ON *.* TO `alice`@`%` WITH GRANT OPTION", "GRANT ALL PRIVILEGES ON *.* TO 'bob'@'10.%' WITH GRANT OPTION", true},
+		{"role", "GRANT `r1`@`%` TO `alice`@`%` WITH ADMIN OPTION", "GRANT `r1`@`%` TO 'bob'@'10.%' WITH ADMIN OPTION", true},
+		{"partial revoke", "REVOKE INSERT ON `mysql`.* FROM `alice`@`%`", "REVOKE INSERT ON `mysql`.* FROM 'bob'@'10.%'", true},
+		{"no grantee", "FLUSH PRIVILEGES", "", false},
+		{"revoke without from", "REVOKE INSERT ON `mysql`.* TO `alice`@`%`", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := RewriteGrantee(c.grant, target)
+			if ok != c.ok || got != c.want {
+				t.Fatalf("RewriteGrantee(%q) = %q, %v; want %q, %v", c.grant, got, ok, c.want, c.ok)
+			}
+		})
+	}
+}
+
+// partialRevokeGrants SHOW GRANTS output with partial_revokes enabled
+var partialRevokeGrants = []string{
+	"GRANT SELECT, INSERT ON *.* TO `alice`@`%` WITH GRANT OPTION",
+	"GRANT UPDATE ON `app`.* TO `alice`@`%`",
+	"REVOKE INSERT ON `mysql`.* FROM `alice`@`%`",
+	"REVOKE GRANT OPTION ON `secret`.* FROM `alice`@`%`",
+	"GRANT SELECT (`email`) ON `app`.`users` TO `alice`@`%`",
+}
+
+func TestParseDatabasesFromGrantsSkipsRevokes(t *testing.T) {
+	got := ParseDatabasesFromGrants(partialRevokeGrants)
+	want := []string{"*", "app"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseDatabasesFromGrants = %q, want %q", got, want)
+	}
+}
+
+func TestParsePrivilegesFromGrantsSkipsRevokes(t *testing.T) {
+	got := ParsePrivilegesFromGrants(partialRevokeGrants)
+	want := []string{"SELECT", "INSERT", "UPDATE", "SELECT (email)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParsePrivilegesFromGrants = %q, want %q", got, want)
+	}
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"mysql-backend/config"
 	"mysql-backend/databases"
 	"mysql-backend/router"
+	"mysql-backend/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,6 +19,9 @@ func main() {
 	// 设置Gin模式
 	gin.SetMode(config.AppConfig.Server.Mode)
 	r := gin.New()
+	if err := r.SetTrustedProxies(config.AppConfig.Server.TrustedProxies); err != nil {
+		log.Fatalf("invalid server.trusted_proxies: %v", err)
+	}
 
 	// 注册业务路由
 	router.RegisterRoutes(r)
@@ -30,6 +35,32 @@ func main() {
 			log.Printf("close db error: %v", err)
 		}
 	}()
+	// 登记实例的连接池按需打开，后台定期检查健康状态并关闭空闲连接池
+	databases.SetInstanceResolver(service.InstanceDatabase)
+	databases.StartPoolManager(context.Background())
+
+	// 初始化Redis，未启用时不持久化agent会话
+	if err := databases.InitRedis(context.Background()); err != nil {
+		log.Fatalf("failed to init redis: %v", err)
+	}
+	defer func() {
+		if err := databases.CloseRedis(); err != nil {
+			log.Printf("close redis error: %v", err)
+		}
+	}()
+
+	// 上次进程退出时未完成的表维护与备份任务标记为失败
+	if err := service.FailInterruptedMaintenance(context.Background()); err != nil {
+		log.Printf("mark interrupted maintenance jobs failed: %v", err)
+	}
+	if err := service.FailInterruptedBackups(context.Background()); err != nil {
+		log.Printf("mark interrupted backup jobs failed: %v", err)
+	}
+
+	// 启动备份定时任务
+	if config.AppConfig.Backup.SchedulerEnabled {
+		service.StartBackupScheduler(context.Background())
+	}
 
 	// 启动服务器
 	addr := config.AppConfig.GetServerAddr()
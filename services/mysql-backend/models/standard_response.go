@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 // StandardResponse 统一响应结构
 type StandardResponse struct {
 	Data         interface{} `json:"data"`
@@ -9,22 +11,279 @@ type StandardResponse struct {
 
 // CreateUserResponse 创建用户的响应数据
 type CreateUserResponse struct {
+	Success           bool     `json:"success"`
+	DryRun            bool     `json:"dry_run,omitempty"`
+	Statements        []string `json:"statements,omitempty"`         // dry_run 时将要执行的语句
+	GeneratedPassword string   `json:"generated_password,omitempty"` // generate_password 时生成的密码，服务端不保存
+}
+
+// DropUserResponse 删除用户的响应数据
+type DropUserResponse struct {
+	Success    bool     `json:"success"`
+	Dropped    []string `json:"dropped"`
+	DryRun     bool     `json:"dry_run,omitempty"`
+	Statements []string `json:"statements,omitempty"` // dry_run 时将要执行的语句
+}
+
+// ChangePasswordResponse 修改密码的响应数据
+type ChangePasswordResponse struct {
+	Success bool `json:"success"`
+}
+
+// RoleResponse 角色管理操作的响应数据
+type RoleResponse struct {
+	Success    bool     `json:"success"`
+	DryRun     bool     `json:"dry_run,omitempty"`
+	Statements []string `json:"statements,omitempty"` // dry_run 时将要执行的语句
+}
+
+// PrivilegeProfile 命名的权限模板
+type PrivilegeProfile struct {
+	Name        string   `json:"name"`
+	Privileges  []string `json:"privileges"`
+	WithGrant   bool     `json:"with_grant"`
+	Description string   `json:"description"`
+}
+
+// ProfileResponse 权限模板管理操作的响应数据
+type ProfileResponse struct {
 	Success bool `json:"success"`
 }
 
+// CloneUserResponse 复制用户授权的响应数据
+type CloneUserResponse struct {
+	Success    bool     `json:"success"`
+	Grants     int      `json:"grants"`
+	DryRun     bool     `json:"dry_run,omitempty"`
+	Statements []string `json:"statements,omitempty"` // dry_run 时将要执行的语句
+}
+
 type CheckUserResponse struct {
 	UserInfos []UserInfo `json:"user_infos"`
 }
 
 type AgentQueryResponse struct {
-	Analysis AgentAnalysis          `json:"analysis"`
-	ToolRuns []AgentToolRun         `json:"tool_runs"`
-	Raw      map[string]interface{} `json:"raw,omitempty"`
+	Analysis   AgentAnalysis          `json:"analysis"`
+	Plan       []AgentToolCall        `json:"plan,omitempty"` // 实际执行或待审核的工具计划
+	ToolRuns   []AgentToolRun         `json:"tool_runs"`
+	Raw        map[string]interface{} `json:"raw,omitempty"`
+	SessionID  string                 `json:"session_id,omitempty"`  // 启用 Redis 时本次提问所属的会话ID
+	Usage      *AgentTokenUsage       `json:"usage,omitempty"`       // 本次提问的 LLM token 用量
+	InstanceID string                 `json:"instance_id,omitempty"` // 本次诊断所在的实例，default 为 agent 的 [database]
+}
+
+// AgentRateLimit agent 拒绝诊断请求时返回的限流信息，Scope 为 global 或 caller
+type AgentRateLimit struct {
+	Scope        string `json:"scope"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+}
+
+// AgentTokenUsage LLM token 用量，Calls 为模型调用次数
+type AgentTokenUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+	Calls            int64 `json:"calls"`
+}
+
+func (u *AgentTokenUsage) Add(other AgentTokenUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Calls += other.Calls
+}
+
+// AgentDailyUsage 某一天的 LLM token 用量，Queries 为当天完成的提问数（仅 Redis 统计时有值）
+type AgentDailyUsage struct {
+	Date string `json:"date"`
+	AgentTokenUsage
+	Queries int64 `json:"queries,omitempty"`
+}
+
+// AgentUsageResponse 按天统计的 LLM token 用量及 agent 当日预算状态
+type AgentUsageResponse struct {
+	Days        []AgentDailyUsage `json:"days"`
+	DailyBudget int64             `json:"daily_budget"` // 0 表示不限制
+	Remaining   int64             `json:"remaining"`    // 未设置预算时为 -1
+	Exceeded    bool              `json:"exceeded"`     // 超出后 agent 改用规则诊断
+}
+
+// AgentHealth agent 的健康检查结果，Status 为 ok、degraded（仅 LLM 不可用）、down 或 unreachable（backend 无法连接 agent）
+type AgentHealth struct {
+	Status    string               `json:"status"`
+	Ready     bool                 `json:"ready"`
+	Version   string               `json:"version,omitempty"`
+	UptimeSec int64                `json:"uptime_sec"`
+	Instance  string               `json:"instance,omitempty"` // 检查数据库连通性的实例
+	Database  AgentComponentHealth `json:"database"`
+	LLM       AgentComponentHealth `json:"llm"`
+	ToolCount int                  `json:"tool_count"`
+	ToolError string               `json:"tool_error,omitempty"`
+	Queue     *AgentQueueStats     `json:"queue,omitempty"`
+	Error     string               `json:"error,omitempty"` // backend 调用 agent 失败的原因
+}
+
+type AgentComponentHealth struct {
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Skipped   bool   `json:"skipped,omitempty"`
+}
+
+// AgentQueueStats agent 诊断请求的并发与排队情况
+type AgentQueueStats struct {
+	Running    int   `json:"running"`
+	Queued     int   `json:"queued"`
+	Rejected   int64 `json:"rejected"`
+	MaxRunning int   `json:"max_running"`
+	MaxQueued  int   `json:"max_queued"`
+}
+
+// AgentToolSpec agent 注册的工具，Parameters 为参数的 JSON Schema
+type AgentToolSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+	Mutating    bool            `json:"mutating,omitempty"` // 会修改数据库状态
+	// RequiredSignals 工具依赖的实例前提条件，如 performance_schema、sys_schema
+	RequiredSignals []string `json:"required_signals,omitempty"`
+	// Supported 为 false 表示 agent 默认实例不满足 RequiredSignals，UnsupportedReason 说明原因
+	Supported         bool   `json:"supported"`
+	UnsupportedReason string `json:"unsupported_reason,omitempty"`
+}
+
+type AgentToolListResponse struct {
+	Tools []AgentToolSpec `json:"tools"`
+}
+
+// AgentReportSummary 诊断报告列表中的一项，完整内容通过报告ID获取
+type AgentReportSummary struct {
+	ID         int64  `json:"id"`
+	CreatedAt  string `json:"created_at"`
+	Actor      string `json:"actor"`
+	SessionID  string `json:"session_id,omitempty"`
+	InstanceID string `json:"instance_id,omitempty"`
+	Question   string `json:"question"`
+	Status     string `json:"status"` // success 或 failed
+	Summary    string `json:"summary,omitempty"`
+	ToolCount  int    `json:"tool_count"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// AgentReportListResponse 诊断报告分页查询的响应数据
+type AgentReportListResponse struct {
+	Reports []AgentReportSummary `json:"reports"`
+	Limit   int                  `json:"limit"`
+	Offset  int                  `json:"offset"`
+}
+
+// AgentReportRecord 持久化的一次完整诊断：提问、工具计划、工具输出、分析结果与耗时
+type AgentReportRecord struct {
+	ID         int64            `json:"id"`
+	CreatedAt  string           `json:"created_at"`
+	Actor      string           `json:"actor"`
+	SessionID  string           `json:"session_id,omitempty"`
+	InstanceID string           `json:"instance_id,omitempty"`
+	Question   string           `json:"question"`
+	Status     string           `json:"status"`
+	Summary    string           `json:"summary,omitempty"`
+	Plan       []AgentToolCall  `json:"plan"`
+	ToolRuns   []AgentToolRun   `json:"tool_runs"`
+	Analysis   AgentAnalysis    `json:"analysis"`
+	Usage      *AgentTokenUsage `json:"usage,omitempty"`
+	DurationMs int64            `json:"duration_ms"` // backend 调用 agent 的总耗时
+	Error      string           `json:"error,omitempty"`
+}
+
+// AgentReportRef 对比结果中引用的一份诊断报告
+type AgentReportRef struct {
+	ID        int64  `json:"id"`
+	CreatedAt string `json:"created_at"`
+	Question  string `json:"question"`
+}
+
+// AgentMetricDelta 一个指标在两份报告中的取值，只在一份报告中出现时另一侧为空
+type AgentMetricDelta struct {
+	Name   string   `json:"name"`
+	Before *float64 `json:"before"`
+	After  *float64 `json:"after"`
+	Delta  float64  `json:"delta"`
+	// Ratio after/before，before 为 0 或缺失时为空
+	Ratio *float64 `json:"ratio,omitempty"`
+	// Highlight 变化显著时的说明，如连接数上升 3 倍、复制延迟增长
+	Highlight string `json:"highlight,omitempty"`
+}
+
+// AgentReportDiff 两份诊断报告的对比结果，Target 相对 Base 的变化
+type AgentReportDiff struct {
+	Base                AgentReportRef     `json:"base"`
+	Target              AgentReportRef     `json:"target"`
+	Highlights          []string           `json:"highlights"`
+	Metrics             []AgentMetricDelta `json:"metrics"`
+	NewSlowQueries      []string           `json:"new_slow_queries"`
+	ResolvedSlowQueries []string           `json:"resolved_slow_queries"`
+	NewRisks            []string           `json:"new_risks"`
+	ResolvedRisks       []string           `json:"resolved_risks"`
 }
 
 type AgentAnalysis struct {
 	Summary string `json:"summary,omitempty"`
 	Error   string `json:"error,omitempty"`
+	// Report 结构化诊断报告，agent 未启用结构化输出或报告校验失败时为空，此时只有 Summary
+	Report   *AgentReport   `json:"report,omitempty"`
+	Findings []AgentFinding `json:"findings,omitempty"`
+	// Fallback 为 true 表示 LLM 不可用，Summary 由 agent 的规则诊断生成
+	Fallback bool `json:"fallback,omitempty"`
+	// Anomalies 诊断时偏离 agent 指标基线的状态变量
+	Anomalies []AgentAnomaly `json:"anomalies,omitempty"`
+}
+
+// AgentAnomaly 偏离基线的指标，Kind 为 gauge（瞬时值）或 rate（每秒增量）
+type AgentAnomaly struct {
+	Metric  string  `json:"metric"`
+	Kind    string  `json:"kind"`
+	Value   float64 `json:"value"`
+	Mean    float64 `json:"mean"`
+	Stddev  float64 `json:"stddev"`
+	Sigma   float64 `json:"sigma"`
+	Samples int     `json:"samples"`
+}
+
+// AgentReport agent 返回的结构化诊断报告
+type AgentReport struct {
+	TLDR    string              `json:"tldr"`
+	Metrics []AgentReportMetric `json:"metrics"`
+	Risks   []AgentReportRisk   `json:"risks"`
+	Actions []AgentReportAction `json:"actions"`
+	Sources []string            `json:"sources"`
+}
+
+type AgentReportMetric struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value"`
+	Status string      `json:"status,omitempty"`
+	Note   string      `json:"note,omitempty"`
+}
+
+type AgentReportRisk struct {
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+type AgentReportAction struct {
+	Priority    int    `json:"priority"`
+	Description string `json:"description"`
+	SQL         string `json:"sql,omitempty"`
+}
+
+// AgentFinding agent 规则诊断的一条结论，Severity 取值 high/moderate/low
+type AgentFinding struct {
+	Rule           string `json:"rule"`
+	Severity       string `json:"severity"`
+	Message        string `json:"message"`
+	Recommendation string `json:"recommendation,omitempty"`
 }
 
 type AgentToolRun struct {
@@ -34,11 +293,412 @@ type AgentToolRun struct {
 	Output     interface{} `json:"output,omitempty"`
 	Error      string      `json:"error,omitempty"`
 	DurationMs int64       `json:"duration_ms"`
+	// RejectedParams 未通过工具参数定义校验而被 agent 丢弃的参数
+	RejectedParams []AgentRejectedParam `json:"rejected_params,omitempty"`
+}
+
+type AgentRejectedParam struct {
+	Name   string      `json:"name"`
+	Value  interface{} `json:"value"`
+	Reason string      `json:"reason"`
+}
+
+// AgentSession 持久化在 Redis 中的 agent 会话，Turns 按提问顺序排列
+type AgentSession struct {
+	ID        string             `json:"id"`
+	Status    string             `json:"status"` // 最近一轮的状态：running、planned、success 或 failed
+	CreatedAt string             `json:"created_at"`
+	UpdatedAt string             `json:"updated_at"`
+	Turns     []AgentSessionTurn `json:"turns"`
+	Usage     AgentTokenUsage    `json:"usage"` // 会话中所有提问累计的 LLM token 用量
+}
+
+// AgentSessionTurn 会话中的一次提问
+type AgentSessionTurn struct {
+	Query      string           `json:"query"`
+	Tools      []AgentToolCall  `json:"tools,omitempty"` // 调用方指定的工具计划，为空表示由 agent 规划
+	PlanOnly   bool             `json:"plan_only,omitempty"`
+	Execute    bool             `json:"execute,omitempty"` // 本轮执行的是审核后的计划
+	Status     string           `json:"status"`
+	Summary    string           `json:"summary,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	StartedAt  string           `json:"started_at"`
+	FinishedAt string           `json:"finished_at,omitempty"`
+	Usage      *AgentTokenUsage `json:"usage,omitempty"`
+}
+
+// AgentToolCall 调用方指定的一次工具调用
+type AgentToolCall struct {
+	Name   string          `json:"name"`
+	Args   json.RawMessage `json:"args,omitempty"`
+	Reason string          `json:"reason,omitempty"`
+}
+
+// AgentSessionDetail 会话详情：最近的工具输出按时间倒序，Report 为最近一次成功的诊断报告或待审核的计划
+type AgentSessionDetail struct {
+	AgentSession
+	ToolRuns []AgentToolRun      `json:"tool_runs"`
+	Report   *AgentQueryResponse `json:"report,omitempty"`
+}
+
+// AgentSessionListResponse 分页列出 agent 会话的响应数据，按最近更新时间倒序
+type AgentSessionListResponse struct {
+	Sessions []AgentSession `json:"sessions"`
+	Total    int64          `json:"total"`
+	Limit    int            `json:"limit"`
+	Offset   int            `json:"offset"`
+}
+
+// ListUsersResponse 分页列出用户的响应数据
+type ListUsersResponse struct {
+	Users  []UserAccount `json:"users"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// UserAccount mysql.user 中的一条账号记录
+type UserAccount struct {
+	Username        string `json:"username"`
+	Host            string `json:"host"`
+	Plugin          string `json:"plugin"`
+	AccountLocked   bool   `json:"account_locked"`
+	PasswordExpired bool   `json:"password_expired"`
 }
 
 type UserInfo struct {
-	Exist     bool     `json:"exist"`
-	DB        string   `json:"db"`
-	Privilege []string `json:"privilege"`
-	Plugins   []string `json:"plugins"`
+	Exist        bool                `json:"exist"`
+	DB           string              `json:"db"`
+	Privilege    []string            `json:"privilege"`
+	DBPrivileges map[string][]string `json:"db_privileges"` // 按授权范围分组的权限："*" 全局、"db" 库级、"db.table" 表级
+	Plugins      []string            `json:"plugins"`
+	Roles        []string            `json:"roles"`
+	Proxies      []string            `json:"proxies"` // 通过 GRANT PROXY 可代理的账号，格式 user@host
+	Limits       []ResourceLimits    `json:"resource_limits"`
+}
+
+// ResourceLimits 账号在某个host下的资源限制，0 表示不限制
+type ResourceLimits struct {
+	Host                  string `json:"host"`
+	MaxQueriesPerHour     int64  `json:"max_queries_per_hour"`
+	MaxUpdatesPerHour     int64  `json:"max_updates_per_hour"`
+	MaxConnectionsPerHour int64  `json:"max_connections_per_hour"`
+	MaxUserConnections    int64  `json:"max_user_connections"`
+}
+
+// AuditLogResponse 审计日志查询的响应数据
+type AuditLogResponse struct {
+	Entries []AuditEntry `json:"entries"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+}
+
+// AuditEntry 一条用户管理操作的审计记录，语句中的密码已打码
+type AuditEntry struct {
+	ID         int64    `json:"id"`
+	CreatedAt  string   `json:"created_at"`
+	Actor      string   `json:"actor"`
+	Action     string   `json:"action"`
+	Target     string   `json:"target"`
+	Statements []string `json:"statements"`
+	Success    bool     `json:"success"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// PasswordPolicyViolation 密码未通过策略校验时的响应数据，Violations 为未通过的规则名
+type PasswordPolicyViolation struct {
+	Success    bool     `json:"success"`
+	Violations []string `json:"violations"`
+}
+
+// DatabaseResponse 创建/删除数据库的响应数据
+type DatabaseResponse struct {
+	Success      bool   `json:"success"`
+	Database     string `json:"database"`
+	ConfirmToken string `json:"confirm_token,omitempty"` // 删除数据库时签发的一次性确认令牌
+	ExpiresAt    string `json:"expires_at,omitempty"`    // 确认令牌过期时间
+}
+
+// ListTablesResponse 库中表统计信息的分页响应
+type ListTablesResponse struct {
+	Schema string      `json:"schema"`
+	Tables []TableStat `json:"tables"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// TableStat 单张表的统计信息，来自 information_schema.tables，行数为估算值
+type TableStat struct {
+	Name          string  `json:"name"`
+	Engine        string  `json:"engine"`
+	RowEstimate   int64   `json:"row_estimate"`
+	DataLength    int64   `json:"data_length"`
+	IndexLength   int64   `json:"index_length"`
+	TotalLength   int64   `json:"total_length"`
+	AutoIncrement *int64  `json:"auto_increment"`
+	UpdateTime    *string `json:"update_time"`
+}
+
+// MigrationInfo 迁移文件信息，dry_run 时附带拆分后的语句
+type MigrationInfo struct {
+	Version    int64    `json:"version"`
+	Name       string   `json:"name"`
+	Checksum   string   `json:"checksum"`
+	Statements []string `json:"statements,omitempty"`
+}
+
+// MigrationRecord 一条已执行的迁移记录
+type MigrationRecord struct {
+	Version    int64  `json:"version"`
+	Name       string `json:"name"`
+	Checksum   string `json:"checksum"`
+	AppliedAt  string `json:"applied_at"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// MigrationStatusResponse 待执行迁移的响应数据，Modified 为已执行但文件内容已变化的迁移
+type MigrationStatusResponse struct {
+	Schema         string          `json:"schema"`
+	CurrentVersion int64           `json:"current_version"`
+	Pending        []MigrationInfo `json:"pending"`
+	Modified       []MigrationInfo `json:"modified"`
+}
+
+// MigrationHistoryResponse 迁移历史的响应数据
+type MigrationHistoryResponse struct {
+	Schema  string            `json:"schema"`
+	History []MigrationRecord `json:"history"`
+}
+
+// ApplyMigrationResponse 执行迁移的响应数据
+type ApplyMigrationResponse struct {
+	Schema  string          `json:"schema"`
+	DryRun  bool            `json:"dry_run,omitempty"`
+	Applied []MigrationInfo `json:"applied"`
+}
+
+// ConvertCharsetResponse 字符集转换的响应数据
+type ConvertCharsetResponse struct {
+	Success        bool           `json:"success"`
+	DryRun         bool           `json:"dry_run,omitempty"`
+	Schema         string         `json:"schema"`
+	Charset        string         `json:"charset"`
+	Collation      string         `json:"collation,omitempty"`
+	Tables         []ConvertTable `json:"tables"`          // 需要转换的表
+	EstimatedBytes int64          `json:"estimated_bytes"` // 受影响表的数据与索引总大小，ALTER TABLE 会重建这些表
+	Statements     []string       `json:"statements,omitempty"`
+}
+
+// ConvertTable 一张待转换的表
+type ConvertTable struct {
+	Name             string `json:"name"`
+	CurrentCollation string `json:"current_collation"`
+	RowEstimate      int64  `json:"row_estimate"`
+	TotalLength      int64  `json:"total_length"`
+}
+
+// QueryConsoleResponse 只读 SQL 控制台的响应数据，Truncated 表示结果超过行数限制被截断
+type QueryConsoleResponse struct {
+	Columns   []string `json:"columns"`
+	Rows      [][]any  `json:"rows"`
+	RowCount  int      `json:"row_count"`
+	Limit     int      `json:"limit"`
+	Truncated bool     `json:"truncated"`
+	ElapsedMs int64    `json:"elapsed_ms"`
+}
+
+// ExplainResponse 执行计划的响应数据，Format 为 json 时使用 Plan，为 traditional 时使用 Rows
+type ExplainResponse struct {
+	Format string           `json:"format"`
+	Plan   any              `json:"plan,omitempty"`
+	Rows   []map[string]any `json:"rows,omitempty"`
+}
+
+// KillSessionResponse 终止会话的响应数据，包含被终止连接的信息
+type KillSessionResponse struct {
+	Success   bool   `json:"success"`
+	ID        uint64 `json:"id"`
+	QueryOnly bool   `json:"query_only"`
+	User      string `json:"user,omitempty"`
+	Host      string `json:"host,omitempty"`
+	DB        string `json:"db,omitempty"`
+	Command   string `json:"command,omitempty"`
+}
+
+// ProcessListResponse processlist 的响应数据
+type ProcessListResponse struct {
+	Total     int           `json:"total"`
+	Processes []ProcessInfo `json:"processes"`
+}
+
+// ProcessInfo processlist 中的一个连接
+type ProcessInfo struct {
+	ID      uint64 `json:"id"`
+	User    string `json:"user"`
+	Host    string `json:"host"`
+	DB      string `json:"db"`
+	Command string `json:"command"`
+	Time    int64  `json:"time"`
+	State   string `json:"state"`
+	Info    string `json:"info"`
+}
+
+// VariablesResponse 全局变量列表的响应数据
+type VariablesResponse struct {
+	Variables []Variable `json:"variables"`
+}
+
+// Variable 一个全局变量，Settable 表示在修改白名单中
+type Variable struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Settable bool   `json:"settable"`
+}
+
+// SetVariableResponse 修改全局变量的响应数据
+type SetVariableResponse struct {
+	Success  bool   `json:"success"`
+	Name     string `json:"name"`
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
+
+// BackupJob 逻辑备份任务，运行中时 SizeBytes 为当前已写入的压缩后字节数
+type BackupJob struct {
+	ID         int64    `json:"id"`
+	ScheduleID int64    `json:"schedule_id,omitempty"` // 由定时任务触发时的定时任务ID
+	Schemas    []string `json:"schemas"`
+	Status     string   `json:"status"` // running、success、failed 或 expired
+	TriggerBy  string   `json:"trigger_by"`
+	File       string   `json:"file,omitempty"`
+	SizeBytes  int64    `json:"size_bytes"`
+	StartedAt  string   `json:"started_at,omitempty"`
+	FinishedAt string   `json:"finished_at,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// BackupSchedule 备份定时任务
+type BackupSchedule struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Schemas    []string `json:"schemas"`
+	Cron       string   `json:"cron"`
+	KeepDaily  int      `json:"keep_daily"`
+	KeepWeekly int      `json:"keep_weekly"`
+	Enabled    bool     `json:"enabled"`
+	LastRunAt  string   `json:"last_run_at,omitempty"`
+	NextRunAt  string   `json:"next_run_at,omitempty"`
+}
+
+// BinlogResponse binlog 文件列表与当前写入位置
+type BinlogResponse struct {
+	Logs       []BinlogFile  `json:"logs"`
+	TotalBytes int64         `json:"total_bytes"`
+	Current    *BinlogStatus `json:"current"` // 未开启 binlog 时为空
+}
+
+// BinlogFile 一个 binlog 文件
+type BinlogFile struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	Encrypted bool   `json:"encrypted"`
+}
+
+// BinlogStatus 当前 binlog 写入位置
+type BinlogStatus struct {
+	File          string `json:"file"`
+	Position      int64  `json:"position"`
+	ExecutedGTIDs string `json:"executed_gtid_set"`
+}
+
+// PurgeBinlogResponse 清理 binlog 的响应数据，按时间清理的 dry_run 无法预估时 EstimateUnavailable 为 true
+type PurgeBinlogResponse struct {
+	DryRun              bool         `json:"dry_run,omitempty"`
+	Statement           string       `json:"statement"`
+	Purged              []BinlogFile `json:"purged"`
+	FreedBytes          int64        `json:"freed_bytes"`
+	EstimateUnavailable bool         `json:"estimate_unavailable,omitempty"`
+}
+
+// ReplicationStatusResponse 复制状态，IsReplica 为 false 表示当前实例未配置复制
+type ReplicationStatusResponse struct {
+	IsReplica bool             `json:"is_replica"`
+	Channels  []ReplicaChannel `json:"channels"`
+}
+
+// ReplicaChannel 一个复制通道的状态，SecondsBehind 在 SQL 线程未运行时为空
+type ReplicaChannel struct {
+	Channel          string `json:"channel"`
+	SourceHost       string `json:"source_host"`
+	SourcePort       int64  `json:"source_port"`
+	SourceUser       string `json:"source_user"`
+	IORunning        string `json:"io_running"`
+	SQLRunning       string `json:"sql_running"`
+	ReplicaSQLState  string `json:"sql_running_state"`
+	SecondsBehind    *int64 `json:"seconds_behind_source"`
+	SourceLogFile    string `json:"source_log_file"`
+	ReadSourceLogPos int64  `json:"read_source_log_pos"`
+	RelaySourceLog   string `json:"relay_source_log_file"`
+	ExecSourceLogPos int64  `json:"exec_source_log_pos"`
+	LastIOErrno      int64  `json:"last_io_errno"`
+	LastIOError      string `json:"last_io_error"`
+	LastSQLErrno     int64  `json:"last_sql_errno"`
+	LastSQLError     string `json:"last_sql_error"`
+	AutoPosition     bool   `json:"auto_position"`
+	RetrievedGTIDSet string `json:"retrieved_gtid_set"`
+	ExecutedGTIDSet  string `json:"executed_gtid_set"`
+}
+
+// ReplicationResponse 复制管理操作的结果
+type ReplicationResponse struct {
+	Success    bool     `json:"success"`
+	DryRun     bool     `json:"dry_run,omitempty"`
+	Statements []string `json:"statements,omitempty"` // dry_run 时将要执行的语句，密码已隐去
+}
+
+// SkipReplicationResponse 跳过复制错误的结果，GTID 模式下 GTID 为注入空事务的 GTID
+type SkipReplicationResponse struct {
+	Channel      string `json:"channel"`
+	Skipped      int    `json:"skipped"`
+	GTID         string `json:"gtid,omitempty"`
+	LastSQLError string `json:"last_sql_error"`
+}
+
+// MaintenanceJob 表维护任务，同步执行时 ID 为 0
+type MaintenanceJob struct {
+	ID         int64                    `json:"id,omitempty"`
+	Operation  string                   `json:"operation"`
+	Schema     string                   `json:"schema"`
+	Tables     []string                 `json:"tables"`
+	Statement  string                   `json:"statement,omitempty"`
+	Status     string                   `json:"status"` // running、success 或 failed
+	TriggerBy  string                   `json:"trigger_by"`
+	Results    []TableMaintenanceResult `json:"results,omitempty"`
+	StartedAt  string                   `json:"started_at,omitempty"`
+	FinishedAt string                   `json:"finished_at,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// TableMaintenanceResult OPTIMIZE/ANALYZE/CHECK TABLE 返回的一行结果
+type TableMaintenanceResult struct {
+	Table   string `json:"table"`
+	Op      string `json:"op"`
+	MsgType string `json:"msg_type"`
+	MsgText string `json:"msg_text"`
+}
+
+// MySQLInstance 登记的被监控实例，不返回密码
+type MySQLInstance struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Host          string   `json:"host"`
+	Port          int      `json:"port"`
+	Username      string   `json:"username"`
+	HasPassword   bool     `json:"has_password"`
+	CredentialRef string   `json:"credential_ref,omitempty"`
+	Tags          []string `json:"tags"`
+	Environment   string   `json:"environment,omitempty"`
+	MaxOpenConns  int      `json:"max_open_conns"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
 }
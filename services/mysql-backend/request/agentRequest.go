@@ -3,8 +3,16 @@ package request
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
 )
 
+// agentSessionIDPattern 会话ID为 32 位十六进制字符串
+var agentSessionIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
 type AgentToolCall struct {
 	Name   string          `json:"name"`
 	Args   json.RawMessage `json:"args,omitempty"`
@@ -12,10 +20,231 @@ type AgentToolCall struct {
 }
 
 type AgentQueryRequest struct {
-	Query          string            `json:"query"`
-	Tools          []AgentToolCall   `json:"tools,omitempty"`
-	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
-	Context        map[string]string `json:"context,omitempty"`
+	Query              string            `json:"query"`
+	Tools              []AgentToolCall   `json:"tools,omitempty"`
+	TimeoutSeconds     int               `json:"timeout_seconds,omitempty"`
+	Context            map[string]string `json:"context,omitempty"`
+	IncludeRaw         *bool             `json:"include_raw,omitempty"`
+	IncludeToolOutputs *bool             `json:"include_tool_outputs,omitempty"`
+	SessionID          string            `json:"session_id,omitempty"`  // 继续已有会话，启用 Redis 时有效；为空时新建会话
+	PlanOnly           bool              `json:"plan_only,omitempty"`   // 只返回工具计划不执行，审核后通过 /api/agent/plan/execute 执行
+	InstanceID         string            `json:"instance_id,omitempty"` // 被诊断的实例，为空时使用 agent 的默认实例
+
+	Ctx context.Context `json:"-"`
+}
+
+// AgentSessionListRequest 定义分页列出 agent 会话的查询参数
+type AgentSessionListRequest struct {
+	Limit  int `form:"limit"`  // 每页条数，默认50，最大500
+	Offset int `form:"offset"` // 偏移量
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+// AgentUsageRequest 定义查询 LLM token 用量的参数
+type AgentUsageRequest struct {
+	Days int `form:"days"` // 最近多少天，默认7，最大31
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+// AgentHealthRequest 定义 agent 健康检查的查询参数
+type AgentHealthRequest struct {
+	SkipLLM    bool   `form:"skip_llm"`    // 不检查 LLM 连通性，用于高频存活探测
+	InstanceID string `form:"instance_id"` // 检查数据库连通性的实例，为空时使用 agent 的默认实例
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+// AgentToolsRequest 定义查询 agent 工具列表的参数
+type AgentToolsRequest struct {
+	Refresh bool `form:"refresh"` // 忽略缓存重新从 agent 获取
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+// AgentRunToolRequest 定义直接执行单个 agent 工具的参数
+type AgentRunToolRequest struct {
+	Name           string          `json:"name"`                      // 工具名
+	Args           json.RawMessage `json:"args,omitempty"`            // 工具参数，JSON 对象
+	TimeoutSeconds int             `json:"timeout_seconds,omitempty"` // 执行超时，0 使用 agent 默认值，最大300
+	InstanceID     string          `json:"instance_id,omitempty"`     // 执行工具的实例，为空时使用 agent 的默认实例
 
 	Ctx context.Context `json:"-"`
 }
+
+// AgentReportListRequest 定义分页查询诊断报告的参数
+type AgentReportListRequest struct {
+	From       string `form:"from"`        // 起始时间(含)，RFC3339 格式
+	To         string `form:"to"`          // 结束时间(不含)，RFC3339 格式
+	Status     string `form:"status"`      // success 或 failed
+	InstanceID string `form:"instance_id"` // 只列出该实例的报告
+	Limit      int    `form:"limit"`       // 每页条数，默认50，最大500
+	Offset     int    `form:"offset"`      // 偏移量
+
+	FromTime time.Time       `form:"-"`
+	ToTime   time.Time       `form:"-"`
+	Ctx      context.Context `form:"-"` // 请求上下文
+}
+
+// AgentReportRequest 定义按ID访问诊断报告的参数，查询时为路径参数，删除时为请求体
+type AgentReportRequest struct {
+	ID int64 `uri:"id" json:"id"` // 报告ID
+
+	Ctx context.Context `uri:"-" json:"-"` // 请求上下文
+}
+
+// AgentReportDiffRequest 定义对比两份诊断报告的参数，每一侧给出报告ID或时间点之一，
+// 按时间点时取该时间点及之前最近一份成功的报告
+type AgentReportDiffRequest struct {
+	Base     int64  `form:"base"`      // 基准报告ID
+	Target   int64  `form:"target"`    // 对比报告ID
+	BaseAt   string `form:"base_at"`   // 基准时间点，RFC3339 格式
+	TargetAt string `form:"target_at"` // 对比时间点，RFC3339 格式
+	// InstanceID 按时间点选择报告时只在该实例的报告中查找
+	InstanceID string `form:"instance_id"`
+
+	BaseTime   time.Time       `form:"-"`
+	TargetTime time.Time       `form:"-"`
+	Ctx        context.Context `form:"-"` // 请求上下文
+}
+
+// AgentSessionRequest 定义按ID访问 agent 会话的路径参数
+type AgentSessionRequest struct {
+	ID string `uri:"id"` // 会话ID
+
+	Ctx context.Context `uri:"-"` // 请求上下文
+}
+
+// ValidateExecutePlan 执行审核后的计划时 query 与 tools 均为必填
+func (r *AgentQueryRequest) ValidateExecutePlan() error {
+	if strings.TrimSpace(r.Query) == "" {
+		return errors.New("query is required")
+	}
+	if len(r.Tools) == 0 {
+		return errors.New("tools is required")
+	}
+	for i, t := range r.Tools {
+		if strings.TrimSpace(t.Name) == "" {
+			return fmt.Errorf("tools[%d].name is required", i)
+		}
+	}
+	r.PlanOnly = false
+	return nil
+}
+
+func (r *AgentSessionListRequest) Validate() error {
+	if r.Offset < 0 {
+		return fmt.Errorf("invalid offset: %d", r.Offset)
+	}
+	if r.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d", r.Limit)
+	}
+	if r.Limit == 0 {
+		r.Limit = defaultListLimit
+	}
+	if r.Limit > maxListLimit {
+		r.Limit = maxListLimit
+	}
+	return nil
+}
+
+func (r *AgentUsageRequest) Validate() error {
+	if r.Days < 0 || r.Days > 31 {
+		return fmt.Errorf("invalid days: %d", r.Days)
+	}
+	if r.Days == 0 {
+		r.Days = 7
+	}
+	return nil
+}
+
+func (r *AgentRunToolRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+	if r.TimeoutSeconds < 0 || r.TimeoutSeconds > 300 {
+		return fmt.Errorf("invalid timeout_seconds: %d", r.TimeoutSeconds)
+	}
+	return nil
+}
+
+func (r *AgentReportListRequest) Validate() error {
+	var err error
+	if r.From != "" {
+		if r.FromTime, err = time.Parse(time.RFC3339, r.From); err != nil {
+			return fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if r.To != "" {
+		if r.ToTime, err = time.Parse(time.RFC3339, r.To); err != nil {
+			return fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if !r.FromTime.IsZero() && !r.ToTime.IsZero() && !r.FromTime.Before(r.ToTime) {
+		return fmt.Errorf("from must be earlier than to")
+	}
+	if r.Status != "" && r.Status != "success" && r.Status != "failed" {
+		return fmt.Errorf("invalid status: %q", r.Status)
+	}
+	if r.Offset < 0 {
+		return fmt.Errorf("invalid offset: %d", r.Offset)
+	}
+	if r.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d", r.Limit)
+	}
+	if r.Limit == 0 {
+		r.Limit = defaultListLimit
+	}
+	if r.Limit > maxListLimit {
+		r.Limit = maxListLimit
+	}
+	return nil
+}
+
+func (r *AgentReportRequest) Validate() error {
+	if r.ID <= 0 {
+		return fmt.Errorf("invalid id: %d", r.ID)
+	}
+	return nil
+}
+
+func (r *AgentReportDiffRequest) Validate() error {
+	var err error
+	if r.BaseTime, err = parseReportRef("base", r.Base, r.BaseAt); err != nil {
+		return err
+	}
+	if r.TargetTime, err = parseReportRef("target", r.Target, r.TargetAt); err != nil {
+		return err
+	}
+	if r.Base != 0 && r.Base == r.Target {
+		return errors.New("base and target must be different reports")
+	}
+	return nil
+}
+
+// parseReportRef 校验报告ID与时间点二选一，返回解析后的时间点
+func parseReportRef(name string, id int64, at string) (time.Time, error) {
+	switch {
+	case id < 0:
+		return time.Time{}, fmt.Errorf("invalid %s: %d", name, id)
+	case id > 0 && at != "":
+		return time.Time{}, fmt.Errorf("%s and %s_at are mutually exclusive", name, name)
+	case id > 0:
+		return time.Time{}, nil
+	case at == "":
+		return time.Time{}, fmt.Errorf("%s or %s_at is required", name, name)
+	}
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s_at: %w", name, err)
+	}
+	return t, nil
+}
+
+func (r *AgentSessionRequest) Validate() error {
+	if !agentSessionIDPattern.MatchString(r.ID) {
+		return fmt.Errorf("invalid id: %q", r.ID)
+	}
+	return nil
+}
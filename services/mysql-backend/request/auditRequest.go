@@ -0,0 +1,51 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AuditQueryRequest 定义查询审计日志的查询参数
+type AuditQueryRequest struct {
+	From   string `form:"from"`   // 起始时间(含)，RFC3339 格式
+	To     string `form:"to"`     // 结束时间(不含)，RFC3339 格式
+	Action string `form:"action"` // 操作类型，例如 create_user
+	Target string `form:"target"` // 目标账号 LIKE 过滤
+	Limit  int    `form:"limit"`  // 每页条数，默认50，最大500
+	Offset int    `form:"offset"` // 偏移量
+
+	FromTime time.Time       `form:"-"`
+	ToTime   time.Time       `form:"-"`
+	Ctx      context.Context `form:"-"` // 请求上下文
+}
+
+func (r *AuditQueryRequest) Validate() error {
+	var err error
+	if r.From != "" {
+		if r.FromTime, err = time.Parse(time.RFC3339, r.From); err != nil {
+			return fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if r.To != "" {
+		if r.ToTime, err = time.Parse(time.RFC3339, r.To); err != nil {
+			return fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if !r.FromTime.IsZero() && !r.ToTime.IsZero() && !r.FromTime.Before(r.ToTime) {
+		return fmt.Errorf("from must be earlier than to")
+	}
+	if r.Offset < 0 {
+		return fmt.Errorf("invalid offset: %d", r.Offset)
+	}
+	if r.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d", r.Limit)
+	}
+	if r.Limit == 0 {
+		r.Limit = defaultListLimit
+	}
+	if r.Limit > maxListLimit {
+		r.Limit = maxListLimit
+	}
+	return nil
+}
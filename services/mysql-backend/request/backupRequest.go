@@ -0,0 +1,105 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"mysql-backend/helper"
+)
+
+// BackupRequest 定义触发逻辑备份的请求体
+type BackupRequest struct {
+	Schemas []string `json:"schemas"` // 需要备份的数据库列表
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// BackupStatusRequest 定义查询备份任务状态的路径参数
+type BackupStatusRequest struct {
+	ID int64 `uri:"id"` // 备份任务ID
+
+	Ctx context.Context `uri:"-"` // 请求上下文
+}
+
+func (r *BackupRequest) Validate() error {
+	schemas := make([]string, 0, len(r.Schemas))
+	for _, s := range r.Schemas {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !schemaNamePattern.MatchString(s) {
+			return fmt.Errorf("invalid schema: %q", s)
+		}
+		schemas = append(schemas, s)
+	}
+	if len(schemas) == 0 {
+		return errors.New("schemas is required")
+	}
+	r.Schemas = schemas
+	return nil
+}
+
+func (r *BackupStatusRequest) Validate() error {
+	if r.ID <= 0 {
+		return fmt.Errorf("invalid id: %d", r.ID)
+	}
+	return nil
+}
+
+// BackupScheduleRequest 定义创建/更新备份定时任务的请求体
+type BackupScheduleRequest struct {
+	ID         int64    `json:"id"`          // 定时任务ID，更新时必填
+	Name       string   `json:"name"`        // 定时任务名
+	Schemas    []string `json:"schemas"`     // 需要备份的数据库列表
+	Cron       string   `json:"cron"`        // 5 段 cron 表达式，例如 "0 3 * * *"，按服务端本地时区
+	KeepDaily  int      `json:"keep_daily"`  // 保留最近 N 天每天最新的一份备份
+	KeepWeekly int      `json:"keep_weekly"` // 保留最近 M 周每周最新的一份备份，两者都为 0 表示不清理
+	Enabled    *bool    `json:"enabled"`     // 是否启用，默认启用
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// DeleteBackupScheduleRequest 定义删除备份定时任务的请求体
+type DeleteBackupScheduleRequest struct {
+	ID int64 `json:"id"` // 定时任务ID
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// Validate 校验定时任务请求，requireID 为 true 时用于更新
+func (r *BackupScheduleRequest) Validate(requireID bool) error {
+	if requireID && r.ID <= 0 {
+		return fmt.Errorf("invalid id: %d", r.ID)
+	}
+	r.Name = strings.TrimSpace(r.Name)
+	if !profileNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid name: %q", r.Name)
+	}
+	backup := BackupRequest{Schemas: r.Schemas}
+	if err := backup.Validate(); err != nil {
+		return err
+	}
+	r.Schemas = backup.Schemas
+	r.Cron = strings.TrimSpace(r.Cron)
+	if _, err := helper.ParseCron(r.Cron); err != nil {
+		return fmt.Errorf("invalid cron: %w", err)
+	}
+	if r.KeepDaily < 0 || r.KeepWeekly < 0 {
+		return errors.New("keep_daily and keep_weekly must not be negative")
+	}
+	if r.Enabled == nil {
+		enabled := true
+		r.Enabled = &enabled
+	}
+	return nil
+}
+
+func (r *DeleteBackupScheduleRequest) Validate() error {
+	if r.ID <= 0 {
+		return fmt.Errorf("invalid id: %d", r.ID)
+	}
+	return nil
+}
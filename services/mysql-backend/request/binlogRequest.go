@@ -0,0 +1,42 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// binlogNamePattern binlog 文件名允许的字符集
+var binlogNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,255}$`)
+
+// PurgeBinlogRequest 定义清理 binlog 的请求体，to 与 before 二选一
+type PurgeBinlogRequest struct {
+	To     string `json:"to"`      // 删除该文件之前的所有 binlog（不含该文件）
+	Before string `json:"before"`  // 删除修改时间早于该时间的 binlog，RFC3339 格式
+	DryRun bool   `json:"dry_run"` // 只返回将被删除的文件与可释放的空间
+
+	BeforeTime time.Time       `json:"-"`
+	Ctx        context.Context `json:"-"` // 请求上下文
+}
+
+func (r *PurgeBinlogRequest) Validate() error {
+	r.To = strings.TrimSpace(r.To)
+	r.Before = strings.TrimSpace(r.Before)
+	if (r.To == "") == (r.Before == "") {
+		return errors.New("exactly one of to and before is required")
+	}
+	if r.To != "" && !binlogNamePattern.MatchString(r.To) {
+		return fmt.Errorf("invalid binlog name: %q", r.To)
+	}
+	if r.Before != "" {
+		t, err := time.Parse(time.RFC3339, r.Before)
+		if err != nil {
+			return fmt.Errorf("invalid before: %w", err)
+		}
+		r.BeforeTime = t
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// schemaNamePattern 数据库名允许的字符集
+	schemaNamePattern = regexp.MustCompile(`^[A-Za-z0-9_$]{1,64}$`)
+	// charsetPattern 字符集/排序规则名允许的字符集
+	charsetPattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+)
+
+// systemSchemas 禁止通过接口创建或删除的系统库
+var systemSchemas = map[string]struct{}{
+	"mysql":              {},
+	"sys":                {},
+	"information_schema": {},
+	"performance_schema": {},
+}
+
+// CreateDatabaseRequest 定义创建数据库的请求体
+type CreateDatabaseRequest struct {
+	Name        string `json:"name"`          // 数据库名
+	Charset     string `json:"charset"`       // 默认字符集，例如"utf8mb4"，为空使用服务端默认
+	Collation   string `json:"collation"`     // 默认排序规则，例如"utf8mb4_0900_ai_ci"，为空使用字符集默认
+	IfNotExists bool   `json:"if_not_exists"` // 已存在时不报错
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// DropDatabaseRequest 定义删除数据库的请求体，confirm_token 为空时只签发确认令牌不执行删除
+type DropDatabaseRequest struct {
+	Name         string `json:"name"`          // 数据库名
+	ConfirmToken string `json:"confirm_token"` // 上一次不带令牌调用返回的确认令牌
+	IfExists     bool   `json:"if_exists"`     // 不存在时不报错
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// ConvertCharsetRequest 定义转换库或表字符集的请求体
+type ConvertCharsetRequest struct {
+	Schema    string `json:"schema"`    // 数据库名
+	Table     string `json:"table"`     // 表名，为空时修改库默认字符集并转换库中所有字符集不符的表
+	Charset   string `json:"charset"`   // 目标字符集，例如"utf8mb4"
+	Collation string `json:"collation"` // 目标排序规则，为空使用字符集默认排序规则
+	DryRun    bool   `json:"dry_run"`   // 只返回受影响的表、估算大小与将要执行的语句
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// ListTablesRequest 定义列出库中表统计信息的请求参数
+type ListTablesRequest struct {
+	Schema string `uri:"schema"`  // 数据库名
+	Like   string `form:"like"`   // 表名 LIKE 过滤
+	Engine string `form:"engine"` // 存储引擎过滤，例如"InnoDB"
+	Sort   string `form:"sort"`   // 排序字段：size（默认）、rows、name、update_time
+	Order  string `form:"order"`  // 排序方向：asc 或 desc，默认 desc（name 默认 asc）
+	Limit  int    `form:"limit"`  // 每页条数，默认50，最大500
+	Offset int    `form:"offset"` // 偏移量
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+// tableSortColumns 允许的排序字段与对应的 information_schema.tables 列
+var tableSortColumns = map[string]string{
+	"size":        "TOTAL_LENGTH",
+	"rows":        "TABLE_ROWS",
+	"name":        "TABLE_NAME",
+	"update_time": "UPDATE_TIME",
+}
+
+// SortColumn 返回校验后的排序列
+func (r *ListTablesRequest) SortColumn() string {
+	return tableSortColumns[r.Sort]
+}
+
+func (r *CreateDatabaseRequest) Validate() error {
+	if err := validateSchemaName(&r.Name); err != nil {
+		return err
+	}
+	r.Charset = strings.TrimSpace(r.Charset)
+	if r.Charset != "" && !charsetPattern.MatchString(r.Charset) {
+		return fmt.Errorf("invalid charset: %q", r.Charset)
+	}
+	r.Collation = strings.TrimSpace(r.Collation)
+	if r.Collation != "" && !charsetPattern.MatchString(r.Collation) {
+		return fmt.Errorf("invalid collation: %q", r.Collation)
+	}
+	return nil
+}
+
+func (r *DropDatabaseRequest) Validate() error {
+	r.ConfirmToken = strings.TrimSpace(r.ConfirmToken)
+	return validateSchemaName(&r.Name)
+}
+
+func validateSchemaName(name *string) error {
+	*name = strings.TrimSpace(*name)
+	if !schemaNamePattern.MatchString(*name) {
+		return fmt.Errorf("invalid database name: %q", *name)
+	}
+	if _, ok := systemSchemas[strings.ToLower(*name)]; ok {
+		return fmt.Errorf("system database %s cannot be managed", *name)
+	}
+	return nil
+}
+
+func (r *ListTablesRequest) Validate() error {
+	r.Schema = strings.TrimSpace(r.Schema)
+	if !schemaNamePattern.MatchString(r.Schema) {
+		return fmt.Errorf("invalid database name: %q", r.Schema)
+	}
+	r.Sort = strings.ToLower(strings.TrimSpace(r.Sort))
+	if r.Sort == "" {
+		r.Sort = "size"
+	}
+	if _, ok := tableSortColumns[r.Sort]; !ok {
+		return fmt.Errorf("invalid sort: %s", r.Sort)
+	}
+	r.Order = strings.ToUpper(strings.TrimSpace(r.Order))
+	switch r.Order {
+	case "":
+		r.Order = "DESC"
+		if r.Sort == "name" {
+			r.Order = "ASC"
+		}
+	case "ASC", "DESC":
+	default:
+		return fmt.Errorf("invalid order: %s", r.Order)
+	}
+	if r.Offset < 0 {
+		return fmt.Errorf("invalid offset: %d", r.Offset)
+	}
+	if r.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d", r.Limit)
+	}
+	if r.Limit == 0 {
+		r.Limit = defaultListLimit
+	}
+	if r.Limit > maxListLimit {
+		r.Limit = maxListLimit
+	}
+	return nil
+}
+
+func (r *ConvertCharsetRequest) Validate() error {
+	if err := validateSchemaName(&r.Schema); err != nil {
+		return err
+	}
+	r.Table = strings.TrimSpace(r.Table)
+	if r.Table != "" && !schemaNamePattern.MatchString(r.Table) {
+		return fmt.Errorf("invalid table name: %q", r.Table)
+	}
+	r.Charset = strings.TrimSpace(r.Charset)
+	if !charsetPattern.MatchString(r.Charset) {
+		return fmt.Errorf("invalid charset: %q", r.Charset)
+	}
+	r.Collation = strings.TrimSpace(r.Collation)
+	if r.Collation != "" && !charsetPattern.MatchString(r.Collation) {
+		return fmt.Errorf("invalid collation: %q", r.Collation)
+	}
+	return nil
+}
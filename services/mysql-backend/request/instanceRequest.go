@@ -0,0 +1,103 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// instanceEnvironmentPattern 实例环境标识允许的字符集，如 prod、staging
+var instanceEnvironmentPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]{0,32}$`)
+
+// reservedInstanceID agent 用 default 表示自身 [database] 配置的实例，不允许登记
+const reservedInstanceID = "default"
+
+// MySQLInstanceRequest 定义登记/更新被监控实例的请求体
+type MySQLInstanceRequest struct {
+	ID       string `json:"id"`       // 实例ID，即诊断请求中的 instance_id
+	Name     string `json:"name"`     // 展示名称，为空时使用 ID
+	Host     string `json:"host"`     // 主机名或IP
+	Port     int    `json:"port"`     // 端口，默认 3306
+	Username string `json:"username"` // 诊断账号
+	// Password 诊断账号密码，加密后保存；更新时不传表示保留原密码，传空字符串表示清除
+	Password *string `json:"password"`
+	// CredentialRef 密码引用，env:NAME 从后端进程环境变量读取，file:/path 从文件读取，与 password 互斥
+	CredentialRef string   `json:"credential_ref"`
+	Tags          []string `json:"tags"`        // 标签
+	Environment   string   `json:"environment"` // 所属环境，如 prod、staging
+	// MaxOpenConns 后端访问该实例时连接池的最大连接数，0 表示沿用 database.max_open_conns
+	MaxOpenConns int `json:"max_open_conns"`
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// MySQLInstanceIDRequest 定义按ID访问实例的参数，查询时为路径参数，删除时为请求体
+type MySQLInstanceIDRequest struct {
+	ID string `uri:"id" json:"id"` // 实例ID
+
+	Ctx context.Context `uri:"-" json:"-"` // 请求上下文
+}
+
+// Validate 校验实例登记请求
+func (r *MySQLInstanceRequest) Validate() error {
+	r.ID = strings.TrimSpace(r.ID)
+	if err := validateInstanceID(r.ID); err != nil {
+		return err
+	}
+	r.Name = strings.TrimSpace(r.Name)
+	r.Host = strings.TrimSpace(r.Host)
+	if r.Host == "" {
+		return errors.New("host is required")
+	}
+	if r.Port == 0 {
+		r.Port = 3306
+	}
+	if r.Port < 1 || r.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", r.Port)
+	}
+	r.Username = strings.TrimSpace(r.Username)
+	if r.Username == "" {
+		return errors.New("username is required")
+	}
+	r.CredentialRef = strings.TrimSpace(r.CredentialRef)
+	if r.CredentialRef != "" {
+		if r.Password != nil && *r.Password != "" {
+			return errors.New("password and credential_ref are mutually exclusive")
+		}
+		if !strings.HasPrefix(r.CredentialRef, "env:") && !strings.HasPrefix(r.CredentialRef, "file:") {
+			return fmt.Errorf("invalid credential_ref %q: must start with env: or file:", r.CredentialRef)
+		}
+	}
+	tags := make([]string, 0, len(r.Tags))
+	for _, tag := range r.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	r.Tags = tags
+	r.Environment = strings.TrimSpace(r.Environment)
+	if !instanceEnvironmentPattern.MatchString(r.Environment) {
+		return fmt.Errorf("invalid environment: %q", r.Environment)
+	}
+	if r.MaxOpenConns < 0 {
+		return fmt.Errorf("invalid max_open_conns: %d", r.MaxOpenConns)
+	}
+	return nil
+}
+
+func (r *MySQLInstanceIDRequest) Validate() error {
+	r.ID = strings.TrimSpace(r.ID)
+	return validateInstanceID(r.ID)
+}
+
+func validateInstanceID(id string) error {
+	if !profileNamePattern.MatchString(id) {
+		return fmt.Errorf("invalid id: %q", id)
+	}
+	if id == reservedInstanceID {
+		return fmt.Errorf("id %q is reserved", id)
+	}
+	return nil
+}
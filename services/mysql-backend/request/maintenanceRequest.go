@@ -0,0 +1,92 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maintenanceOperations 支持的表维护操作
+var maintenanceOperations = map[string]struct{}{
+	"optimize": {},
+	"analyze":  {},
+	"check":    {},
+}
+
+// checkOptions CHECK TABLE 支持的选项
+var checkOptions = map[string]struct{}{
+	"QUICK":    {},
+	"FAST":     {},
+	"MEDIUM":   {},
+	"EXTENDED": {},
+	"CHANGED":  {},
+}
+
+// TableMaintenanceRequest 定义表维护（OPTIMIZE/ANALYZE/CHECK TABLE）的请求体
+type TableMaintenanceRequest struct {
+	Schema      string   `json:"schema"`       // 数据库名
+	Tables      []string `json:"tables"`       // 表名列表
+	Operation   string   `json:"operation"`    // optimize / analyze / check
+	CheckOption string   `json:"check_option"` // CHECK TABLE 的选项：QUICK、FAST、MEDIUM、EXTENDED、CHANGED
+	Local       bool     `json:"local"`        // OPTIMIZE/ANALYZE 不写 binlog（NO_WRITE_TO_BINLOG），不会复制到从库
+	Async       bool     `json:"async"`        // 后台执行并立即返回任务ID，适合大表
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// MaintenanceJobRequest 定义查询表维护任务的路径参数
+type MaintenanceJobRequest struct {
+	ID int64 `uri:"id"` // 任务ID
+
+	Ctx context.Context `uri:"-"` // 请求上下文
+}
+
+func (r *TableMaintenanceRequest) Validate() error {
+	if !schemaNamePattern.MatchString(r.Schema) {
+		return fmt.Errorf("invalid schema: %q", r.Schema)
+	}
+	r.Operation = strings.ToLower(strings.TrimSpace(r.Operation))
+	if _, ok := maintenanceOperations[r.Operation]; !ok {
+		return fmt.Errorf("invalid operation: %q, must be optimize, analyze or check", r.Operation)
+	}
+
+	tables := make([]string, 0, len(r.Tables))
+	seen := make(map[string]struct{}, len(r.Tables))
+	for _, t := range r.Tables {
+		t = strings.TrimSpace(t)
+		if !schemaNamePattern.MatchString(t) {
+			return fmt.Errorf("invalid table: %q", t)
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		tables = append(tables, t)
+	}
+	if len(tables) == 0 {
+		return errors.New("tables is required")
+	}
+	r.Tables = tables
+
+	r.CheckOption = strings.ToUpper(strings.TrimSpace(r.CheckOption))
+	if r.CheckOption != "" {
+		if r.Operation != "check" {
+			return errors.New("check_option is only valid for check")
+		}
+		if _, ok := checkOptions[r.CheckOption]; !ok {
+			return fmt.Errorf("invalid check_option: %q", r.CheckOption)
+		}
+	}
+	if r.Local && r.Operation == "check" {
+		return errors.New("local is only valid for optimize and analyze")
+	}
+	return nil
+}
+
+func (r *MaintenanceJobRequest) Validate() error {
+	if r.ID <= 0 {
+		return fmt.Errorf("invalid id: %d", r.ID)
+	}
+	return nil
+}
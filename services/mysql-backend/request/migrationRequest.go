@@ -0,0 +1,45 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MigrationStatusRequest 定义查看待执行迁移与迁移历史的请求参数
+type MigrationStatusRequest struct {
+	Schema string `form:"schema"` // 目标数据库名
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+// ApplyMigrationRequest 定义执行迁移的请求体
+type ApplyMigrationRequest struct {
+	Schema        string `json:"schema"`         // 目标数据库名
+	TargetVersion int64  `json:"target_version"` // 执行到该版本（含），0 表示执行全部待执行迁移
+	DryRun        bool   `json:"dry_run"`        // 只返回将要执行的迁移与语句，不实际执行
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+func (r *MigrationStatusRequest) Validate() error {
+	return validateMigrationSchema(&r.Schema)
+}
+
+func (r *ApplyMigrationRequest) Validate() error {
+	if r.TargetVersion < 0 {
+		return fmt.Errorf("invalid target_version: %d", r.TargetVersion)
+	}
+	return validateMigrationSchema(&r.Schema)
+}
+
+func validateMigrationSchema(schema *string) error {
+	*schema = strings.TrimSpace(*schema)
+	if !schemaNamePattern.MatchString(*schema) {
+		return fmt.Errorf("invalid schema: %q", *schema)
+	}
+	if _, ok := systemSchemas[strings.ToLower(*schema)]; ok {
+		return fmt.Errorf("system database %s cannot be migrated", *schema)
+	}
+	return nil
+}
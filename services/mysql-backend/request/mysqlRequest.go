@@ -5,10 +5,25 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
+	"strings"
+
+	"mysql-backend/helper"
 )
 
 type Privilege string
 
+// defaultMinPasswordLength 未配置 password_policy.min_length 时的密码最短长度
+const defaultMinPasswordLength = 8
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// usernamePattern 用户名允许的字符集
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-\.]+$`)
+
 // 常见权限集合
 var allowedPrivileges = map[Privilege]struct{}{
 	"ALL":                     {},
@@ -42,43 +57,292 @@ var allowedPrivileges = map[Privilege]struct{}{
 	"TRIGGER":                 {},
 }
 
+// 支持的认证插件
+const (
+	AuthPluginCachingSHA2 = "caching_sha2_password"
+	AuthPluginNative      = "mysql_native_password"
+	AuthPluginSocket      = "auth_socket"
+)
+
+// 允许的认证插件，空字符串表示使用服务端默认插件
+var allowedAuthPlugins = map[string]struct{}{
+	"":                    {},
+	AuthPluginCachingSHA2: {},
+	AuthPluginNative:      {},
+	AuthPluginSocket:      {},
+}
+
+// 可以授予到列级别的权限
+var columnPrivileges = map[Privilege]struct{}{
+	"SELECT":     {},
+	"INSERT":     {},
+	"UPDATE":     {},
+	"REFERENCES": {},
+}
+
+// ColumnPrivilege 定义一条列级授权，例如 {"privilege":"SELECT","database":"app","table":"users","columns":["email","name"]}
+type ColumnPrivilege struct {
+	Privilege Privilege `json:"privilege"` // 仅支持 SELECT/INSERT/UPDATE/REFERENCES
+	Database  string    `json:"database"`  // 数据库名
+	Table     string    `json:"table"`     // 表名
+	Columns   []string  `json:"columns"`   // 列名列表
+}
+
 // CreateUserRequest 定义创建用户的请求体
 type CreateUserRequest struct {
-	Username   string      `json:"username"`    // 新用户用户名
-	Host       string      `json:"host"`        // 允许连接的host，默认"%"
-	Password   string      `json:"password"`    // 用户密码
-	Databases  []string    `json:"databases"`   // 授权的数据库列表，例如["db1","db2"]，支持通配符"*"
-	Privileges []Privilege `json:"privileges"`  // 权限列表，例如["SELECT","INSERT"]或["ALL"]
-	WithGrant  bool        `json:"with_grant"`  // 是否包含 GRANT OPTION
-	TLSRequire bool        `json:"tls_require"` // 是否需要 REQUIRE SSL
+	Username         string              `json:"username"`          // 新用户用户名
+	Host             string              `json:"host"`              // 允许连接的host，默认"%"
+	Password         string              `json:"password"`          // 用户密码，auth_plugin 为 auth_socket 时可为空
+	GeneratePassword bool                `json:"generate_password"` // 由服务端生成随机强密码，仅在响应中返回一次
+	AuthPlugin       string              `json:"auth_plugin"`       // 认证插件：caching_sha2_password、mysql_native_password 或 auth_socket，默认使用服务端默认插件
+	Databases        []string            `json:"databases"`         // 授权的数据库列表，例如["db1","db2"]，支持通配符"*"
+	ProxyOf          string              `json:"proxy_of"`          // 被代理账号 "user@host"，host 省略时为"%"，生成 GRANT PROXY
+	ExcludeDatabases []string            `json:"exclude_databases"` // 全局授权时排除的数据库（MySQL 8 partial revokes），需与"*"一起使用
+	Tables           map[string][]string `json:"tables"`            // 表级授权，db -> 表列表，例如{"db1":["orders","users"]}
+	Columns          []ColumnPrivilege   `json:"column_privileges"` // 列级授权
+	Privileges       []Privilege         `json:"privileges"`        // 权限列表，例如["SELECT","INSERT"]或["ALL"]
+	Profile          string              `json:"profile"`           // 权限模板名，例如"readonly"，与 privileges 互斥
+	WithGrant        bool                `json:"with_grant"`        // 是否包含 GRANT OPTION
+	DryRun           bool                `json:"dry_run"`           // 只返回将要执行的语句，不实际执行（密码会被打码）
+	TLSRequire       bool                `json:"tls_require"`       // 是否需要 REQUIRE SSL，等价于 tls_type 为 SSL
+	TLSType          string              `json:"tls_type"`          // REQUIRE 类型：NONE、SSL、X509 或 SPECIFIED
+	TLSCipher        string              `json:"tls_cipher"`        // tls_type 为 SPECIFIED 时要求的加密套件
+	TLSIssuer        string              `json:"tls_issuer"`        // tls_type 为 SPECIFIED 时要求的证书签发者
+	TLSSubject       string              `json:"tls_subject"`       // tls_type 为 SPECIFIED 时要求的证书主题
+
+	MaxQueriesPerHour     *int `json:"max_queries_per_hour"`     // 每小时最大查询数，0 表示不限制
+	MaxUpdatesPerHour     *int `json:"max_updates_per_hour"`     // 每小时最大更新数，0 表示不限制
+	MaxConnectionsPerHour *int `json:"max_connections_per_hour"` // 每小时最大连接数，0 表示不限制
+	MaxUserConnections    *int `json:"max_user_connections"`     // 最大并发连接数，0 表示使用全局设置
+
+	PasswordExpire        string `json:"password_expire"`         // 密码过期策略：DEFAULT、NEVER 或 INTERVAL
+	PasswordExpireDays    int    `json:"password_expire_days"`    // password_expire 为 INTERVAL 时的天数
+	PasswordHistory       *int   `json:"password_history"`        // 禁止重复使用最近 n 个密码（MySQL 8.0.3+）
+	PasswordReuseInterval *int   `json:"password_reuse_interval"` // 禁止重复使用 n 天内用过的密码（MySQL 8.0.3+）
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// DropUserRequest 定义删除用户的请求体
+type DropUserRequest struct {
+	Username string   `json:"username"`  // 要删除的用户名
+	Hosts    []string `json:"hosts"`     // 要删除的host列表，默认["%"]
+	IfExists bool     `json:"if_exists"` // 是否使用 DROP USER IF EXISTS，忽略不存在的账号
+	DryRun   bool     `json:"dry_run"`   // 只返回将要执行的语句，不实际执行
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// ChangePasswordRequest 定义修改用户密码的请求体
+type ChangePasswordRequest struct {
+	Username           string `json:"username"`             // 用户名
+	Host               string `json:"host"`                 // 用户host，默认"%"
+	Password           string `json:"password"`             // 新密码
+	RetainCurrent      bool   `json:"retain_current"`       // 保留旧密码为次要密码（MySQL 8.0.14+），用于灰度切换
+	DiscardOldPassword bool   `json:"discard_old_password"` // 丢弃之前保留的次要密码，灰度完成后使用
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// ListUsersRequest 定义分页列出用户的查询参数
+type ListUsersRequest struct {
+	User   string `form:"user"`   // 用户名 LIKE 过滤，例如 "app_%"
+	Host   string `form:"host"`   // host LIKE 过滤
+	Limit  int    `form:"limit"`  // 每页条数，默认50，最大500
+	Offset int    `form:"offset"` // 偏移量
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+// CloneUserRequest 定义从已有用户复制授权的请求体
+type CloneUserRequest struct {
+	SourceUsername string `json:"source_username"` // 源用户名
+	SourceHost     string `json:"source_host"`     // 源用户host，默认"%"
+	Username       string `json:"username"`        // 新用户名
+	Host           string `json:"host"`            // 新用户host，默认与源用户相同
+	Password       string `json:"password"`        // 新用户密码，为空时沿用源用户的认证方式与密码哈希
+	DryRun         bool   `json:"dry_run"`         // 只返回将要执行的语句，不实际执行
 
 	Ctx context.Context `json:"-"` // 请求上下文
 }
 
 type CheckUserRequst struct {
-	Username []string `json:"usernames"`
+	Username  []string `json:"usernames"`
+	ExpandAll bool     `json:"expand_all"` // 将 ALL PRIVILEGES 展开为当前服务端版本下的具体权限列表
 
 	Ctx context.Context `json:"-"`
 }
 
+// AddUsernames 解析查询参数中的用户名，支持 usernames=a,b 与重复的 usernames=a&usernames=b 两种写法
+func (r *CheckUserRequst) AddUsernames(values []string) {
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				r.Username = append(r.Username, name)
+			}
+		}
+	}
+}
+
 func (r *CreateUserRequest) Validate() error {
 	if r.Username == "" {
 		return errors.New("username is required")
 	}
-	if r.Password == "" {
+	r.AuthPlugin = strings.ToLower(strings.TrimSpace(r.AuthPlugin))
+	if _, ok := allowedAuthPlugins[r.AuthPlugin]; !ok {
+		return fmt.Errorf("invalid auth_plugin: %s", r.AuthPlugin)
+	}
+	if r.GeneratePassword {
+		if r.Password != "" {
+			return errors.New("password and generate_password cannot be used together")
+		}
+		if r.AuthPlugin == AuthPluginSocket {
+			return errors.New("auth_socket does not accept a password")
+		}
+	} else if r.Password == "" && r.AuthPlugin != AuthPluginSocket {
 		return errors.New("password is required")
 	}
+	if r.Password != "" && r.AuthPlugin == AuthPluginSocket {
+		return errors.New("auth_socket does not accept a password")
+	}
+	if r.Password != "" {
+		if err := checkPasswordPolicy(r.Username, r.Password); err != nil {
+			return err
+		}
+	}
 	if r.Host == "" {
 		r.Host = "%"
 	}
-	if len(r.Databases) == 0 {
+	// 仅指定表级授权时不再默认授予全局权限
+	if len(r.Databases) == 0 && len(r.Tables) == 0 && len(r.Columns) == 0 {
 		r.Databases = []string{"*"}
 	}
+	if r.ProxyOf = strings.TrimSpace(r.ProxyOf); r.ProxyOf != "" {
+		proxyUser, proxyHost := helper.SplitAccount(r.ProxyOf)
+		if !usernamePattern.MatchString(proxyUser) {
+			return fmt.Errorf("invalid proxy_of user: %s", proxyUser)
+		}
+		if proxyUser == r.Username && proxyHost == r.Host {
+			return errors.New("proxy_of must differ from the created account")
+		}
+	}
+	if len(r.ExcludeDatabases) > 0 {
+		if !slices.Contains(r.Databases, "*") {
+			return errors.New(`exclude_databases requires databases to include "*"`)
+		}
+		excludes := make([]string, 0, len(r.ExcludeDatabases))
+		for _, db := range r.ExcludeDatabases {
+			db = strings.TrimSpace(db)
+			if db == "" || db == "*" {
+				return fmt.Errorf("invalid exclude_databases entry: %q", db)
+			}
+			excludes = append(excludes, db)
+		}
+		r.ExcludeDatabases = excludes
+	}
+	for db, tables := range r.Tables {
+		if strings.TrimSpace(db) == "" || db == "*" {
+			return fmt.Errorf("invalid database for table grant: %q", db)
+		}
+		if len(tables) == 0 {
+			return fmt.Errorf("no tables given for database %s", db)
+		}
+		for _, t := range tables {
+			if strings.TrimSpace(t) == "" || t == "*" {
+				return fmt.Errorf("invalid table name in database %s: %q", db, t)
+			}
+		}
+	}
 	// 用户名与host格式校验（基础）
-	if !regexp.MustCompile(`^[A-Za-z0-9_\-\.]+$`).MatchString(r.Username) {
+	if !usernamePattern.MatchString(r.Username) {
 		return fmt.Errorf("invalid username: %s", r.Username)
 	}
+	for _, cp := range r.Columns {
+		if _, ok := columnPrivileges[cp.Privilege]; !ok {
+			return fmt.Errorf("privilege %s cannot be granted on columns", cp.Privilege)
+		}
+		if strings.TrimSpace(cp.Database) == "" || cp.Database == "*" || strings.TrimSpace(cp.Table) == "" || cp.Table == "*" {
+			return fmt.Errorf("column privilege %s requires a concrete database and table", cp.Privilege)
+		}
+		if len(cp.Columns) == 0 {
+			return fmt.Errorf("column privilege %s on %s.%s has no columns", cp.Privilege, cp.Database, cp.Table)
+		}
+		for _, col := range cp.Columns {
+			if strings.TrimSpace(col) == "" {
+				return fmt.Errorf("empty column name in privilege %s on %s.%s", cp.Privilege, cp.Database, cp.Table)
+			}
+		}
+	}
+	// TLS 要求校验
+	r.TLSType = strings.ToUpper(strings.TrimSpace(r.TLSType))
+	specified := r.TLSCipher != "" || r.TLSIssuer != "" || r.TLSSubject != ""
+	if r.TLSType == "" {
+		switch {
+		case specified:
+			r.TLSType = "SPECIFIED"
+		case r.TLSRequire:
+			r.TLSType = "SSL"
+		}
+	}
+	switch r.TLSType {
+	case "":
+	case "NONE", "SSL", "X509":
+		if specified {
+			return fmt.Errorf("tls_cipher/tls_issuer/tls_subject require tls_type SPECIFIED, got %s", r.TLSType)
+		}
+		if r.TLSType == "NONE" && r.TLSRequire {
+			return errors.New("tls_require conflicts with tls_type NONE")
+		}
+	case "SPECIFIED":
+		if !specified {
+			return errors.New("tls_type SPECIFIED requires at least one of tls_cipher, tls_issuer, tls_subject")
+		}
+	default:
+		return fmt.Errorf("invalid tls_type: %s", r.TLSType)
+	}
+	// 资源限制校验
+	for name, v := range map[string]*int{
+		"max_queries_per_hour":     r.MaxQueriesPerHour,
+		"max_updates_per_hour":     r.MaxUpdatesPerHour,
+		"max_connections_per_hour": r.MaxConnectionsPerHour,
+		"max_user_connections":     r.MaxUserConnections,
+	} {
+		if v != nil && *v < 0 {
+			return fmt.Errorf("invalid %s: %d", name, *v)
+		}
+	}
+	// 密码过期策略校验
+	r.PasswordExpire = strings.ToUpper(strings.TrimSpace(r.PasswordExpire))
+	switch r.PasswordExpire {
+	case "", "DEFAULT", "NEVER":
+		if r.PasswordExpireDays != 0 {
+			return errors.New("password_expire_days requires password_expire INTERVAL")
+		}
+	case "INTERVAL":
+		if r.PasswordExpireDays <= 0 || r.PasswordExpireDays > 65535 {
+			return fmt.Errorf("invalid password_expire_days: %d", r.PasswordExpireDays)
+		}
+	default:
+		return fmt.Errorf("invalid password_expire: %s", r.PasswordExpire)
+	}
+	if r.PasswordHistory != nil && *r.PasswordHistory < 0 {
+		return fmt.Errorf("invalid password_history: %d", *r.PasswordHistory)
+	}
+	if r.PasswordReuseInterval != nil && *r.PasswordReuseInterval < 0 {
+		return fmt.Errorf("invalid password_reuse_interval: %d", *r.PasswordReuseInterval)
+	}
 	// 权限校验
+	r.Profile = strings.TrimSpace(r.Profile)
+	if r.Profile != "" {
+		if len(r.Privileges) > 0 {
+			return errors.New("profile and privileges cannot be used together")
+		}
+		if !profileNamePattern.MatchString(r.Profile) {
+			return fmt.Errorf("invalid profile name: %q", r.Profile)
+		}
+		// 模板中的权限在执行时由 service 解析
+		return nil
+	}
 	if len(r.Privileges) == 0 {
 		r.Privileges = []Privilege{"ALL"}
 	}
@@ -89,3 +353,101 @@ func (r *CreateUserRequest) Validate() error {
 	}
 	return nil
 }
+
+func (r *DropUserRequest) Validate() error {
+	if r.Username == "" {
+		return errors.New("username is required")
+	}
+	if !usernamePattern.MatchString(r.Username) {
+		return fmt.Errorf("invalid username: %s", r.Username)
+	}
+	hosts := make([]string, 0, len(r.Hosts))
+	for _, h := range r.Hosts {
+		if h = strings.TrimSpace(h); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = []string{"%"}
+	}
+	r.Hosts = hosts
+	return nil
+}
+
+func (r *ChangePasswordRequest) Validate() error {
+	if r.Username == "" {
+		return errors.New("username is required")
+	}
+	if !usernamePattern.MatchString(r.Username) {
+		return fmt.Errorf("invalid username: %s", r.Username)
+	}
+	if r.Host == "" {
+		r.Host = "%"
+	}
+	if r.DiscardOldPassword && r.Password == "" {
+		// 只丢弃次要密码时无需新密码
+		if r.RetainCurrent {
+			return errors.New("retain_current requires a new password")
+		}
+		return nil
+	}
+	if r.RetainCurrent && r.DiscardOldPassword {
+		return errors.New("retain_current and discard_old_password cannot be used together")
+	}
+	return validatePassword(r.Username, r.Password)
+}
+
+// validatePassword 校验密码非空且满足配置的密码策略
+func validatePassword(username, password string) error {
+	if password == "" {
+		return errors.New("password is required")
+	}
+	return checkPasswordPolicy(username, password)
+}
+
+func (r *ListUsersRequest) Validate() error {
+	if r.Offset < 0 {
+		return fmt.Errorf("invalid offset: %d", r.Offset)
+	}
+	if r.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d", r.Limit)
+	}
+	if r.Limit == 0 {
+		r.Limit = defaultListLimit
+	}
+	if r.Limit > maxListLimit {
+		r.Limit = maxListLimit
+	}
+	if r.User == "" {
+		r.User = "%"
+	}
+	if r.Host == "" {
+		r.Host = "%"
+	}
+	return nil
+}
+
+func (r *CloneUserRequest) Validate() error {
+	if r.SourceUsername == "" || r.Username == "" {
+		return errors.New("source_username and username are required")
+	}
+	if !usernamePattern.MatchString(r.SourceUsername) {
+		return fmt.Errorf("invalid source_username: %s", r.SourceUsername)
+	}
+	if !usernamePattern.MatchString(r.Username) {
+		return fmt.Errorf("invalid username: %s", r.Username)
+	}
+	if r.SourceHost == "" {
+		r.SourceHost = "%"
+	}
+	if r.Host == "" {
+		r.Host = r.SourceHost
+	}
+	if r.SourceUsername == r.Username && r.SourceHost == r.Host {
+		return errors.New("source and target account must differ")
+	}
+	if r.Password != "" {
+		return checkPasswordPolicy(r.Username, r.Password)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package request
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"mysql-backend/config"
+)
+
+// 密码策略规则名，违反时在 PASSWORD_POLICY_VIOLATION 响应中返回
+const (
+	RuleMinLength   = "min_length"
+	RuleUppercase   = "uppercase"
+	RuleLowercase   = "lowercase"
+	RuleDigit       = "digit"
+	RuleSymbol      = "symbol"
+	RuleDenyList    = "deny_list"
+	RuleNotUsername = "not_username"
+)
+
+// PasswordPolicyError 密码不满足强度策略时返回，Violations 为全部未通过的规则
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password violates policy: " + strings.Join(e.Violations, ", ")
+}
+
+// passwordPolicy 返回当前生效的密码策略，未加载配置时使用默认最短长度
+func passwordPolicy() config.PasswordPolicyConfig {
+	if config.AppConfig == nil {
+		return config.PasswordPolicyConfig{MinLength: defaultMinPasswordLength}
+	}
+	policy := config.AppConfig.PasswordPolicy
+	if policy.MinLength <= 0 {
+		policy.MinLength = defaultMinPasswordLength
+	}
+	return policy
+}
+
+// checkPasswordPolicy 按配置的策略逐条校验密码，返回所有未通过的规则
+func checkPasswordPolicy(username, password string) error {
+	policy := passwordPolicy()
+
+	var upper, lower, digit, symbol bool
+	for _, ch := range password {
+		switch {
+		case unicode.IsUpper(ch):
+			upper = true
+		case unicode.IsLower(ch):
+			lower = true
+		case unicode.IsDigit(ch):
+			digit = true
+		case unicode.IsPunct(ch) || unicode.IsSymbol(ch):
+			symbol = true
+		}
+	}
+
+	violations := make([]string, 0, 4)
+	if len([]rune(password)) < policy.MinLength {
+		violations = append(violations, fmt.Sprintf("%s:%d", RuleMinLength, policy.MinLength))
+	}
+	if policy.RequireUppercase && !upper {
+		violations = append(violations, RuleUppercase)
+	}
+	if policy.RequireLowercase && !lower {
+		violations = append(violations, RuleLowercase)
+	}
+	if policy.RequireDigit && !digit {
+		violations = append(violations, RuleDigit)
+	}
+	if policy.RequireSymbol && !symbol {
+		violations = append(violations, RuleSymbol)
+	}
+	for _, denied := range policy.DenyList {
+		if denied != "" && strings.EqualFold(password, denied) {
+			violations = append(violations, RuleDenyList)
+			break
+		}
+	}
+	if strings.EqualFold(password, username) {
+		violations = append(violations, RuleNotUsername)
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// profileNamePattern 权限模板名允许的字符集
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,64}$`)
+
+// PrivilegeProfileRequest 定义创建/更新权限模板的请求体
+type PrivilegeProfileRequest struct {
+	Name        string      `json:"name"`        // 模板名，例如"readonly"
+	Privileges  []Privilege `json:"privileges"`  // 模板包含的权限列表
+	WithGrant   bool        `json:"with_grant"`  // 使用该模板时是否包含 GRANT OPTION
+	Description string      `json:"description"` // 模板说明
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// DeleteProfileRequest 定义删除权限模板的请求体
+type DeleteProfileRequest struct {
+	Name string `json:"name"` // 模板名
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+func (r *PrivilegeProfileRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if !profileNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid profile name: %q", r.Name)
+	}
+	if len(r.Privileges) == 0 {
+		return errors.New("privileges is required")
+	}
+	for _, p := range r.Privileges {
+		if _, ok := allowedPrivileges[p]; !ok {
+			return fmt.Errorf("invalid privilege: %s", p)
+		}
+	}
+	return nil
+}
+
+func (r *DeleteProfileRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if !profileNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid profile name: %q", r.Name)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"mysql-backend/helper"
+)
+
+// QueryConsoleRequest 定义只读 SQL 控制台的请求体
+type QueryConsoleRequest struct {
+	SQL       string `json:"sql"`        // 待执行的只读语句，仅允许 SELECT/SHOW/EXPLAIN/DESC
+	Schema    string `json:"schema"`     // 默认数据库，可为空
+	Limit     int    `json:"limit"`      // 最多返回行数，默认与上限见 query_console 配置
+	TimeoutMs int    `json:"timeout_ms"` // 执行超时（毫秒），不超过 query_console.max_execution_time
+	// InstanceID 执行语句的登记实例（/api/mysql/instances），为空时使用 [database]
+	InstanceID string `json:"instance_id"`
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+func (r *QueryConsoleRequest) Validate() error {
+	if strings.TrimSpace(r.SQL) == "" {
+		return errors.New("sql is required")
+	}
+	stmt, err := helper.ReadOnlyStatement(r.SQL)
+	if err != nil {
+		return err
+	}
+	r.SQL = stmt
+	r.Schema = strings.TrimSpace(r.Schema)
+	if r.Schema != "" && !schemaNamePattern.MatchString(r.Schema) {
+		return fmt.Errorf("invalid schema: %q", r.Schema)
+	}
+	if r.Limit < 0 {
+		return fmt.Errorf("invalid limit: %d", r.Limit)
+	}
+	if r.TimeoutMs < 0 {
+		return fmt.Errorf("invalid timeout_ms: %d", r.TimeoutMs)
+	}
+	r.InstanceID = strings.TrimSpace(r.InstanceID)
+	if r.InstanceID != "" {
+		if err := validateInstanceID(r.InstanceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// explainableKeywords EXPLAIN 支持的语句类型
+var explainableKeywords = map[string]struct{}{
+	"SELECT":  {},
+	"INSERT":  {},
+	"UPDATE":  {},
+	"DELETE":  {},
+	"REPLACE": {},
+	"TABLE":   {},
+	"WITH":    {},
+}
+
+// ExplainRequest 定义查看执行计划的请求体
+type ExplainRequest struct {
+	SQL    string `json:"sql"`    // 待分析的单条语句，不会被执行
+	Schema string `json:"schema"` // 默认数据库
+	// InstanceID 获取执行计划的登记实例，为空时使用 [database]
+	InstanceID string `json:"instance_id"`
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+func (r *ExplainRequest) Validate() error {
+	stmts := helper.SplitSQLStatements(r.SQL)
+	if len(stmts) != 1 {
+		return errors.New("sql must contain exactly one statement")
+	}
+	keyword := helper.LeadingKeyword(stmts[0])
+	if _, ok := explainableKeywords[keyword]; !ok {
+		return fmt.Errorf("statement %s cannot be explained", keyword)
+	}
+	r.SQL = stmts[0]
+	r.Schema = strings.TrimSpace(r.Schema)
+	if r.Schema != "" && !schemaNamePattern.MatchString(r.Schema) {
+		return fmt.Errorf("invalid schema: %q", r.Schema)
+	}
+	r.InstanceID = strings.TrimSpace(r.InstanceID)
+	if r.InstanceID != "" {
+		if err := validateInstanceID(r.InstanceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
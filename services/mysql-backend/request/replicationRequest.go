@@ -0,0 +1,109 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// channelNamePattern 复制通道名允许的字符集
+var channelNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-]{0,64}$`)
+
+// ReplicationStatusRequest 定义查看复制状态的查询参数
+type ReplicationStatusRequest struct {
+	Channel string `form:"channel"` // 只返回该通道，为空返回全部通道
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+// ChangeSourceRequest 定义配置复制源（CHANGE REPLICATION SOURCE TO）的请求体
+type ChangeSourceRequest struct {
+	Host               string `json:"host"`                  // 主库地址
+	Port               int    `json:"port"`                  // 主库端口，默认 3306
+	User               string `json:"user"`                  // 复制账号
+	Password           string `json:"password"`              // 复制账号密码
+	AutoPosition       bool   `json:"auto_position"`         // 使用 GTID 自动定位
+	LogFile            string `json:"log_file"`              // 不使用 GTID 时的起始 binlog 文件
+	LogPos             uint64 `json:"log_pos"`               // 不使用 GTID 时的起始位置
+	SSL                bool   `json:"ssl"`                   // 复制连接使用 SSL
+	GetSourcePublicKey bool   `json:"get_source_public_key"` // caching_sha2_password 非 SSL 连接时请求主库公钥
+	Channel            string `json:"channel"`               // 复制通道，为空表示默认通道
+	DryRun             bool   `json:"dry_run"`               // 只返回将要执行的语句
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// ReplicationControlRequest 定义启动/停止复制的请求体
+type ReplicationControlRequest struct {
+	Channel string `json:"channel"` // 复制通道，为空表示全部通道
+	Thread  string `json:"thread"`  // io / sql，为空表示两个线程
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// SkipReplicationErrorRequest 定义跳过复制错误的请求体
+type SkipReplicationErrorRequest struct {
+	Channel string `json:"channel"` // 复制通道，为空表示默认通道
+	Count   int    `json:"count"`   // 跳过的事件组数量，默认 1；GTID 模式下只能跳过出错的那个事务
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+func (r *ReplicationStatusRequest) Validate() error {
+	return validateChannel(r.Channel)
+}
+
+func (r *ChangeSourceRequest) Validate() error {
+	r.Host = strings.TrimSpace(r.Host)
+	if r.Host == "" {
+		return errors.New("host is required")
+	}
+	if r.User == "" {
+		return errors.New("user is required")
+	}
+	if r.Port == 0 {
+		r.Port = 3306
+	}
+	if r.Port < 1 || r.Port > 65535 {
+		return fmt.Errorf("invalid port: %d", r.Port)
+	}
+	if r.AutoPosition && (r.LogFile != "" || r.LogPos != 0) {
+		return errors.New("log_file and log_pos cannot be used with auto_position")
+	}
+	if (r.LogFile == "") != (r.LogPos == 0) {
+		return errors.New("log_file and log_pos must be provided together")
+	}
+	if r.LogFile != "" && !binlogNamePattern.MatchString(r.LogFile) {
+		return fmt.Errorf("invalid log_file: %q", r.LogFile)
+	}
+	return validateChannel(r.Channel)
+}
+
+func (r *ReplicationControlRequest) Validate() error {
+	r.Thread = strings.ToLower(strings.TrimSpace(r.Thread))
+	switch r.Thread {
+	case "", "io", "sql":
+	default:
+		return fmt.Errorf("invalid thread: %q, must be io or sql", r.Thread)
+	}
+	return validateChannel(r.Channel)
+}
+
+func (r *SkipReplicationErrorRequest) Validate() error {
+	if r.Count == 0 {
+		r.Count = 1
+	}
+	if r.Count < 1 {
+		return fmt.Errorf("invalid count: %d", r.Count)
+	}
+	return validateChannel(r.Channel)
+}
+
+func validateChannel(channel string) error {
+	if !channelNamePattern.MatchString(channel) {
+		return fmt.Errorf("invalid channel: %q", channel)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RoleRequest 定义创建/删除角色的请求体
+type RoleRequest struct {
+	Roles    []string `json:"roles"`     // 角色名列表，host 固定为"%"
+	IfExists bool     `json:"if_exists"` // 创建时对应 IF NOT EXISTS，删除时对应 IF EXISTS
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// GrantRoleRequest 定义授予角色或设置默认角色的请求体
+type GrantRoleRequest struct {
+	Username string   `json:"username"` // 目标用户名
+	Host     string   `json:"host"`     // 目标用户host，默认"%"
+	Roles    []string `json:"roles"`    // 角色名列表；设置默认角色时为空表示 NONE
+	DryRun   bool     `json:"dry_run"`  // 只返回将要执行的语句，不实际执行
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+func (r *RoleRequest) Validate() error {
+	roles, err := cleanRoles(r.Roles)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 {
+		return errors.New("roles is required")
+	}
+	r.Roles = roles
+	return nil
+}
+
+// Validate 校验授予角色请求，allowEmptyRoles 为 true 时允许空角色列表（SET DEFAULT ROLE NONE）
+func (r *GrantRoleRequest) Validate(allowEmptyRoles bool) error {
+	if r.Username == "" {
+		return errors.New("username is required")
+	}
+	if !usernamePattern.MatchString(r.Username) {
+		return fmt.Errorf("invalid username: %s", r.Username)
+	}
+	if r.Host == "" {
+		r.Host = "%"
+	}
+	roles, err := cleanRoles(r.Roles)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 && !allowEmptyRoles {
+		return errors.New("roles is required")
+	}
+	r.Roles = roles
+	return nil
+}
+
+func cleanRoles(in []string) ([]string, error) {
+	out := make([]string, 0, len(in))
+	for _, role := range in {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		if !usernamePattern.MatchString(role) {
+			return nil, fmt.Errorf("invalid role: %s", role)
+		}
+		out = append(out, role)
+	}
+	return out, nil
+}
@@ -0,0 +1,40 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// KillSessionRequest 定义终止会话的请求体
+type KillSessionRequest struct {
+	ID        uint64 `json:"id"`         // processlist 中的连接ID
+	QueryOnly bool   `json:"query_only"` // 只终止当前语句（KILL QUERY），保留连接
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+// ProcessListRequest 定义查看 processlist 的查询参数
+type ProcessListRequest struct {
+	User         string `form:"user"`          // 按用户名精确过滤
+	DB           string `form:"db"`            // 按当前库精确过滤
+	MinTime      int    `form:"min_time"`      // 只返回运行时间不小于该秒数的连接
+	IncludeSleep bool   `form:"include_sleep"` // 是否包含空闲（Sleep）连接
+	Full         bool   `form:"full"`          // 返回完整语句，默认截断为 256 个字符
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+func (r *ProcessListRequest) Validate() error {
+	if r.MinTime < 0 {
+		return fmt.Errorf("invalid min_time: %d", r.MinTime)
+	}
+	return nil
+}
+
+func (r *KillSessionRequest) Validate() error {
+	if r.ID == 0 {
+		return errors.New("id is required")
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// variableNamePattern 系统变量名允许的字符集
+var variableNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// ListVariablesRequest 定义查看全局变量的查询参数
+type ListVariablesRequest struct {
+	Like string `form:"like"` // 变量名 LIKE 过滤，例如 "innodb_%"
+
+	Ctx context.Context `form:"-"` // 请求上下文
+}
+
+// SetVariableRequest 定义修改全局变量的请求体
+type SetVariableRequest struct {
+	Name  string `json:"name"`  // 变量名，必须在 variables.allowed 白名单中
+	Value string `json:"value"` // 新值，数字与 ON/OFF/DEFAULT 原样使用，其余按字符串处理
+
+	Ctx context.Context `json:"-"` // 请求上下文
+}
+
+func (r *ListVariablesRequest) Validate() error {
+	r.Like = strings.TrimSpace(r.Like)
+	return nil
+}
+
+func (r *SetVariableRequest) Validate() error {
+	r.Name = strings.ToLower(strings.TrimSpace(r.Name))
+	if !variableNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid variable name: %q", r.Name)
+	}
+	r.Value = strings.TrimSpace(r.Value)
+	if r.Value == "" {
+		return errors.New("value is required")
+	}
+	return nil
+}
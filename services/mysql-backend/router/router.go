@@ -8,7 +8,77 @@ import (
 // RegisterRoutes 注册项目的所有HTTP路由
 func RegisterRoutes(r *gin.Engine) {
 	// 注册路由
-	r.POST("/api/mysql/user/create", handler.CreateMySQLUser)
 	r.GET("/api/mysql/user/check", handler.CheckMySQLUser)
-	r.POST("/api/agent/query", handler.QueryAgent)
+	r.POST("/api/mysql/user/check", handler.CheckMySQLUser)
+	r.GET("/api/mysql/user/list", handler.ListMySQLUsers)
+	r.GET("/api/mysql/profile/list", handler.ListPrivilegeProfiles)
+	r.GET("/api/mysql/audit", handler.QueryAuditLog)
+	r.GET("/api/mysql/db/:schema/tables", handler.ListMySQLTables)
+	r.GET("/api/mysql/migration/pending", handler.ListPendingMigrations)
+	r.GET("/api/mysql/migration/history", handler.ListMigrationHistory)
+	// 诊断请求以客户端IP作为 agent 侧按调用方限流的标识，X-Operator 只作为审计标记
+	r.POST("/api/agent/query", handler.AuditActor(), handler.QueryAgent)
+	r.POST("/api/agent/query/stream", handler.AuditActor(), handler.QueryAgentStream)
+	r.POST("/api/agent/plan/execute", handler.AuditActor(), handler.ExecuteAgentPlan)
+	r.GET("/api/agent/sessions", handler.ListAgentSessions)
+	r.GET("/api/agent/sessions/:id", handler.GetAgentSession)
+	r.POST("/api/agent/sessions/:id/resume", handler.AuditActor(), handler.ResumeAgentSession)
+	r.GET("/api/agent/usage", handler.GetAgentUsage)
+	r.GET("/api/agent/health", handler.GetAgentHealth)
+	r.GET("/api/agent/tools", handler.ListAgentTools)
+	r.POST("/api/agent/tools/run", handler.AuditActor(), handler.RunAgentTool)
+	r.GET("/api/agent/reports", handler.ListAgentReports)
+	r.GET("/api/agent/reports/diff", handler.DiffAgentReports)
+	r.GET("/api/agent/reports/:id", handler.GetAgentReport)
+	r.POST("/api/mysql/query", handler.QueryMySQL)
+	r.POST("/api/mysql/explain", handler.ExplainMySQL)
+	r.GET("/api/mysql/processlist", handler.ListMySQLProcesses)
+	r.GET("/api/mysql/variables", handler.ListMySQLVariables)
+	r.GET("/api/mysql/backup/:id", handler.GetMySQLBackup)
+	r.GET("/api/mysql/backup/schedule/list", handler.ListMySQLBackupSchedules)
+	r.GET("/api/mysql/binlog", handler.ListMySQLBinlogs)
+	r.GET("/api/mysql/replication/status", handler.GetMySQLReplicationStatus)
+	r.GET("/api/mysql/table/maintain/:id", handler.GetMySQLMaintenanceJob)
+	r.GET("/api/mysql/instances", handler.ListMySQLInstances)
+	r.GET("/api/mysql/instances/:id", handler.GetMySQLInstance)
+
+	// 会修改 MySQL 或元数据（备份文件、定时任务、模板、实例登记、诊断报告等）的路由，agent.read_only 开启时统一返回 403
+	registerWriteRoutes(r.Group("/", handler.RejectWhenReadOnly(), handler.AuditActor()))
+}
+
+// registerWriteRoutes 注册会修改 MySQL 或元数据的路由，write 已挂载只读拦截与操作人中间件；
+// 只读取数据的路由注册在 RegisterRoutes 中，不要放进这里
+func registerWriteRoutes(write gin.IRoutes) {
+	write.POST("/api/mysql/user/create", handler.CreateMySQLUser)
+	write.POST("/api/mysql/user/delete", handler.DropMySQLUser)
+	write.POST("/api/mysql/user/password", handler.ChangeMySQLUserPassword)
+	write.POST("/api/mysql/user/clone", handler.CloneMySQLUser)
+	write.POST("/api/mysql/role/create", handler.CreateMySQLRole)
+	write.POST("/api/mysql/role/drop", handler.DropMySQLRole)
+	write.POST("/api/mysql/role/grant", handler.GrantMySQLRole)
+	write.POST("/api/mysql/role/default", handler.SetMySQLDefaultRole)
+	write.POST("/api/mysql/profile/create", handler.CreatePrivilegeProfile)
+	write.POST("/api/mysql/profile/update", handler.UpdatePrivilegeProfile)
+	write.POST("/api/mysql/profile/delete", handler.DeletePrivilegeProfile)
+	write.POST("/api/mysql/db/create", handler.CreateMySQLDatabase)
+	write.POST("/api/mysql/db/drop", handler.DropMySQLDatabase)
+	write.POST("/api/mysql/db/convert", handler.ConvertMySQLCharset)
+	write.POST("/api/mysql/migration/apply", handler.ApplyMigrations)
+	write.POST("/api/mysql/session/kill", handler.KillMySQLSession)
+	write.POST("/api/mysql/variables/set", handler.SetMySQLVariable)
+	write.POST("/api/mysql/backup", handler.TriggerMySQLBackup)
+	write.POST("/api/mysql/backup/schedule/create", handler.CreateMySQLBackupSchedule)
+	write.POST("/api/mysql/backup/schedule/update", handler.UpdateMySQLBackupSchedule)
+	write.POST("/api/mysql/backup/schedule/delete", handler.DeleteMySQLBackupSchedule)
+	write.POST("/api/mysql/binlog/purge", handler.PurgeMySQLBinlogs)
+	write.POST("/api/mysql/replication/source", handler.ChangeMySQLReplicationSource)
+	write.POST("/api/mysql/replication/start", handler.StartMySQLReplication)
+	write.POST("/api/mysql/replication/stop", handler.StopMySQLReplication)
+	write.POST("/api/mysql/replication/skip", handler.SkipMySQLReplicationError)
+	write.POST("/api/mysql/table/maintain", handler.MaintainMySQLTables)
+	// 实例登记决定 agent 连接的主机与下发的凭据
+	write.POST("/api/mysql/instances/create", handler.CreateMySQLInstance)
+	write.POST("/api/mysql/instances/update", handler.UpdateMySQLInstance)
+	write.POST("/api/mysql/instances/delete", handler.DeleteMySQLInstance)
+	write.POST("/api/agent/reports/delete", handler.DeleteAgentReport)
 }
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"mysql-backend/config"
+)
+
+// TestWriteRoutesRejectedWhenReadOnly 开启 agent.read_only 时 write 组的每个路由都在进入 handler 前返回 403
+func TestWriteRoutesRejectedWhenReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prev := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = prev })
+	config.AppConfig = &config.Config{Agent: config.AgentConfig{ReadOnly: true}}
+
+	// 单独注册一遍 write 组以枚举其中的路由
+	probe := gin.New()
+	registerWriteRoutes(probe.Group("/"))
+	routes := probe.Routes()
+	if len(routes) == 0 {
+		t.Fatal("no write routes registered")
+	}
+
+	r := gin.New()
+	RegisterRoutes(r)
+	for _, route := range routes {
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(route.Method, route.Path, nil))
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
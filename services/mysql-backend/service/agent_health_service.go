@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"mysql-backend/config"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// GetAgentHealth 探测 agent 的数据库、LLM 与工具注册状态。agent 无法连接时 Status 为 unreachable，
+// agent 不可用（ready=false）时返回 AGENT_UNHEALTHY，Data 中仍带有检查详情
+func GetAgentHealth(req request.AgentHealthRequest) models.StandardResponse {
+	if config.AppConfig == nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: "config is not initialised"}
+	}
+
+	health, err := fetchAgentHealth(req.Ctx, req.SkipLLM, req.InstanceID)
+	if err != nil {
+		health = models.AgentHealth{Status: "unreachable", Error: err.Error()}
+		return models.StandardResponse{Data: health, Error: "AGENT_UNHEALTHY", ErrorMessage: err.Error()}
+	}
+	if !health.Ready {
+		return models.StandardResponse{Data: health, Error: "AGENT_UNHEALTHY", ErrorMessage: fmt.Sprintf("agent status %s", health.Status)}
+	}
+	return models.StandardResponse{Data: health, Error: "NO_ERROR", ErrorMessage: "Operation completed successfully"}
+}
+
+// fetchAgentHealth 调用 agent 的 Agent.Health（http 传输为 GET /health，不可用时为 503），instanceID 为空时检查默认实例；
+// 登记实例的连接信息只随 rpc 请求发送，http 传输下只能检查 agent 已知的实例
+func fetchAgentHealth(ctx context.Context, skipLLM bool, instanceID string) (models.AgentHealth, error) {
+	var resp models.AgentHealth
+	agentCfg := config.AppConfig.Agent
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && agentCfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, agentCfg.Timeout)
+		defer cancel()
+	}
+
+	switch strings.ToLower(strings.TrimSpace(agentCfg.Transport)) {
+	case "", "rpc":
+		conn, err := resolveInstanceConnection(ctx, instanceID)
+		if err != nil {
+			return resp, err
+		}
+		args := struct {
+			SkipLLM    bool                     `json:"skip_llm,omitempty"`
+			InstanceID string                   `json:"instance_id,omitempty"`
+			Connection *agentInstanceConnection `json:"connection,omitempty"`
+		}{SkipLLM: skipLLM, InstanceID: instanceID, Connection: conn}
+		err = callAgentRPC(ctx, "Agent.Health", args, &resp)
+		return resp, err
+	case "http":
+		query := neturl.Values{}
+		if skipLLM {
+			query.Set("skip_llm", "true")
+		}
+		if instanceID != "" {
+			query.Set("instance_id", instanceID)
+		}
+		path := "/health"
+		if len(query) > 0 {
+			path += "?" + query.Encode()
+		}
+		err := callAgentHTTP(ctx, http.MethodGet, path, nil, &resp, http.StatusServiceUnavailable)
+		return resp, err
+	default:
+		return resp, fmt.Errorf("unsupported agent transport: %s", agentCfg.Transport)
+	}
+}
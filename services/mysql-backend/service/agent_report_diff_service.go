@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mysql-backend/databases"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const (
+	// agentDiffRatio 指标前后比值达到该倍数（或降到其倒数以下）时列为重点变化
+	agentDiffRatio = 2.0
+	// agentDiffLagGrowth 复制延迟增长超过该秒数时列为重点变化
+	agentDiffLagGrowth = 10.0
+)
+
+// agentDiffStatusVars 参与对比的 SHOW GLOBAL STATUS 指标，只选取瞬时值，累计计数器的前后差值受重启影响不便比较
+var agentDiffStatusVars = []string{
+	"Threads_connected",
+	"Threads_running",
+	"Max_used_connections",
+	"Innodb_row_lock_current_waits",
+	"Innodb_buffer_pool_pages_dirty",
+	"Innodb_buffer_pool_pages_free",
+	"Open_tables",
+}
+
+// DiffAgentReports 对比两份诊断报告中的指标、慢查询指纹与风险，按报告ID或时间点（取该时间点前最近一份成功的报告）选择报告
+func DiffAgentReports(req request.AgentReportDiffRequest) models.StandardResponse {
+	diff, err := diffAgentReports(req.Ctx, req)
+	return agentReportResponse(diff, err)
+}
+
+func diffAgentReports(ctx context.Context, req request.AgentReportDiffRequest) (models.AgentReportDiff, error) {
+	baseID, targetID := req.Base, req.Target
+	var err error
+	if baseID == 0 {
+		if baseID, err = agentReportAt(ctx, req.BaseTime, req.InstanceID); err != nil {
+			return models.AgentReportDiff{}, err
+		}
+	}
+	if targetID == 0 {
+		if targetID, err = agentReportAt(ctx, req.TargetTime, req.InstanceID); err != nil {
+			return models.AgentReportDiff{}, err
+		}
+	}
+
+	base, err := getAgentReport(ctx, baseID)
+	if err != nil {
+		return models.AgentReportDiff{}, err
+	}
+	target, err := getAgentReport(ctx, targetID)
+	if err != nil {
+		return models.AgentReportDiff{}, err
+	}
+
+	diff := models.AgentReportDiff{
+		Base:                agentReportRef(base),
+		Target:              agentReportRef(target),
+		Metrics:             diffAgentMetrics(agentReportMetrics(base), agentReportMetrics(target)),
+		Highlights:          []string{},
+		NewSlowQueries:      []string{},
+		ResolvedSlowQueries: []string{},
+	}
+	diff.NewSlowQueries, diff.ResolvedSlowQueries = diffStringSets(agentSlowQueryDigests(base), agentSlowQueryDigests(target))
+	diff.NewRisks, diff.ResolvedRisks = diffStringSets(agentReportRisks(base), agentReportRisks(target))
+
+	for _, m := range diff.Metrics {
+		if m.Highlight != "" {
+			diff.Highlights = append(diff.Highlights, m.Highlight)
+		}
+	}
+	if n := len(diff.NewSlowQueries); n > 0 {
+		diff.Highlights = append(diff.Highlights, fmt.Sprintf("新出现 %d 类慢查询", n))
+	}
+	if n := len(diff.NewRisks); n > 0 {
+		diff.Highlights = append(diff.Highlights, fmt.Sprintf("新增 %d 项风险", n))
+	}
+	return diff, nil
+}
+
+// agentReportAt 返回 at 时刻及之前最近一份成功的诊断报告ID，instanceID 非空时只在该实例的报告中查找
+func agentReportAt(ctx context.Context, at time.Time, instanceID string) (int64, error) {
+	if err := ensureAgentReportTable(ctx); err != nil {
+		return 0, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return 0, err
+	}
+	cond, args := "", []any{at, AgentReportSuccess}
+	if instanceID != "" {
+		cond, args = " AND instance_id = ?", append(args, instanceID)
+	}
+	query := fmt.Sprintf("SELECT id FROM %s WHERE created_at <= ? AND status = ?%s ORDER BY created_at DESC, id DESC LIMIT 1",
+		databases.MetaTable(agentReportTable), cond)
+	var id int64
+	err = db.QueryRowContext(ctx, query, args...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("no agent report at or before %s", at.Format(time.RFC3339))
+	}
+	return id, err
+}
+
+func agentReportRef(r models.AgentReportRecord) models.AgentReportRef {
+	return models.AgentReportRef{ID: r.ID, CreatedAt: r.CreatedAt, Question: r.Question}
+}
+
+// agentToolOutputs 按工具名取每个工具最后一次成功执行的输出
+func agentToolOutputs(r models.AgentReportRecord) map[string]map[string]interface{} {
+	outputs := make(map[string]map[string]interface{})
+	for _, run := range r.ToolRuns {
+		if run.Error != "" {
+			continue
+		}
+		if out, ok := run.Output.(map[string]interface{}); ok {
+			outputs[run.Name] = out
+		}
+	}
+	return outputs
+}
+
+func agentOutputRows(output map[string]interface{}) []map[string]interface{} {
+	raw, _ := output["rows"].([]interface{})
+	rows := make([]map[string]interface{}, 0, len(raw))
+	for _, r := range raw {
+		if row, ok := r.(map[string]interface{}); ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func agentNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// agentReportMetrics 从工具输出与结构化报告中提取可比较的数值指标
+func agentReportMetrics(r models.AgentReportRecord) map[string]float64 {
+	metrics := make(map[string]float64)
+	outputs := agentToolOutputs(r)
+
+	if out, ok := outputs["mysql_global_status"]; ok {
+		wanted := make(map[string]string, len(agentDiffStatusVars))
+		for _, name := range agentDiffStatusVars {
+			wanted[strings.ToLower(name)] = name
+		}
+		for _, row := range agentOutputRows(out) {
+			name, _ := row["variable_name"].(string)
+			if canonical, ok := wanted[strings.ToLower(name)]; ok {
+				if v, ok := agentNumber(row["value"]); ok {
+					metrics[canonical] = v
+				}
+			}
+		}
+	}
+	if out, ok := outputs["mysql_processlist"]; ok {
+		metrics["processlist_rows"] = float64(len(agentOutputRows(out)))
+	}
+	if out, ok := outputs["mysql_innodb_trx"]; ok {
+		metrics["active_transactions"] = float64(len(agentOutputRows(out)))
+	}
+	if out, ok := outputs["mysql_replication_status"]; ok {
+		channels, _ := out["channels"].([]interface{})
+		for _, c := range channels {
+			ch, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if lag, ok := agentNumber(ch["seconds_behind_source"]); ok {
+				label, _ := ch["channel"].(string)
+				metrics["replication_lag:"+label] = lag
+			}
+		}
+	}
+	if report := r.Analysis.Report; report != nil {
+		for _, m := range report.Metrics {
+			if v, ok := agentNumber(m.Value); ok {
+				metrics["report:"+m.Name] = v
+			}
+		}
+	}
+	return metrics
+}
+
+// diffAgentMetrics 按指标名排序返回两份报告中出现过的全部指标的前后取值
+func diffAgentMetrics(before, after map[string]float64) []models.AgentMetricDelta {
+	names := make([]string, 0, len(before)+len(after))
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	deltas := make([]models.AgentMetricDelta, 0, len(names))
+	for _, name := range names {
+		d := models.AgentMetricDelta{Name: name}
+		b, hasBefore := before[name]
+		a, hasAfter := after[name]
+		if hasBefore {
+			d.Before = &b
+		}
+		if hasAfter {
+			d.After = &a
+		}
+		if hasBefore && hasAfter {
+			d.Delta = a - b
+			if b != 0 {
+				ratio := math.Round(a/b*100) / 100
+				d.Ratio = &ratio
+			}
+			d.Highlight = agentMetricHighlight(name, b, a)
+		}
+		deltas = append(deltas, d)
+	}
+	return deltas
+}
+
+func agentMetricHighlight(name string, before, after float64) string {
+	if strings.HasPrefix(name, "replication_lag:") {
+		if after-before >= agentDiffLagGrowth {
+			return fmt.Sprintf("复制延迟%s从 %.0fs 增长到 %.0fs", strings.TrimPrefix(name, "replication_lag:"), before, after)
+		}
+		return ""
+	}
+	switch {
+	case before > 0 && after >= before*agentDiffRatio:
+		return fmt.Sprintf("%s 上升 %.1f 倍 (%.0f -> %.0f)", name, after/before, before, after)
+	case after > 0 && before >= after*agentDiffRatio:
+		return fmt.Sprintf("%s 下降到 1/%.1f (%.0f -> %.0f)", name, before/after, before, after)
+	case before == 0 && after > 0:
+		return fmt.Sprintf("%s 从 0 变为 %.0f", name, after)
+	}
+	return ""
+}
+
+func agentSlowQueryDigests(r models.AgentReportRecord) []string {
+	out, ok := agentToolOutputs(r)["mysql_slow_queries"]
+	if !ok {
+		return nil
+	}
+	digests := make([]string, 0)
+	for _, row := range agentOutputRows(out) {
+		if text, _ := row["digest_text"].(string); text != "" {
+			digests = append(digests, text)
+		}
+	}
+	return digests
+}
+
+// agentReportRisks 汇总结构化报告中的风险与规则诊断结论
+func agentReportRisks(r models.AgentReportRecord) []string {
+	var risks []string
+	if report := r.Analysis.Report; report != nil {
+		for _, risk := range report.Risks {
+			risks = append(risks, fmt.Sprintf("[%s] %s", risk.Severity, risk.Description))
+		}
+	}
+	for _, f := range r.Analysis.Findings {
+		risks = append(risks, fmt.Sprintf("[%s] %s", f.Severity, f.Message))
+	}
+	return risks
+}
+
+// diffStringSets 返回 after 中新出现的与 before 中已消失的项，保持原有顺序
+func diffStringSets(before, after []string) (added, removed []string) {
+	inBefore := make(map[string]struct{}, len(before))
+	for _, s := range before {
+		inBefore[s] = struct{}{}
+	}
+	inAfter := make(map[string]struct{}, len(after))
+	for _, s := range after {
+		inAfter[s] = struct{}{}
+	}
+	added, removed = []string{}, []string{}
+	for _, s := range after {
+		if _, ok := inBefore[s]; !ok {
+			added = append(added, s)
+			inBefore[s] = struct{}{}
+		}
+	}
+	for _, s := range before {
+		if _, ok := inAfter[s]; !ok {
+			removed = append(removed, s)
+			inAfter[s] = struct{}{}
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mysql-backend/config"
+	"mysql-backend/databases"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const agentReportTable = "agent_reports"
+
+// 诊断报告状态
+const (
+	AgentReportSuccess = "success"
+	AgentReportFailed  = "failed"
+)
+
+func ensureAgentReportTable(ctx context.Context) error {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+	actor VARCHAR(128) NOT NULL,
+	session_id VARCHAR(32) NOT NULL DEFAULT '',
+	instance_id VARCHAR(64) NOT NULL DEFAULT '',
+	question TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	summary MEDIUMTEXT NULL,
+	plan JSON NOT NULL,
+	tool_runs JSON NOT NULL,
+	analysis JSON NOT NULL,
+	token_usage JSON NULL,
+	duration_ms BIGINT NOT NULL,
+	error TEXT NULL,
+	KEY idx_created_at (created_at),
+	KEY idx_session_id (session_id)
+)`, databases.MetaTable(agentReportTable))
+	if err := databases.EnsureMetaTable(ctx, ddl); err != nil {
+		return err
+	}
+	// instance_id 在支持多实例后加入，旧表需要补充该列
+	return databases.EnsureMetaColumn(ctx, agentReportTable, "instance_id", "VARCHAR(64) NOT NULL DEFAULT '' AFTER session_id")
+}
+
+func agentReportHistoryEnabled() bool {
+	return config.AppConfig != nil && config.AppConfig.Agent.ReportHistory
+}
+
+// saveAgentReport 把一次完成的诊断（agent 返回了分析结果）写入 agent_reports，只返回工具计划的提问不记录。
+// 写入失败只记录日志，不影响本次调用的返回
+func saveAgentReport(ctx context.Context, req request.AgentQueryRequest, sessionID string, resp models.AgentQueryResponse, elapsed time.Duration) {
+	if !agentReportHistoryEnabled() {
+		return
+	}
+	if len(resp.Plan) > 0 && len(resp.ToolRuns) == 0 && resp.Analysis.Summary == "" && resp.Analysis.Error == "" {
+		return
+	}
+	if id, err := insertAgentReport(ctx, req, sessionID, resp, elapsed); err != nil {
+		log.Printf("[agent-report] save failed: %v", err)
+	} else {
+		log.Printf("[agent-report] saved report %d", id)
+	}
+}
+
+func insertAgentReport(ctx context.Context, req request.AgentQueryRequest, sessionID string, resp models.AgentQueryResponse, elapsed time.Duration) (int64, error) {
+	if err := ensureAgentReportTable(ctx); err != nil {
+		return 0, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return 0, err
+	}
+
+	plan := resp.Plan
+	if plan == nil {
+		plan = []models.AgentToolCall{}
+	}
+	toolRuns := resp.ToolRuns
+	if toolRuns == nil {
+		toolRuns = []models.AgentToolRun{}
+	}
+	encoded := make([]string, 0, 3)
+	for _, v := range []any{plan, toolRuns, resp.Analysis} {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return 0, err
+		}
+		encoded = append(encoded, string(raw))
+	}
+	var usage, errText, summary any
+	if resp.Usage != nil {
+		raw, err := json.Marshal(resp.Usage)
+		if err != nil {
+			return 0, err
+		}
+		usage = string(raw)
+	}
+	status := AgentReportSuccess
+	if resp.Analysis.Error != "" {
+		status, errText = AgentReportFailed, resp.Analysis.Error
+	}
+	if resp.Analysis.Summary != "" {
+		summary = resp.Analysis.Summary
+	}
+
+	instanceID := resp.InstanceID
+	if instanceID == "" {
+		instanceID = req.InstanceID
+	}
+
+	insert := fmt.Sprintf(`INSERT INTO %s (actor, session_id, instance_id, question, status, summary, plan, tool_runs, analysis, token_usage, duration_ms, error)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, databases.MetaTable(agentReportTable))
+	res, err := db.ExecContext(ctx, insert, actorFrom(ctx), sessionID, instanceID, req.Query, status, summary,
+		encoded[0], encoded[1], encoded[2], usage, elapsed.Milliseconds(), errText)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// ListAgentReports 按时间倒序分页列出诊断报告，列表只包含摘要信息
+func ListAgentReports(req request.AgentReportListRequest) models.StandardResponse {
+	resp, err := listAgentReports(req.Ctx, req)
+	return agentReportResponse(resp, err)
+}
+
+func listAgentReports(ctx context.Context, req request.AgentReportListRequest) (models.AgentReportListResponse, error) {
+	if err := ensureAgentReportTable(ctx); err != nil {
+		return models.AgentReportListResponse{}, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.AgentReportListResponse{}, err
+	}
+
+	conds := make([]string, 0, 3)
+	args := make([]any, 0, 5)
+	if !req.FromTime.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, req.FromTime)
+	}
+	if !req.ToTime.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, req.ToTime)
+	}
+	if req.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, req.Status)
+	}
+	if req.InstanceID != "" {
+		conds = append(conds, "instance_id = ?")
+		args = append(args, req.InstanceID)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	query := fmt.Sprintf(`SELECT id, created_at, actor, session_id, instance_id, question, status, summary, JSON_LENGTH(tool_runs), duration_ms, error
+FROM %s%s ORDER BY id DESC LIMIT ? OFFSET ?`, databases.MetaTable(agentReportTable), where)
+	args = append(args, req.Limit, req.Offset)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.AgentReportListResponse{}, err
+	}
+	defer rows.Close()
+
+	resp := models.AgentReportListResponse{Reports: []models.AgentReportSummary{}, Limit: req.Limit, Offset: req.Offset}
+	for rows.Next() {
+		var r models.AgentReportSummary
+		var createdAt time.Time
+		var summary, errText sql.NullString
+		if err := rows.Scan(&r.ID, &createdAt, &r.Actor, &r.SessionID, &r.InstanceID, &r.Question, &r.Status, &summary, &r.ToolCount, &r.DurationMs, &errText); err != nil {
+			return models.AgentReportListResponse{}, err
+		}
+		r.CreatedAt = createdAt.Format(time.RFC3339Nano)
+		r.Summary = summary.String
+		r.Error = errText.String
+		resp.Reports = append(resp.Reports, r)
+	}
+	return resp, rows.Err()
+}
+
+// GetAgentReport 返回一份完整的诊断报告，包括工具计划、工具输出与分析结果
+func GetAgentReport(req request.AgentReportRequest) models.StandardResponse {
+	report, err := getAgentReport(req.Ctx, req.ID)
+	return agentReportResponse(report, err)
+}
+
+func getAgentReport(ctx context.Context, id int64) (models.AgentReportRecord, error) {
+	if err := ensureAgentReportTable(ctx); err != nil {
+		return models.AgentReportRecord{}, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.AgentReportRecord{}, err
+	}
+
+	query := fmt.Sprintf(`SELECT id, created_at, actor, session_id, instance_id, question, status, summary, plan, tool_runs, analysis, token_usage, duration_ms, error
+FROM %s WHERE id = ?`, databases.MetaTable(agentReportTable))
+	var r models.AgentReportRecord
+	var createdAt time.Time
+	var summary, usage, errText sql.NullString
+	var plan, toolRuns, analysis string
+	err = db.QueryRowContext(ctx, query, id).Scan(&r.ID, &createdAt, &r.Actor, &r.SessionID, &r.InstanceID, &r.Question, &r.Status,
+		&summary, &plan, &toolRuns, &analysis, &usage, &r.DurationMs, &errText)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.AgentReportRecord{}, fmt.Errorf("agent report %d not found", id)
+	}
+	if err != nil {
+		return models.AgentReportRecord{}, err
+	}
+
+	r.CreatedAt = createdAt.Format(time.RFC3339Nano)
+	r.Summary = summary.String
+	r.Error = errText.String
+	if err := json.Unmarshal([]byte(plan), &r.Plan); err != nil {
+		return models.AgentReportRecord{}, err
+	}
+	if err := json.Unmarshal([]byte(toolRuns), &r.ToolRuns); err != nil {
+		return models.AgentReportRecord{}, err
+	}
+	if err := json.Unmarshal([]byte(analysis), &r.Analysis); err != nil {
+		return models.AgentReportRecord{}, err
+	}
+	if usage.Valid {
+		r.Usage = &models.AgentTokenUsage{}
+		if err := json.Unmarshal([]byte(usage.String), r.Usage); err != nil {
+			return models.AgentReportRecord{}, err
+		}
+	}
+	return r, nil
+}
+
+// DeleteAgentReport 删除一份诊断报告
+func DeleteAgentReport(req request.AgentReportRequest) models.StandardResponse {
+	return agentReportResponse(nil, deleteAgentReport(req.Ctx, req.ID))
+}
+
+func deleteAgentReport(ctx context.Context, id int64) error {
+	if err := ensureAgentReportTable(ctx); err != nil {
+		return err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE id = ?", databases.MetaTable(agentReportTable))
+	res, err := db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("agent report %d not found", id)
+	}
+	log.Printf("[agent-report] report %d deleted by %s", id, actorFrom(ctx))
+	return nil
+}
+
+func agentReportResponse(data any, err error) models.StandardResponse {
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         data,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
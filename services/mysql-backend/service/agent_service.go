@@ -1,12 +1,22 @@
 package service
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net"
+	"net/http"
 	"net/rpc"
 	"net/rpc/jsonrpc"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"mysql-backend/config"
@@ -21,15 +31,71 @@ type agentToolCall struct {
 }
 
 type agentRPCRequest struct {
-	Query          string            `json:"query"`
-	Tools          []agentToolCall   `json:"tools,omitempty"`
-	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
-	Context        map[string]string `json:"context,omitempty"`
+	Query              string            `json:"query"`
+	Tools              []agentToolCall   `json:"tools,omitempty"`
+	TimeoutSeconds     int               `json:"timeout_seconds,omitempty"`
+	Context            map[string]string `json:"context,omitempty"`
+	IncludeRaw         *bool             `json:"include_raw,omitempty"`
+	IncludeToolOutputs *bool             `json:"include_tool_outputs,omitempty"`
+	PlanOnly           bool              `json:"plan_only,omitempty"`
+	Caller             string            `json:"caller,omitempty"`
+	InstanceID         string            `json:"instance_id,omitempty"`
+	// Connection instance_id 为登记实例时的连接信息
+	Connection *agentInstanceConnection `json:"connection,omitempty"`
 }
 
+// agentEndpoint mysql-agent 的一个调用入口，RPC 方法与 HTTP 路径一一对应
+type agentEndpoint struct {
+	rpcMethod string
+	httpPath  string
+}
+
+var (
+	agentQueryEndpoint       = agentEndpoint{rpcMethod: "Agent.Query", httpPath: "/query"}
+	agentExecutePlanEndpoint = agentEndpoint{rpcMethod: "Agent.ExecutePlan", httpPath: "/plan/execute"}
+)
+
 func QueryAgent(req request.AgentQueryRequest) models.StandardResponse {
-	resp, err := queryAgent(req.Ctx, req)
+	return callAgent(req, agentQueryEndpoint)
+}
 
+// ExecuteAgentPlan 执行审核（可能已修改）后的工具计划，配合 plan_only 或 agent 侧的 require_plan_approval 使用
+func ExecuteAgentPlan(req request.AgentQueryRequest) models.StandardResponse {
+	return callAgent(req, agentExecutePlanEndpoint)
+}
+
+func callAgent(req request.AgentQueryRequest, endpoint agentEndpoint) models.StandardResponse {
+	sessionID, err := startAgentSession(req, endpoint == agentExecutePlanEndpoint)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "NOT_FOUND",
+			ErrorMessage: err.Error(),
+		}
+	}
+
+	started := time.Now()
+	resp, err := queryAgent(req.Ctx, endpoint, req)
+	finishAgentTurn(context.WithoutCancel(req.Ctx), sessionID, resp, err)
+	if err == nil {
+		saveAgentReport(context.WithoutCancel(req.Ctx), req, sessionID, resp, time.Since(started))
+	}
+	resp.SessionID = sessionID
+
+	if limit, ok := AgentRateLimitFromError(err); ok {
+		return models.StandardResponse{
+			Data:         limit,
+			Error:        "RATE_LIMITED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	if IsAgentBusy(err) {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "AGENT_BUSY",
+			ErrorMessage: err.Error(),
+		}
+	}
 	if err != nil {
 		return models.StandardResponse{
 			Data:         nil,
@@ -44,11 +110,104 @@ func QueryAgent(req request.AgentQueryRequest) models.StandardResponse {
 	}
 }
 
-func queryAgent(ctx context.Context, req request.AgentQueryRequest) (models.AgentQueryResponse, error) {
+// agentRateLimitPattern 匹配 agent 限流错误的固定文本，RPC 与 HTTP 传输的错误中都包含该文本
+var agentRateLimitPattern = regexp.MustCompile(`rate limited \((\w+)\), retry after (\d+)ms`)
+
+// AgentRateLimitFromError 判断调用 agent 的错误是否为限流，是则返回限流范围和建议的等待时间
+func AgentRateLimitFromError(err error) (models.AgentRateLimit, bool) {
+	if err == nil {
+		return models.AgentRateLimit{}, false
+	}
+	m := agentRateLimitPattern.FindStringSubmatch(err.Error())
+	if m == nil {
+		return models.AgentRateLimit{}, false
+	}
+	ms, _ := strconv.ParseInt(m[2], 10, 64)
+	return models.AgentRateLimit{Scope: m[1], RetryAfterMs: ms}, true
+}
+
+// IsAgentBusy 判断调用 agent 的错误是否因为 agent 并发已满且排队队列已满或排队超时
+func IsAgentBusy(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "agent busy")
+}
+
+// startAgentSession 记录本次提问所属的会话。只有指定的会话不存在时返回错误，
+// 其余 Redis 故障仅记录日志，本次提问照常执行但不持久化
+func startAgentSession(req request.AgentQueryRequest, execute bool) (string, error) {
+	sessionID, err := beginAgentTurn(req.Ctx, req, execute)
+	if errors.Is(err, errAgentSessionNotFound) {
+		return "", err
+	}
+	if err != nil {
+		log.Printf("[agent-session] begin turn failed: %v", err)
+		return "", nil
+	}
+	return sessionID, nil
+}
+
+func queryAgent(ctx context.Context, endpoint agentEndpoint, req request.AgentQueryRequest) (models.AgentQueryResponse, error) {
 	if config.AppConfig == nil {
 		return models.AgentQueryResponse{}, fmt.Errorf("config is not initialised")
 	}
 
+	agentCfg := config.AppConfig.Agent
+	rpcReq, err := buildAgentRPCRequest(req)
+	if err != nil {
+		return models.AgentQueryResponse{}, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(agentCfg.Transport)) {
+	case "", "rpc":
+		return queryAgentRPC(ctx, endpoint.rpcMethod, rpcReq)
+	case "http":
+		return queryAgentHTTP(ctx, endpoint.httpPath, rpcReq)
+	default:
+		return models.AgentQueryResponse{}, fmt.Errorf("unsupported agent transport: %s", agentCfg.Transport)
+	}
+}
+
+// buildAgentRPCRequest 组装发给 agent 的请求，instance_id 为登记实例时附带其连接信息
+func buildAgentRPCRequest(req request.AgentQueryRequest) (agentRPCRequest, error) {
+	agentCfg := config.AppConfig.Agent
+	conn, err := resolveInstanceConnection(req.Ctx, req.InstanceID)
+	if err != nil {
+		return agentRPCRequest{}, err
+	}
+
+	toolCalls := make([]agentToolCall, 0, len(req.Tools))
+	for _, t := range req.Tools {
+		toolCalls = append(toolCalls, agentToolCall{Name: t.Name, Args: t.Args, Reason: t.Reason})
+	}
+
+	timeoutSeconds := req.TimeoutSeconds
+	if timeoutSeconds <= 0 && agentCfg.Timeout > 0 {
+		timeoutSeconds = int(agentCfg.Timeout / time.Second)
+	}
+
+	return agentRPCRequest{
+		Query:              req.Query,
+		Tools:              toolCalls,
+		TimeoutSeconds:     timeoutSeconds,
+		Context:            req.Context,
+		IncludeRaw:         req.IncludeRaw,
+		IncludeToolOutputs: req.IncludeToolOutputs,
+		PlanOnly:           req.PlanOnly,
+		Caller:             clientIPFrom(req.Ctx),
+		InstanceID:         req.InstanceID,
+		Connection:         conn,
+	}, nil
+}
+
+func queryAgentRPC(ctx context.Context, method string, rpcReq agentRPCRequest) (models.AgentQueryResponse, error) {
+	var rpcResp models.AgentQueryResponse
+	if err := callAgentRPC(ctx, method, rpcReq, &rpcResp); err != nil {
+		return models.AgentQueryResponse{}, err
+	}
+	return rpcResp, nil
+}
+
+// callAgentRPC 通过 jsonrpc 调用 mysql-agent 的指定方法，ctx 取消时中断连接
+func callAgentRPC(ctx context.Context, method string, args interface{}, reply interface{}) error {
 	agentCfg := config.AppConfig.Agent
 	rpcAddr := config.AppConfig.GetAgentRPCAddr()
 
@@ -59,7 +218,7 @@ func queryAgent(ctx context.Context, req request.AgentQueryRequest) (models.Agen
 
 	conn, err := dialer.DialContext(ctx, "tcp", rpcAddr)
 	if err != nil {
-		return models.AgentQueryResponse{}, fmt.Errorf("dial mysql-agent rpc: %w", err)
+		return fmt.Errorf("dial mysql-agent rpc: %w", err)
 	}
 	defer conn.Close()
 
@@ -71,45 +230,226 @@ func queryAgent(ctx context.Context, req request.AgentQueryRequest) (models.Agen
 
 	if hasDeadline {
 		if err := conn.SetDeadline(deadline); err != nil {
-			return models.AgentQueryResponse{}, fmt.Errorf("set deadline: %w", err)
+			return fmt.Errorf("set deadline: %w", err)
 		}
 	}
 
 	client := rpc.NewClientWithCodec(jsonrpc.NewClientCodec(conn))
 	defer client.Close()
 
-	toolCalls := make([]agentToolCall, 0, len(req.Tools))
-	for _, t := range req.Tools {
-		toolCalls = append(toolCalls, agentToolCall{Name: t.Name, Args: t.Args, Reason: t.Reason})
-	}
-
-	timeoutSeconds := req.TimeoutSeconds
-	if timeoutSeconds <= 0 && agentCfg.Timeout > 0 {
-		timeoutSeconds = int(agentCfg.Timeout / time.Second)
-	}
-
-	rpcReq := agentRPCRequest{
-		Query:          req.Query,
-		Tools:          toolCalls,
-		TimeoutSeconds: timeoutSeconds,
-		Context:        req.Context,
-	}
-
-	var rpcResp models.AgentQueryResponse
 	done := make(chan error, 1)
 	go func() {
-		done <- client.Call("Agent.Query", rpcReq, &rpcResp)
+		done <- client.Call(method, args, reply)
 	}()
 
 	select {
 	case <-ctx.Done():
 		_ = conn.Close()
-		return models.AgentQueryResponse{}, fmt.Errorf("rpc call canceled: %w", ctx.Err())
+		return fmt.Errorf("rpc call canceled: %w", ctx.Err())
 	case err := <-done:
 		if err != nil {
-			return models.AgentQueryResponse{}, fmt.Errorf("call Agent.Query: %w", err)
+			return fmt.Errorf("call %s: %w", method, err)
 		}
 	}
+	return nil
+}
 
-	return rpcResp, nil
+func queryAgentHTTP(ctx context.Context, path string, rpcReq agentRPCRequest) (models.AgentQueryResponse, error) {
+	agentCfg := config.AppConfig.Agent
+
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && agentCfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, agentCfg.Timeout)
+		defer cancel()
+	}
+
+	var resp models.AgentQueryResponse
+	if err := callAgentHTTP(ctx, http.MethodPost, path, rpcReq, &resp); err != nil {
+		return models.AgentQueryResponse{}, err
+	}
+	return resp, nil
+}
+
+// callAgentHTTP 以 JSON 调用 agent 的 HTTP 接口（http 传输），body 为 nil 时不发送请求体，
+// 响应解码到 out；accept 为 200 之外仍按正常响应解码的状态码，例如 /health 不健康时的 503
+func callAgentHTTP(ctx context.Context, method, path string, body, out any, accept ...int) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal agent request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	url := strings.TrimRight(config.AppConfig.GetAgentBaseURL(), "/") + path
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("build agent http request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("call mysql-agent http: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK && !slices.Contains(accept, httpResp.StatusCode) {
+		return agentHTTPStatusError(httpResp)
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode agent http response: %w", err)
+	}
+	return nil
+}
+
+// agentHTTPStatusError 把 agent 的非 200 响应转换为错误，带上响应体中的 error 字段
+func agentHTTPStatusError(httpResp *http.Response) error {
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+		return fmt.Errorf("mysql-agent http status %d", httpResp.StatusCode)
+	}
+	return fmt.Errorf("mysql-agent http status %d: %s", httpResp.StatusCode, errResp.Error)
+}
+
+// StreamAgent 以流式方式调用 mysql-agent，每收到一个事件回调一次 emit，data 为事件的原始 JSON。
+// http 传输转发 agent 的 Server-Sent Events；rpc 传输不支持流式，完成后仅推送一个 done 事件。
+// 启用 Redis 时首先推送携带会话ID的 session 事件
+func StreamAgent(req request.AgentQueryRequest, emit func(event string, data json.RawMessage)) error {
+	if config.AppConfig == nil {
+		return fmt.Errorf("config is not initialised")
+	}
+
+	sessionID, err := startAgentSession(req, false)
+	if err != nil {
+		return err
+	}
+	if sessionID != "" {
+		data, _ := json.Marshal(map[string]string{"type": "session", "session_id": sessionID})
+		emit("session", data)
+	}
+
+	// 转发的同时截获 done 事件中的完整响应用于持久化会话
+	var result models.AgentQueryResponse
+	streamErr := errors.New("agent stream ended without result")
+	forward := func(event string, data json.RawMessage) {
+		switch event {
+		case "done":
+			var done struct {
+				Result models.AgentQueryResponse `json:"result"`
+			}
+			if err := json.Unmarshal(data, &done); err == nil {
+				result, streamErr = done.Result, nil
+			}
+		case "error":
+			var failed struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(data, &failed); err == nil && failed.Error != "" {
+				streamErr = errors.New(failed.Error)
+			}
+		}
+		emit(event, data)
+	}
+	started := time.Now()
+	if err := streamAgent(req, forward); err != nil {
+		finishAgentTurn(context.WithoutCancel(req.Ctx), sessionID, result, err)
+		return err
+	}
+	// 流已正常结束，agent 侧的错误已通过 error 事件转发给调用方，这里只用于记录会话状态
+	finishAgentTurn(context.WithoutCancel(req.Ctx), sessionID, result, streamErr)
+	if streamErr == nil {
+		saveAgentReport(context.WithoutCancel(req.Ctx), req, sessionID, result, time.Since(started))
+	}
+	return nil
+}
+
+func streamAgent(req request.AgentQueryRequest, emit func(event string, data json.RawMessage)) error {
+	agentCfg := config.AppConfig.Agent
+	rpcReq, err := buildAgentRPCRequest(req)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(agentCfg.Transport)) {
+	case "", "rpc":
+		resp, err := queryAgentRPC(req.Ctx, agentQueryEndpoint.rpcMethod, rpcReq)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(map[string]interface{}{"type": "done", "result": resp})
+		if err != nil {
+			return fmt.Errorf("marshal agent response: %w", err)
+		}
+		emit("done", data)
+		return nil
+	case "http":
+		return streamAgentHTTP(req.Ctx, rpcReq, emit)
+	default:
+		return fmt.Errorf("unsupported agent transport: %s", agentCfg.Transport)
+	}
+}
+
+func streamAgentHTTP(ctx context.Context, rpcReq agentRPCRequest, emit func(event string, data json.RawMessage)) error {
+	agentCfg := config.AppConfig.Agent
+
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && agentCfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, agentCfg.Timeout)
+		defer cancel()
+	}
+
+	body, err := json.Marshal(rpcReq)
+	if err != nil {
+		return fmt.Errorf("marshal agent request: %w", err)
+	}
+
+	url := strings.TrimRight(config.AppConfig.GetAgentBaseURL(), "/") + "/query/stream"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build agent http request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("call mysql-agent http: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return agentHTTPStatusError(httpResp)
+	}
+
+	// 逐行解析 SSE：event/data 行累积，空行表示一个事件结束
+	reader := bufio.NewReader(httpResp.Body)
+	event, data := "", ""
+	for {
+		line, err := reader.ReadString('\n')
+		trimmed := strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(trimmed, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(trimmed, "event:"))
+		case strings.HasPrefix(trimmed, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(trimmed, "data:"))
+		case trimmed == "" && data != "":
+			if event == "" {
+				event = "message"
+			}
+			emit(event, json.RawMessage(data))
+			event, data = "", ""
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read agent event stream: %w", err)
+		}
+	}
 }
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"mysql-backend/config"
+	"mysql-backend/databases"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// agent 会话状态
+const (
+	AgentSessionRunning = "running"
+	AgentSessionPlanned = "planned" // 只生成了工具计划，等待审核后执行
+	AgentSessionSuccess = "success"
+	AgentSessionFailed  = "failed"
+)
+
+// agentSessionIndex 按最近更新时间排序的会话ID集合
+const agentSessionIndex = "agent:sessions"
+
+// errAgentSessionNotFound 会话不存在或已过期
+var errAgentSessionNotFound = errors.New("agent session not found or expired")
+
+func agentSessionKey(id string) string  { return "agent:session:" + id }
+func agentToolRunsKey(id string) string { return "agent:session:" + id + ":tools" }
+func agentReportKey(id string) string   { return "agent:report:" + id }
+
+// beginAgentTurn 在会话中记录一次进行中的提问并返回会话ID，req.SessionID 为空时新建会话；
+// execute 表示本轮执行的是审核后的计划。未启用 Redis 时返回空ID
+func beginAgentTurn(ctx context.Context, req request.AgentQueryRequest, execute bool) (string, error) {
+	rdb, err := databases.GetRedis()
+	if err != nil {
+		return "", nil
+	}
+
+	now := time.Now().Format(time.RFC3339Nano)
+	var session models.AgentSession
+	if req.SessionID != "" {
+		if session, err = loadAgentSession(ctx, rdb, req.SessionID); err != nil {
+			return "", err
+		}
+	} else {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		session = models.AgentSession{ID: hex.EncodeToString(buf), CreatedAt: now}
+	}
+
+	tools := make([]models.AgentToolCall, 0, len(req.Tools))
+	for _, t := range req.Tools {
+		tools = append(tools, models.AgentToolCall{Name: t.Name, Args: t.Args, Reason: t.Reason})
+	}
+	session.Turns = append(session.Turns, models.AgentSessionTurn{
+		Query:     req.Query,
+		Tools:     tools,
+		PlanOnly:  req.PlanOnly,
+		Execute:   execute,
+		Status:    AgentSessionRunning,
+		StartedAt: now,
+	})
+	session.Status = AgentSessionRunning
+	session.UpdatedAt = now
+
+	if err := saveAgentSession(ctx, rdb, session, nil, nil); err != nil {
+		return "", err
+	}
+	return session.ID, nil
+}
+
+// finishAgentTurn 记录会话最近一轮的结果：追加工具输出，成功时保存诊断报告，并把 token 用量计入会话与当日统计。
+// 持久化失败只记录日志，不影响本次调用的返回
+func finishAgentTurn(ctx context.Context, sessionID string, resp models.AgentQueryResponse, callErr error) {
+	rdb, err := databases.GetRedis()
+	if err != nil {
+		return
+	}
+	if callErr == nil {
+		recordAgentUsage(ctx, rdb, resp.Usage)
+	}
+	if sessionID == "" {
+		return
+	}
+
+	session, err := loadAgentSession(ctx, rdb, sessionID)
+	if err != nil {
+		log.Printf("[agent-session] load %s failed: %v", sessionID, err)
+		return
+	}
+
+	now := time.Now().Format(time.RFC3339Nano)
+	turn := &session.Turns[len(session.Turns)-1]
+	turn.FinishedAt = now
+	if resp.Usage != nil {
+		turn.Usage = resp.Usage
+		session.Usage.Add(*resp.Usage)
+	}
+	var report *models.AgentQueryResponse
+	switch {
+	case callErr != nil:
+		turn.Status, turn.Error = AgentSessionFailed, callErr.Error()
+	case resp.Analysis.Error != "":
+		turn.Status, turn.Error = AgentSessionFailed, resp.Analysis.Error
+	case len(resp.Plan) > 0 && len(resp.ToolRuns) == 0:
+		turn.Status = AgentSessionPlanned
+		resp.SessionID = sessionID
+		report = &resp
+	default:
+		turn.Status, turn.Summary = AgentSessionSuccess, resp.Analysis.Summary
+		resp.SessionID = sessionID
+		report = &resp
+	}
+	session.Status = turn.Status
+	session.UpdatedAt = now
+
+	if err := saveAgentSession(ctx, rdb, session, resp.ToolRuns, report); err != nil {
+		log.Printf("[agent-session] save %s failed: %v", sessionID, err)
+	}
+}
+
+func loadAgentSession(ctx context.Context, rdb *redis.Client, id string) (models.AgentSession, error) {
+	var session models.AgentSession
+	raw, err := rdb.Get(ctx, agentSessionKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return session, errAgentSessionNotFound
+	}
+	if err != nil {
+		return session, fmt.Errorf("read agent session: %w", err)
+	}
+	if err := json.Unmarshal(raw, &session); err != nil {
+		return session, fmt.Errorf("decode agent session: %w", err)
+	}
+	return session, nil
+}
+
+// saveAgentSession 在一个事务中写入会话、追加工具输出并刷新所有相关键的过期时间
+func saveAgentSession(ctx context.Context, rdb *redis.Client, session models.AgentSession, toolRuns []models.AgentToolRun, report *models.AgentQueryResponse) error {
+	cfg := config.AppConfig.Redis
+	payload, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, agentSessionKey(session.ID), payload, cfg.SessionTTL)
+		if len(toolRuns) > 0 {
+			values := make([]interface{}, 0, len(toolRuns))
+			for _, run := range toolRuns {
+				b, err := json.Marshal(run)
+				if err != nil {
+					return err
+				}
+				values = append(values, b)
+			}
+			pipe.LPush(ctx, agentToolRunsKey(session.ID), values...)
+			if cfg.MaxToolRuns > 0 {
+				pipe.LTrim(ctx, agentToolRunsKey(session.ID), 0, cfg.MaxToolRuns-1)
+			}
+		}
+		pipe.Expire(ctx, agentToolRunsKey(session.ID), cfg.SessionTTL)
+		if report != nil {
+			b, err := json.Marshal(report)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, agentReportKey(session.ID), b, cfg.SessionTTL)
+		} else {
+			pipe.Expire(ctx, agentReportKey(session.ID), cfg.SessionTTL)
+		}
+		pipe.ZAdd(ctx, agentSessionIndex, redis.Z{Score: float64(time.Now().Unix()), Member: session.ID})
+		return nil
+	})
+	return err
+}
+
+// ListAgentSessions 按最近更新时间倒序分页列出未过期的 agent 会话
+func ListAgentSessions(req request.AgentSessionListRequest) models.StandardResponse {
+	resp, err := listAgentSessions(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: err.Error()}
+	}
+	return models.StandardResponse{Data: resp, Error: "NO_ERROR", ErrorMessage: "Operation completed successfully"}
+}
+
+func listAgentSessions(ctx context.Context, req request.AgentSessionListRequest) (models.AgentSessionListResponse, error) {
+	resp := models.AgentSessionListResponse{Sessions: []models.AgentSession{}, Limit: req.Limit, Offset: req.Offset}
+	rdb, err := databases.GetRedis()
+	if err != nil {
+		return resp, err
+	}
+
+	// 会话键过期后索引中的ID也随之清理
+	if ttl := config.AppConfig.Redis.SessionTTL; ttl > 0 {
+		cutoff := strconv.FormatInt(time.Now().Add(-ttl).Unix(), 10)
+		if err := rdb.ZRemRangeByScore(ctx, agentSessionIndex, "-inf", "("+cutoff).Err(); err != nil {
+			return resp, fmt.Errorf("prune agent session index: %w", err)
+		}
+	}
+
+	if resp.Total, err = rdb.ZCard(ctx, agentSessionIndex).Result(); err != nil {
+		return resp, fmt.Errorf("count agent sessions: %w", err)
+	}
+	ids, err := rdb.ZRevRange(ctx, agentSessionIndex, int64(req.Offset), int64(req.Offset+req.Limit-1)).Result()
+	if err != nil {
+		return resp, fmt.Errorf("list agent sessions: %w", err)
+	}
+	if len(ids) == 0 {
+		return resp, nil
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, agentSessionKey(id))
+	}
+	values, err := rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return resp, fmt.Errorf("read agent sessions: %w", err)
+	}
+	for _, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var session models.AgentSession
+		if err := json.Unmarshal([]byte(raw), &session); err != nil {
+			continue
+		}
+		resp.Sessions = append(resp.Sessions, session)
+	}
+	return resp, nil
+}
+
+// GetAgentSession 返回会话详情，包括最近的工具输出和最近一次成功的诊断报告或待审核的计划
+func GetAgentSession(req request.AgentSessionRequest) models.StandardResponse {
+	detail, err := getAgentSession(req.Ctx, req.ID)
+	if errors.Is(err, errAgentSessionNotFound) {
+		return models.StandardResponse{Data: nil, Error: "NOT_FOUND", ErrorMessage: err.Error()}
+	}
+	if err != nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: err.Error()}
+	}
+	return models.StandardResponse{Data: detail, Error: "NO_ERROR", ErrorMessage: "Operation completed successfully"}
+}
+
+func getAgentSession(ctx context.Context, id string) (models.AgentSessionDetail, error) {
+	detail := models.AgentSessionDetail{ToolRuns: []models.AgentToolRun{}}
+	rdb, err := databases.GetRedis()
+	if err != nil {
+		return detail, err
+	}
+
+	if detail.AgentSession, err = loadAgentSession(ctx, rdb, id); err != nil {
+		return detail, err
+	}
+
+	runs, err := rdb.LRange(ctx, agentToolRunsKey(id), 0, -1).Result()
+	if err != nil {
+		return detail, fmt.Errorf("read agent tool runs: %w", err)
+	}
+	for _, raw := range runs {
+		var run models.AgentToolRun
+		if err := json.Unmarshal([]byte(raw), &run); err == nil {
+			detail.ToolRuns = append(detail.ToolRuns, run)
+		}
+	}
+
+	raw, err := rdb.Get(ctx, agentReportKey(id)).Bytes()
+	switch {
+	case errors.Is(err, redis.Nil):
+	case err != nil:
+		return detail, fmt.Errorf("read agent report: %w", err)
+	default:
+		var report models.AgentQueryResponse
+		if err := json.Unmarshal(raw, &report); err == nil {
+			detail.Report = &report
+		}
+	}
+	return detail, nil
+}
+
+// ResumeAgentSession 重新执行会话中最近一轮失败的提问，例如 agent 重启导致调用中断
+func ResumeAgentSession(req request.AgentSessionRequest) models.StandardResponse {
+	rdb, err := databases.GetRedis()
+	if err != nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: err.Error()}
+	}
+	session, err := loadAgentSession(req.Ctx, rdb, req.ID)
+	if errors.Is(err, errAgentSessionNotFound) {
+		return models.StandardResponse{Data: nil, Error: "NOT_FOUND", ErrorMessage: err.Error()}
+	}
+	if err != nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: err.Error()}
+	}
+
+	last := session.Turns[len(session.Turns)-1]
+	if last.Status != AgentSessionFailed {
+		return models.StandardResponse{Data: nil, Error: "INVALID_STATE", ErrorMessage: fmt.Sprintf("last turn is %s, only failed turns can be resumed", last.Status)}
+	}
+
+	tools := make([]request.AgentToolCall, 0, len(last.Tools))
+	for _, t := range last.Tools {
+		tools = append(tools, request.AgentToolCall{Name: t.Name, Args: t.Args, Reason: t.Reason})
+	}
+	resumed := request.AgentQueryRequest{
+		Query:     last.Query,
+		Tools:     tools,
+		PlanOnly:  last.PlanOnly,
+		SessionID: session.ID,
+		Ctx:       req.Ctx,
+	}
+	if last.Execute {
+		return ExecuteAgentPlan(resumed)
+	}
+	return QueryAgent(resumed)
+}
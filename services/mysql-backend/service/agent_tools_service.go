@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"mysql-backend/config"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// agentToolsTTL agent 工具列表的缓存时长，agent 侧工具只在启动时注册，短时间缓存即可
+const agentToolsTTL = time.Minute
+
+var agentToolsCache struct {
+	mu        sync.Mutex
+	tools     []models.AgentToolSpec
+	fetchedAt time.Time
+}
+
+// ListAgentTools 返回 agent 已注册的工具及其参数定义，供前端构建工具选择界面
+func ListAgentTools(req request.AgentToolsRequest) models.StandardResponse {
+	if config.AppConfig == nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: "config is not initialised"}
+	}
+	tools, err := loadAgentTools(req.Ctx, req.Refresh)
+	if err != nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: err.Error()}
+	}
+	return models.StandardResponse{
+		Data:         models.AgentToolListResponse{Tools: tools},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// ValidateAgentTools 转发前按 agent 的工具定义校验调用方指定的工具：工具必须已注册、args 必须是 JSON 对象且包含必填参数。
+// 获取工具列表失败时不做校验，交由 agent 处理
+func ValidateAgentTools(ctx context.Context, calls []request.AgentToolCall) error {
+	if len(calls) == 0 || config.AppConfig == nil {
+		return nil
+	}
+	tools, err := loadAgentTools(ctx, false)
+	if err != nil {
+		log.Printf("[agent-tools] skip validation: %v", err)
+		return nil
+	}
+
+	specs := make(map[string]models.AgentToolSpec, len(tools))
+	for _, t := range tools {
+		specs[t.Name] = t
+	}
+	for i, call := range calls {
+		spec, ok := specs[call.Name]
+		if !ok {
+			return fmt.Errorf("tools[%d]: unknown tool %q", i, call.Name)
+		}
+		args := map[string]json.RawMessage{}
+		if raw := strings.TrimSpace(string(call.Args)); raw != "" && raw != "null" {
+			if err := json.Unmarshal(call.Args, &args); err != nil {
+				return fmt.Errorf("tools[%d] %s: args must be a JSON object", i, call.Name)
+			}
+		}
+		var schema struct {
+			Required []string `json:"required"`
+		}
+		_ = json.Unmarshal(spec.Parameters, &schema)
+		for _, name := range schema.Required {
+			if _, ok := args[name]; !ok {
+				return fmt.Errorf("tools[%d] %s: missing required arg %q", i, call.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+func loadAgentTools(ctx context.Context, refresh bool) ([]models.AgentToolSpec, error) {
+	agentToolsCache.mu.Lock()
+	defer agentToolsCache.mu.Unlock()
+	if !refresh && agentToolsCache.tools != nil && time.Since(agentToolsCache.fetchedAt) < agentToolsTTL {
+		return agentToolsCache.tools, nil
+	}
+
+	tools, err := fetchAgentTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	agentToolsCache.tools = tools
+	agentToolsCache.fetchedAt = time.Now()
+	return tools, nil
+}
+
+// fetchAgentTools 调用 agent 的 Agent.ListTools（http 传输为 GET /tools）
+func fetchAgentTools(ctx context.Context) ([]models.AgentToolSpec, error) {
+	var resp models.AgentToolListResponse
+	agentCfg := config.AppConfig.Agent
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && agentCfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, agentCfg.Timeout)
+		defer cancel()
+	}
+
+	switch strings.ToLower(strings.TrimSpace(agentCfg.Transport)) {
+	case "", "rpc":
+		if err := callAgentRPC(ctx, "Agent.ListTools", struct{}{}, &resp); err != nil {
+			return nil, err
+		}
+	case "http":
+		if err := callAgentHTTP(ctx, http.MethodGet, "/tools", nil, &resp); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported agent transport: %s", agentCfg.Transport)
+	}
+	if resp.Tools == nil {
+		resp.Tools = []models.AgentToolSpec{}
+	}
+	return resp.Tools, nil
+}
+
+// RunAgentTool 直接执行 agent 的单个工具并返回结构化输出，不经过 LLM
+func RunAgentTool(req request.AgentRunToolRequest) models.StandardResponse {
+	if config.AppConfig == nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: "config is not initialised"}
+	}
+	run, err := runAgentTool(req)
+	if err != nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: err.Error()}
+	}
+	return models.StandardResponse{Data: run, Error: "NO_ERROR", ErrorMessage: "Operation completed successfully"}
+}
+
+// runAgentTool 调用 agent 的 Agent.RunTool（http 传输为 POST /tools/run）
+func runAgentTool(req request.AgentRunToolRequest) (models.AgentToolRun, error) {
+	var run models.AgentToolRun
+	ctx := req.Ctx
+	agentCfg := config.AppConfig.Agent
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && agentCfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, agentCfg.Timeout)
+		defer cancel()
+	}
+
+	conn, err := resolveInstanceConnection(ctx, req.InstanceID)
+	if err != nil {
+		return run, err
+	}
+	args := struct {
+		Name           string                   `json:"name"`
+		Args           json.RawMessage          `json:"args,omitempty"`
+		TimeoutSeconds int                      `json:"timeout_seconds,omitempty"`
+		InstanceID     string                   `json:"instance_id,omitempty"`
+		Connection     *agentInstanceConnection `json:"connection,omitempty"`
+	}{Name: req.Name, Args: req.Args, TimeoutSeconds: req.TimeoutSeconds, InstanceID: req.InstanceID, Connection: conn}
+
+	switch strings.ToLower(strings.TrimSpace(agentCfg.Transport)) {
+	case "", "rpc":
+		err := callAgentRPC(ctx, "Agent.RunTool", args, &run)
+		return run, err
+	case "http":
+		err := callAgentHTTP(ctx, http.MethodPost, "/tools/run", args, &run)
+		return run, err
+	default:
+		return run, fmt.Errorf("unsupported agent transport: %s", agentCfg.Transport)
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"mysql-backend/config"
+	"mysql-backend/databases"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const (
+	agentUsageDateLayout = "2006-01-02"
+	// agentUsageRetention 每日用量统计的保留时长
+	agentUsageRetention = 32 * 24 * time.Hour
+)
+
+func agentUsageKey(date string) string { return "agent:usage:" + date }
+
+// recordAgentUsage 把一次提问的 token 用量累加到当日统计，失败只记录日志
+func recordAgentUsage(ctx context.Context, rdb *redis.Client, usage *models.AgentTokenUsage) {
+	if usage == nil {
+		return
+	}
+	key := agentUsageKey(time.Now().Format(agentUsageDateLayout))
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, "prompt_tokens", usage.PromptTokens)
+		pipe.HIncrBy(ctx, key, "completion_tokens", usage.CompletionTokens)
+		pipe.HIncrBy(ctx, key, "total_tokens", usage.TotalTokens)
+		pipe.HIncrBy(ctx, key, "calls", usage.Calls)
+		pipe.HIncrBy(ctx, key, "queries", 1)
+		pipe.Expire(ctx, key, agentUsageRetention)
+		return nil
+	})
+	if err != nil {
+		log.Printf("[agent-usage] record %s failed: %v", key, err)
+	}
+}
+
+// GetAgentUsage 返回最近若干天的 LLM token 用量以及 agent 的当日预算状态。
+// 启用 Redis 时按天统计来自 backend 持久化的数据，否则使用 agent 自身的统计
+func GetAgentUsage(req request.AgentUsageRequest) models.StandardResponse {
+	resp, err := getAgentUsage(req.Ctx, req.Days)
+	if err != nil {
+		return models.StandardResponse{Data: nil, Error: "OPERATION_FAILED", ErrorMessage: err.Error()}
+	}
+	return models.StandardResponse{Data: resp, Error: "NO_ERROR", ErrorMessage: "Operation completed successfully"}
+}
+
+func getAgentUsage(ctx context.Context, days int) (models.AgentUsageResponse, error) {
+	if config.AppConfig == nil {
+		return models.AgentUsageResponse{}, fmt.Errorf("config is not initialised")
+	}
+
+	resp, err := fetchAgentUsage(ctx, days)
+	if err != nil {
+		return resp, err
+	}
+
+	rdb, err := databases.GetRedis()
+	if err != nil {
+		return resp, nil
+	}
+	stored, err := loadAgentDailyUsage(ctx, rdb, days)
+	if err != nil {
+		return resp, err
+	}
+	resp.Days = stored
+	return resp, nil
+}
+
+func loadAgentDailyUsage(ctx context.Context, rdb *redis.Client, days int) ([]models.AgentDailyUsage, error) {
+	now := time.Now()
+	cmds := make([]*redis.MapStringStringCmd, 0, days)
+	_, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i := 0; i < days; i++ {
+			cmds = append(cmds, pipe.HGetAll(ctx, agentUsageKey(now.AddDate(0, 0, -i).Format(agentUsageDateLayout))))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read agent usage: %w", err)
+	}
+
+	result := make([]models.AgentDailyUsage, 0, days)
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		parse := func(name string) int64 {
+			v, _ := strconv.ParseInt(fields[name], 10, 64)
+			return v
+		}
+		result = append(result, models.AgentDailyUsage{
+			Date: now.AddDate(0, 0, -i).Format(agentUsageDateLayout),
+			AgentTokenUsage: models.AgentTokenUsage{
+				PromptTokens:     parse("prompt_tokens"),
+				CompletionTokens: parse("completion_tokens"),
+				TotalTokens:      parse("total_tokens"),
+				Calls:            parse("calls"),
+			},
+			Queries: parse("queries"),
+		})
+	}
+	return result, nil
+}
+
+// fetchAgentUsage 调用 agent 的 Agent.Usage（http 传输为 GET /usage）
+func fetchAgentUsage(ctx context.Context, days int) (models.AgentUsageResponse, error) {
+	var resp models.AgentUsageResponse
+	agentCfg := config.AppConfig.Agent
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && agentCfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, agentCfg.Timeout)
+		defer cancel()
+	}
+
+	switch strings.ToLower(strings.TrimSpace(agentCfg.Transport)) {
+	case "", "rpc":
+		args := struct {
+			Days int `json:"days"`
+		}{Days: days}
+		err := callAgentRPC(ctx, "Agent.Usage", args, &resp)
+		return resp, err
+	case "http":
+		err := callAgentHTTP(ctx, http.MethodGet, "/usage?days="+strconv.Itoa(days), nil, &resp)
+		return resp, err
+	default:
+		return resp, fmt.Errorf("unsupported agent transport: %s", agentCfg.Transport)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mysql-backend/databases"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const auditTable = "admin_audit_log"
+
+type actorKey struct{}
+
+// WithActor 在请求上下文中记录操作人，供审计日志使用
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "unknown"
+}
+
+type clientIPKey struct{}
+
+// WithClientIP 在请求上下文中记录客户端IP，作为 agent 按调用方限流的标识
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+func clientIPFrom(ctx context.Context) string {
+	if ip, ok := ctx.Value(clientIPKey{}).(string); ok && ip != "" {
+		return ip
+	}
+	return "unknown"
+}
+
+func ensureAuditTable(ctx context.Context) error {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+	actor VARCHAR(128) NOT NULL,
+	action VARCHAR(64) NOT NULL,
+	target VARCHAR(255) NOT NULL,
+	statements JSON NOT NULL,
+	success TINYINT(1) NOT NULL,
+	error TEXT NULL,
+	KEY idx_created_at (created_at),
+	KEY idx_target (target)
+)`, databases.MetaTable(auditTable))
+	return databases.EnsureMetaTable(ctx, ddl)
+}
+
+// runPlan 执行语句并写入审计日志，审计写入失败只记录日志不影响操作结果
+func runPlan(ctx context.Context, action, target string, plan []sqlStatement) error {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+
+	execErr := execStatements(ctx, db, plan)
+	if err := recordAudit(ctx, action, target, plan, execErr); err != nil {
+		log.Printf("[audit] record %s on %s failed: %v", action, target, err)
+	}
+	return execErr
+}
+
+func recordAudit(ctx context.Context, action, target string, plan []sqlStatement, execErr error) error {
+	// 请求被取消时仍需落审计
+	ctx = context.WithoutCancel(ctx)
+	if err := ensureAuditTable(ctx); err != nil {
+		return err
+	}
+
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+
+	stmts := make([]string, 0, len(plan))
+	for _, st := range plan {
+		stmts = append(stmts, redactSQL(st.SQL))
+	}
+	payload, err := json.Marshal(stmts)
+	if err != nil {
+		return err
+	}
+
+	// MySQL 语法错误会回显出错位置附近的语句，其中可能含有明文密码
+	var errText interface{}
+	if execErr != nil {
+		errText = redactSQL(execErr.Error())
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (actor, action, target, statements, success, error) VALUES (?, ?, ?, ?, ?, ?)", databases.MetaTable(auditTable))
+	_, err = db.ExecContext(ctx, insert, actorFrom(ctx), action, target, string(payload), execErr == nil, errText)
+	return err
+}
+
+// QueryAuditLog 按时间范围等条件分页查询审计日志
+func QueryAuditLog(ctx context.Context, req request.AuditQueryRequest) (models.AuditLogResponse, error) {
+	if err := ensureAuditTable(ctx); err != nil {
+		return models.AuditLogResponse{}, err
+	}
+
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.AuditLogResponse{}, err
+	}
+
+	conds := make([]string, 0, 4)
+	args := make([]any, 0, 6)
+	if !req.FromTime.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, req.FromTime)
+	}
+	if !req.ToTime.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, req.ToTime)
+	}
+	if req.Action != "" {
+		conds = append(conds, "action = ?")
+		args = append(args, req.Action)
+	}
+	if req.Target != "" {
+		conds = append(conds, "target LIKE ?")
+		args = append(args, req.Target)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	query := fmt.Sprintf("SELECT id, created_at, actor, action, target, statements, success, COALESCE(error, '') FROM %s%s ORDER BY id DESC LIMIT ?, ?",
+		databases.MetaTable(auditTable), where)
+	rows, err := db.QueryContext(ctx, query, append(args, req.Offset, req.Limit)...)
+	if err != nil {
+		return models.AuditLogResponse{}, err
+	}
+	defer rows.Close()
+
+	resp := models.AuditLogResponse{Entries: []models.AuditEntry{}, Limit: req.Limit, Offset: req.Offset}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var createdAt time.Time
+		var stmts string
+		if err := rows.Scan(&entry.ID, &createdAt, &entry.Actor, &entry.Action, &entry.Target, &stmts, &entry.Success, &entry.Error); err != nil {
+			return models.AuditLogResponse{}, err
+		}
+		entry.CreatedAt = createdAt.Format(time.RFC3339Nano)
+		if err := json.Unmarshal([]byte(stmts), &entry.Statements); err != nil {
+			entry.Statements = []string{stmts}
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return models.AuditLogResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// QueryAudit 处理查询审计日志的业务逻辑，返回统一响应
+func QueryAudit(req request.AuditQueryRequest) models.StandardResponse {
+	resp, err := QueryAuditLog(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
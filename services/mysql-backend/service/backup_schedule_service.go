@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const backupScheduleTable = "backup_schedules"
+
+func ensureBackupScheduleTable(ctx context.Context) error {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(64) NOT NULL,
+	schema_names JSON NOT NULL,
+	cron VARCHAR(128) NOT NULL,
+	keep_daily INT NOT NULL DEFAULT 0,
+	keep_weekly INT NOT NULL DEFAULT 0,
+	enabled TINYINT(1) NOT NULL DEFAULT 1,
+	last_run_at DATETIME(3) NULL,
+	UNIQUE KEY uk_name (name)
+)`, databases.MetaTable(backupScheduleTable))
+	return databases.EnsureMetaTable(ctx, ddl)
+}
+
+// StartBackupScheduler 在后台每分钟检查一次定时任务并触发到期的备份，ctx 取消时退出；
+// 多实例部署时每个实例都会触发，由 backup.scheduler_enabled 控制只在一个实例上开启
+func StartBackupScheduler(ctx context.Context) {
+	go func() {
+		for {
+			now := time.Now()
+			next := now.Truncate(time.Minute).Add(time.Minute)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(next.Sub(now)):
+			}
+			if err := runDueSchedules(ctx, next); err != nil {
+				log.Printf("[backup] run schedules failed: %v", err)
+			}
+		}
+	}()
+}
+
+// FailInterruptedBackups 进程重启后，上次未结束的备份任务（手动或定时触发）不会再完成，标记为失败
+func FailInterruptedBackups(ctx context.Context) error {
+	if err := ensureBackupTable(ctx); err != nil {
+		return err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+	stmt := fmt.Sprintf("UPDATE %s SET status = ?, finished_at = CURRENT_TIMESTAMP(3), error = ? WHERE status = ?",
+		databases.MetaTable(backupTable))
+	_, err = db.ExecContext(ctx, stmt, BackupFailed, "interrupted by backend restart", BackupRunning)
+	return err
+}
+
+func runDueSchedules(ctx context.Context, now time.Time) error {
+	schedules, err := listBackupSchedules(ctx)
+	if err != nil {
+		return err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range schedules {
+		if !s.Enabled {
+			continue
+		}
+		cron, err := helper.ParseCron(s.Cron)
+		if err != nil {
+			log.Printf("[backup] schedule %s has invalid cron %q: %v", s.Name, s.Cron, err)
+			continue
+		}
+		if !cron.Match(now) {
+			continue
+		}
+
+		// 上一次备份还未结束时跳过本次
+		var running bool
+		query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE schedule_id = ? AND status = ?)", databases.MetaTable(backupTable))
+		if err := db.QueryRowContext(ctx, query, s.ID, BackupRunning).Scan(&running); err != nil {
+			return err
+		}
+		if running {
+			log.Printf("[backup] schedule %s skipped: previous backup still running", s.Name)
+			continue
+		}
+
+		jobID, err := StartBackup(WithActor(ctx, "schedule:"+s.Name), s.Schemas, s.ID)
+		if err != nil {
+			log.Printf("[backup] schedule %s start failed: %v", s.Name, err)
+			continue
+		}
+		log.Printf("[backup] schedule %s started job %d", s.Name, jobID)
+
+		update := fmt.Sprintf("UPDATE %s SET last_run_at = ? WHERE id = ?", databases.MetaTable(backupScheduleTable))
+		if _, err := db.ExecContext(ctx, update, now, s.ID); err != nil {
+			log.Printf("[backup] schedule %s update last_run_at failed: %v", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyRetention 保留最近 keep_daily 天每天最新一份与最近 keep_weekly 周每周最新一份成功备份，
+// 其余备份删除文件并标记为 expired
+func applyRetention(ctx context.Context, scheduleID int64) error {
+	schedule, err := getBackupSchedule(ctx, scheduleID)
+	if err != nil {
+		return err
+	}
+	if schedule.KeepDaily == 0 && schedule.KeepWeekly == 0 {
+		return nil
+	}
+
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("SELECT id, file, started_at FROM %s WHERE schedule_id = ? AND status = ? ORDER BY started_at DESC",
+		databases.MetaTable(backupTable))
+	rows, err := db.QueryContext(ctx, query, scheduleID, BackupSuccess)
+	if err != nil {
+		return err
+	}
+	type backupFile struct {
+		id        int64
+		file      string
+		startedAt time.Time
+	}
+	var backups []backupFile
+	for rows.Next() {
+		var b backupFile
+		if err := rows.Scan(&b.id, &b.file, &b.startedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		backups = append(backups, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	keep := make(map[int64]struct{})
+	days := make(map[string]struct{})
+	weeks := make(map[string]struct{})
+	for _, b := range backups {
+		day := b.startedAt.Format("2006-01-02")
+		if _, ok := days[day]; !ok && len(days) < schedule.KeepDaily {
+			days[day] = struct{}{}
+			keep[b.id] = struct{}{}
+		}
+		year, week := b.startedAt.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+		if _, ok := weeks[weekKey]; !ok && len(weeks) < schedule.KeepWeekly {
+			weeks[weekKey] = struct{}{}
+			keep[b.id] = struct{}{}
+		}
+	}
+
+	expire := fmt.Sprintf("UPDATE %s SET status = ?, file = '' WHERE id = ?", databases.MetaTable(backupTable))
+	for _, b := range backups {
+		if _, ok := keep[b.id]; ok {
+			continue
+		}
+		if err := os.Remove(b.file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[backup] remove expired backup %s failed: %v", b.file, err)
+			continue
+		}
+		if _, err := db.ExecContext(ctx, expire, BackupExpired, b.id); err != nil {
+			return err
+		}
+		log.Printf("[backup] expired backup job %d (%s)", b.id, b.file)
+	}
+	return nil
+}
+
+func listBackupSchedules(ctx context.Context) ([]models.BackupSchedule, error) {
+	if err := ensureBackupScheduleTable(ctx); err != nil {
+		return nil, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT id, name, schema_names, cron, keep_daily, keep_weekly, enabled, last_run_at FROM %s ORDER BY id",
+		databases.MetaTable(backupScheduleTable))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]models.BackupSchedule, 0)
+	for rows.Next() {
+		s, err := scanBackupSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+func getBackupSchedule(ctx context.Context, id int64) (models.BackupSchedule, error) {
+	if err := ensureBackupScheduleTable(ctx); err != nil {
+		return models.BackupSchedule{}, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.BackupSchedule{}, err
+	}
+
+	query := fmt.Sprintf("SELECT id, name, schema_names, cron, keep_daily, keep_weekly, enabled, last_run_at FROM %s WHERE id = ?",
+		databases.MetaTable(backupScheduleTable))
+	s, err := scanBackupSchedule(db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.BackupSchedule{}, fmt.Errorf("backup schedule %d not found", id)
+	}
+	return s, err
+}
+
+func scanBackupSchedule(row rowScanner) (models.BackupSchedule, error) {
+	var s models.BackupSchedule
+	var schemas string
+	var lastRun sql.NullTime
+	if err := row.Scan(&s.ID, &s.Name, &schemas, &s.Cron, &s.KeepDaily, &s.KeepWeekly, &s.Enabled, &lastRun); err != nil {
+		return models.BackupSchedule{}, err
+	}
+	if err := json.Unmarshal([]byte(schemas), &s.Schemas); err != nil {
+		return models.BackupSchedule{}, err
+	}
+	if lastRun.Valid {
+		s.LastRunAt = lastRun.Time.Format(time.RFC3339)
+	}
+	if cron, err := helper.ParseCron(s.Cron); err == nil && s.Enabled {
+		if next := cron.Next(time.Now()); !next.IsZero() {
+			s.NextRunAt = next.Format(time.RFC3339)
+		}
+	}
+	return s, nil
+}
+
+// SaveBackupSchedule 创建或更新备份定时任务，create 为 false 时按 ID 更新
+func SaveBackupSchedule(ctx context.Context, req request.BackupScheduleRequest, create bool) (models.BackupSchedule, error) {
+	if err := ensureBackupScheduleTable(ctx); err != nil {
+		return models.BackupSchedule{}, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.BackupSchedule{}, err
+	}
+	schemas, err := json.Marshal(req.Schemas)
+	if err != nil {
+		return models.BackupSchedule{}, err
+	}
+
+	id := req.ID
+	if create {
+		stmt := fmt.Sprintf("INSERT INTO %s (name, schema_names, cron, keep_daily, keep_weekly, enabled) VALUES (?, ?, ?, ?, ?, ?)",
+			databases.MetaTable(backupScheduleTable))
+		res, err := db.ExecContext(ctx, stmt, req.Name, string(schemas), req.Cron, req.KeepDaily, req.KeepWeekly, *req.Enabled)
+		if err != nil {
+			return models.BackupSchedule{}, err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return models.BackupSchedule{}, err
+		}
+	} else {
+		stmt := fmt.Sprintf("UPDATE %s SET name = ?, schema_names = ?, cron = ?, keep_daily = ?, keep_weekly = ?, enabled = ? WHERE id = ?",
+			databases.MetaTable(backupScheduleTable))
+		res, err := db.ExecContext(ctx, stmt, req.Name, string(schemas), req.Cron, req.KeepDaily, req.KeepWeekly, *req.Enabled, req.ID)
+		if err != nil {
+			return models.BackupSchedule{}, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			if _, err := getBackupSchedule(ctx, req.ID); err != nil {
+				return models.BackupSchedule{}, err
+			}
+		}
+	}
+	return getBackupSchedule(ctx, id)
+}
+
+// DeleteBackupSchedule 删除备份定时任务，已有的备份文件与任务记录保留
+func DeleteBackupSchedule(ctx context.Context, id int64) error {
+	if err := ensureBackupScheduleTable(ctx); err != nil {
+		return err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE id = ?", databases.MetaTable(backupScheduleTable))
+	res, err := db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("backup schedule %d not found", id)
+	}
+	return nil
+}
+
+// ListBackupSchedules 处理列出备份定时任务的业务逻辑，返回统一响应
+func ListBackupSchedules(ctx context.Context) models.StandardResponse {
+	schedules, err := listBackupSchedules(ctx)
+	return backupScheduleResponse(schedules, err)
+}
+
+// CreateBackupSchedule 处理创建备份定时任务的业务逻辑，返回统一响应
+func CreateBackupSchedule(req request.BackupScheduleRequest) models.StandardResponse {
+	schedule, err := SaveBackupSchedule(req.Ctx, req, true)
+	return backupScheduleResponse(schedule, err)
+}
+
+// UpdateBackupSchedule 处理更新备份定时任务的业务逻辑，返回统一响应
+func UpdateBackupSchedule(req request.BackupScheduleRequest) models.StandardResponse {
+	schedule, err := SaveBackupSchedule(req.Ctx, req, false)
+	return backupScheduleResponse(schedule, err)
+}
+
+// RemoveBackupSchedule 处理删除备份定时任务的业务逻辑，返回统一响应
+func RemoveBackupSchedule(req request.DeleteBackupScheduleRequest) models.StandardResponse {
+	return backupScheduleResponse(nil, DeleteBackupSchedule(req.Ctx, req.ID))
+}
+
+func backupScheduleResponse(data any, err error) models.StandardResponse {
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         data,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mysql-backend/config"
+	"mysql-backend/databases"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const backupTable = "backup_jobs"
+
+// 备份任务状态
+const (
+	BackupRunning = "running"
+	BackupSuccess = "success"
+	BackupFailed  = "failed"
+	BackupExpired = "expired" // 超出保留策略，备份文件已删除
+)
+
+// maxBackupStderr 记录到任务表中的 mysqldump 错误输出上限
+const maxBackupStderr = 4096
+
+// runningBackups 正在执行的备份任务已写入的字节数，id -> *atomic.Int64
+var runningBackups sync.Map
+
+func ensureBackupTable(ctx context.Context) error {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	schedule_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+	schema_names JSON NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	trigger_by VARCHAR(128) NOT NULL,
+	file VARCHAR(1024) NOT NULL DEFAULT '',
+	size_bytes BIGINT NOT NULL DEFAULT 0,
+	started_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+	finished_at DATETIME(3) NULL,
+	error TEXT NULL,
+	KEY idx_started_at (started_at),
+	KEY idx_schedule (schedule_id, status)
+)`, databases.MetaTable(backupTable))
+	return databases.EnsureMetaTable(ctx, ddl)
+}
+
+// StartBackup 创建备份任务并在后台执行 mysqldump，立即返回任务ID；
+// scheduleID 非 0 表示由定时任务触发，成功后按该定时任务的保留策略清理旧备份
+func StartBackup(ctx context.Context, schemas []string, scheduleID int64) (int64, error) {
+	if err := ensureBackupTable(ctx); err != nil {
+		return 0, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return 0, err
+	}
+
+	dir := config.AppConfig.Backup.Dir
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return 0, fmt.Errorf("create backup dir %s failed: %w", dir, err)
+	}
+
+	payload, err := json.Marshal(schemas)
+	if err != nil {
+		return 0, err
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (schedule_id, schema_names, status, trigger_by) VALUES (?, ?, ?, ?)", databases.MetaTable(backupTable))
+	res, err := db.ExecContext(ctx, insert, scheduleID, string(payload), BackupRunning, actorFrom(ctx))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	file := filepath.Join(dir, fmt.Sprintf("backup_%d_%s.sql.gz", id, time.Now().Format("20060102_150405")))
+	written := &atomic.Int64{}
+	runningBackups.Store(id, written)
+
+	// 备份在后台执行，不随请求取消
+	go func(ctx context.Context) {
+		defer runningBackups.Delete(id)
+		size, runErr := runMysqldump(ctx, schemas, file, written)
+		if runErr != nil {
+			log.Printf("[backup] job %d failed: %v", id, runErr)
+			_ = os.Remove(file)
+		}
+		if err := finishBackup(ctx, id, file, size, runErr); err != nil {
+			log.Printf("[backup] update job %d failed: %v", id, err)
+			return
+		}
+		if runErr == nil && scheduleID != 0 {
+			if err := applyRetention(ctx, scheduleID); err != nil {
+				log.Printf("[backup] retention for schedule %d failed: %v", scheduleID, err)
+			}
+		}
+	}(context.WithoutCancel(ctx))
+
+	return id, nil
+}
+
+// runMysqldump 执行 mysqldump 并将输出 gzip 压缩写入 file，密码通过 MYSQL_PWD 传递避免出现在进程列表中
+func runMysqldump(ctx context.Context, schemas []string, file string, written *atomic.Int64) (int64, error) {
+	cfg := config.AppConfig
+	args := []string{
+		"--host=" + cfg.Database.Host,
+		"--port=" + strconv.Itoa(cfg.Database.Port),
+		"--user=" + cfg.Database.Username,
+		"--single-transaction",
+		"--routines",
+		"--triggers",
+		"--events",
+		"--databases",
+	}
+	args = append(args, schemas...)
+
+	cmd := exec.CommandContext(ctx, cfg.Backup.MysqldumpPath, args...)
+	cmd.Env = append(os.Environ(), "MYSQL_PWD="+cfg.Database.Password)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("start mysqldump failed: %w", err)
+	}
+
+	gz := gzip.NewWriter(&countingWriter{w: out, n: written})
+	_, copyErr := io.Copy(gz, stdout)
+	if copyErr != nil {
+		// 写文件失败时 mysqldump 会阻塞在管道上，先结束进程再 Wait
+		_ = cmd.Process.Kill()
+	}
+	closeErr := gz.Close()
+	waitErr := cmd.Wait()
+
+	switch {
+	case copyErr != nil:
+		return 0, fmt.Errorf("write backup failed: %w", copyErr)
+	case waitErr != nil:
+		msg := stderr.String()
+		if len(msg) > maxBackupStderr {
+			msg = msg[:maxBackupStderr]
+		}
+		return 0, fmt.Errorf("mysqldump failed: %w: %s", waitErr, msg)
+	case closeErr != nil:
+		return 0, fmt.Errorf("write backup failed: %w", closeErr)
+	}
+	if err := out.Sync(); err != nil {
+		return 0, err
+	}
+	return written.Load(), nil
+}
+
+// countingWriter 统计写入的字节数，用于查询运行中任务的进度
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func finishBackup(ctx context.Context, id int64, file string, size int64, runErr error) error {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+	status := BackupSuccess
+	var errText any
+	if runErr != nil {
+		status, file, size = BackupFailed, "", 0
+		errText = runErr.Error()
+	}
+	stmt := fmt.Sprintf("UPDATE %s SET status = ?, file = ?, size_bytes = ?, finished_at = CURRENT_TIMESTAMP(3), error = ? WHERE id = ?",
+		databases.MetaTable(backupTable))
+	_, err = db.ExecContext(ctx, stmt, status, file, size, errText, id)
+	return err
+}
+
+// GetBackupJob 读取备份任务，运行中的任务返回当前已写入的字节数
+func GetBackupJob(ctx context.Context, id int64) (models.BackupJob, error) {
+	if err := ensureBackupTable(ctx); err != nil {
+		return models.BackupJob{}, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.BackupJob{}, err
+	}
+
+	query := fmt.Sprintf("SELECT id, schedule_id, schema_names, status, trigger_by, file, size_bytes, started_at, finished_at, COALESCE(error, '') FROM %s WHERE id = ?",
+		databases.MetaTable(backupTable))
+	var job models.BackupJob
+	var schemas string
+	var startedAt time.Time
+	var finishedAt sql.NullTime
+	err = db.QueryRowContext(ctx, query, id).Scan(&job.ID, &job.ScheduleID, &schemas, &job.Status, &job.TriggerBy, &job.File, &job.SizeBytes, &startedAt, &finishedAt, &job.Error)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.BackupJob{}, fmt.Errorf("backup job %d not found", id)
+	}
+	if err != nil {
+		return models.BackupJob{}, err
+	}
+	if err := json.Unmarshal([]byte(schemas), &job.Schemas); err != nil {
+		return models.BackupJob{}, err
+	}
+	job.StartedAt = startedAt.Format(time.RFC3339)
+	if finishedAt.Valid {
+		job.FinishedAt = finishedAt.Time.Format(time.RFC3339)
+	}
+	if v, ok := runningBackups.Load(id); ok && job.Status == BackupRunning {
+		job.SizeBytes = v.(*atomic.Int64).Load()
+	}
+	return job, nil
+}
+
+// TriggerBackup 处理触发备份的业务逻辑，返回统一响应
+func TriggerBackup(req request.BackupRequest) models.StandardResponse {
+	id, err := StartBackup(req.Ctx, req.Schemas, 0)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         models.BackupJob{ID: id, Schemas: req.Schemas, Status: BackupRunning, TriggerBy: actorFrom(req.Ctx)},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// BackupStatus 处理查询备份任务状态的业务逻辑，返回统一响应
+func BackupStatus(req request.BackupStatusRequest) models.StandardResponse {
+	job, err := GetBackupJob(req.Ctx, req.ID)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         job,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
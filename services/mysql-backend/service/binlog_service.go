@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// ListBinaryLogs 读取 SHOW BINARY LOGS 与当前写入位置
+func ListBinaryLogs(ctx context.Context) (models.BinlogResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.BinlogResponse{}, err
+	}
+
+	logs, err := showBinaryLogs(ctx, db)
+	if err != nil {
+		return models.BinlogResponse{}, err
+	}
+	resp := models.BinlogResponse{Logs: logs}
+	for _, l := range logs {
+		resp.TotalBytes += l.Size
+	}
+
+	status, err := binlogStatus(ctx, db)
+	if err != nil {
+		return models.BinlogResponse{}, err
+	}
+	resp.Current = status
+	return resp, nil
+}
+
+func showBinaryLogs(ctx context.Context, db *sql.DB) ([]models.BinlogFile, error) {
+	rows, err := db.QueryContext(ctx, "SHOW BINARY LOGS")
+	if err != nil {
+		return nil, fmt.Errorf("show binary logs failed: %w", err)
+	}
+	defer rows.Close()
+
+	result, err := scanRowMaps(rows)
+	if err != nil {
+		return nil, err
+	}
+	logs := make([]models.BinlogFile, 0, len(result))
+	for _, row := range result {
+		logs = append(logs, models.BinlogFile{
+			Name:      fmt.Sprint(row["Log_name"]),
+			Size:      toInt64(row["File_size"]),
+			Encrypted: fmt.Sprint(row["Encrypted"]) == "Yes",
+		})
+	}
+	return logs, nil
+}
+
+// binlogStatus 读取当前 binlog 位置，MySQL 8.2+ 使用 SHOW BINARY LOG STATUS，旧版本回退到 SHOW MASTER STATUS
+func binlogStatus(ctx context.Context, db *sql.DB) (*models.BinlogStatus, error) {
+	rows, err := db.QueryContext(ctx, "SHOW BINARY LOG STATUS")
+	if err != nil {
+		log.Printf("[binlog] SHOW BINARY LOG STATUS failed, falling back to SHOW MASTER STATUS: %v", err)
+		if rows, err = db.QueryContext(ctx, "SHOW MASTER STATUS"); err != nil {
+			return nil, fmt.Errorf("show master status failed: %w", err)
+		}
+	}
+	defer rows.Close()
+
+	result, err := scanRowMaps(rows)
+	if err != nil || len(result) == 0 {
+		// binlog 未开启时没有结果
+		return nil, err
+	}
+	row := result[0]
+	return &models.BinlogStatus{
+		File:          fmt.Sprint(row["File"]),
+		Position:      toInt64(row["Position"]),
+		ExecutedGTIDs: fmt.Sprint(row["Executed_Gtid_Set"]),
+	}, nil
+}
+
+// PurgeBinaryLogs 执行 PURGE BINARY LOGS TO/BEFORE 并写入审计日志，dry_run 时只计算将被删除的文件
+func PurgeBinaryLogs(ctx context.Context, req request.PurgeBinlogRequest) (models.PurgeBinlogResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.PurgeBinlogResponse{}, err
+	}
+	logs, err := showBinaryLogs(ctx, db)
+	if err != nil {
+		return models.PurgeBinlogResponse{}, err
+	}
+
+	resp := models.PurgeBinlogResponse{DryRun: req.DryRun, Purged: []models.BinlogFile{}}
+	var stmt string
+	if req.To != "" {
+		idx := -1
+		for i, l := range logs {
+			if l.Name == req.To {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return resp, fmt.Errorf("binlog %s not found", req.To)
+		}
+		resp.Purged = append(resp.Purged, logs[:idx]...)
+		stmt = "PURGE BINARY LOGS TO " + helper.QuoteString(req.To)
+	} else {
+		// SQL 无法读取 binlog 文件的修改时间，按时间清理时无法预估
+		resp.EstimateUnavailable = true
+		stmt = "PURGE BINARY LOGS BEFORE " + helper.QuoteString(req.BeforeTime.Local().Format(time.DateTime))
+	}
+	for _, l := range resp.Purged {
+		resp.FreedBytes += l.Size
+	}
+	resp.Statement = stmt
+
+	if req.DryRun {
+		return resp, nil
+	}
+	if err := runPlan(ctx, "purge_binlog", stmt, []sqlStatement{{SQL: stmt, Desc: "purge binary logs"}}); err != nil {
+		return resp, err
+	}
+
+	// 按时间清理时用清理前后的文件列表计算实际释放的空间
+	if req.Before != "" {
+		after, err := showBinaryLogs(ctx, db)
+		if err != nil {
+			return resp, err
+		}
+		remaining := make(map[string]struct{}, len(after))
+		for _, l := range after {
+			remaining[l.Name] = struct{}{}
+		}
+		for _, l := range logs {
+			if _, ok := remaining[l.Name]; !ok {
+				resp.Purged = append(resp.Purged, l)
+				resp.FreedBytes += l.Size
+			}
+		}
+		resp.EstimateUnavailable = false
+	}
+	return resp, nil
+}
+
+func toInt64(v any) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case uint64:
+		return int64(n)
+	case string:
+		var out int64
+		fmt.Sscan(n, &out)
+		return out
+	}
+	return 0
+}
+
+// BinaryLogs 处理查看 binlog 的业务逻辑，返回统一响应
+func BinaryLogs(ctx context.Context) models.StandardResponse {
+	resp, err := ListBinaryLogs(ctx)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// PurgeBinlog 处理清理 binlog 的业务逻辑，返回统一响应
+func PurgeBinlog(req request.PurgeBinlogRequest) models.StandardResponse {
+	resp, err := PurgeBinaryLogs(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         resp,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// CloneUserGrants 读取源用户的 SHOW CREATE USER 与 SHOW GRANTS，改写被授权账号后应用到新用户
+func CloneUserGrants(ctx context.Context, req request.CloneUserRequest) ([]string, int, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	source := helper.Account(req.SourceUsername, req.SourceHost)
+	target := helper.Account(req.Username, req.Host)
+
+	plan := make([]sqlStatement, 0)
+
+	if req.Password != "" {
+		password := req.Password
+		if req.DryRun {
+			password = maskedPassword
+		}
+		plan = append(plan, sqlStatement{
+			SQL:  fmt.Sprintf("CREATE USER %s IDENTIFIED BY %s", target, helper.QuoteString(password)),
+			Desc: "create user " + target,
+		})
+	} else {
+		var createStmt string
+		if err := db.QueryRowContext(ctx, "SHOW CREATE USER "+source).Scan(&createStmt); err != nil {
+			return nil, 0, fmt.Errorf("show create user %s failed: %w", source, err)
+		}
+		rewritten, ok := helper.RewriteCreateUser(createStmt, target)
+		if !ok {
+			return nil, 0, fmt.Errorf("unexpected SHOW CREATE USER output for %s", source)
+		}
+		if req.DryRun {
+			rewritten = redactSQL(rewritten)
+		}
+		plan = append(plan, sqlStatement{SQL: rewritten, Desc: "create user " + target})
+	}
+
+	rows, err := db.QueryContext(ctx, "SHOW GRANTS FOR "+source)
+	if err != nil {
+		return nil, 0, fmt.Errorf("show grants for %s failed: %w", source, err)
+	}
+	defer rows.Close()
+
+	grants := 0
+	for rows.Next() {
+		var grant string
+		if err := rows.Scan(&grant); err != nil {
+			return nil, 0, err
+		}
+		// 新建用户已具备 USAGE
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(grant)), "GRANT USAGE ON *.* TO ") {
+			continue
+		}
+		rewritten, ok := helper.RewriteGrantee(grant, target)
+		if !ok {
+			return nil, 0, fmt.Errorf("unexpected grant format: %s", grant)
+		}
+		desc := "apply grant"
+		// partial_revokes 开启时 SHOW GRANTS 会包含 REVOKE ... FROM 行，需同样应用到新用户
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(grant)), "REVOKE ") {
+			desc = "apply partial revoke"
+		}
+		plan = append(plan, sqlStatement{SQL: rewritten, Desc: desc})
+		grants++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// 刷新权限
+	plan = append(plan, sqlStatement{SQL: "FLUSH PRIVILEGES", Desc: "flush privileges"})
+
+	if req.DryRun {
+		return statementSQL(plan), grants, nil
+	}
+	return nil, grants, runPlan(ctx, "clone_user", target, plan)
+}
+
+// CloneUser 处理复制用户授权的业务逻辑，返回统一响应
+func CloneUser(req request.CloneUserRequest) models.StandardResponse {
+	stmts, grants, err := CloneUserGrants(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         models.CloneUserResponse{Success: false},
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+
+	return models.StandardResponse{
+		Data:         models.CloneUserResponse{Success: true, Grants: grants, DryRun: req.DryRun, Statements: stmts},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mysql-backend/config"
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// dropConfirmTTL 删除数据库确认令牌的有效期
+const dropConfirmTTL = 5 * time.Minute
+
+type dropConfirmation struct {
+	schema    string
+	expiresAt time.Time
+}
+
+var (
+	dropConfirmMu sync.Mutex
+	dropConfirms  = make(map[string]dropConfirmation)
+)
+
+// CreateDatabaseWithOptions 执行 CREATE DATABASE，可指定字符集与排序规则
+func CreateDatabaseWithOptions(ctx context.Context, req request.CreateDatabaseRequest) error {
+	var sb strings.Builder
+	sb.WriteString("CREATE DATABASE ")
+	if req.IfNotExists {
+		sb.WriteString("IF NOT EXISTS ")
+	}
+	sb.WriteString(helper.QuoteIdent(req.Name))
+	if req.Charset != "" {
+		sb.WriteString(" CHARACTER SET " + req.Charset)
+	}
+	if req.Collation != "" {
+		sb.WriteString(" COLLATE " + req.Collation)
+	}
+
+	return runPlan(ctx, "create_database", req.Name, []sqlStatement{{SQL: sb.String(), Desc: "create database"}})
+}
+
+// DropDatabaseWithConfirm 删除数据库，必须携带同一数据库签发且未过期的确认令牌；
+// 令牌为空时签发新令牌并返回，不执行删除
+func DropDatabaseWithConfirm(ctx context.Context, req request.DropDatabaseRequest) (token string, expiresAt time.Time, err error) {
+	if strings.EqualFold(req.Name, config.AppConfig.Database.DBName) {
+		return "", time.Time{}, errors.New("backend metadata database cannot be dropped")
+	}
+
+	if req.ConfirmToken == "" {
+		return issueDropToken(req.Name)
+	}
+	if !consumeDropToken(req.ConfirmToken, req.Name) {
+		return "", time.Time{}, errors.New("invalid or expired confirm_token")
+	}
+
+	stmt := "DROP DATABASE "
+	if req.IfExists {
+		stmt = "DROP DATABASE IF EXISTS "
+	}
+	stmt += helper.QuoteIdent(req.Name)
+	return "", time.Time{}, runPlan(ctx, "drop_database", req.Name, []sqlStatement{{SQL: stmt, Desc: "drop database"}})
+}
+
+func issueDropToken(schema string) (string, time.Time, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(dropConfirmTTL)
+
+	dropConfirmMu.Lock()
+	defer dropConfirmMu.Unlock()
+	// 顺带清理过期令牌
+	for t, c := range dropConfirms {
+		if time.Now().After(c.expiresAt) {
+			delete(dropConfirms, t)
+		}
+	}
+	dropConfirms[token] = dropConfirmation{schema: schema, expiresAt: expiresAt}
+	return token, expiresAt, nil
+}
+
+// consumeDropToken 校验并作废令牌，令牌只能使用一次
+func consumeDropToken(token, schema string) bool {
+	dropConfirmMu.Lock()
+	defer dropConfirmMu.Unlock()
+	c, ok := dropConfirms[token]
+	if !ok {
+		return false
+	}
+	delete(dropConfirms, token)
+	return c.schema == schema && time.Now().Before(c.expiresAt)
+}
+
+// ListTableStats 分页读取库中各表的引擎、估算行数、数据与索引大小等统计信息
+func ListTableStats(ctx context.Context, req request.ListTablesRequest) (models.ListTablesResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.ListTablesResponse{}, err
+	}
+
+	query := "SELECT TABLE_NAME, COALESCE(ENGINE, ''), COALESCE(TABLE_ROWS, 0), COALESCE(DATA_LENGTH, 0), COALESCE(INDEX_LENGTH, 0)," +
+		" COALESCE(DATA_LENGTH, 0) + COALESCE(INDEX_LENGTH, 0) AS TOTAL_LENGTH, AUTO_INCREMENT, UPDATE_TIME" +
+		" FROM information_schema.tables WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'"
+	args := []any{req.Schema}
+	if req.Like != "" {
+		query += " AND TABLE_NAME LIKE ?"
+		args = append(args, req.Like)
+	}
+	if req.Engine != "" {
+		query += " AND ENGINE = ?"
+		args = append(args, req.Engine)
+	}
+	// 排序字段与方向均已在 Validate 中白名单校验
+	query += " ORDER BY " + req.SortColumn() + " " + req.Order + ", TABLE_NAME LIMIT ?, ?"
+	args = append(args, req.Offset, req.Limit)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.ListTablesResponse{}, err
+	}
+	defer rows.Close()
+
+	resp := models.ListTablesResponse{Schema: req.Schema, Tables: []models.TableStat{}, Limit: req.Limit, Offset: req.Offset}
+	for rows.Next() {
+		var t models.TableStat
+		var autoInc sql.NullInt64
+		var updated sql.NullTime
+		if err := rows.Scan(&t.Name, &t.Engine, &t.RowEstimate, &t.DataLength, &t.IndexLength, &t.TotalLength, &autoInc, &updated); err != nil {
+			return models.ListTablesResponse{}, err
+		}
+		if autoInc.Valid {
+			t.AutoIncrement = &autoInc.Int64
+		}
+		if updated.Valid {
+			ts := updated.Time.Format(time.RFC3339)
+			t.UpdateTime = &ts
+		}
+		resp.Tables = append(resp.Tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return models.ListTablesResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// ListTables 处理列出表统计信息的业务逻辑，返回统一响应
+func ListTables(req request.ListTablesRequest) models.StandardResponse {
+	resp, err := ListTableStats(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// ConvertCharsetPlan 找出字符集与目标不符的表并生成转换语句，dry_run 时不执行
+func ConvertCharsetPlan(ctx context.Context, req request.ConvertCharsetRequest) (models.ConvertCharsetResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.ConvertCharsetResponse{}, err
+	}
+
+	resp := models.ConvertCharsetResponse{
+		DryRun:    req.DryRun,
+		Schema:    req.Schema,
+		Charset:   req.Charset,
+		Collation: req.Collation,
+		Tables:    []models.ConvertTable{},
+	}
+
+	query := "SELECT t.TABLE_NAME, COALESCE(t.TABLE_COLLATION, ''), COALESCE(t.TABLE_ROWS, 0)," +
+		" COALESCE(t.DATA_LENGTH, 0) + COALESCE(t.INDEX_LENGTH, 0)" +
+		" FROM information_schema.tables t" +
+		" LEFT JOIN information_schema.COLLATION_CHARACTER_SET_APPLICABILITY c ON c.COLLATION_NAME = t.TABLE_COLLATION" +
+		" WHERE t.TABLE_SCHEMA = ? AND t.TABLE_TYPE = 'BASE TABLE'"
+	args := []any{req.Schema}
+	if req.Table != "" {
+		query += " AND t.TABLE_NAME = ?"
+		args = append(args, req.Table)
+	}
+	if req.Collation != "" {
+		query += " AND (t.TABLE_COLLATION IS NULL OR t.TABLE_COLLATION <> ?)"
+		args = append(args, req.Collation)
+	} else {
+		query += " AND (c.CHARACTER_SET_NAME IS NULL OR c.CHARACTER_SET_NAME <> ?)"
+		args = append(args, req.Charset)
+	}
+	query += " ORDER BY t.TABLE_NAME"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.ConvertCharsetResponse{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.ConvertTable
+		if err := rows.Scan(&t.Name, &t.CurrentCollation, &t.RowEstimate, &t.TotalLength); err != nil {
+			return models.ConvertCharsetResponse{}, err
+		}
+		resp.Tables = append(resp.Tables, t)
+		resp.EstimatedBytes += t.TotalLength
+	}
+	if err := rows.Err(); err != nil {
+		return models.ConvertCharsetResponse{}, err
+	}
+
+	charsetClause := "CHARACTER SET " + req.Charset
+	if req.Collation != "" {
+		charsetClause += " COLLATE " + req.Collation
+	}
+	plan := make([]sqlStatement, 0, len(resp.Tables)+1)
+	if req.Table == "" {
+		plan = append(plan, sqlStatement{
+			SQL:  fmt.Sprintf("ALTER DATABASE %s %s", helper.QuoteIdent(req.Schema), charsetClause),
+			Desc: "alter database " + req.Schema,
+		})
+	}
+	for _, t := range resp.Tables {
+		scope := helper.TableScope(req.Schema, t.Name)
+		plan = append(plan, sqlStatement{
+			SQL:  fmt.Sprintf("ALTER TABLE %s CONVERT TO %s", scope, charsetClause),
+			Desc: "convert table " + scope,
+		})
+	}
+
+	if req.DryRun {
+		resp.Statements = statementSQL(plan)
+		return resp, nil
+	}
+	return resp, runPlan(ctx, "convert_charset", req.Schema, plan)
+}
+
+// ConvertCharset 处理字符集转换的业务逻辑，返回统一响应
+func ConvertCharset(req request.ConvertCharsetRequest) models.StandardResponse {
+	resp, err := ConvertCharsetPlan(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         resp,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	resp.Success = true
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// CreateDatabase 处理创建数据库的业务逻辑，返回统一响应
+func CreateDatabase(req request.CreateDatabaseRequest) models.StandardResponse {
+	if err := CreateDatabaseWithOptions(req.Ctx, req); err != nil {
+		return models.StandardResponse{
+			Data:         models.DatabaseResponse{Success: false, Database: req.Name},
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         models.DatabaseResponse{Success: true, Database: req.Name},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// DropDatabase 处理删除数据库的业务逻辑，返回统一响应
+func DropDatabase(req request.DropDatabaseRequest) models.StandardResponse {
+	token, expiresAt, err := DropDatabaseWithConfirm(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         models.DatabaseResponse{Success: false, Database: req.Name},
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	if token != "" {
+		return models.StandardResponse{
+			Data: models.DatabaseResponse{
+				Success:      false,
+				Database:     req.Name,
+				ConfirmToken: token,
+				ExpiresAt:    expiresAt.Format(time.RFC3339),
+			},
+			Error:        "CONFIRMATION_REQUIRED",
+			ErrorMessage: "resend the request with confirm_token to drop the database",
+		}
+	}
+	return models.StandardResponse{
+		Data:         models.DatabaseResponse{Success: true, Database: req.Name},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"mysql-backend/config"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// ExplainStatement 获取语句的执行计划，优先使用 EXPLAIN FORMAT=JSON，不支持时回退到传统表格格式
+func ExplainStatement(ctx context.Context, req request.ExplainRequest) (models.ExplainResponse, error) {
+	timeout := config.AppConfig.QueryConsole.MaxExecutionTime
+	ctx, cancel := context.WithTimeout(ctx, timeout+time.Second)
+	defer cancel()
+
+	conn, release, err := sessionConn(ctx, req.InstanceID, req.Schema, timeout)
+	if err != nil {
+		return models.ExplainResponse{}, err
+	}
+	defer release()
+
+	var plan string
+	err = conn.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+req.SQL).Scan(&plan)
+	if err == nil {
+		var parsed any
+		if err := json.Unmarshal([]byte(plan), &parsed); err == nil {
+			return models.ExplainResponse{Format: "json", Plan: parsed}, nil
+		}
+		log.Printf("[explain] parse json plan failed, falling back to traditional format")
+	} else {
+		log.Printf("[explain] EXPLAIN FORMAT=JSON failed, falling back to traditional format: %v", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "EXPLAIN "+req.SQL)
+	if err != nil {
+		return models.ExplainResponse{}, err
+	}
+	defer rows.Close()
+
+	result, err := scanRowMaps(rows)
+	if err != nil {
+		return models.ExplainResponse{}, err
+	}
+	return models.ExplainResponse{Format: "traditional", Rows: result}, nil
+}
+
+// scanRowMaps 将结果集读取为列名到值的映射，[]byte 转为字符串
+func scanRowMaps(rows *sql.Rows) ([]map[string]any, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]any, 0)
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// Explain 处理查看执行计划的业务逻辑，返回统一响应
+func Explain(req request.ExplainRequest) models.StandardResponse {
+	resp, err := ExplainStatement(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mysql-backend/config"
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const instanceTable = "mysql_instances"
+
+// agentInstanceConnection 随诊断请求发给 agent 的已登记实例连接信息，密码已解密
+type agentInstanceConnection struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+}
+
+func ensureInstanceTable(ctx context.Context) (*sql.DB, error) {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(64) NOT NULL PRIMARY KEY,
+	name VARCHAR(128) NOT NULL DEFAULT '',
+	host VARCHAR(255) NOT NULL,
+	port INT NOT NULL,
+	username VARCHAR(128) NOT NULL,
+	password_enc TEXT NULL,
+	credential_ref VARCHAR(255) NOT NULL DEFAULT '',
+	tags JSON NOT NULL,
+	environment VARCHAR(32) NOT NULL DEFAULT '',
+	created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+	updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3)
+)`, databases.MetaTable(instanceTable))
+	if err := databases.EnsureMetaTable(ctx, ddl); err != nil {
+		return nil, err
+	}
+	if err := databases.EnsureMetaColumn(ctx, instanceTable, "max_open_conns", "INT NOT NULL DEFAULT 0"); err != nil {
+		return nil, err
+	}
+	return databases.GetAdminDB()
+}
+
+const instanceColumns = "id, name, host, port, username, password_enc IS NOT NULL, credential_ref, tags, environment, max_open_conns, created_at, updated_at"
+
+func listInstances(ctx context.Context) ([]models.MySQLInstance, error) {
+	db, err := ensureInstanceTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY id", instanceColumns, databases.MetaTable(instanceTable))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instances := make([]models.MySQLInstance, 0)
+	for rows.Next() {
+		inst, err := scanInstance(rows)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, inst)
+	}
+	return instances, rows.Err()
+}
+
+func getInstance(ctx context.Context, id string) (models.MySQLInstance, error) {
+	db, err := ensureInstanceTable(ctx)
+	if err != nil {
+		return models.MySQLInstance{}, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = ?", instanceColumns, databases.MetaTable(instanceTable))
+	inst, err := scanInstance(db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.MySQLInstance{}, fmt.Errorf("instance %s not found", id)
+	}
+	return inst, err
+}
+
+func scanInstance(row rowScanner) (models.MySQLInstance, error) {
+	var inst models.MySQLInstance
+	var tags string
+	var createdAt, updatedAt time.Time
+	if err := row.Scan(&inst.ID, &inst.Name, &inst.Host, &inst.Port, &inst.Username, &inst.HasPassword,
+		&inst.CredentialRef, &tags, &inst.Environment, &inst.MaxOpenConns, &createdAt, &updatedAt); err != nil {
+		return models.MySQLInstance{}, err
+	}
+	if err := json.Unmarshal([]byte(tags), &inst.Tags); err != nil {
+		return models.MySQLInstance{}, err
+	}
+	if inst.Name == "" {
+		inst.Name = inst.ID
+	}
+	inst.CreatedAt = createdAt.Format(time.RFC3339)
+	inst.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return inst, nil
+}
+
+// SaveInstance 登记或更新被监控实例，create 为 false 时按 ID 更新；密码使用 instance_registry.secret_key 加密后保存，
+// 更新时未传 password 保留原密码，改用 credential_ref 时清除已保存的密码
+func SaveInstance(ctx context.Context, req request.MySQLInstanceRequest, create bool) (models.MySQLInstance, error) {
+	db, err := ensureInstanceTable(ctx)
+	if err != nil {
+		return models.MySQLInstance{}, err
+	}
+	tags, err := json.Marshal(req.Tags)
+	if err != nil {
+		return models.MySQLInstance{}, err
+	}
+	if req.CredentialRef != "" {
+		if _, err := checkCredentialRef(req.CredentialRef); err != nil {
+			return models.MySQLInstance{}, err
+		}
+	}
+
+	var passwordEnc sql.NullString
+	if req.Password != nil && *req.Password != "" {
+		enc, err := helper.EncryptSecret(config.AppConfig.InstanceRegistry.SecretKey, *req.Password)
+		if errors.Is(err, helper.ErrNoSecretKey) {
+			return models.MySQLInstance{}, errors.New("instance_registry.secret_key is not configured, use credential_ref instead of password")
+		}
+		if err != nil {
+			return models.MySQLInstance{}, err
+		}
+		passwordEnc = sql.NullString{String: enc, Valid: true}
+	}
+
+	table := databases.MetaTable(instanceTable)
+	if create {
+		stmt := fmt.Sprintf(`INSERT INTO %s (id, name, host, port, username, password_enc, credential_ref, tags, environment, max_open_conns)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, table)
+		if _, err := db.ExecContext(ctx, stmt, req.ID, req.Name, req.Host, req.Port, req.Username, passwordEnc,
+			req.CredentialRef, string(tags), req.Environment, req.MaxOpenConns); err != nil {
+			return models.MySQLInstance{}, fmt.Errorf("create instance %s failed: %w", req.ID, err)
+		}
+		return getInstance(ctx, req.ID)
+	}
+
+	sets := "name = ?, host = ?, port = ?, username = ?, credential_ref = ?, tags = ?, environment = ?, max_open_conns = ?"
+	args := []any{req.Name, req.Host, req.Port, req.Username, req.CredentialRef, string(tags), req.Environment, req.MaxOpenConns}
+	if req.Password != nil || req.CredentialRef != "" {
+		sets += ", password_enc = ?"
+		args = append(args, passwordEnc)
+	}
+	stmt := fmt.Sprintf("UPDATE %s SET %s WHERE id = ?", table, sets)
+	res, err := db.ExecContext(ctx, stmt, append(args, req.ID)...)
+	if err != nil {
+		return models.MySQLInstance{}, fmt.Errorf("update instance %s failed: %w", req.ID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := getInstance(ctx, req.ID); err != nil {
+			return models.MySQLInstance{}, err
+		}
+	}
+	// 连接参数可能已变化，下次使用时按新参数重新打开
+	databases.CloseInstanceDB(req.ID)
+	return getInstance(ctx, req.ID)
+}
+
+// DeleteInstance 删除登记的实例，已保存的诊断报告保留
+func DeleteInstance(ctx context.Context, id string) error {
+	db, err := ensureInstanceTable(ctx)
+	if err != nil {
+		return err
+	}
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE id = ?", databases.MetaTable(instanceTable))
+	res, err := db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return fmt.Errorf("delete instance %s failed: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("instance %s not found", id)
+	}
+	databases.CloseInstanceDB(id)
+	return nil
+}
+
+// resolveInstanceConnection 返回已登记实例的连接信息，解密保存的密码或读取 credential_ref；
+// id 为空或未登记（如 agent 自身配置的实例）时返回 nil，由 agent 按自身配置处理
+func resolveInstanceConnection(ctx context.Context, id string) (*agentInstanceConnection, error) {
+	if id == "" {
+		return nil, nil
+	}
+	db, err := ensureInstanceTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn agentInstanceConnection
+	var passwordEnc sql.NullString
+	var credentialRef string
+	query := fmt.Sprintf("SELECT host, port, username, password_enc, credential_ref FROM %s WHERE id = ?", databases.MetaTable(instanceTable))
+	err = db.QueryRowContext(ctx, query, id).Scan(&conn.Host, &conn.Port, &conn.Username, &passwordEnc, &credentialRef)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case passwordEnc.Valid:
+		if conn.Password, err = helper.DecryptSecret(config.AppConfig.InstanceRegistry.SecretKey, passwordEnc.String); err != nil {
+			return nil, fmt.Errorf("instance %s: %w", id, err)
+		}
+	case credentialRef != "":
+		if conn.Password, err = readCredentialRef(credentialRef); err != nil {
+			return nil, fmt.Errorf("instance %s: %w", id, err)
+		}
+	}
+	return &conn, nil
+}
+
+// InstanceDatabase 返回登记实例的连接参数供 databases.GetInstanceDB 打开连接池：主机、端口与账号取自登记信息，
+// 字符集与连接池参数沿用 [database]，登记了 max_open_conns 时使用登记的值
+func InstanceDatabase(ctx context.Context, id string) (config.DatabaseConfig, bool, error) {
+	conn, err := resolveInstanceConnection(ctx, id)
+	if err != nil || conn == nil {
+		return config.DatabaseConfig{}, false, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return config.DatabaseConfig{}, false, err
+	}
+	var maxOpen int
+	query := fmt.Sprintf("SELECT max_open_conns FROM %s WHERE id = ?", databases.MetaTable(instanceTable))
+	if err := db.QueryRowContext(ctx, query, id).Scan(&maxOpen); err != nil {
+		return config.DatabaseConfig{}, false, err
+	}
+
+	cfg := config.AppConfig.Database
+	cfg.Host, cfg.Port, cfg.Username, cfg.Password = conn.Host, conn.Port, conn.Username, conn.Password
+	if maxOpen > 0 {
+		cfg.MaxOpenConns = maxOpen
+		cfg.MaxIdleConns = min(cfg.MaxIdleConns, maxOpen)
+	}
+	return cfg, true, nil
+}
+
+// checkCredentialRef 校验密码引用在允许的范围内：env: 的变量名必须以 instance_registry.credential_env_prefix 开头，
+// file: 的路径必须位于 instance_registry.credential_dir 下（相对路径按该目录解析，不允许 ..）。
+// 返回变量名或清理后的文件路径
+func checkCredentialRef(ref string) (string, error) {
+	cfg := config.AppConfig.InstanceRegistry
+	switch {
+	case strings.HasPrefix(ref, "env:"):
+		name := strings.TrimPrefix(ref, "env:")
+		if cfg.CredentialEnvPrefix == "" {
+			return "", errors.New("env: credential_ref is disabled, set instance_registry.credential_env_prefix")
+		}
+		if name == cfg.CredentialEnvPrefix || !strings.HasPrefix(name, cfg.CredentialEnvPrefix) {
+			return "", fmt.Errorf("credential env %q must start with %q", name, cfg.CredentialEnvPrefix)
+		}
+		return name, nil
+	case strings.HasPrefix(ref, "file:"):
+		path := strings.TrimPrefix(ref, "file:")
+		if cfg.CredentialDir == "" {
+			return "", errors.New("file: credential_ref is disabled, set instance_registry.credential_dir")
+		}
+		for _, elem := range strings.Split(filepath.ToSlash(path), "/") {
+			if elem == ".." {
+				return "", fmt.Errorf("credential file %q must not contain ..", path)
+			}
+		}
+		dir := filepath.Clean(cfg.CredentialDir)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		path = filepath.Clean(path)
+		if !withinDir(dir, path) {
+			return "", fmt.Errorf("credential file %q is outside %s", path, dir)
+		}
+		return path, nil
+	default:
+		return "", fmt.Errorf("unsupported credential_ref: %s", ref)
+	}
+}
+
+// withinDir 判断 path 是否位于 dir 之下（不含 dir 本身），两者都应已 Clean
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// readCredentialRef 读取 env:NAME 或 file:/path 形式的密码引用，文件内容去掉末尾换行；
+// 引用须通过 checkCredentialRef 校验，文件经符号链接解析后仍须位于 credential_dir 下
+func readCredentialRef(ref string) (string, error) {
+	target, err := checkCredentialRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(ref, "env:") {
+		value, ok := os.LookupEnv(target)
+		if !ok {
+			return "", fmt.Errorf("credential env %s is not set", target)
+		}
+		return value, nil
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Clean(config.AppConfig.InstanceRegistry.CredentialDir))
+	if err != nil {
+		return "", fmt.Errorf("resolve credential dir: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", fmt.Errorf("read credential file: %w", err)
+	}
+	if !withinDir(dir, resolved) {
+		return "", fmt.Errorf("credential file %q is outside %s", target, dir)
+	}
+	raw, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", fmt.Errorf("read credential file: %w", err)
+	}
+	return strings.TrimRight(string(raw), "\r\n"), nil
+}
+
+// ListInstances 处理列出登记实例的业务逻辑，返回统一响应
+func ListInstances(ctx context.Context) models.StandardResponse {
+	instances, err := listInstances(ctx)
+	return instanceResponse(instances, err)
+}
+
+// GetInstance 处理查询登记实例的业务逻辑，返回统一响应
+func GetInstance(req request.MySQLInstanceIDRequest) models.StandardResponse {
+	inst, err := getInstance(req.Ctx, req.ID)
+	return instanceResponse(inst, err)
+}
+
+// CreateInstance 处理登记实例的业务逻辑，返回统一响应
+func CreateInstance(req request.MySQLInstanceRequest) models.StandardResponse {
+	inst, err := SaveInstance(req.Ctx, req, true)
+	return instanceResponse(inst, err)
+}
+
+// UpdateInstance 处理更新登记实例的业务逻辑，返回统一响应
+func UpdateInstance(req request.MySQLInstanceRequest) models.StandardResponse {
+	inst, err := SaveInstance(req.Ctx, req, false)
+	return instanceResponse(inst, err)
+}
+
+// RemoveInstance 处理删除登记实例的业务逻辑，返回统一响应
+func RemoveInstance(req request.MySQLInstanceIDRequest) models.StandardResponse {
+	return instanceResponse(nil, DeleteInstance(req.Ctx, req.ID))
+}
+
+func instanceResponse(data any, err error) models.StandardResponse {
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         data,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
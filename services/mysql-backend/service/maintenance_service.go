@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const maintenanceTable = "maintenance_jobs"
+
+// 表维护任务状态
+const (
+	MaintenanceRunning = "running"
+	MaintenanceSuccess = "success"
+	MaintenanceFailed  = "failed"
+)
+
+func ensureMaintenanceTable(ctx context.Context) error {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	operation VARCHAR(16) NOT NULL,
+	schema_name VARCHAR(64) NOT NULL,
+	tables JSON NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	trigger_by VARCHAR(128) NOT NULL,
+	results JSON NULL,
+	started_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+	finished_at DATETIME(3) NULL,
+	error TEXT NULL,
+	KEY idx_started_at (started_at)
+)`, databases.MetaTable(maintenanceTable))
+	return databases.EnsureMetaTable(ctx, ddl)
+}
+
+// maintenanceStatement 生成 OPTIMIZE/ANALYZE/CHECK TABLE 语句
+func maintenanceStatement(req request.TableMaintenanceRequest) string {
+	names := make([]string, 0, len(req.Tables))
+	for _, t := range req.Tables {
+		names = append(names, helper.TableScope(req.Schema, t))
+	}
+	stmt := strings.ToUpper(req.Operation)
+	if req.Local {
+		stmt += " NO_WRITE_TO_BINLOG"
+	}
+	stmt += " TABLE " + strings.Join(names, ", ")
+	if req.CheckOption != "" {
+		stmt += " " + req.CheckOption
+	}
+	return stmt
+}
+
+// MaintainTables 执行表维护语句并返回 MySQL 输出的逐表结果；async 时创建任务后在后台执行，立即返回任务ID
+func MaintainTables(ctx context.Context, req request.TableMaintenanceRequest) (models.MaintenanceJob, error) {
+	stmt := maintenanceStatement(req)
+	job := models.MaintenanceJob{
+		Operation: req.Operation,
+		Schema:    req.Schema,
+		Tables:    req.Tables,
+		Statement: stmt,
+		TriggerBy: actorFrom(ctx),
+	}
+
+	if !req.Async {
+		results, err := runMaintenance(ctx, req, stmt)
+		job.Results = results
+		job.Status = MaintenanceSuccess
+		if err != nil {
+			job.Status = MaintenanceFailed
+		}
+		return job, err
+	}
+
+	if err := ensureMaintenanceTable(ctx); err != nil {
+		return job, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return job, err
+	}
+	tables, err := json.Marshal(req.Tables)
+	if err != nil {
+		return job, err
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (operation, schema_name, tables, status, trigger_by) VALUES (?, ?, ?, ?, ?)",
+		databases.MetaTable(maintenanceTable))
+	res, err := db.ExecContext(ctx, insert, req.Operation, req.Schema, string(tables), MaintenanceRunning, job.TriggerBy)
+	if err != nil {
+		return job, err
+	}
+	if job.ID, err = res.LastInsertId(); err != nil {
+		return job, err
+	}
+	job.Status = MaintenanceRunning
+
+	// 后台执行，不随请求取消
+	go func(ctx context.Context, id int64) {
+		results, runErr := runMaintenance(ctx, req, stmt)
+		if runErr != nil {
+			log.Printf("[maintenance] job %d failed: %v", id, runErr)
+		}
+		if err := finishMaintenance(ctx, id, results, runErr); err != nil {
+			log.Printf("[maintenance] update job %d failed: %v", id, err)
+		}
+	}(context.WithoutCancel(ctx), job.ID)
+
+	return job, nil
+}
+
+// runMaintenance 执行语句、读取结果集并写入审计日志
+func runMaintenance(ctx context.Context, req request.TableMaintenanceRequest, stmt string) ([]models.TableMaintenanceResult, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return nil, err
+	}
+
+	results, execErr := queryMaintenance(ctx, db, stmt)
+	plan := []sqlStatement{{SQL: stmt, Desc: req.Operation + " table"}}
+	target := req.Schema + "." + strings.Join(req.Tables, ",")
+	if err := recordAudit(ctx, req.Operation+"_table", target, plan, execErr); err != nil {
+		log.Printf("[audit] record %s_table on %s failed: %v", req.Operation, target, err)
+	}
+	return results, execErr
+}
+
+func queryMaintenance(ctx context.Context, db *sql.DB, stmt string) ([]models.TableMaintenanceResult, error) {
+	rows, err := db.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.TableMaintenanceResult, 0)
+	for rows.Next() {
+		var r models.TableMaintenanceResult
+		if err := rows.Scan(&r.Table, &r.Op, &r.MsgType, &r.MsgText); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func finishMaintenance(ctx context.Context, id int64, results []models.TableMaintenanceResult, runErr error) error {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+	status := MaintenanceSuccess
+	var errText any
+	if runErr != nil {
+		status = MaintenanceFailed
+		errText = runErr.Error()
+	}
+	var payload any
+	if results != nil {
+		b, err := json.Marshal(results)
+		if err != nil {
+			return err
+		}
+		payload = string(b)
+	}
+	stmt := fmt.Sprintf("UPDATE %s SET status = ?, results = ?, finished_at = CURRENT_TIMESTAMP(3), error = ? WHERE id = ?",
+		databases.MetaTable(maintenanceTable))
+	_, err = db.ExecContext(ctx, stmt, status, payload, errText, id)
+	return err
+}
+
+// FailInterruptedMaintenance 进程重启后，上次未结束的表维护任务不会再完成，标记为失败
+func FailInterruptedMaintenance(ctx context.Context) error {
+	if err := ensureMaintenanceTable(ctx); err != nil {
+		return err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+	stmt := fmt.Sprintf("UPDATE %s SET status = ?, finished_at = CURRENT_TIMESTAMP(3), error = ? WHERE status = ?",
+		databases.MetaTable(maintenanceTable))
+	_, err = db.ExecContext(ctx, stmt, MaintenanceFailed, "interrupted by backend restart", MaintenanceRunning)
+	return err
+}
+
+// GetMaintenanceJob 读取后台表维护任务及其结果
+func GetMaintenanceJob(ctx context.Context, id int64) (models.MaintenanceJob, error) {
+	if err := ensureMaintenanceTable(ctx); err != nil {
+		return models.MaintenanceJob{}, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.MaintenanceJob{}, err
+	}
+
+	query := fmt.Sprintf("SELECT id, operation, schema_name, tables, status, trigger_by, results, started_at, finished_at, COALESCE(error, '') FROM %s WHERE id = ?",
+		databases.MetaTable(maintenanceTable))
+	var job models.MaintenanceJob
+	var tables string
+	var results sql.NullString
+	var startedAt time.Time
+	var finishedAt sql.NullTime
+	err = db.QueryRowContext(ctx, query, id).Scan(&job.ID, &job.Operation, &job.Schema, &tables, &job.Status, &job.TriggerBy, &results, &startedAt, &finishedAt, &job.Error)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.MaintenanceJob{}, fmt.Errorf("maintenance job %d not found", id)
+	}
+	if err != nil {
+		return models.MaintenanceJob{}, err
+	}
+	if err := json.Unmarshal([]byte(tables), &job.Tables); err != nil {
+		return models.MaintenanceJob{}, err
+	}
+	if results.Valid {
+		if err := json.Unmarshal([]byte(results.String), &job.Results); err != nil {
+			return models.MaintenanceJob{}, err
+		}
+	}
+	job.StartedAt = startedAt.Format(time.RFC3339)
+	if finishedAt.Valid {
+		job.FinishedAt = finishedAt.Time.Format(time.RFC3339)
+	}
+	return job, nil
+}
+
+// MaintainTable 处理表维护的业务逻辑，返回统一响应
+func MaintainTable(req request.TableMaintenanceRequest) models.StandardResponse {
+	job, err := MaintainTables(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         job,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         job,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// MaintenanceStatus 处理查询表维护任务的业务逻辑，返回统一响应
+func MaintenanceStatus(req request.MaintenanceJobRequest) models.StandardResponse {
+	job, err := GetMaintenanceJob(req.Ctx, req.ID)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         job,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
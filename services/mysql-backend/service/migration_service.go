@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"mysql-backend/config"
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const migrationTable = "schema_migrations"
+
+// migrationFilePattern 迁移文件命名规则：<版本号>_<名称>.sql，例如 0001_create_orders.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_\-]+)\.sql$`)
+
+// migrationFile 迁移目录中的一个迁移文件
+type migrationFile struct {
+	Version  int64
+	Name     string
+	Path     string
+	Checksum string
+	SQL      string
+}
+
+func ensureMigrationTable(ctx context.Context) error {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	schema_name VARCHAR(64) NOT NULL,
+	version BIGINT NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	checksum CHAR(64) NOT NULL,
+	applied_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+	duration_ms BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (schema_name, version)
+)`, databases.MetaTable(migrationTable))
+	return databases.EnsureMetaTable(ctx, ddl)
+}
+
+// loadMigrationFiles 读取 migration.dir 下的迁移文件，按版本号升序返回
+func loadMigrationFiles() ([]migrationFile, error) {
+	dir := config.AppConfig.Migration.Dir
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migration dir %s failed: %w", dir, err)
+	}
+
+	files := make([]migrationFile, 0, len(entries))
+	seen := make(map[int64]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		m := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		if prev, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, prev, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		path := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s failed: %w", entry.Name(), err)
+		}
+		sum := sha256.Sum256(content)
+		files = append(files, migrationFile{
+			Version:  version,
+			Name:     m[2],
+			Path:     path,
+			Checksum: hex.EncodeToString(sum[:]),
+			SQL:      string(content),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Version < files[j].Version })
+	return files, nil
+}
+
+// appliedMigrations 读取目标库已执行的迁移记录，按版本号升序返回
+func appliedMigrations(ctx context.Context, schema string) ([]models.MigrationRecord, error) {
+	if err := ensureMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT version, name, checksum, applied_at, duration_ms FROM %s WHERE schema_name = ? ORDER BY version",
+		databases.MetaTable(migrationTable))
+	rows, err := db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]models.MigrationRecord, 0)
+	for rows.Next() {
+		var rec models.MigrationRecord
+		var appliedAt time.Time
+		if err := rows.Scan(&rec.Version, &rec.Name, &rec.Checksum, &appliedAt, &rec.DurationMs); err != nil {
+			return nil, err
+		}
+		rec.AppliedAt = appliedAt.Format(time.RFC3339)
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// PendingMigrationList 对比迁移目录与已执行记录，返回待执行迁移以及已执行但文件被修改的迁移
+func PendingMigrationList(ctx context.Context, schema string) (models.MigrationStatusResponse, error) {
+	files, err := loadMigrationFiles()
+	if err != nil {
+		return models.MigrationStatusResponse{}, err
+	}
+	applied, err := appliedMigrations(ctx, schema)
+	if err != nil {
+		return models.MigrationStatusResponse{}, err
+	}
+
+	checksums := make(map[int64]string, len(applied))
+	for _, rec := range applied {
+		checksums[rec.Version] = rec.Checksum
+	}
+
+	resp := models.MigrationStatusResponse{Schema: schema, Pending: []models.MigrationInfo{}, Modified: []models.MigrationInfo{}}
+	for _, f := range files {
+		info := models.MigrationInfo{Version: f.Version, Name: f.Name, Checksum: f.Checksum}
+		sum, ok := checksums[f.Version]
+		switch {
+		case !ok:
+			resp.Pending = append(resp.Pending, info)
+		case sum != f.Checksum:
+			resp.Modified = append(resp.Modified, info)
+		}
+	}
+	if len(applied) > 0 {
+		resp.CurrentVersion = applied[len(applied)-1].Version
+	}
+	return resp, nil
+}
+
+// migrationLockTimeout 等待同一库上其他迁移结束的秒数
+const migrationLockTimeout = 10
+
+// pendingMigrationFiles 返回目标版本内待执行的迁移文件。已执行的迁移文件被修改，
+// 或待执行迁移的版本低于当前版本时拒绝执行，需人工处理后再迁移
+func pendingMigrationFiles(ctx context.Context, schema string, targetVersion int64) ([]migrationFile, error) {
+	files, err := loadMigrationFiles()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[int64]string, len(applied))
+	for _, rec := range applied {
+		checksums[rec.Version] = rec.Checksum
+	}
+	var current int64
+	if len(applied) > 0 {
+		current = applied[len(applied)-1].Version
+	}
+
+	pending := make([]migrationFile, 0)
+	for _, f := range files {
+		sum, ok := checksums[f.Version]
+		switch {
+		case ok && sum != f.Checksum:
+			return nil, fmt.Errorf("migration %d_%s was modified after being applied", f.Version, f.Name)
+		case ok:
+			continue
+		case f.Version < current:
+			return nil, fmt.Errorf("migration %d_%s is older than current version %d", f.Version, f.Name, current)
+		}
+		if targetVersion > 0 && f.Version > targetVersion {
+			continue
+		}
+		pending = append(pending, f)
+	}
+	return pending, nil
+}
+
+// ApplyMigrations 按版本号顺序执行待执行迁移直至目标版本，遇到错误立即停止；
+// DDL 无法回滚，失败的迁移不会记录为已执行，修复后可重新执行
+func ApplyMigrations(ctx context.Context, req request.ApplyMigrationRequest) (models.ApplyMigrationResponse, error) {
+	resp := models.ApplyMigrationResponse{Schema: req.Schema, DryRun: req.DryRun, Applied: []models.MigrationInfo{}}
+	if req.DryRun {
+		pending, err := pendingMigrationFiles(ctx, req.Schema, req.TargetVersion)
+		if err != nil {
+			return models.ApplyMigrationResponse{}, err
+		}
+		for _, f := range pending {
+			resp.Applied = append(resp.Applied, models.MigrationInfo{
+				Version: f.Version, Name: f.Name, Checksum: f.Checksum, Statements: helper.SplitSQLStatements(f.SQL),
+			})
+		}
+		return resp, nil
+	}
+
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.ApplyMigrationResponse{}, err
+	}
+	// USE 与 GET_LOCK 只对单个连接生效，迁移期间独占一个连接
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return models.ApplyMigrationResponse{}, err
+	}
+	defer conn.Close()
+
+	// 同一库的迁移互斥，加锁后再计算待执行迁移，避免并发请求重复执行同一版本
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(CONCAT('migrate:', ?), ?)", req.Schema, migrationLockTimeout).Scan(&locked); err != nil {
+		return models.ApplyMigrationResponse{}, fmt.Errorf("acquire migration lock for %s failed: %w", req.Schema, err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return models.ApplyMigrationResponse{}, fmt.Errorf("another migration is running on schema %s", req.Schema)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(CONCAT('migrate:', ?))", req.Schema); err != nil {
+			log.Printf("[migration] release lock for %s failed: %v", req.Schema, err)
+		}
+	}()
+
+	pending, err := pendingMigrationFiles(ctx, req.Schema, req.TargetVersion)
+	if err != nil {
+		return models.ApplyMigrationResponse{}, err
+	}
+	if len(pending) == 0 {
+		return resp, nil
+	}
+	if _, err := conn.ExecContext(ctx, "USE "+helper.QuoteIdent(req.Schema)); err != nil {
+		return models.ApplyMigrationResponse{}, fmt.Errorf("use schema %s failed: %w", req.Schema, err)
+	}
+
+	record := fmt.Sprintf("INSERT INTO %s (schema_name, version, name, checksum, duration_ms) VALUES (?, ?, ?, ?, ?)",
+		databases.MetaTable(migrationTable))
+	for _, f := range pending {
+		stmts := helper.SplitSQLStatements(f.SQL)
+		plan := make([]sqlStatement, 0, len(stmts))
+		for i, stmt := range stmts {
+			plan = append(plan, sqlStatement{SQL: stmt, Desc: fmt.Sprintf("migration %d statement %d", f.Version, i+1)})
+		}
+
+		start := time.Now()
+		var execErr error
+		for _, st := range plan {
+			if _, execErr = conn.ExecContext(ctx, st.SQL); execErr != nil {
+				execErr = fmt.Errorf("%s failed: %w", st.Desc, execErr)
+				break
+			}
+		}
+		if err := recordAudit(ctx, "apply_migration", fmt.Sprintf("%s@%d", req.Schema, f.Version), plan, execErr); err != nil {
+			log.Printf("[migration] record audit for %s version %d failed: %v", req.Schema, f.Version, err)
+		}
+		if execErr != nil {
+			return resp, execErr
+		}
+
+		if _, err := db.ExecContext(ctx, record, req.Schema, f.Version, f.Name, f.Checksum, time.Since(start).Milliseconds()); err != nil {
+			return resp, fmt.Errorf("record migration %d failed: %w", f.Version, err)
+		}
+		resp.Applied = append(resp.Applied, models.MigrationInfo{Version: f.Version, Name: f.Name, Checksum: f.Checksum})
+	}
+	return resp, nil
+}
+
+// PendingMigrations 处理查看待执行迁移的业务逻辑，返回统一响应
+func PendingMigrations(req request.MigrationStatusRequest) models.StandardResponse {
+	resp, err := PendingMigrationList(req.Ctx, req.Schema)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// MigrationHistory 处理查看迁移历史的业务逻辑，返回统一响应
+func MigrationHistory(req request.MigrationStatusRequest) models.StandardResponse {
+	records, err := appliedMigrations(req.Ctx, req.Schema)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         models.MigrationHistoryResponse{Schema: req.Schema, History: records},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// ApplyMigration 处理执行迁移的业务逻辑，返回统一响应；失败时 Data 中包含已成功执行的迁移
+func ApplyMigration(req request.ApplyMigrationRequest) models.StandardResponse {
+	resp, err := ApplyMigrations(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         resp,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"mysql-backend/databases"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+const profileTable = "privilege_profiles"
+
+// 内置权限模板，进程内首次使用时写入模板表（已存在则跳过），之后可通过接口修改但不能删除
+var builtinProfiles = []models.PrivilegeProfile{
+	{Name: "readonly", Privileges: []string{"SELECT", "SHOW VIEW"}, Description: "只读访问"},
+	{Name: "readwrite", Privileges: []string{"SELECT", "INSERT", "UPDATE", "DELETE", "SHOW VIEW"}, Description: "应用读写"},
+	{Name: "migration", Privileges: []string{"SELECT", "INSERT", "UPDATE", "DELETE", "CREATE", "DROP", "ALTER", "INDEX", "REFERENCES", "CREATE VIEW", "SHOW VIEW", "TRIGGER"}, Description: "结构变更"},
+}
+
+// profilesSeeded 本进程是否已写入内置模板，之后的读写不再访问种子数据
+var profilesSeeded struct {
+	sync.Mutex
+	done bool
+}
+
+// ensureProfileTable 确保模板表存在，并在进程内首次调用时写入内置模板
+func ensureProfileTable(ctx context.Context) (*sql.DB, error) {
+	ddl := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	name VARCHAR(64) NOT NULL PRIMARY KEY,
+	privileges TEXT NOT NULL,
+	with_grant TINYINT(1) NOT NULL DEFAULT 0,
+	description VARCHAR(255) NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+)`, databases.MetaTable(profileTable))
+	if err := databases.EnsureMetaTable(ctx, ddl); err != nil {
+		return nil, err
+	}
+
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return nil, err
+	}
+
+	profilesSeeded.Lock()
+	defer profilesSeeded.Unlock()
+	if profilesSeeded.done {
+		return db, nil
+	}
+	seed := fmt.Sprintf("INSERT IGNORE INTO %s (name, privileges, with_grant, description) VALUES (?, ?, ?, ?)", databases.MetaTable(profileTable))
+	for _, p := range builtinProfiles {
+		if _, err := db.ExecContext(ctx, seed, p.Name, strings.Join(p.Privileges, ","), p.WithGrant, p.Description); err != nil {
+			return nil, fmt.Errorf("seed profile %s failed: %w", p.Name, err)
+		}
+	}
+	profilesSeeded.done = true
+	return db, nil
+}
+
+func isBuiltinProfile(name string) bool {
+	for _, p := range builtinProfiles {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPrivilegeProfile 按名称读取权限模板
+func GetPrivilegeProfile(ctx context.Context, name string) (models.PrivilegeProfile, error) {
+	db, err := ensureProfileTable(ctx)
+	if err != nil {
+		return models.PrivilegeProfile{}, err
+	}
+
+	query := fmt.Sprintf("SELECT name, privileges, with_grant, description FROM %s WHERE name = ?", databases.MetaTable(profileTable))
+	profile, err := scanProfile(db.QueryRowContext(ctx, query, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.PrivilegeProfile{}, fmt.Errorf("privilege profile not found: %s", name)
+	}
+	return profile, err
+}
+
+// ListPrivilegeProfiles 列出全部权限模板
+func ListPrivilegeProfiles(ctx context.Context) ([]models.PrivilegeProfile, error) {
+	db, err := ensureProfileTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT name, privileges, with_grant, description FROM %s ORDER BY name", databases.MetaTable(profileTable))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := make([]models.PrivilegeProfile, 0)
+	for rows.Next() {
+		profile, err := scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// SavePrivilegeProfile 创建或更新权限模板，create 为 true 时模板已存在则报错，为 false 时模板不存在则报错
+func SavePrivilegeProfile(ctx context.Context, req request.PrivilegeProfileRequest, create bool) error {
+	db, err := ensureProfileTable(ctx)
+	if err != nil {
+		return err
+	}
+
+	privs := make([]string, 0, len(req.Privileges))
+	for _, p := range req.Privileges {
+		privs = append(privs, string(p))
+	}
+
+	if create {
+		stmt := fmt.Sprintf("INSERT INTO %s (name, privileges, with_grant, description) VALUES (?, ?, ?, ?)", databases.MetaTable(profileTable))
+		if _, err := db.ExecContext(ctx, stmt, req.Name, strings.Join(privs, ","), req.WithGrant, req.Description); err != nil {
+			return fmt.Errorf("create profile %s failed: %w", req.Name, err)
+		}
+		return nil
+	}
+
+	stmt := fmt.Sprintf("UPDATE %s SET privileges = ?, with_grant = ?, description = ? WHERE name = ?", databases.MetaTable(profileTable))
+	res, err := db.ExecContext(ctx, stmt, strings.Join(privs, ","), req.WithGrant, req.Description, req.Name)
+	if err != nil {
+		return fmt.Errorf("update profile %s failed: %w", req.Name, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		if _, err := GetPrivilegeProfile(ctx, req.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePrivilegeProfile 删除权限模板，内置模板不能删除
+func DeletePrivilegeProfile(ctx context.Context, name string) error {
+	if isBuiltinProfile(name) {
+		return fmt.Errorf("built-in privilege profile %s cannot be deleted", name)
+	}
+	db, err := ensureProfileTable(ctx)
+	if err != nil {
+		return err
+	}
+
+	stmt := fmt.Sprintf("DELETE FROM %s WHERE name = ?", databases.MetaTable(profileTable))
+	res, err := db.ExecContext(ctx, stmt, name)
+	if err != nil {
+		return fmt.Errorf("delete profile %s failed: %w", name, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("privilege profile not found: %s", name)
+	}
+	return nil
+}
+
+// ListProfiles 处理列出权限模板的业务逻辑，返回统一响应
+func ListProfiles(ctx context.Context) models.StandardResponse {
+	profiles, err := ListPrivilegeProfiles(ctx)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         profiles,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// CreateProfile 处理创建权限模板的业务逻辑，返回统一响应
+func CreateProfile(req request.PrivilegeProfileRequest) models.StandardResponse {
+	return profileResponse(SavePrivilegeProfile(req.Ctx, req, true))
+}
+
+// UpdateProfile 处理更新权限模板的业务逻辑，返回统一响应
+func UpdateProfile(req request.PrivilegeProfileRequest) models.StandardResponse {
+	return profileResponse(SavePrivilegeProfile(req.Ctx, req, false))
+}
+
+// DeleteProfile 处理删除权限模板的业务逻辑，返回统一响应
+func DeleteProfile(req request.DeleteProfileRequest) models.StandardResponse {
+	return profileResponse(DeletePrivilegeProfile(req.Ctx, req.Name))
+}
+
+func profileResponse(err error) models.StandardResponse {
+	if err != nil {
+		return models.StandardResponse{
+			Data:         models.ProfileResponse{Success: false},
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         models.ProfileResponse{Success: true},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProfile(row rowScanner) (models.PrivilegeProfile, error) {
+	var profile models.PrivilegeProfile
+	var privs string
+	if err := row.Scan(&profile.Name, &privs, &profile.WithGrant, &profile.Description); err != nil {
+		return models.PrivilegeProfile{}, err
+	}
+	profile.Privileges = strings.Split(privs, ",")
+	return profile, nil
+}
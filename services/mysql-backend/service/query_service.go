@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"mysql-backend/config"
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// RunReadOnlyQuery 在只读事务中执行单条只读语句，限制返回行数与执行时间
+func RunReadOnlyQuery(ctx context.Context, req request.QueryConsoleRequest) (models.QueryConsoleResponse, error) {
+	cfg := config.AppConfig.QueryConsole
+	limit := req.Limit
+	if limit == 0 {
+		limit = cfg.DefaultRows
+	}
+	limit = min(limit, cfg.MaxRows)
+	timeout := cfg.MaxExecutionTime
+	if req.TimeoutMs > 0 {
+		timeout = min(timeout, time.Duration(req.TimeoutMs)*time.Millisecond)
+	}
+
+	// 超时后客户端也放弃等待，多留 1 秒让服务端先返回 max_execution_time 错误
+	ctx, cancel := context.WithTimeout(ctx, timeout+time.Second)
+	defer cancel()
+
+	conn, release, err := sessionConn(ctx, req.InstanceID, req.Schema, timeout)
+	if err != nil {
+		return models.QueryConsoleResponse{}, err
+	}
+	defer release()
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return models.QueryConsoleResponse{}, err
+	}
+	defer tx.Rollback()
+
+	start := time.Now()
+	rows, err := tx.QueryContext(ctx, req.SQL)
+	if err != nil {
+		return models.QueryConsoleResponse{}, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return models.QueryConsoleResponse{}, err
+	}
+
+	resp := models.QueryConsoleResponse{Columns: columns, Rows: [][]any{}, Limit: limit}
+	for rows.Next() {
+		if len(resp.Rows) >= limit {
+			resp.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return models.QueryConsoleResponse{}, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		resp.Rows = append(resp.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return models.QueryConsoleResponse{}, err
+	}
+	resp.RowCount = len(resp.Rows)
+	resp.ElapsedMs = time.Since(start).Milliseconds()
+	return resp, nil
+}
+
+// sessionConn 从 instanceID 对应实例（为空时为 [database]）取出一个独占连接并设置默认库与 max_execution_time；
+// 会话状态会残留在连接上，release 时直接丢弃该连接，避免污染连接池
+func sessionConn(ctx context.Context, instanceID, schema string, timeout time.Duration) (*sql.Conn, func(), error) {
+	db, err := databases.GetInstanceDB(ctx, instanceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() {
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		conn.Close()
+	}
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_execution_time = %d", timeout.Milliseconds())); err != nil {
+		release()
+		return nil, nil, fmt.Errorf("set max_execution_time failed: %w", err)
+	}
+	if schema != "" {
+		if _, err := conn.ExecContext(ctx, "USE "+helper.QuoteIdent(schema)); err != nil {
+			release()
+			return nil, nil, fmt.Errorf("use schema %s failed: %w", schema, err)
+		}
+	}
+	return conn, release, nil
+}
+
+// QueryConsole 处理只读 SQL 控制台的业务逻辑，返回统一响应
+func QueryConsole(req request.QueryConsoleRequest) models.StandardResponse {
+	resp, err := RunReadOnlyQuery(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// replicationSyntax 按服务端版本选择复制语句的关键字，MySQL 8.0.23 起使用 SOURCE/REPLICA 术语
+type replicationSyntax struct {
+	changeSource string // CHANGE REPLICATION SOURCE TO / CHANGE MASTER TO
+	optionPrefix string // SOURCE_ / MASTER_
+	replica      string // REPLICA / SLAVE
+	skipCounter  string // sql_replica_skip_counter / sql_slave_skip_counter
+}
+
+var (
+	modernReplicationSyntax = replicationSyntax{"CHANGE REPLICATION SOURCE TO", "SOURCE_", "REPLICA", "sql_replica_skip_counter"}
+	legacyReplicationSyntax = replicationSyntax{"CHANGE MASTER TO", "MASTER_", "SLAVE", "sql_slave_skip_counter"}
+)
+
+func detectReplicationSyntax(ctx context.Context, db *sql.DB) (replicationSyntax, error) {
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return replicationSyntax{}, err
+	}
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		return legacyReplicationSyntax, nil
+	}
+	if versionAtLeast(version, 8, 0, 23) {
+		syntax := modernReplicationSyntax
+		if !versionAtLeast(version, 8, 0, 26) {
+			syntax.skipCounter = legacyReplicationSyntax.skipCounter
+		}
+		return syntax, nil
+	}
+	return legacyReplicationSyntax, nil
+}
+
+// versionAtLeast 比较 "8.0.35-log" 形式的版本号
+func versionAtLeast(version string, want ...int) bool {
+	if i := strings.IndexAny(version, "-+ "); i != -1 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	for i, w := range want {
+		got := 0
+		if i < len(parts) {
+			got, _ = strconv.Atoi(parts[i])
+		}
+		if got != w {
+			return got > w
+		}
+	}
+	return true
+}
+
+func channelClause(channel string) string {
+	if channel == "" {
+		return ""
+	}
+	return " FOR CHANNEL " + helper.QuoteString(channel)
+}
+
+// ReplicaStatus 读取 SHOW REPLICA STATUS，旧版本回退到 SHOW SLAVE STATUS，每个复制通道一行
+func ReplicaStatus(ctx context.Context, req request.ReplicationStatusRequest) (models.ReplicationStatusResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.ReplicationStatusResponse{}, err
+	}
+	channels, err := replicaChannels(ctx, db, req.Channel)
+	if err != nil {
+		return models.ReplicationStatusResponse{}, err
+	}
+	return models.ReplicationStatusResponse{IsReplica: len(channels) > 0, Channels: channels}, nil
+}
+
+func replicaChannels(ctx context.Context, db *sql.DB, channel string) ([]models.ReplicaChannel, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS"+channelClause(channel))
+	if err != nil {
+		log.Printf("[replication] SHOW REPLICA STATUS failed, falling back to SHOW SLAVE STATUS: %v", err)
+		if rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS"+channelClause(channel)); err != nil {
+			return nil, fmt.Errorf("show replica status failed: %w", err)
+		}
+	}
+	defer rows.Close()
+
+	result, err := scanRowMaps(rows)
+	if err != nil {
+		return nil, err
+	}
+	channels := make([]models.ReplicaChannel, 0, len(result))
+	for _, row := range result {
+		// 新旧版本列名不同，依次尝试 SOURCE/REPLICA 与 MASTER/SLAVE 术语
+		col := func(names ...string) string {
+			for _, name := range names {
+				if v, ok := row[name]; ok && v != nil {
+					return fmt.Sprint(v)
+				}
+			}
+			return ""
+		}
+		ch := models.ReplicaChannel{
+			Channel:          col("Channel_Name"),
+			SourceHost:       col("Source_Host", "Master_Host"),
+			SourceUser:       col("Source_User", "Master_User"),
+			IORunning:        col("Replica_IO_Running", "Slave_IO_Running"),
+			SQLRunning:       col("Replica_SQL_Running", "Slave_SQL_Running"),
+			SourceLogFile:    col("Source_Log_File", "Master_Log_File"),
+			RelaySourceLog:   col("Relay_Source_Log_File", "Relay_Master_Log_File"),
+			LastIOError:      col("Last_IO_Error"),
+			LastSQLError:     col("Last_SQL_Error"),
+			RetrievedGTIDSet: col("Retrieved_Gtid_Set"),
+			ExecutedGTIDSet:  col("Executed_Gtid_Set"),
+			AutoPosition:     col("Auto_Position") == "1",
+			ReplicaSQLState:  col("Replica_SQL_Running_State", "Slave_SQL_Running_State"),
+			SourcePort:       toInt64(col("Source_Port", "Master_Port")),
+			ReadSourceLogPos: toInt64(col("Read_Source_Log_Pos", "Read_Master_Log_Pos")),
+			ExecSourceLogPos: toInt64(col("Exec_Source_Log_Pos", "Exec_Master_Log_Pos")),
+			LastIOErrno:      toInt64(col("Last_IO_Errno")),
+			LastSQLErrno:     toInt64(col("Last_SQL_Errno")),
+		}
+		// SQL 线程未运行时该列为 NULL
+		if behind := col("Seconds_Behind_Source", "Seconds_Behind_Master"); behind != "" {
+			n := toInt64(behind)
+			ch.SecondsBehind = &n
+		}
+		channels = append(channels, ch)
+	}
+	return channels, nil
+}
+
+// ChangeReplicationSource 执行 CHANGE REPLICATION SOURCE TO，复制线程运行中时拒绝修改
+func ChangeReplicationSource(ctx context.Context, req request.ChangeSourceRequest) ([]string, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return nil, err
+	}
+	syntax, err := detectReplicationSyntax(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	p := syntax.optionPrefix
+	opts := []string{
+		fmt.Sprintf("%sHOST = %s", p, helper.QuoteString(req.Host)),
+		fmt.Sprintf("%sPORT = %d", p, req.Port),
+		fmt.Sprintf("%sUSER = %s", p, helper.QuoteString(req.User)),
+		fmt.Sprintf("%sPASSWORD = %s", p, helper.QuoteString(req.Password)),
+	}
+	if req.AutoPosition {
+		opts = append(opts, p+"AUTO_POSITION = 1")
+	} else if req.LogFile != "" {
+		opts = append(opts,
+			p+"AUTO_POSITION = 0",
+			fmt.Sprintf("%sLOG_FILE = %s", p, helper.QuoteString(req.LogFile)),
+			fmt.Sprintf("%sLOG_POS = %d", p, req.LogPos),
+		)
+	}
+	if req.SSL {
+		opts = append(opts, p+"SSL = 1")
+	}
+	if req.GetSourcePublicKey {
+		// 该选项在旧语法下名为 GET_MASTER_PUBLIC_KEY
+		opts = append(opts, "GET_"+p+"PUBLIC_KEY = 1")
+	}
+	stmt := syntax.changeSource + " " + strings.Join(opts, ", ") + channelClause(req.Channel)
+	plan := []sqlStatement{{SQL: stmt, Desc: "change replication source"}}
+
+	if req.DryRun {
+		return []string{redactSQL(stmt)}, nil
+	}
+
+	channels, err := replicaChannels(ctx, db, req.Channel)
+	if err != nil && req.Channel == "" {
+		return nil, err
+	}
+	for _, ch := range channels {
+		if ch.Channel == req.Channel && (ch.IORunning == "Yes" || ch.SQLRunning == "Yes") {
+			return nil, fmt.Errorf("replication is running on channel %q, stop it before changing the source", req.Channel)
+		}
+	}
+
+	target := fmt.Sprintf("%s:%d%s", req.Host, req.Port, channelClause(req.Channel))
+	return nil, runPlan(ctx, "change_replication_source", target, plan)
+}
+
+// ControlReplication 执行 START/STOP REPLICA，可只操作 IO 或 SQL 线程
+func ControlReplication(ctx context.Context, req request.ReplicationControlRequest, start bool) error {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+	syntax, err := detectReplicationSyntax(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	verb, action := "STOP", "stop_replication"
+	if start {
+		verb, action = "START", "start_replication"
+	}
+	stmt := verb + " " + syntax.replica
+	if req.Thread != "" {
+		stmt += " " + strings.ToUpper(req.Thread) + "_THREAD"
+	}
+	stmt += channelClause(req.Channel)
+
+	target := req.Channel
+	if target == "" {
+		target = "all channels"
+	}
+	return runPlan(ctx, action, target, []sqlStatement{{SQL: stmt, Desc: strings.ToLower(verb) + " replication"}})
+}
+
+// SkipReplicationError 跳过导致 SQL 线程停止的事务并重新启动 SQL 线程。
+// 非 GTID 模式使用 skip counter；GTID 模式下向出错的 GTID 注入空事务，只能跳过一个事务
+func SkipReplicationError(ctx context.Context, req request.SkipReplicationErrorRequest) (models.SkipReplicationResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.SkipReplicationResponse{}, err
+	}
+	syntax, err := detectReplicationSyntax(ctx, db)
+	if err != nil {
+		return models.SkipReplicationResponse{}, err
+	}
+
+	channels, err := replicaChannels(ctx, db, req.Channel)
+	if err != nil {
+		return models.SkipReplicationResponse{}, err
+	}
+	var current *models.ReplicaChannel
+	for i := range channels {
+		if channels[i].Channel == req.Channel {
+			current = &channels[i]
+			break
+		}
+	}
+	if current == nil {
+		return models.SkipReplicationResponse{}, fmt.Errorf("replication channel %q not found", req.Channel)
+	}
+	if current.SQLRunning == "Yes" {
+		return models.SkipReplicationResponse{}, fmt.Errorf("sql thread on channel %q is running, nothing to skip", req.Channel)
+	}
+
+	var gtidMode string
+	if err := db.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_mode").Scan(&gtidMode); err != nil {
+		return models.SkipReplicationResponse{}, err
+	}
+	resp := models.SkipReplicationResponse{Channel: req.Channel, LastSQLError: current.LastSQLError}
+	startSQL := sqlStatement{SQL: "START " + syntax.replica + " SQL_THREAD" + channelClause(req.Channel), Desc: "start sql thread"}
+
+	if !strings.EqualFold(gtidMode, "ON") {
+		plan := []sqlStatement{
+			{SQL: fmt.Sprintf("SET GLOBAL %s = %d", syntax.skipCounter, req.Count), Desc: "set skip counter"},
+			startSQL,
+		}
+		resp.Skipped = req.Count
+		return resp, runPlan(ctx, "skip_replication_error", "channel "+strconv.Quote(req.Channel), plan)
+	}
+
+	if req.Count != 1 {
+		return resp, fmt.Errorf("gtid mode only supports skipping the failed transaction, count must be 1")
+	}
+	gtid, err := failedTransaction(ctx, db, req.Channel)
+	if err != nil {
+		return resp, err
+	}
+	resp.GTID = gtid
+	plan := []sqlStatement{
+		{SQL: "SET GTID_NEXT = " + helper.QuoteString(gtid), Desc: "set gtid_next"},
+		{SQL: "BEGIN", Desc: "begin empty transaction"},
+		{SQL: "COMMIT", Desc: "commit empty transaction"},
+		{SQL: "SET GTID_NEXT = 'AUTOMATIC'", Desc: "reset gtid_next"},
+		startSQL,
+	}
+
+	// GTID_NEXT 是会话变量，需要在同一个连接上执行，结束后丢弃该连接
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return resp, err
+	}
+	defer func() {
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		conn.Close()
+	}()
+	var execErr error
+	for _, st := range plan {
+		if _, execErr = conn.ExecContext(ctx, st.SQL); execErr != nil {
+			execErr = fmt.Errorf("%s failed: %w", st.Desc, execErr)
+			break
+		}
+	}
+	if err := recordAudit(ctx, "skip_replication_error", gtid, plan, execErr); err != nil {
+		log.Printf("[replication] record audit for %s failed: %v", gtid, err)
+	}
+	if execErr != nil {
+		return resp, execErr
+	}
+	resp.Skipped = 1
+	return resp, nil
+}
+
+// failedTransaction 从 performance_schema 读取 SQL 线程出错时正在应用的 GTID
+func failedTransaction(ctx context.Context, db *sql.DB, channel string) (string, error) {
+	query := `SELECT APPLYING_TRANSACTION FROM performance_schema.replication_applier_status_by_worker
+WHERE CHANNEL_NAME = ? AND LAST_ERROR_NUMBER <> 0 AND APPLYING_TRANSACTION <> '' LIMIT 1`
+	var gtid string
+	err := db.QueryRowContext(ctx, query, channel).Scan(&gtid)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("no failed transaction found on channel %q", channel)
+	}
+	return gtid, err
+}
+
+// ReplicationStatus 处理查看复制状态的业务逻辑，返回统一响应
+func ReplicationStatus(req request.ReplicationStatusRequest) models.StandardResponse {
+	resp, err := ReplicaStatus(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// ChangeSource 处理配置复制源的业务逻辑，返回统一响应
+func ChangeSource(req request.ChangeSourceRequest) models.StandardResponse {
+	stmts, err := ChangeReplicationSource(req.Ctx, req)
+	resp := replicationResponse(err)
+	if err == nil && req.DryRun {
+		resp.Data = models.ReplicationResponse{Success: true, DryRun: true, Statements: stmts}
+	}
+	return resp
+}
+
+// StartReplication 处理启动复制的业务逻辑，返回统一响应
+func StartReplication(req request.ReplicationControlRequest) models.StandardResponse {
+	return replicationResponse(ControlReplication(req.Ctx, req, true))
+}
+
+// StopReplication 处理停止复制的业务逻辑，返回统一响应
+func StopReplication(req request.ReplicationControlRequest) models.StandardResponse {
+	return replicationResponse(ControlReplication(req.Ctx, req, false))
+}
+
+// SkipReplication 处理跳过复制错误的业务逻辑，返回统一响应
+func SkipReplication(req request.SkipReplicationErrorRequest) models.StandardResponse {
+	resp, err := SkipReplicationError(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         resp,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+func replicationResponse(err error) models.StandardResponse {
+	if err != nil {
+		return models.StandardResponse{
+			Data:         models.ReplicationResponse{Success: false},
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         models.ReplicationResponse{Success: true},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
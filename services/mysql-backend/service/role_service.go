@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// CreateRoles 执行 CREATE ROLE
+func CreateRoles(ctx context.Context, req request.RoleRequest) error {
+	stmt := "CREATE ROLE "
+	if req.IfExists {
+		stmt = "CREATE ROLE IF NOT EXISTS "
+	}
+	stmt += roleList(req.Roles)
+	return runPlan(ctx, "create_role", roleList(req.Roles), []sqlStatement{{SQL: stmt, Desc: "create role"}})
+}
+
+// DropRoles 执行 DROP ROLE
+func DropRoles(ctx context.Context, req request.RoleRequest) error {
+	stmt := "DROP ROLE "
+	if req.IfExists {
+		stmt = "DROP ROLE IF EXISTS "
+	}
+	stmt += roleList(req.Roles)
+	return runPlan(ctx, "drop_role", roleList(req.Roles), []sqlStatement{{SQL: stmt, Desc: "drop role"}})
+}
+
+// GrantRolesToUser 执行 GRANT role TO user，dry_run 时只返回将要执行的语句
+func GrantRolesToUser(ctx context.Context, req request.GrantRoleRequest) ([]string, error) {
+	userIdent := helper.Account(req.Username, req.Host)
+	plan := []sqlStatement{{SQL: fmt.Sprintf("GRANT %s TO %s", roleList(req.Roles), userIdent), Desc: "grant role"}}
+	if req.DryRun {
+		return statementSQL(plan), nil
+	}
+	return nil, runPlan(ctx, "grant_role", userIdent, plan)
+}
+
+// SetDefaultRoles 执行 SET DEFAULT ROLE，角色为空时设置为 NONE
+func SetDefaultRoles(ctx context.Context, req request.GrantRoleRequest) error {
+	roles := "NONE"
+	if len(req.Roles) > 0 {
+		roles = roleList(req.Roles)
+	}
+	userIdent := helper.Account(req.Username, req.Host)
+	stmt := fmt.Sprintf("SET DEFAULT ROLE %s TO %s", roles, userIdent)
+	return runPlan(ctx, "set_default_role", userIdent, []sqlStatement{{SQL: stmt, Desc: "set default role"}})
+}
+
+// CreateRole 处理创建角色的业务逻辑，返回统一响应
+func CreateRole(req request.RoleRequest) models.StandardResponse {
+	return roleResponse(CreateRoles(req.Ctx, req))
+}
+
+// DropRole 处理删除角色的业务逻辑，返回统一响应
+func DropRole(req request.RoleRequest) models.StandardResponse {
+	return roleResponse(DropRoles(req.Ctx, req))
+}
+
+// GrantRole 处理授予角色的业务逻辑，返回统一响应
+func GrantRole(req request.GrantRoleRequest) models.StandardResponse {
+	stmts, err := GrantRolesToUser(req.Ctx, req)
+	resp := roleResponse(err)
+	if err == nil && req.DryRun {
+		resp.Data = models.RoleResponse{Success: true, DryRun: true, Statements: stmts}
+	}
+	return resp
+}
+
+// SetDefaultRole 处理设置默认角色的业务逻辑，返回统一响应
+func SetDefaultRole(req request.GrantRoleRequest) models.StandardResponse {
+	return roleResponse(SetDefaultRoles(req.Ctx, req))
+}
+
+func roleResponse(err error) models.StandardResponse {
+	if err != nil {
+		return models.StandardResponse{
+			Data:         models.RoleResponse{Success: false},
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         models.RoleResponse{Success: true},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+func roleList(roles []string) string {
+	idents := make([]string, 0, len(roles))
+	for _, role := range roles {
+		idents = append(idents, helper.Account(role, "%"))
+	}
+	return strings.Join(idents, ", ")
+}
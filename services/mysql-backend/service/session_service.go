@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"mysql-backend/databases"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// protectedCommands 复制与后台线程的 COMMAND，禁止终止
+var protectedCommands = map[string]struct{}{
+	"binlog dump":      {},
+	"binlog dump gtid": {},
+	"daemon":           {},
+	"connect":          {},
+	"register slave":   {},
+	"register replica": {},
+}
+
+// protectedUsers 系统线程使用的账号，禁止终止
+var protectedUsers = map[string]struct{}{
+	"system user":     {},
+	"event_scheduler": {},
+}
+
+// KillSessionByID 校验目标连接存在且不是复制或系统线程后执行 KILL [QUERY]，并写入审计日志
+func KillSessionByID(ctx context.Context, req request.KillSessionRequest) (models.KillSessionResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.KillSessionResponse{}, err
+	}
+
+	resp := models.KillSessionResponse{ID: req.ID, QueryOnly: req.QueryOnly}
+	query := "SELECT USER, HOST, COALESCE(DB, ''), COMMAND FROM information_schema.PROCESSLIST WHERE ID = ?"
+	err = db.QueryRowContext(ctx, query, req.ID).Scan(&resp.User, &resp.Host, &resp.DB, &resp.Command)
+	if errors.Is(err, sql.ErrNoRows) {
+		return resp, fmt.Errorf("connection %d not found", req.ID)
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	if _, ok := protectedUsers[strings.ToLower(resp.User)]; ok {
+		return resp, fmt.Errorf("connection %d is a system thread (%s) and cannot be killed", req.ID, resp.User)
+	}
+	if _, ok := protectedCommands[strings.ToLower(resp.Command)]; ok {
+		return resp, fmt.Errorf("connection %d is a replication or daemon thread (%s) and cannot be killed", req.ID, resp.Command)
+	}
+
+	stmt := fmt.Sprintf("KILL %d", req.ID)
+	action := "kill_connection"
+	if req.QueryOnly {
+		stmt = fmt.Sprintf("KILL QUERY %d", req.ID)
+		action = "kill_query"
+	}
+	target := fmt.Sprintf("%d (%s@%s)", req.ID, resp.User, resp.Host)
+	if err := runPlan(ctx, action, target, []sqlStatement{{SQL: stmt, Desc: action}}); err != nil {
+		return resp, err
+	}
+	resp.Success = true
+	return resp, nil
+}
+
+// processInfoMaxLength 非 full 模式下返回的语句最大长度
+const processInfoMaxLength = 256
+
+// ListProcesses 读取 information_schema.PROCESSLIST，按用户、库与运行时间过滤，运行时间长的排在前面
+func ListProcesses(ctx context.Context, req request.ProcessListRequest) (models.ProcessListResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.ProcessListResponse{}, err
+	}
+
+	query := "SELECT ID, USER, HOST, COALESCE(DB, ''), COMMAND, TIME, COALESCE(STATE, ''), INFO FROM information_schema.PROCESSLIST WHERE TIME >= ?"
+	args := []any{req.MinTime}
+	if req.User != "" {
+		query += " AND USER = ?"
+		args = append(args, req.User)
+	}
+	if req.DB != "" {
+		query += " AND DB = ?"
+		args = append(args, req.DB)
+	}
+	if !req.IncludeSleep {
+		query += " AND COMMAND <> 'Sleep'"
+	}
+	query += " ORDER BY TIME DESC, ID"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.ProcessListResponse{}, err
+	}
+	defer rows.Close()
+
+	resp := models.ProcessListResponse{Processes: []models.ProcessInfo{}}
+	for rows.Next() {
+		var p models.ProcessInfo
+		var info sql.NullString
+		if err := rows.Scan(&p.ID, &p.User, &p.Host, &p.DB, &p.Command, &p.Time, &p.State, &info); err != nil {
+			return models.ProcessListResponse{}, err
+		}
+		p.Info = info.String
+		if !req.Full && len([]rune(p.Info)) > processInfoMaxLength {
+			p.Info = string([]rune(p.Info)[:processInfoMaxLength]) + "..."
+		}
+		resp.Processes = append(resp.Processes, p)
+	}
+	if err := rows.Err(); err != nil {
+		return models.ProcessListResponse{}, err
+	}
+	resp.Total = len(resp.Processes)
+	return resp, nil
+}
+
+// ProcessList 处理查看 processlist 的业务逻辑，返回统一响应
+func ProcessList(req request.ProcessListRequest) models.StandardResponse {
+	resp, err := ListProcesses(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// KillSession 处理终止会话的业务逻辑，返回统一响应
+func KillSession(req request.KillSessionRequest) models.StandardResponse {
+	resp, err := KillSessionByID(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         resp,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+)
+
+// maskedPassword dry_run 返回的语句中用于替换明文密码
+const maskedPassword = "******"
+
+var (
+	// authStringPattern 匹配 SHOW CREATE USER 中的密码哈希
+	authStringPattern = regexp.MustCompile(`AS '(?:[^'\\]|\\.|'')*'`)
+	// passwordPattern 匹配 IDENTIFIED ... BY 后的明文密码
+	passwordPattern = regexp.MustCompile(`BY '(?:[^'\\]|\\.|'')*'`)
+	// sourcePasswordPattern 匹配 CHANGE REPLICATION SOURCE TO 中的复制账号密码
+	sourcePasswordPattern = regexp.MustCompile(`((?:SOURCE|MASTER)_PASSWORD = )'(?:[^'\\]|\\.|'')*'`)
+)
+
+// redactSQL 隐去语句中的明文密码与密码哈希，用于 dry_run 与审计日志
+func redactSQL(stmt string) string {
+	stmt = passwordPattern.ReplaceAllString(stmt, "BY '"+maskedPassword+"'")
+	stmt = sourcePasswordPattern.ReplaceAllString(stmt, "${1}'"+maskedPassword+"'")
+	return authStringPattern.ReplaceAllString(stmt, "AS '"+maskedPassword+"'")
+}
+
+// sqlStatement 一条待执行的管理语句，Desc 用于出错时描述失败的步骤
+type sqlStatement struct {
+	SQL  string
+	Desc string
+}
+
+// execStatements 按顺序执行语句，遇到错误立即返回
+func execStatements(ctx context.Context, db *sql.DB, stmts []sqlStatement) error {
+	for _, st := range stmts {
+		if _, err := db.ExecContext(ctx, st.SQL); err != nil {
+			return fmt.Errorf("%s failed: %w", st.Desc, err)
+		}
+	}
+	return nil
+}
+
+// statementSQL 提取语句文本，用于 dry_run 返回
+func statementSQL(stmts []sqlStatement) []string {
+	out := make([]string, 0, len(stmts))
+	for _, st := range stmts {
+		out = append(out, st.SQL)
+	}
+	return out
+}
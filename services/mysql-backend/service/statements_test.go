@@ -0,0 +1,39 @@
+package service
+
+import "testing"
+
+func TestRedactSQLErrorText(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"syntax error near identified by",
+			"create user 'bob'@'%' failed: Error 1064 (42000): You have an error in your SQL syntax; check the manual near 'BY 's3cr\\'et' PASSWORD EXPIRE' at line 1",
+			"create user 'bob'@'%' failed: Error 1064 (42000): You have an error in your SQL syntax; check the manual near 'BY '******' PASSWORD EXPIRE' at line 1",
+		},
+		{
+			"truncated near text",
+			"change password failed: Error 1064 (42000): syntax error near 'BY 'a-very-long-secret-cut-off' at line 1",
+			"change password failed: Error 1064 (42000): syntax error near 'BY '******' at line 1",
+		},
+		{
+			"source password",
+			"change source failed: Error 1064 (42000): near 'SOURCE_PASSWORD = 'repl-pass', SOURCE_AUTO_POSITION = 1' at line 1",
+			"change source failed: Error 1064 (42000): near 'SOURCE_PASSWORD = '******', SOURCE_AUTO_POSITION = 1' at line 1",
+		},
+		{
+			"no password",
+			"drop user failed: Error 1396 (HY000): Operation DROP USER failed for 'bob'@'%'",
+			"drop user failed: Error 1396 (HY000): Operation DROP USER failed for 'bob'@'%'",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := redactSQL(c.in); got != c.want {
+				t.Fatalf("redactSQL(%q)\n got %q\nwant %q", c.in, got, c.want)
+			}
+		})
+	}
+}
@@ -2,34 +2,76 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"mysql-backend/helper"
+	"sort"
+	"strconv"
 	"strings"
 
+	"mysql-backend/config"
 	"mysql-backend/databases"
 	"mysql-backend/models"
 	"mysql-backend/request"
 )
 
-// CreateUserWithPrivileges 创建或更新用户并授予权限
-func CreateUserWithPrivileges(ctx context.Context, req request.CreateUserRequest) error {
+// CreateUserWithPrivileges 创建或更新用户并授予权限，dry_run 时只返回将要执行的语句
+func CreateUserWithPrivileges(ctx context.Context, req request.CreateUserRequest) ([]string, error) {
 	db, err := databases.GetAdminDB()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if req.DryRun && req.Password != "" {
+		req.Password = maskedPassword
+	}
+
+	stmts, err := buildCreateUserStatements(ctx, db, req)
+	if err != nil {
+		return nil, err
 	}
+	if req.DryRun {
+		return statementSQL(stmts), nil
+	}
+	return nil, runPlan(ctx, "create_user", helper.Account(req.Username, req.Host), stmts)
+}
+
+// buildCreateUserStatements 生成创建用户与授权所需的全部语句
+func buildCreateUserStatements(ctx context.Context, db *sql.DB, req request.CreateUserRequest) ([]sqlStatement, error) {
+	userIdent := helper.Account(req.Username, req.Host)
 
-	userIdent := fmt.Sprintf("'%s'@'%s'", req.Username, req.Host)
+	// 按模板展开权限列表
+	if req.Profile != "" {
+		profile, err := GetPrivilegeProfile(ctx, req.Profile)
+		if err != nil {
+			return nil, err
+		}
+		req.Privileges = make([]request.Privilege, 0, len(profile.Privileges))
+		for _, p := range profile.Privileges {
+			req.Privileges = append(req.Privileges, request.Privilege(p))
+		}
+		req.WithGrant = req.WithGrant || profile.WithGrant
+	}
 
-	// CREATE USER IF NOT EXISTS + IDENTIFIED BY '...'
-	createStmt := fmt.Sprintf("CREATE USER IF NOT EXISTS %s IDENTIFIED BY '%s'", userIdent, helper.EscapeSQLString(req.Password))
-	if _, err := db.ExecContext(ctx, createStmt); err != nil {
-		return fmt.Errorf("create user failed: %w", err)
+	if req.PasswordHistory != nil || req.PasswordReuseInterval != nil {
+		major, err := serverMajorVersion(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		if major < 8 {
+			return nil, fmt.Errorf("password_history and password_reuse_interval require MySQL 8.0.3 or later")
+		}
 	}
+	options := userAccountOptions(req)
+
+	identified := identifiedClause(req)
 
-	// ALTER USER 确保更新密码/SSL
-	alterStmt := fmt.Sprintf("ALTER USER %s IDENTIFIED BY '%s'", userIdent, helper.EscapeSQLString(req.Password))
-	if _, err := db.ExecContext(ctx, alterStmt); err != nil {
-		return fmt.Errorf("alter user failed: %w", err)
+	stmts := []sqlStatement{
+		// CREATE USER IF NOT EXISTS + IDENTIFIED [WITH plugin] BY '...'
+		{SQL: fmt.Sprintf("CREATE USER IF NOT EXISTS %s %s%s", userIdent, identified, options), Desc: "create user"},
+		// ALTER USER 确保更新密码/SSL
+		{SQL: fmt.Sprintf("ALTER USER %s %s%s", userIdent, identified, options), Desc: "alter user"},
 	}
 
 	// 权限列表
@@ -41,35 +83,179 @@ func CreateUserWithPrivileges(ctx context.Context, req request.CreateUserRequest
 
 	// 对每个数据库授权
 	for _, dbName := range req.Databases {
-		scope := "*.*"
-		if dbName != "*" {
-			safe := strings.TrimSpace(dbName)
-			if safe == "" {
-				continue
-			}
-			scope = fmt.Sprintf("`%s`.*", strings.ReplaceAll(safe, "`", ""))
+		dbName = strings.TrimSpace(dbName)
+		if dbName == "" {
+			continue
 		}
+		scope := helper.DatabaseScope(dbName)
 
 		grant := fmt.Sprintf("GRANT %s ON %s TO %s", privList, scope, userIdent)
 		if req.WithGrant {
 			grant += " WITH GRANT OPTION"
 		}
-		if _, err := db.ExecContext(ctx, grant); err != nil {
-			return fmt.Errorf("grant on %s failed: %w", scope, err)
+		stmts = append(stmts, sqlStatement{SQL: grant, Desc: "grant on " + scope})
+	}
+
+	// 部分撤销：全局授权后从指定库上撤销同样的权限
+	if len(req.ExcludeDatabases) > 0 {
+		if err := checkPartialRevokes(ctx, db); err != nil {
+			return nil, err
+		}
+		revokeList := privList
+		if req.WithGrant {
+			revokeList += ", GRANT OPTION"
+		}
+		for _, dbName := range req.ExcludeDatabases {
+			scope := helper.DatabaseScope(dbName)
+			stmts = append(stmts, sqlStatement{
+				SQL:  fmt.Sprintf("REVOKE %s ON %s FROM %s", revokeList, scope, userIdent),
+				Desc: "partial revoke on " + scope,
+			})
 		}
 	}
 
+	// 代理用户：允许以被代理账号的权限登录
+	if req.ProxyOf != "" {
+		base := helper.Account(helper.SplitAccount(req.ProxyOf))
+		stmts = append(stmts, sqlStatement{
+			SQL:  fmt.Sprintf("GRANT PROXY ON %s TO %s", base, userIdent),
+			Desc: "grant proxy on " + base,
+		})
+	}
+
+	// 表级授权，按库名排序保证执行顺序稳定
+	tableDBs := make([]string, 0, len(req.Tables))
+	for dbName := range req.Tables {
+		tableDBs = append(tableDBs, dbName)
+	}
+	sort.Strings(tableDBs)
+	for _, dbName := range tableDBs {
+		for _, table := range req.Tables[dbName] {
+			scope := helper.TableScope(strings.TrimSpace(dbName), strings.TrimSpace(table))
+
+			grant := fmt.Sprintf("GRANT %s ON %s TO %s", privList, scope, userIdent)
+			if req.WithGrant {
+				grant += " WITH GRANT OPTION"
+			}
+			stmts = append(stmts, sqlStatement{SQL: grant, Desc: "grant on " + scope})
+		}
+	}
+
+	// 列级授权
+	for _, cp := range req.Columns {
+		cols := make([]string, 0, len(cp.Columns))
+		for _, col := range cp.Columns {
+			cols = append(cols, strings.TrimSpace(col))
+		}
+		scope := helper.TableScope(strings.TrimSpace(cp.Database), strings.TrimSpace(cp.Table))
+
+		grant := fmt.Sprintf("GRANT %s (%s) ON %s TO %s", cp.Privilege, helper.QuoteIdentList(cols), scope, userIdent)
+		if req.WithGrant {
+			grant += " WITH GRANT OPTION"
+		}
+		stmts = append(stmts, sqlStatement{SQL: grant, Desc: fmt.Sprintf("grant %s columns on %s", cp.Privilege, scope)})
+	}
+
 	// 刷新权限
-	if _, err := db.ExecContext(ctx, "FLUSH PRIVILEGES"); err != nil {
-		return fmt.Errorf("flush privileges failed: %w", err)
+	stmts = append(stmts, sqlStatement{SQL: "FLUSH PRIVILEGES", Desc: "flush privileges"})
+
+	return stmts, nil
+}
+
+// identifiedClause 生成认证子句，未指定插件时沿用服务端默认插件
+func identifiedClause(req request.CreateUserRequest) string {
+	switch req.AuthPlugin {
+	case "":
+		return "IDENTIFIED BY " + helper.QuoteString(req.Password)
+	case request.AuthPluginSocket:
+		return "IDENTIFIED WITH " + request.AuthPluginSocket
+	default:
+		return fmt.Sprintf("IDENTIFIED WITH %s BY %s", req.AuthPlugin, helper.QuoteString(req.Password))
+	}
+}
+
+// userAccountOptions 按 CREATE/ALTER USER 语法顺序拼接账号选项子句
+func userAccountOptions(req request.CreateUserRequest) string {
+	var sb strings.Builder
+
+	// tls_option
+	switch req.TLSType {
+	case "NONE", "SSL", "X509":
+		sb.WriteString(" REQUIRE " + req.TLSType)
+	case "SPECIFIED":
+		parts := make([]string, 0, 3)
+		if req.TLSCipher != "" {
+			parts = append(parts, "CIPHER "+helper.QuoteString(req.TLSCipher))
+		}
+		if req.TLSIssuer != "" {
+			parts = append(parts, "ISSUER "+helper.QuoteString(req.TLSIssuer))
+		}
+		if req.TLSSubject != "" {
+			parts = append(parts, "SUBJECT "+helper.QuoteString(req.TLSSubject))
+		}
+		sb.WriteString(" REQUIRE " + strings.Join(parts, " AND "))
+	}
+
+	// resource_option
+	limits := make([]string, 0, 4)
+	if req.MaxQueriesPerHour != nil {
+		limits = append(limits, fmt.Sprintf("MAX_QUERIES_PER_HOUR %d", *req.MaxQueriesPerHour))
+	}
+	if req.MaxUpdatesPerHour != nil {
+		limits = append(limits, fmt.Sprintf("MAX_UPDATES_PER_HOUR %d", *req.MaxUpdatesPerHour))
+	}
+	if req.MaxConnectionsPerHour != nil {
+		limits = append(limits, fmt.Sprintf("MAX_CONNECTIONS_PER_HOUR %d", *req.MaxConnectionsPerHour))
+	}
+	if req.MaxUserConnections != nil {
+		limits = append(limits, fmt.Sprintf("MAX_USER_CONNECTIONS %d", *req.MaxUserConnections))
+	}
+	if len(limits) > 0 {
+		sb.WriteString(" WITH " + strings.Join(limits, " "))
+	}
+
+	// password_option
+	switch req.PasswordExpire {
+	case "DEFAULT", "NEVER":
+		sb.WriteString(" PASSWORD EXPIRE " + req.PasswordExpire)
+	case "INTERVAL":
+		sb.WriteString(fmt.Sprintf(" PASSWORD EXPIRE INTERVAL %d DAY", req.PasswordExpireDays))
+	}
+	if req.PasswordHistory != nil {
+		sb.WriteString(fmt.Sprintf(" PASSWORD HISTORY %d", *req.PasswordHistory))
+	}
+	if req.PasswordReuseInterval != nil {
+		sb.WriteString(fmt.Sprintf(" PASSWORD REUSE INTERVAL %d DAY", *req.PasswordReuseInterval))
 	}
 
-	return nil
+	return sb.String()
 }
 
+// generatedPasswordLength 自动生成密码的默认长度，低于密码策略最短长度时以策略为准
+const generatedPasswordLength = 24
+
 // CreateUser 处理创建用户的业务逻辑，返回统一响应
 func CreateUser(req request.CreateUserRequest) models.StandardResponse {
-	if err := CreateUserWithPrivileges(req.Ctx, req); err != nil {
+	var generated string
+	if req.GeneratePassword {
+		length := max(generatedPasswordLength, config.AppConfig.PasswordPolicy.MinLength)
+		password, err := helper.GeneratePassword(length)
+		if err != nil {
+			return models.StandardResponse{
+				Data:         models.CreateUserResponse{Success: false},
+				Error:        "OPERATION_FAILED",
+				ErrorMessage: fmt.Sprintf("generate password failed: %v", err),
+			}
+		}
+		req.Password = password
+		// dry_run 不会创建账号，无需返回密码
+		if !req.DryRun {
+			generated = password
+		}
+	}
+
+	stmts, err := CreateUserWithPrivileges(req.Ctx, req)
+	if err != nil {
 		return models.StandardResponse{
 			Data:         models.CreateUserResponse{Success: false},
 			Error:        "OPERATION_FAILED",
@@ -78,7 +264,237 @@ func CreateUser(req request.CreateUserRequest) models.StandardResponse {
 	}
 
 	return models.StandardResponse{
-		Data:         models.CreateUserResponse{Success: true},
+		Data:         models.CreateUserResponse{Success: true, DryRun: req.DryRun, Statements: stmts, GeneratedPassword: generated},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// DropUserWithPrivileges 删除用户在各host下的账号，DROP USER 会一并清理其全部权限记录，
+// dry_run 时只返回将要执行的语句
+func DropUserWithPrivileges(ctx context.Context, req request.DropUserRequest) (dropped []string, stmts []string, err error) {
+	plan := make([]sqlStatement, 0, len(req.Hosts)+1)
+	for _, host := range req.Hosts {
+		userIdent := helper.Account(req.Username, host)
+
+		dropStmt := "DROP USER " + userIdent
+		if req.IfExists {
+			dropStmt = "DROP USER IF EXISTS " + userIdent
+		}
+		plan = append(plan, sqlStatement{SQL: dropStmt, Desc: "drop user " + userIdent})
+		dropped = append(dropped, userIdent)
+	}
+
+	// 刷新权限
+	plan = append(plan, sqlStatement{SQL: "FLUSH PRIVILEGES", Desc: "flush privileges"})
+
+	if req.DryRun {
+		return dropped, statementSQL(plan), nil
+	}
+	return dropped, nil, runPlan(ctx, "drop_user", strings.Join(dropped, ","), plan)
+}
+
+// DropUser 处理删除用户的业务逻辑，返回统一响应
+func DropUser(req request.DropUserRequest) models.StandardResponse {
+	dropped, stmts, err := DropUserWithPrivileges(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         models.DropUserResponse{Success: false},
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+
+	return models.StandardResponse{
+		Data:         models.DropUserResponse{Success: true, Dropped: dropped, DryRun: req.DryRun, Statements: stmts},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// ChangeUserPassword 仅修改已有用户的密码，可选保留/丢弃次要密码
+func ChangeUserPassword(ctx context.Context, req request.ChangePasswordRequest) error {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return err
+	}
+
+	if req.RetainCurrent || req.DiscardOldPassword {
+		major, err := serverMajorVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+		if major < 8 {
+			return fmt.Errorf("dual passwords require MySQL 8.0.14 or later")
+		}
+	}
+
+	userIdent := helper.Account(req.Username, req.Host)
+
+	plan := make([]sqlStatement, 0, 2)
+	if req.Password != "" {
+		alterStmt := fmt.Sprintf("ALTER USER %s IDENTIFIED BY %s", userIdent, helper.QuoteString(req.Password))
+		if req.RetainCurrent {
+			alterStmt += " RETAIN CURRENT PASSWORD"
+		}
+		plan = append(plan, sqlStatement{SQL: alterStmt, Desc: "alter user password"})
+	}
+
+	if req.DiscardOldPassword {
+		plan = append(plan, sqlStatement{SQL: fmt.Sprintf("ALTER USER %s DISCARD OLD PASSWORD", userIdent), Desc: "discard old password"})
+	}
+
+	return runPlan(ctx, "change_password", userIdent, plan)
+}
+
+// ChangePassword 处理修改密码的业务逻辑，返回统一响应
+func ChangePassword(req request.ChangePasswordRequest) models.StandardResponse {
+	if err := ChangeUserPassword(req.Ctx, req); err != nil {
+		return models.StandardResponse{
+			Data:         models.ChangePasswordResponse{Success: false},
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+
+	return models.StandardResponse{
+		Data:         models.ChangePasswordResponse{Success: true},
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// serverMajorVersion 返回 MySQL 服务端主版本号
+func serverMajorVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return 0, fmt.Errorf("query server version failed: %w", err)
+	}
+	major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("parse server version %q failed: %w", version, err)
+	}
+	return major, nil
+}
+
+// allPrivileges 当前服务端 SHOW PRIVILEGES 给出的权限，用于展开 ALL PRIVILEGES
+type allPrivileges struct {
+	global []string // *.* 上 ALL 包含的权限
+	db     []string // 库级及表级 ALL 包含的权限
+}
+
+// loadAllPrivileges 读取 SHOW PRIVILEGES，GRANT OPTION、PROXY、USAGE 不属于 ALL
+func loadAllPrivileges(ctx context.Context, db *sql.DB) (*allPrivileges, error) {
+	rows, err := db.QueryContext(ctx, "SHOW PRIVILEGES")
+	if err != nil {
+		return nil, fmt.Errorf("show privileges failed: %w", err)
+	}
+	defer rows.Close()
+
+	all := &allPrivileges{}
+	for rows.Next() {
+		var name, privContext, comment string
+		if err := rows.Scan(&name, &privContext, &comment); err != nil {
+			return nil, err
+		}
+		name = strings.ToUpper(name)
+		switch name {
+		case "GRANT OPTION", "PROXY", "USAGE":
+			continue
+		}
+		all.global = append(all.global, name)
+		if strings.Contains(privContext, "Databases") || strings.Contains(privContext, "Tables") ||
+			strings.Contains(privContext, "Functions") || strings.Contains(privContext, "Procedures") {
+			all.db = append(all.db, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// expand 将 scope 上的 ALL / ALL PRIVILEGES 替换为具体权限列表
+func (a *allPrivileges) expand(scope string, privs []string) []string {
+	out := make([]string, 0, len(privs))
+	for _, p := range privs {
+		if p != "ALL" && p != "ALL PRIVILEGES" {
+			out = append(out, p)
+			continue
+		}
+		if scope == "*" {
+			out = append(out, a.global...)
+		} else {
+			out = append(out, a.db...)
+		}
+	}
+	return helper.UniqueStrings(out)
+}
+
+// checkPartialRevokes 确认服务端开启了 partial_revokes（MySQL 8.0.16+），否则 REVOKE 库级权限会失败
+func checkPartialRevokes(ctx context.Context, db *sql.DB) error {
+	var enabled sql.NullString
+	if err := db.QueryRowContext(ctx, "SELECT @@GLOBAL.partial_revokes").Scan(&enabled); err != nil {
+		return fmt.Errorf("exclude_databases requires MySQL 8.0.16 or later with partial_revokes: %w", err)
+	}
+	switch strings.ToUpper(enabled.String) {
+	case "1", "ON":
+		return nil
+	}
+	return errors.New("exclude_databases requires partial_revokes to be enabled (SET PERSIST partial_revokes = ON)")
+}
+
+// ListUsersWithFilter 按 LIKE 条件分页读取 mysql.user
+func ListUsersWithFilter(ctx context.Context, req request.ListUsersRequest) (models.ListUsersResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.ListUsersResponse{}, err
+	}
+
+	resp := models.ListUsersResponse{Users: []models.UserAccount{}, Limit: req.Limit, Offset: req.Offset}
+
+	countQuery := "SELECT COUNT(*) FROM mysql.user WHERE user LIKE ? AND host LIKE ?"
+	if err := db.QueryRowContext(ctx, countQuery, req.User, req.Host).Scan(&resp.Total); err != nil {
+		return models.ListUsersResponse{}, err
+	}
+
+	query := "SELECT user, host, plugin, account_locked, password_expired FROM mysql.user" +
+		" WHERE user LIKE ? AND host LIKE ? ORDER BY user, host LIMIT ?, ?"
+	rows, err := db.QueryContext(ctx, query, req.User, req.Host, req.Offset, req.Limit)
+	if err != nil {
+		return models.ListUsersResponse{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var account models.UserAccount
+		var locked, expired string
+		if err := rows.Scan(&account.Username, &account.Host, &account.Plugin, &locked, &expired); err != nil {
+			return models.ListUsersResponse{}, err
+		}
+		account.AccountLocked = strings.EqualFold(locked, "Y")
+		account.PasswordExpired = strings.EqualFold(expired, "Y")
+		resp.Users = append(resp.Users, account)
+	}
+	if err := rows.Err(); err != nil {
+		return models.ListUsersResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// ListUsers 处理列出用户的业务逻辑，返回统一响应
+func ListUsers(req request.ListUsersRequest) models.StandardResponse {
+	resp, err := ListUsersWithFilter(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
 		Error:        "NO_ERROR",
 		ErrorMessage: "Operation completed successfully",
 	}
@@ -111,6 +527,13 @@ func CheckUserWithId(ctx context.Context, req request.CheckUserRequst) (models.C
 	if err != nil {
 		return models.CheckUserResponse{}, err
 	}
+	var allPrivs *allPrivileges
+	if req.ExpandAll {
+		if allPrivs, err = loadAllPrivileges(ctx, db); err != nil {
+			return models.CheckUserResponse{}, err
+		}
+	}
+
 	userinfos := make([]models.UserInfo, 0)
 	for _, username := range req.Username {
 		var userinfo models.UserInfo
@@ -129,15 +552,18 @@ func CheckUserWithId(ctx context.Context, req request.CheckUserRequst) (models.C
 		userinfo.Exist = true
 
 		// 查询 host 与 auth plugin（可能多条）
-		hostRows, err := db.QueryContext(ctx, "SELECT host, plugin FROM mysql.user WHERE user = ?", username)
+		hostQuery := "SELECT host, plugin, max_questions, max_updates, max_connections, max_user_connections FROM mysql.user WHERE user = ?"
+		hostRows, err := db.QueryContext(ctx, hostQuery, username)
 		if err != nil {
 			return models.CheckUserResponse{}, err
 		}
 		hosts := make([]string, 0)
 		plugins := make([]string, 0)
+		limits := make([]models.ResourceLimits, 0)
 		for hostRows.Next() {
 			var host, plugin string
-			if err := hostRows.Scan(&host, &plugin); err != nil {
+			var limit models.ResourceLimits
+			if err := hostRows.Scan(&host, &plugin, &limit.MaxQueriesPerHour, &limit.MaxUpdatesPerHour, &limit.MaxConnectionsPerHour, &limit.MaxUserConnections); err != nil {
 				hostRows.Close()
 				return models.CheckUserResponse{}, err
 			}
@@ -145,6 +571,8 @@ func CheckUserWithId(ctx context.Context, req request.CheckUserRequst) (models.C
 			if strings.TrimSpace(plugin) != "" {
 				plugins = append(plugins, plugin)
 			}
+			limit.Host = host
+			limits = append(limits, limit)
 		}
 		if err := hostRows.Err(); err != nil {
 			hostRows.Close()
@@ -155,9 +583,7 @@ func CheckUserWithId(ctx context.Context, req request.CheckUserRequst) (models.C
 		// SHOW GRANTS for each host, 聚合权限
 		allGrants := make([]string, 0)
 		for _, host := range hosts {
-			uEsc := helper.EscapeSQLString(username)
-			hEsc := helper.EscapeSQLString(host)
-			query := fmt.Sprintf("SHOW GRANTS FOR '%s'@'%s'", uEsc, hEsc)
+			query := "SHOW GRANTS FOR " + helper.Account(username, host)
 
 			rows, err := db.QueryContext(ctx, query)
 			if err != nil {
@@ -183,6 +609,18 @@ func CheckUserWithId(ctx context.Context, req request.CheckUserRequst) (models.C
 
 		// 解析权限列表
 		userinfo.Privilege = helper.ParsePrivilegesFromGrants(allGrants)
+		userinfo.DBPrivileges = helper.ParsePrivilegesByScope(allGrants)
+		if allPrivs != nil {
+			userinfo.Privilege = nil
+			for scope, privs := range userinfo.DBPrivileges {
+				userinfo.DBPrivileges[scope] = allPrivs.expand(scope, privs)
+			}
+			for _, privs := range userinfo.DBPrivileges {
+				userinfo.Privilege = append(userinfo.Privilege, privs...)
+			}
+			userinfo.Privilege = helper.UniqueStrings(userinfo.Privilege)
+			sort.Strings(userinfo.Privilege)
+		}
 
 		// 解析数据库列表
 		dbs := helper.ParseDatabasesFromGrants(allGrants)
@@ -196,6 +634,13 @@ func CheckUserWithId(ctx context.Context, req request.CheckUserRequst) (models.C
 
 		// 设置插件列表
 		userinfo.Plugins = helper.UniqueStrings(plugins)
+		userinfo.Limits = limits
+
+		// 解析已授予的角色
+		userinfo.Roles = helper.ParseRolesFromGrants(allGrants)
+
+		// 解析可代理的账号
+		userinfo.Proxies = helper.ParseProxiesFromGrants(allGrants)
 
 		userinfos = append(userinfos, userinfo)
 	}
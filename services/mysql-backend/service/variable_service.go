@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"mysql-backend/config"
+	"mysql-backend/databases"
+	"mysql-backend/helper"
+	"mysql-backend/models"
+	"mysql-backend/request"
+)
+
+// numericValuePattern 按数字原样写入 SET GLOBAL 的取值
+var numericValuePattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// ListGlobalVariables 读取 SHOW GLOBAL VARIABLES，可按 LIKE 过滤
+func ListGlobalVariables(ctx context.Context, req request.ListVariablesRequest) (models.VariablesResponse, error) {
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.VariablesResponse{}, err
+	}
+
+	query := "SHOW GLOBAL VARIABLES"
+	args := []any{}
+	if req.Like != "" {
+		query += " LIKE ?"
+		args = append(args, req.Like)
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.VariablesResponse{}, err
+	}
+	defer rows.Close()
+
+	resp := models.VariablesResponse{Variables: []models.Variable{}}
+	for rows.Next() {
+		var v models.Variable
+		if err := rows.Scan(&v.Name, &v.Value); err != nil {
+			return models.VariablesResponse{}, err
+		}
+		v.Settable = variableAllowed(v.Name)
+		resp.Variables = append(resp.Variables, v)
+	}
+	return resp, rows.Err()
+}
+
+// SetGlobalVariable 对白名单内的动态变量执行 SET GLOBAL，记录修改前后的值并写入审计日志
+func SetGlobalVariable(ctx context.Context, req request.SetVariableRequest) (models.SetVariableResponse, error) {
+	if !variableAllowed(req.Name) {
+		return models.SetVariableResponse{}, fmt.Errorf("variable %s is not in variables.allowed", req.Name)
+	}
+
+	db, err := databases.GetAdminDB()
+	if err != nil {
+		return models.SetVariableResponse{}, err
+	}
+
+	resp := models.SetVariableResponse{Name: req.Name}
+	// 变量名已通过白名单与字符集校验，可以直接拼接
+	readValue := "SELECT COALESCE(@@GLOBAL." + req.Name + ", '')"
+	if err := db.QueryRowContext(ctx, readValue).Scan(&resp.Previous); err != nil {
+		return resp, fmt.Errorf("read %s failed: %w", req.Name, err)
+	}
+
+	stmt := fmt.Sprintf("SET GLOBAL %s = %s", req.Name, variableLiteral(req.Value))
+	if err := runPlan(ctx, "set_global_variable", req.Name, []sqlStatement{{SQL: stmt, Desc: "set global " + req.Name}}); err != nil {
+		return resp, err
+	}
+
+	if err := db.QueryRowContext(ctx, readValue).Scan(&resp.Current); err != nil {
+		return resp, fmt.Errorf("read %s failed: %w", req.Name, err)
+	}
+	resp.Success = true
+	return resp, nil
+}
+
+func variableAllowed(name string) bool {
+	return slices.Contains(config.AppConfig.Variables.Allowed, strings.ToLower(name))
+}
+
+// variableLiteral 数字与 ON/OFF/DEFAULT 等关键字原样使用，其余作为字符串字面量
+func variableLiteral(value string) string {
+	switch strings.ToUpper(value) {
+	case "ON", "OFF", "DEFAULT", "TRUE", "FALSE":
+		return strings.ToUpper(value)
+	}
+	if numericValuePattern.MatchString(value) {
+		return value
+	}
+	return helper.QuoteString(value)
+}
+
+// ListVariables 处理查看全局变量的业务逻辑，返回统一响应
+func ListVariables(req request.ListVariablesRequest) models.StandardResponse {
+	resp, err := ListGlobalVariables(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         nil,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}
+
+// SetVariable 处理修改全局变量的业务逻辑，返回统一响应
+func SetVariable(req request.SetVariableRequest) models.StandardResponse {
+	resp, err := SetGlobalVariable(req.Ctx, req)
+	if err != nil {
+		return models.StandardResponse{
+			Data:         resp,
+			Error:        "OPERATION_FAILED",
+			ErrorMessage: err.Error(),
+		}
+	}
+	return models.StandardResponse{
+		Data:         resp,
+		Error:        "NO_ERROR",
+		ErrorMessage: "Operation completed successfully",
+	}
+}